@@ -0,0 +1,158 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package encoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+
+import (
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/collector/extension"
+)
+
+// Capability is an encoding interface implemented by encoding extensions, e.g. CapabilityLogsUnmarshaler for
+// LogsUnmarshalerExtension.
+type Capability int
+
+// Capabilities of encoding extensions, one per interface.
+const (
+	CapabilityLogsMarshaler Capability = iota
+	CapabilityLogsUnmarshaler
+	CapabilityLogsDecoder
+	CapabilityLogsEncoder
+	CapabilityMetricsMarshaler
+	CapabilityMetricsUnmarshaler
+	CapabilityMetricsDecoder
+	CapabilityTracesMarshaler
+	CapabilityTracesUnmarshaler
+	CapabilityProfilesMarshaler
+	CapabilityProfilesUnmarshaler
+)
+
+// capabilities lists all capabilities, in the order they are described.
+var capabilities = []Capability{
+	CapabilityLogsMarshaler,
+	CapabilityLogsUnmarshaler,
+	CapabilityLogsDecoder,
+	CapabilityLogsEncoder,
+	CapabilityMetricsMarshaler,
+	CapabilityMetricsUnmarshaler,
+	CapabilityMetricsDecoder,
+	CapabilityTracesMarshaler,
+	CapabilityTracesUnmarshaler,
+	CapabilityProfilesMarshaler,
+	CapabilityProfilesUnmarshaler,
+}
+
+func (c Capability) String() string {
+	switch c {
+	case CapabilityLogsMarshaler:
+		return "logs marshaler"
+	case CapabilityLogsUnmarshaler:
+		return "logs unmarshaler"
+	case CapabilityLogsDecoder:
+		return "logs decoder"
+	case CapabilityLogsEncoder:
+		return "logs encoder"
+	case CapabilityMetricsMarshaler:
+		return "metrics marshaler"
+	case CapabilityMetricsUnmarshaler:
+		return "metrics unmarshaler"
+	case CapabilityMetricsDecoder:
+		return "metrics decoder"
+	case CapabilityTracesMarshaler:
+		return "traces marshaler"
+	case CapabilityTracesUnmarshaler:
+		return "traces unmarshaler"
+	case CapabilityProfilesMarshaler:
+		return "profiles marshaler"
+	case CapabilityProfilesUnmarshaler:
+		return "profiles unmarshaler"
+	default:
+		return fmt.Sprintf("Capability(%d)", int(c))
+	}
+}
+
+// implementedBy reports whether ext implements the interface of c.
+func (c Capability) implementedBy(ext extension.Extension) bool {
+	var ok bool
+	switch c {
+	case CapabilityLogsMarshaler:
+		_, ok = ext.(LogsMarshalerExtension)
+	case CapabilityLogsUnmarshaler:
+		_, ok = ext.(LogsUnmarshalerExtension)
+	case CapabilityLogsDecoder:
+		_, ok = ext.(LogsDecoderExtension)
+	case CapabilityLogsEncoder:
+		_, ok = ext.(LogsEncoderExtension)
+	case CapabilityMetricsMarshaler:
+		_, ok = ext.(MetricsMarshalerExtension)
+	case CapabilityMetricsUnmarshaler:
+		_, ok = ext.(MetricsUnmarshalerExtension)
+	case CapabilityMetricsDecoder:
+		_, ok = ext.(MetricsDecoderExtension)
+	case CapabilityTracesMarshaler:
+		_, ok = ext.(TracesMarshalerExtension)
+	case CapabilityTracesUnmarshaler:
+		_, ok = ext.(TracesUnmarshalerExtension)
+	case CapabilityProfilesMarshaler:
+		_, ok = ext.(ProfilesMarshalerExtension)
+	case CapabilityProfilesUnmarshaler:
+		_, ok = ext.(ProfilesUnmarshalerExtension)
+	}
+	return ok
+}
+
+// Capabilities returns the capabilities implemented by ext.
+func Capabilities(ext extension.Extension) []Capability {
+	var implemented []Capability
+	for _, c := range capabilities {
+		if c.implementedBy(ext) {
+			implemented = append(implemented, c)
+		}
+	}
+	return implemented
+}
+
+// DescribeCapabilities returns the capabilities implemented by ext, e.g. "logs marshaler, logs unmarshaler",
+// or "none" if it implements no encoding interface.
+func DescribeCapabilities(ext extension.Extension) string {
+	return describeCapabilities(Capabilities(ext))
+}
+
+func describeCapabilities(implemented []Capability) string {
+	if len(implemented) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(implemented))
+	for _, c := range implemented {
+		names = append(names, c.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// UnsupportedCapabilityError is returned by CheckCapability when an extension does not implement the requested
+// capability, e.g. when a receiver is configured to unmarshal metrics with an extension only handling logs.
+type UnsupportedCapabilityError struct {
+	// Requested is the capability the extension does not implement.
+	Requested Capability
+	// Supported holds the capabilities the extension implements.
+	Supported []Capability
+}
+
+func (e *UnsupportedCapabilityError) Error() string {
+	return fmt.Sprintf("encoding extension supports: %s; requested: %s", describeCapabilities(e.Supported), e.Requested)
+}
+
+// CheckCapability returns an *UnsupportedCapabilityError describing the capabilities of ext if it does not
+// implement c, e.g. for components to report a misconfigured encoding extension on start:
+//
+//	if err := encoding.CheckCapability(ext, encoding.CapabilityMetricsUnmarshaler); err != nil {
+//		return fmt.Errorf("extension %q: %w", id, err)
+//	}
+func CheckCapability(ext extension.Extension, c Capability) error {
+	if c.implementedBy(ext) {
+		return nil
+	}
+	return &UnsupportedCapabilityError{Requested: c, Supported: Capabilities(ext)}
+}
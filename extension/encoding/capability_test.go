@@ -0,0 +1,61 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package encoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+
+import (
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/extension"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// logsExtension implements the logs marshaler, unmarshaler and decoder capabilities.
+type logsExtension struct {
+	extension.Extension
+}
+
+func (logsExtension) MarshalLogs(plog.Logs) ([]byte, error) {
+	return nil, nil
+}
+
+func (logsExtension) UnmarshalLogs([]byte) (plog.Logs, error) {
+	return plog.NewLogs(), nil
+}
+
+func (logsExtension) NewLogsDecoder(io.Reader, ...DecoderOption) (LogsDecoder, error) {
+	return nil, nil
+}
+
+type nopExtension struct {
+	extension.Extension
+}
+
+func TestDescribeCapabilities(t *testing.T) {
+	assert.Equal(t, []Capability{CapabilityLogsMarshaler, CapabilityLogsUnmarshaler, CapabilityLogsDecoder}, Capabilities(logsExtension{}))
+	assert.Equal(t, "logs marshaler, logs unmarshaler, logs decoder", DescribeCapabilities(logsExtension{}))
+	assert.Equal(t, "none", DescribeCapabilities(nopExtension{}))
+}
+
+func TestCheckCapability(t *testing.T) {
+	require.NoError(t, CheckCapability(logsExtension{}, CapabilityLogsDecoder))
+
+	err := CheckCapability(logsExtension{}, CapabilityMetricsUnmarshaler)
+	assert.EqualError(t, err, "encoding extension supports: logs marshaler, logs unmarshaler, logs decoder; requested: metrics unmarshaler")
+	var capabilityErr *UnsupportedCapabilityError
+	require.ErrorAs(t, err, &capabilityErr)
+	assert.Equal(t, CapabilityMetricsUnmarshaler, capabilityErr.Requested)
+
+	err = CheckCapability(nopExtension{}, CapabilityTracesUnmarshaler)
+	assert.EqualError(t, err, "encoding extension supports: none; requested: traces unmarshaler")
+}
+
+func TestCapabilityString(t *testing.T) {
+	for _, c := range capabilities {
+		assert.NotContains(t, c.String(), "Capability(")
+	}
+	assert.Equal(t, "Capability(42)", Capability(42).String())
+}
@@ -4,7 +4,13 @@
 package encoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
 
 import (
+	"errors"
+	"fmt"
 	"io"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
 
 	"go.opentelemetry.io/collector/extension"
 	"go.opentelemetry.io/collector/pdata/plog"
@@ -30,6 +36,13 @@ type LogsUnmarshalerExtension interface {
 	plog.Unmarshaler
 }
 
+// LogsSizer is an optional interface implemented by logs marshalers that estimate the size of marshaled logs
+// without marshaling them, e.g. so that exporters split batches under a message size limit.
+type LogsSizer interface {
+	// LogsSize returns the size in bytes of ld once marshaled.
+	LogsSize(ld plog.Logs) int
+}
+
 // LogsDecoder unmarshals logs from a stream, returning one batch per DecodeLogs call.
 type LogsDecoder interface {
 	// DecodeLogs is expected to be called iteratively to read all derived plog.Logs batches from the stream.
@@ -41,6 +54,191 @@ type LogsDecoder interface {
 	Offset() int64
 }
 
+// PartialDecodeError is returned by DecodeLogs when decoding fails after some records of the batch were decoded.
+// The logs returned along with it hold the successfully decoded records, which callers may forward before
+// resuming with WithOffset from Offset.
+type PartialDecodeError struct {
+	// Decoded is the number of records successfully decoded before the failure.
+	Decoded int
+	// Offset is the offset of the first record that failed to decode, in the same domain as LogsDecoder.Offset.
+	Offset int64
+	// Err is the cause of the failure.
+	Err error
+}
+
+// NewPartialDecodeError creates a PartialDecodeError for a failure of the record at offset after decoded records.
+func NewPartialDecodeError(decoded int, offset int64, err error) *PartialDecodeError {
+	return &PartialDecodeError{
+		Decoded: decoded,
+		Offset:  offset,
+		Err:     err,
+	}
+}
+
+func (e *PartialDecodeError) Error() string {
+	return fmt.Sprintf("failed to decode record at offset %d after %d decoded records: %v", e.Offset, e.Decoded, e.Err)
+}
+
+func (e *PartialDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// OpaqueOffsetDecoder is an optional interface implemented by stream decoders whose position in the stream is not
+// represented by an int64 offset, e.g. a block or a page of a record-oriented source.
+type OpaqueOffsetDecoder interface {
+	// OffsetToken returns the position after the most recent batch read from the stream, or the initial position.
+	// It is empty when the decoder cannot represent its position, in which case Offset should be used instead.
+	// You may use this value with WithOffsetToken option to resume reading from the same position.
+	OffsetToken() string
+}
+
+// ResumeOption returns the option resuming a new decoder from the position of decoder, e.g. a LogsDecoder or a
+// MetricsDecoder. The offset token is preferred when decoder provides OpaqueOffsetDecoder, see DecoderAs, and returns
+// one, otherwise the int64 offset is used.
+func ResumeOption(decoder interface{ Offset() int64 }) DecoderOption {
+	if d, ok := DecoderAs[OpaqueOffsetDecoder](decoder); ok {
+		if token := d.OffsetToken(); token != "" {
+			return WithOffsetToken(token)
+		}
+	}
+	return WithOffset(decoder.Offset())
+}
+
+// DecoderAs returns the first decoder of the chain of decoder providing T, typically one of the optional decoder
+// interfaces, e.g. StatsReporter. The chain holds decoder, followed by the decoders returned by repeatedly calling
+// its Unwrap method, e.g. through the middlewares wrapping a decoder. As with errors.As, a decoder provides T when it
+// implements it, or when it has an As(any) bool method returning true for a pointer to T, having set it. This lets
+// decoders provide optional interfaces depending on their configuration.
+func DecoderAs[T any](decoder any) (T, bool) {
+	for decoder != nil {
+		if d, ok := decoder.(T); ok {
+			return d, true
+		}
+		if d, ok := decoder.(interface{ As(any) bool }); ok {
+			var target T
+			if d.As(&target) {
+				return target, true
+			}
+		}
+		switch d := decoder.(type) {
+		case interface{ Unwrap() LogsDecoder }:
+			decoder = d.Unwrap()
+		case interface{ Unwrap() MetricsDecoder }:
+			decoder = d.Unwrap()
+		default:
+			decoder = nil
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// OffsetSemantics describes what the offsets of a stream decoder count, both those returned by Offset and those
+// accepted by WithOffset.
+type OffsetSemantics int
+
+const (
+	// OffsetSemanticsUnsupported is declared by decoders ignoring WithOffset, whose offsets cannot be restored.
+	OffsetSemanticsUnsupported OffsetSemantics = iota
+	// OffsetSemanticsBytes is declared by decoders whose offsets count bytes of the stream.
+	OffsetSemanticsBytes
+	// OffsetSemanticsRecords is declared by decoders whose offsets count records of the stream.
+	OffsetSemanticsRecords
+	// OffsetSemanticsOpaque is declared by decoders whose offsets are only meaningful to decoders of the same
+	// encoding, e.g. the index of a block.
+	OffsetSemanticsOpaque
+)
+
+var offsetSemanticsNames = [...]string{
+	OffsetSemanticsUnsupported: "unsupported",
+	OffsetSemanticsBytes:       "bytes",
+	OffsetSemanticsRecords:     "records",
+	OffsetSemanticsOpaque:      "opaque",
+}
+
+// String returns the name of s, e.g. "bytes".
+func (s OffsetSemantics) String() string {
+	if s < 0 || int(s) >= len(offsetSemanticsNames) {
+		return fmt.Sprintf("OffsetSemantics(%d)", int(s))
+	}
+	return offsetSemanticsNames[s]
+}
+
+// MarshalText marshals s as its name, so that it can be persisted alongside offsets.
+func (s OffsetSemantics) MarshalText() ([]byte, error) {
+	if s < 0 || int(s) >= len(offsetSemanticsNames) {
+		return nil, fmt.Errorf("invalid offset semantics %d", int(s))
+	}
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText unmarshals s from its name.
+func (s *OffsetSemantics) UnmarshalText(text []byte) error {
+	for semantics, name := range offsetSemanticsNames {
+		if string(text) == name {
+			*s = OffsetSemantics(semantics)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown offset semantics %q", text)
+}
+
+// OffsetAware is an optional interface implemented by stream decoders declaring the semantics of their offsets, so
+// that receivers persisting offsets across restarts can record them alongside, and refuse to restore an offset
+// recorded with other semantics, e.g. after switching encodings. See CanRestoreOffset.
+type OffsetAware interface {
+	// OffsetSemantics returns the semantics of the offsets of the decoder.
+	OffsetSemantics() OffsetSemantics
+}
+
+// CanRestoreOffset reports whether an offset recorded alongside the semantics recorded can be restored on decoder,
+// which must declare the same semantics, other than OffsetSemanticsUnsupported. Decoders not implementing
+// OffsetAware are assumed to have opaque offsets.
+func CanRestoreOffset(decoder any, recorded OffsetSemantics) bool {
+	semantics := OffsetSemanticsOpaque
+	if d, ok := decoder.(OffsetAware); ok {
+		semantics = d.OffsetSemantics()
+	}
+	return semantics != OffsetSemanticsUnsupported && semantics == recorded
+}
+
+// SkipReporting is an optional interface implemented by stream decoders that skip malformed records
+// instead of failing the decoding.
+type SkipReporting interface {
+	// SkippedRecords returns the number of records skipped since the decoder was created.
+	SkippedRecords() int64
+}
+
+// DecoderStats holds the cumulative statistics of a stream decoder since it was created.
+type DecoderStats struct {
+	// BytesRead is the number of bytes read from the stream, separators included.
+	BytesRead int64
+	// RecordsDecoded is the number of records decoded, excluding skipped ones.
+	RecordsDecoded int64
+	// BatchesFlushed is the number of non-empty batches flushed.
+	BatchesFlushed int64
+	// RecordsSkipped is the number of records skipped, e.g. empty ones with WithSkipEmptyRecords.
+	RecordsSkipped int64
+	// LastFlushTime is the time the last batch was flushed, zero if none was.
+	LastFlushTime time.Time
+}
+
+// StatsReporter is an optional interface implemented by stream decoders reporting cumulative statistics,
+// e.g. for receivers tailing long-lived streams to log them periodically or on shutdown.
+// Stats is safe to call concurrently with decoding.
+type StatsReporter interface {
+	Stats() DecoderStats
+}
+
+// ResettableLogsDecoder is a LogsDecoder that can be reset to decode another stream, reusing its buffers,
+// e.g. so that receivers decoding many small streams can pool decoders with sync.Pool.
+type ResettableLogsDecoder interface {
+	LogsDecoder
+	// Reset discards the state of the decoder and binds it to reader, configured with options as if created
+	// by LogsDecoderFactory.NewLogsDecoder with them. The decoder must not be used if an error is returned.
+	Reset(reader io.Reader, options ...DecoderOption) error
+}
+
 // LogsDecoderFactory creates LogsDecoder instances for streaming log deserialization.
 type LogsDecoderFactory interface {
 	NewLogsDecoder(reader io.Reader, options ...DecoderOption) (LogsDecoder, error)
@@ -52,6 +250,27 @@ type LogsDecoderExtension interface {
 	LogsDecoderFactory
 }
 
+// LogsEncoder marshals logs to a stream as delimited records.
+type LogsEncoder interface {
+	// EncodeLogs writes the log records of ld to the stream, following the records written by previous calls.
+	// Records are flushed to the stream whenever a flush threshold of the EncoderOptions is reached,
+	// and all of them have been flushed when EncodeLogs returns.
+	EncodeLogs(ld plog.Logs) error
+	// Offset returns the number of bytes flushed to the stream so far.
+	Offset() int64
+}
+
+// LogsEncoderFactory creates LogsEncoder instances for streaming log serialization.
+type LogsEncoderFactory interface {
+	NewLogsEncoder(writer io.Writer, options ...EncoderOption) (LogsEncoder, error)
+}
+
+// LogsEncoderExtension is an extension that marshals logs to a stream.
+type LogsEncoderExtension interface {
+	extension.Extension
+	LogsEncoderFactory
+}
+
 // MetricsMarshalerExtension is an extension that marshals metrics.
 type MetricsMarshalerExtension interface {
 	extension.Extension
@@ -64,6 +283,13 @@ type MetricsUnmarshalerExtension interface {
 	pmetric.Unmarshaler
 }
 
+// MetricsSizer is an optional interface implemented by metrics marshalers that estimate the size of marshaled
+// metrics without marshaling them, e.g. so that exporters split batches under a message size limit.
+type MetricsSizer interface {
+	// MetricsSize returns the size in bytes of md once marshaled.
+	MetricsSize(md pmetric.Metrics) int
+}
+
 // MetricsDecoder unmarshals metrics from a stream, returning one batch per DecodeMetrics call.
 type MetricsDecoder interface {
 	// DecodeMetrics is expected to be called iteratively to read all derived pmetric.Metrics batches from the stream.
@@ -98,6 +324,13 @@ type TracesUnmarshalerExtension interface {
 	ptrace.Unmarshaler
 }
 
+// TracesSizer is an optional interface implemented by traces marshalers that estimate the size of marshaled
+// traces without marshaling them, e.g. so that exporters split batches under a message size limit.
+type TracesSizer interface {
+	// TracesSize returns the size in bytes of td once marshaled.
+	TracesSize(td ptrace.Traces) int
+}
+
 // ProfilesMarshalerExtension is an extension that marshals profiles.
 type ProfilesMarshalerExtension interface {
 	extension.Extension
@@ -111,15 +344,164 @@ type ProfilesUnmarshalerExtension interface {
 }
 
 // DecoderOptions configures the behavior of stream decoding.
-// FlushBytes and FlushItems control how often the decoder should flush decoded data from the stream.
-// Offset defines the initial stream offset for the stream.
+// Decoders ignore the options they do not support, as documented by each decoder.
 // Use NewDecoderOptions to construct with default options.
 type DecoderOptions struct {
+	// FlushBytes is the number of bytes read after which decoders flush the current batch, 0 disables it.
 	FlushBytes int64
+	// FlushItems is the number of records decoded after which decoders flush the current batch, 0 disables it.
 	FlushItems int64
+	// Offset is the initial offset in the stream.
+	Offset int64
+	// ReaderBufferSize is a hint for decoders that buffer the stream, 0 means the decoder's default buffer size.
+	ReaderBufferSize int
+	// OffsetDomain defines whether offsets are positions in the compressed or the uncompressed stream.
+	OffsetDomain OffsetDomain
+	// SkipEmptyRecords makes decoders skip records that are empty, e.g. blank lines, instead of emitting them.
+	SkipEmptyRecords bool
+	// IdleCloseTimeout closes streams implementing io.Closer, e.g. a net.Conn, once a read waits for data longer
+	// than it, 0 disables it.
+	IdleCloseTimeout time.Duration
+	// OffsetToken is the initial position for decoders implementing OpaqueOffsetDecoder, taking precedence over
+	// Offset when not empty.
+	OffsetToken string
+	// FlushInterval flushes decoded data once it has been pending for that long, 0 disables it.
+	FlushInterval time.Duration
+	// MaxRecordSize is the maximum size in bytes of a record, 0 means the decoder's default limit, if any.
+	MaxRecordSize int
+	// BatchIDAttribute is the resource attribute key decoders stamp each returned batch with, holding an id unique
+	// to the batch within the decoder, empty disables it.
+	BatchIDAttribute string
+	// FlushOnResourceBoundary delays flushes triggered by FlushBytes or FlushItems until the next resource boundary,
+	// so that a resource is not split across batches. Flushes triggered by MaxBatchMemory are not delayed.
+	FlushOnResourceBoundary bool
+	// AdaptiveBatchTarget is the decode time per batch that flush thresholds are adapted to, 0 disables it.
+	AdaptiveBatchTarget time.Duration
+	// HeartbeatInterval is the period after which decoders call Heartbeat while waiting for data without receiving
+	// any, 0 disables it.
+	HeartbeatInterval time.Duration
+	// Heartbeat is called every HeartbeatInterval while waiting for data, nil disables it.
+	Heartbeat func()
+	// MaxBatchMemory is the estimated in-memory size in bytes of a batch after which decoders flush it, whatever
+	// FlushBytes and FlushItems, 0 disables it.
+	MaxBatchMemory int64
+	// DelimiterByte delimits the records of decoders scanning delimited records when DelimiterByteSet is set,
+	// see RecordDelimiter.
+	DelimiterByte byte
+	// DelimiterByteSet reports whether DelimiterByte was set.
+	DelimiterByteSet bool
+	// ReadAheadBuffers is the number of buffers decoders fill from the stream in the background while parsing the
+	// data already read, 0 disables reading ahead.
+	ReadAheadBuffers int
+	// ReadAheadBufferSize is the size in bytes of each read-ahead buffer.
+	ReadAheadBufferSize int
+	// DownstreamLatencyTarget is the latency of the consumers of batches that flush thresholds are adapted to,
+	// 0 disables it.
+	DownstreamLatencyTarget time.Duration
+	// DownstreamLatency reports the latency of the consumers of batches, nil disables DownstreamLatencyTarget.
+	DownstreamLatency DownstreamLatencyFunc
+
+	// values holds the options set with WithValue.
+	values map[any]any
+}
+
+// Value returns the option set for key with WithValue, or nil.
+func (o DecoderOptions) Value(key any) any {
+	return o.values[key]
+}
+
+// RecordDelimiter returns the byte delimiting records, DelimiterByte when set and a new line otherwise.
+func (o DecoderOptions) RecordDelimiter() byte {
+	if o.DelimiterByteSet {
+		return o.DelimiterByte
+	}
+	return '\n'
+}
+
+// OffsetDomain defines the stream that offsets are positions in, when decoding compressed input.
+type OffsetDomain int
+
+const (
+	// OffsetDomainUncompressed is the default domain, where offsets are logical positions in the decompressed stream.
+	OffsetDomainUncompressed OffsetDomain = iota
+	// OffsetDomainCompressed is the domain where offsets are raw positions in the compressed stream.
+	OffsetDomainCompressed
+)
+
+const (
+	// compositeOffsetBits is the number of low bits of a composite int64 offset holding the byte offset.
+	compositeOffsetBits = 48
+	// MaxCompositeGeneration is the largest generation that fits in a composite int64 offset.
+	MaxCompositeGeneration = 1<<(63-compositeOffsetBits) - 1
+	// MaxCompositeOffset is the largest byte offset that fits in a composite int64 offset.
+	MaxCompositeOffset = 1<<compositeOffsetBits - 1
+)
+
+// OffsetInfo is a composite offset identifying a position in a file that may be rotated: the generation of the file,
+// e.g. derived from its inode, along with the byte offset within it. Checking the generation when resuming detects
+// that the file was rotated, in which case the byte offset no longer applies.
+// It can be encoded into an int64 offset, for use with WithOffset, or into a token, for use with WithOffsetToken.
+type OffsetInfo struct {
+	Generation uint64
 	Offset     int64
 }
 
+// OffsetInfoFromInt64 decodes a composite int64 offset created with OffsetInfo.Int64.
+// Plain byte offsets up to MaxCompositeOffset decode to generation 0.
+func OffsetInfoFromInt64(offset int64) OffsetInfo {
+	return OffsetInfo{
+		Generation: uint64(offset) >> compositeOffsetBits,
+		Offset:     offset & MaxCompositeOffset,
+	}
+}
+
+// Int64 encodes the offset into a non-negative int64, with the generation in the high bits.
+// It fails when the generation exceeds MaxCompositeGeneration or the offset is not within 0 and MaxCompositeOffset,
+// in which case Token should be used instead.
+func (o OffsetInfo) Int64() (int64, error) {
+	if o.Generation > MaxCompositeGeneration {
+		return 0, fmt.Errorf("generation %d exceeds %d", o.Generation, MaxCompositeGeneration)
+	}
+	if o.Offset < 0 || o.Offset > MaxCompositeOffset {
+		return 0, fmt.Errorf("offset %d is not within 0 and %d", o.Offset, int64(MaxCompositeOffset))
+	}
+	return int64(o.Generation<<compositeOffsetBits) | o.Offset, nil
+}
+
+// Token encodes the offset into a token of the form "<generation>:<offset>".
+func (o OffsetInfo) Token() string {
+	return strconv.FormatUint(o.Generation, 10) + ":" + strconv.FormatInt(o.Offset, 10)
+}
+
+// ParseOffsetInfo decodes a token created with OffsetInfo.Token.
+func ParseOffsetInfo(token string) (OffsetInfo, error) {
+	generation, offset, ok := strings.Cut(token, ":")
+	if !ok {
+		return OffsetInfo{}, fmt.Errorf("invalid offset token %q: missing generation", token)
+	}
+	var info OffsetInfo
+	var errGeneration, errOffset error
+	info.Generation, errGeneration = strconv.ParseUint(generation, 10, 64)
+	info.Offset, errOffset = strconv.ParseInt(offset, 10, 64)
+	if err := errors.Join(errGeneration, errOffset); err != nil {
+		return OffsetInfo{}, fmt.Errorf("invalid offset token %q: %w", token, err)
+	}
+	if info.Offset < 0 {
+		return OffsetInfo{}, fmt.Errorf("invalid offset token %q: negative offset", token)
+	}
+	return info, nil
+}
+
+// ResumeOffset returns the byte offset to resume reading the current generation of the file from.
+// It returns the byte offset of o when generation matches, otherwise 0 and false as the file was rotated
+// since o was recorded, so that reading restarts from the beginning of the current file.
+func (o OffsetInfo) ResumeOffset(generation uint64) (int64, bool) {
+	if o.Generation != generation {
+		return 0, false
+	}
+	return o.Offset, true
+}
+
 func NewDecoderOptions(opts ...DecoderOption) DecoderOptions {
 	options := DecoderOptions{
 		FlushBytes: defaultFlushBytes,
@@ -133,9 +515,30 @@ func NewDecoderOptions(opts ...DecoderOption) DecoderOptions {
 	return options
 }
 
+// DecoderDefaults overrides the package default flush thresholds of NewDecoderOptions, e.g. so that a collector
+// sets fleet-wide defaults for every receiver decoding streams. Zero values keep the package defaults.
+type DecoderDefaults struct {
+	// FlushBytes is the default number of bytes after which decoded data is flushed.
+	FlushBytes int64
+	// FlushItems is the default number of records after which decoded data is flushed.
+	FlushItems int64
+}
+
+// NewDecoderOptionsWithDefaults constructs DecoderOptions from the defaults, then applies the given options,
+// which take precedence over them.
+func NewDecoderOptionsWithDefaults(defaults DecoderDefaults, opts ...DecoderOption) DecoderOptions {
+	return NewDecoderOptions(append([]DecoderOption{WithDefaults(defaults)}, opts...)...)
+}
+
 // DecoderOption defines the functional option for DecoderOptions.
 type DecoderOption func(*DecoderOptions)
 
+// DownstreamLatencyFunc is the control signal of WithDownstreamLatencyBatching: it returns the current latency of
+// the consumers of decoded batches, e.g. a moving average of the time the receiver takes to export them, or 0 when
+// unknown, e.g. before the first export. Decoders call it once per batch they flush, from their own goroutine, so
+// it must be cheap and safe to call concurrently with the receiver updating the latency.
+type DownstreamLatencyFunc func() time.Duration
+
 // WithFlushBytes sets the number of bytes after stream decoder should flush.
 // Use WithFlushBytes(0) to disable flushing by byte count.
 func WithFlushBytes(b int64) DecoderOption {
@@ -152,6 +555,22 @@ func WithFlushItems(i int64) DecoderOption {
 	}
 }
 
+// WithFlushInterval sets the period after which stream decoders flush pending decoded data.
+// Use WithFlushInterval(0) to disable flushing by time. Decoders that do not support it ignore it.
+func WithFlushInterval(interval time.Duration) DecoderOption {
+	return func(o *DecoderOptions) {
+		o.FlushInterval = interval
+	}
+}
+
+// WithMaxRecordSize sets the maximum size in bytes of a record, beyond which decoding fails.
+// Use WithMaxRecordSize(0) to use the decoder's default limit, if any. Decoders that do not support it ignore it.
+func WithMaxRecordSize(size int) DecoderOption {
+	return func(o *DecoderOptions) {
+		o.MaxRecordSize = size
+	}
+}
+
 // WithOffset defines the initial stream offset for the stream.
 // The exact meaning of the offset may vary by decoder (e.g. bytes, lines, records).
 func WithOffset(offset int64) DecoderOption {
@@ -159,3 +578,294 @@ func WithOffset(offset int64) DecoderOption {
 		o.Offset = offset
 	}
 }
+
+// WithOffsetToken defines the initial position for decoders implementing OpaqueOffsetDecoder,
+// as returned by their OffsetToken method. Other decoders ignore it.
+func WithOffsetToken(token string) DecoderOption {
+	return func(o *DecoderOptions) {
+		o.OffsetToken = token
+	}
+}
+
+// WithReaderBufferSize sets the size of the buffer used by stream decoders to read from the stream.
+// Use WithReaderBufferSize(0) to use the decoder's default buffer size.
+func WithReaderBufferSize(size int) DecoderOption {
+	return func(o *DecoderOptions) {
+		o.ReaderBufferSize = size
+	}
+}
+
+// WithOffsetDomain sets whether offsets are positions in the compressed or the uncompressed stream.
+// Decoders that do not handle compressed input ignore it.
+func WithOffsetDomain(domain OffsetDomain) DecoderOption {
+	return func(o *DecoderOptions) {
+		o.OffsetDomain = domain
+	}
+}
+
+// WithSkipEmptyRecords sets whether decoders skip empty records instead of emitting them.
+// Skipped records still advance the offset, so that decoding can be resumed after them.
+func WithSkipEmptyRecords(skip bool) DecoderOption {
+	return func(o *DecoderOptions) {
+		o.SkipEmptyRecords = skip
+	}
+}
+
+// WithDelimiterByte sets the byte delimiting records of decoders scanning delimited records, a new line by default,
+// e.g. a NUL byte for journald exports or '|' for pipe-delimited exports.
+func WithDelimiterByte(delimiter byte) DecoderOption {
+	return func(o *DecoderOptions) {
+		o.DelimiterByte = delimiter
+		o.DelimiterByteSet = true
+	}
+}
+
+// WithReadAhead makes decoders read the stream ahead in the background, into up to buffers buffers of size bytes,
+// while they parse the data already read, e.g. to overlap the latency of reads from object storage with parsing.
+// A size of 0 uses the reader buffer size of the decoder. Offsets are unaffected.
+// Use WithReadAhead(0, 0) to disable it. Decoders that do not support it ignore it.
+func WithReadAhead(buffers, size int) DecoderOption {
+	return func(o *DecoderOptions) {
+		o.ReadAheadBuffers = buffers
+		o.ReadAheadBufferSize = size
+	}
+}
+
+// WithDownstreamLatencyBatching makes decoders adapt their flush thresholds to the latency of the consumers of their
+// batches, as reported by latency, e.g. so that slow exporters receive fewer but larger batches. Thresholds are raised
+// while latency exceeds target, up to 16 times FlushBytes and FlushItems, and lowered while it is under half of it,
+// down to 1/16 of them. It combines with WithAdaptiveBatching, whose scaling applies on top.
+// Use WithDownstreamLatencyBatching(0, nil) to disable it. Decoders that do not support it ignore it.
+func WithDownstreamLatencyBatching(target time.Duration, latency DownstreamLatencyFunc) DecoderOption {
+	return func(o *DecoderOptions) {
+		o.DownstreamLatencyTarget = target
+		o.DownstreamLatency = latency
+	}
+}
+
+// WithValue sets the option of key to value, so that packages building on stream decoding, e.g. to pass telemetry
+// settings or middlewares to their decoders, can carry options of their own along with the others. As with
+// context.WithValue, key must be comparable and should be of an unexported type of the package defining the option.
+func WithValue(key, value any) DecoderOption {
+	return func(o *DecoderOptions) {
+		values := make(map[any]any, len(o.values)+1)
+		maps.Copy(values, o.values)
+		values[key] = value
+		o.values = values
+	}
+}
+
+// WithBatchIDAttribute sets the resource attribute key decoders stamp each returned batch with, e.g. to correlate
+// the records of a batch through the pipeline. Batch ids are integers increasing from 1 within each decoder.
+func WithBatchIDAttribute(key string) DecoderOption {
+	return func(o *DecoderOptions) {
+		o.BatchIDAttribute = key
+	}
+}
+
+// WithFlushOnResourceBoundary delays flushes triggered by the flush thresholds until the next resource boundary,
+// so that a resource is not split across batches, which may then exceed the thresholds. Flushes triggered by
+// WithMaxBatchMemory are not delayed, and so split resources reaching it.
+func WithFlushOnResourceBoundary() DecoderOption {
+	return func(o *DecoderOptions) {
+		o.FlushOnResourceBoundary = true
+	}
+}
+
+// WithDefaults sets the flush thresholds to the non-zero defaults. It must be applied before other options, so that
+// explicit options take precedence over the defaults, see NewDecoderOptionsWithDefaults.
+func WithDefaults(defaults DecoderDefaults) DecoderOption {
+	return func(o *DecoderOptions) {
+		if defaults.FlushBytes > 0 {
+			o.FlushBytes = defaults.FlushBytes
+		}
+		if defaults.FlushItems > 0 {
+			o.FlushItems = defaults.FlushItems
+		}
+	}
+}
+
+// WithAdaptiveBatching makes decoders adapt their flush thresholds to the time batches take to decode, e.g. so that
+// decoders sharing a collector yield regularly. Thresholds are lowered while batches take longer than target to
+// decode, and raised back, up to FlushBytes and FlushItems, while they take less than half of it.
+// Use WithAdaptiveBatching(0) to disable it. Decoders that do not support it ignore it.
+func WithAdaptiveBatching(target time.Duration) DecoderOption {
+	return func(o *DecoderOptions) {
+		o.AdaptiveBatchTarget = target
+	}
+}
+
+// WithIdleCloseTimeout sets the period after which decoders close an idle stream implementing io.Closer,
+// e.g. to free the resources of abandoned connections. Unlike flushing, closing ends the stream.
+func WithIdleCloseTimeout(timeout time.Duration) DecoderOption {
+	return func(o *DecoderOptions) {
+		o.IdleCloseTimeout = timeout
+	}
+}
+
+// WithMaxBatchMemory makes decoders flush the batch under construction once its estimated in-memory size reaches
+// maxBytes, e.g. to bound the memory held by batches whose records expand while decoding. The size is estimated as
+// the OTLP protobuf size of the decoded data, so the cap is best-effort: the batch exceeds it by up to one record.
+// Use WithMaxBatchMemory(0) to disable it. Decoders that do not support it ignore it.
+func WithMaxBatchMemory(maxBytes int64) DecoderOption {
+	return func(o *DecoderOptions) {
+		o.MaxBatchMemory = maxBytes
+	}
+}
+
+// WithHeartbeat makes decoders call heartbeat every interval they wait for data without receiving any, e.g. so that
+// consumers tell quiet but healthy streams from dead ones. Heartbeats stop as soon as data is read, and resume once
+// the stream is quiet again. heartbeat is called from another goroutine than the decoder's.
+// Use WithHeartbeat(0, nil) to disable it. Decoders that do not support it ignore it.
+func WithHeartbeat(interval time.Duration, heartbeat func()) DecoderOption {
+	return func(o *DecoderOptions) {
+		o.HeartbeatInterval = interval
+		o.Heartbeat = heartbeat
+	}
+}
+
+// DecoderConfig is the user configuration of stream decoding, for components exposing it to embed in their configuration,
+// e.g. with the `mapstructure:",squash"` tag. Use NewDefaultDecoderConfig to construct with the defaults of
+// NewDecoderOptions, and ToOptions to derive the matching DecoderOption values.
+type DecoderConfig struct {
+	// FlushBytes is the number of bytes after which decoded data is flushed, 0 disables flushing by byte count.
+	FlushBytes int64 `mapstructure:"flush_bytes"`
+	// FlushItems is the number of records after which decoded data is flushed, 0 disables flushing by record count.
+	FlushItems int64 `mapstructure:"flush_items"`
+	// FlushInterval is the period after which pending decoded data is flushed, 0 disables flushing by time.
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+	// MaxRecordSize is the maximum size in bytes of a record, 0 means the decoder's default limit, if any.
+	MaxRecordSize int `mapstructure:"max_record_size"`
+	// Offset is the initial offset in the stream.
+	Offset int64 `mapstructure:"offset"`
+	// prevent unkeyed literal initialization
+	_ struct{}
+}
+
+// NewDefaultDecoderConfig returns the DecoderConfig matching the defaults of NewDecoderOptions.
+func NewDefaultDecoderConfig() DecoderConfig {
+	return DecoderConfig{
+		FlushBytes: defaultFlushBytes,
+		FlushItems: defaultFlushItems,
+	}
+}
+
+// Validate checks that no setting is negative.
+func (c *DecoderConfig) Validate() error {
+	var errs []error
+	if c.FlushBytes < 0 {
+		errs = append(errs, fmt.Errorf("flush_bytes must not be negative, got %d", c.FlushBytes))
+	}
+	if c.FlushItems < 0 {
+		errs = append(errs, fmt.Errorf("flush_items must not be negative, got %d", c.FlushItems))
+	}
+	if c.FlushInterval < 0 {
+		errs = append(errs, fmt.Errorf("flush_interval must not be negative, got %s", c.FlushInterval))
+	}
+	if c.MaxRecordSize < 0 {
+		errs = append(errs, fmt.Errorf("max_record_size must not be negative, got %d", c.MaxRecordSize))
+	}
+	if c.Offset < 0 {
+		errs = append(errs, fmt.Errorf("offset must not be negative, got %d", c.Offset))
+	}
+	return errors.Join(errs...)
+}
+
+// ToOptions returns the DecoderOption values applying the configuration, to be followed by any other option.
+func (c *DecoderConfig) ToOptions() []DecoderOption {
+	return []DecoderOption{
+		WithFlushBytes(c.FlushBytes),
+		WithFlushItems(c.FlushItems),
+		WithFlushInterval(c.FlushInterval),
+		WithMaxRecordSize(c.MaxRecordSize),
+		WithOffset(c.Offset),
+	}
+}
+
+// EncoderOptions configures the behavior of stream encoding and marshaling.
+// FlushBytes and FlushItems control how often the encoder should flush encoded data to the stream.
+// MaxPayloadBytes is the maximum size in bytes of the payloads encoders produce, 0 disables it: stream encoders flush
+// before the buffered records exceed it, so that writes can be rotated to a new object at any of them, and
+// marshalers fail with ErrPayloadTooLarge past it. A single record exceeding it is written on its own.
+// RecordSeparator delimits records when RecordSeparatorSet is set, instead of the separator configured for the
+// encoding. AppendTrailingSeparator also terminates the last record with the separator when TrailingSeparatorSet is
+// set, instead of the behavior configured for the encoding.
+// Encoders that do not support MaxPayloadBytes, RecordSeparator or AppendTrailingSeparator ignore them.
+// Use NewEncoderOptions to construct with default options.
+type EncoderOptions struct {
+	FlushBytes              int64
+	FlushItems              int64
+	MaxPayloadBytes         int
+	RecordSeparator         string
+	RecordSeparatorSet      bool
+	AppendTrailingSeparator bool
+	TrailingSeparatorSet    bool
+}
+
+// ErrPayloadTooLarge is returned by marshalers when a payload exceeds EncoderOptions.MaxPayloadBytes.
+var ErrPayloadTooLarge = errors.New("payload exceeds the maximum payload size")
+
+// LogsOptionsMarshaler is implemented by logs marshalers accepting EncoderOptions per call, e.g. for exporters to
+// control how records are delimited.
+type LogsOptionsMarshaler interface {
+	MarshalLogsWithOptions(ld plog.Logs, options ...EncoderOption) ([]byte, error)
+}
+
+// NewEncoderOptions returns the EncoderOptions applying opts, flushing after defaultFlushBytes bytes or
+// defaultFlushItems records by default, without a maximum payload size, and delimiting records as configured for
+// the encoding.
+func NewEncoderOptions(opts ...EncoderOption) EncoderOptions {
+	options := EncoderOptions{
+		FlushBytes: defaultFlushBytes,
+		FlushItems: defaultFlushItems,
+	}
+
+	for _, o := range opts {
+		o(&options)
+	}
+	return options
+}
+
+// EncoderOption defines the functional option for EncoderOptions.
+type EncoderOption func(*EncoderOptions)
+
+// WithEncoderFlushBytes sets the number of bytes after stream encoder should flush.
+// Use WithEncoderFlushBytes(0) to disable flushing by byte count.
+func WithEncoderFlushBytes(b int64) EncoderOption {
+	return func(o *EncoderOptions) {
+		o.FlushBytes = b
+	}
+}
+
+// WithEncoderFlushItems sets the number of items after stream encoder should flush.
+// Use WithEncoderFlushItems(0) to disable flushing by item count.
+func WithEncoderFlushItems(i int64) EncoderOption {
+	return func(o *EncoderOptions) {
+		o.FlushItems = i
+	}
+}
+
+// WithMaxPayloadBytes sets the maximum size in bytes of the payloads encoders produce.
+// Use WithMaxPayloadBytes(0) to disable it.
+func WithMaxPayloadBytes(b int) EncoderOption {
+	return func(o *EncoderOptions) {
+		o.MaxPayloadBytes = b
+	}
+}
+
+// WithRecordSeparator sets the separator delimiting records, overriding the one configured for the encoding.
+func WithRecordSeparator(separator string) EncoderOption {
+	return func(o *EncoderOptions) {
+		o.RecordSeparator = separator
+		o.RecordSeparatorSet = true
+	}
+}
+
+// WithTrailingSeparator sets whether the last record is also terminated with the separator, overriding the behavior
+// configured for the encoding.
+func WithTrailingSeparator(trailing bool) EncoderOption {
+	return func(o *EncoderOptions) {
+		o.AppendTrailingSeparator = trailing
+		o.TrailingSeparatorSet = true
+	}
+}
@@ -4,11 +4,19 @@
 package encoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
 
 import (
+	"io"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
 )
 
+// testKey is the key of the options set with WithValue in tests.
+type testKey struct{}
+
 func TestDecoderOptions(t *testing.T) {
 	t.Run("Check Defaults", func(t *testing.T) {
 		opts := NewDecoderOptions()
@@ -16,6 +24,24 @@ func TestDecoderOptions(t *testing.T) {
 		assert.Equal(t, int64(defaultFlushBytes), opts.FlushBytes)
 		assert.Equal(t, int64(defaultFlushItems), opts.FlushItems)
 		assert.Equal(t, int64(0), opts.Offset)
+		assert.Equal(t, 0, opts.ReaderBufferSize)
+		assert.Equal(t, OffsetDomainUncompressed, opts.OffsetDomain)
+		assert.False(t, opts.SkipEmptyRecords)
+		assert.Equal(t, time.Duration(0), opts.IdleCloseTimeout)
+		assert.Empty(t, opts.OffsetToken)
+		assert.Empty(t, opts.BatchIDAttribute)
+		assert.False(t, opts.FlushOnResourceBoundary)
+		assert.Equal(t, time.Duration(0), opts.AdaptiveBatchTarget)
+		assert.Equal(t, time.Duration(0), opts.HeartbeatInterval)
+		assert.Nil(t, opts.Heartbeat)
+		assert.Equal(t, int64(0), opts.MaxBatchMemory)
+		assert.Equal(t, byte('\n'), opts.RecordDelimiter())
+		assert.Equal(t, byte('\n'), DecoderOptions{}.RecordDelimiter())
+		assert.Equal(t, 0, opts.ReadAheadBuffers)
+		assert.Equal(t, 0, opts.ReadAheadBufferSize)
+		assert.Equal(t, time.Duration(0), opts.DownstreamLatencyTarget)
+		assert.Nil(t, opts.DownstreamLatency)
+		assert.Nil(t, opts.Value(testKey{}))
 	})
 
 	t.Run("Check overrides", func(t *testing.T) {
@@ -23,9 +49,387 @@ func TestDecoderOptions(t *testing.T) {
 		WithFlushBytes(100)(&opts)
 		WithFlushItems(50)(&opts)
 		WithOffset(50)(&opts)
+		WithReaderBufferSize(64 * 1024)(&opts)
+		WithOffsetDomain(OffsetDomainCompressed)(&opts)
+		WithSkipEmptyRecords(true)(&opts)
+		WithIdleCloseTimeout(time.Minute)(&opts)
+		WithOffsetToken("block-3")(&opts)
+		WithBatchIDAttribute("batch.id")(&opts)
+		WithFlushOnResourceBoundary()(&opts)
+		WithAdaptiveBatching(50 * time.Millisecond)(&opts)
+		var heartbeats int
+		WithHeartbeat(time.Second, func() { heartbeats++ })(&opts)
+		WithMaxBatchMemory(1 << 20)(&opts)
+		WithDelimiterByte(0)(&opts)
+		WithReadAhead(4, 1<<20)(&opts)
+		WithDownstreamLatencyBatching(100*time.Millisecond, func() time.Duration { return time.Second })(&opts)
+		WithValue(testKey{}, "value")(&opts)
 
 		assert.Equal(t, int64(100), opts.FlushBytes)
 		assert.Equal(t, int64(50), opts.FlushItems)
 		assert.Equal(t, int64(50), opts.Offset)
+		assert.Equal(t, 64*1024, opts.ReaderBufferSize)
+		assert.Equal(t, OffsetDomainCompressed, opts.OffsetDomain)
+		assert.True(t, opts.SkipEmptyRecords)
+		assert.Equal(t, time.Minute, opts.IdleCloseTimeout)
+		assert.Equal(t, "block-3", opts.OffsetToken)
+		assert.Equal(t, "batch.id", opts.BatchIDAttribute)
+		assert.True(t, opts.FlushOnResourceBoundary)
+		assert.Equal(t, 50*time.Millisecond, opts.AdaptiveBatchTarget)
+		assert.Equal(t, time.Second, opts.HeartbeatInterval)
+		opts.Heartbeat()
+		assert.Equal(t, 1, heartbeats)
+		assert.Equal(t, int64(1<<20), opts.MaxBatchMemory)
+		assert.Equal(t, byte(0), opts.RecordDelimiter())
+		assert.Equal(t, 4, opts.ReadAheadBuffers)
+		assert.Equal(t, 1<<20, opts.ReadAheadBufferSize)
+		assert.Equal(t, 100*time.Millisecond, opts.DownstreamLatencyTarget)
+		assert.Equal(t, time.Second, opts.DownstreamLatency())
+		assert.Equal(t, "value", opts.Value(testKey{}))
+	})
+}
+
+type offsetDecoder struct {
+	offset int64
+}
+
+func (d offsetDecoder) Offset() int64 {
+	return d.offset
+}
+
+type tokenDecoder struct {
+	offsetDecoder
+	token string
+}
+
+func (d tokenDecoder) OffsetToken() string {
+	return d.token
+}
+
+// tokenLogsDecoder is a LogsDecoder implementing OpaqueOffsetDecoder.
+type tokenLogsDecoder struct {
+	wrappingDecoder
+	tokenDecoder
+}
+
+func (d tokenLogsDecoder) Offset() int64 {
+	return d.tokenDecoder.Offset()
+}
+
+func TestDecoderDefaults(t *testing.T) {
+	defaults := DecoderDefaults{FlushBytes: 4096, FlushItems: 10}
+
+	t.Run("package defaults", func(t *testing.T) {
+		opts := NewDecoderOptionsWithDefaults(DecoderDefaults{})
+		assert.Equal(t, int64(defaultFlushBytes), opts.FlushBytes)
+		assert.Equal(t, int64(defaultFlushItems), opts.FlushItems)
+	})
+
+	t.Run("host defaults override package defaults", func(t *testing.T) {
+		opts := NewDecoderOptionsWithDefaults(defaults)
+		assert.Equal(t, int64(4096), opts.FlushBytes)
+		assert.Equal(t, int64(10), opts.FlushItems)
+
+		opts = NewDecoderOptionsWithDefaults(DecoderDefaults{FlushItems: 10})
+		assert.Equal(t, int64(defaultFlushBytes), opts.FlushBytes)
+		assert.Equal(t, int64(10), opts.FlushItems)
+	})
+
+	t.Run("explicit options override host defaults", func(t *testing.T) {
+		opts := NewDecoderOptionsWithDefaults(defaults, WithFlushBytes(0), WithFlushItems(defaultFlushItems))
+		assert.Equal(t, int64(0), opts.FlushBytes)
+		assert.Equal(t, int64(defaultFlushItems), opts.FlushItems)
+
+		opts = NewDecoderOptions(WithDefaults(defaults), WithFlushItems(5))
+		assert.Equal(t, int64(4096), opts.FlushBytes)
+		assert.Equal(t, int64(5), opts.FlushItems)
+	})
+}
+
+func TestResumeOption(t *testing.T) {
+	tests := []struct {
+		name     string
+		decoder  interface{ Offset() int64 }
+		expected DecoderOptions
+	}{
+		{
+			name:     "offset",
+			decoder:  offsetDecoder{offset: 42},
+			expected: NewDecoderOptions(WithOffset(42)),
+		},
+		{
+			name:     "token",
+			decoder:  tokenDecoder{offsetDecoder: offsetDecoder{offset: 42}, token: "block-3"},
+			expected: NewDecoderOptions(WithOffsetToken("block-3")),
+		},
+		{
+			name:     "wrapped token",
+			decoder:  wrappingDecoder{offsetDecoder: offsetDecoder{offset: 42}, decoder: tokenLogsDecoder{tokenDecoder: tokenDecoder{token: "block-3"}}},
+			expected: NewDecoderOptions(WithOffsetToken("block-3")),
+		},
+		{
+			name:     "empty token",
+			decoder:  tokenDecoder{offsetDecoder: offsetDecoder{offset: 42}},
+			expected: NewDecoderOptions(WithOffset(42)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, NewDecoderOptions(ResumeOption(tt.decoder)))
+		})
+	}
+}
+
+// wrappingDecoder wraps a decoder, providing none of its optional interfaces but through Unwrap.
+type wrappingDecoder struct {
+	offsetDecoder
+	decoder LogsDecoder
+}
+
+func (wrappingDecoder) DecodeLogs() (plog.Logs, error) {
+	return plog.NewLogs(), io.EOF
+}
+
+func (d wrappingDecoder) Unwrap() LogsDecoder {
+	return d.decoder
+}
+
+// statsDecoder provides StatsReporter with As when it has stats.
+type statsDecoder struct {
+	wrappingDecoder
+	stats *DecoderStats
+}
+
+func (d statsDecoder) As(target any) bool {
+	if reporter, ok := target.(*StatsReporter); ok && d.stats != nil {
+		*reporter = fixedStats(*d.stats)
+		return true
+	}
+	return false
+}
+
+// fixedStats reports the same stats.
+type fixedStats DecoderStats
+
+func (s fixedStats) Stats() DecoderStats {
+	return DecoderStats(s)
+}
+
+func TestDecoderAs(t *testing.T) {
+	token := tokenDecoder{token: "block-3"}
+	stats := statsDecoder{
+		wrappingDecoder: wrappingDecoder{decoder: wrappingDecoder{decoder: wrappingDecoder{}}},
+		stats:           &DecoderStats{RecordsDecoded: 3},
+	}
+	decoder := wrappingDecoder{offsetDecoder: offsetDecoder{offset: 42}, decoder: stats}
+
+	reporter, ok := DecoderAs[StatsReporter](decoder)
+	require.True(t, ok)
+	assert.Equal(t, int64(3), reporter.Stats().RecordsDecoded)
+	_, ok = DecoderAs[StatsReporter](statsDecoder{})
+	assert.False(t, ok)
+
+	_, ok = DecoderAs[OpaqueOffsetDecoder](decoder)
+	assert.False(t, ok)
+	tokenDecoder, ok := DecoderAs[OpaqueOffsetDecoder](token)
+	require.True(t, ok)
+	assert.Equal(t, "block-3", tokenDecoder.OffsetToken())
+
+	_, ok = DecoderAs[StatsReporter](nil)
+	assert.False(t, ok)
+}
+
+type offsetAwareDecoder struct {
+	offsetDecoder
+	semantics OffsetSemantics
+}
+
+func (d offsetAwareDecoder) OffsetSemantics() OffsetSemantics {
+	return d.semantics
+}
+
+func TestCanRestoreOffset(t *testing.T) {
+	bytesDecoder := offsetAwareDecoder{semantics: OffsetSemanticsBytes}
+	assert.True(t, CanRestoreOffset(bytesDecoder, OffsetSemanticsBytes))
+	assert.False(t, CanRestoreOffset(bytesDecoder, OffsetSemanticsRecords))
+	assert.False(t, CanRestoreOffset(bytesDecoder, OffsetSemanticsOpaque))
+
+	unsupported := offsetAwareDecoder{semantics: OffsetSemanticsUnsupported}
+	assert.False(t, CanRestoreOffset(unsupported, OffsetSemanticsUnsupported))
+
+	// Decoders not declaring their semantics are assumed to have opaque offsets
+	assert.True(t, CanRestoreOffset(offsetDecoder{}, OffsetSemanticsOpaque))
+	assert.False(t, CanRestoreOffset(offsetDecoder{}, OffsetSemanticsBytes))
+}
+
+func TestOffsetSemanticsText(t *testing.T) {
+	for _, semantics := range []OffsetSemantics{OffsetSemanticsUnsupported, OffsetSemanticsBytes, OffsetSemanticsRecords, OffsetSemanticsOpaque} {
+		text, err := semantics.MarshalText()
+		require.NoError(t, err)
+		assert.Equal(t, semantics.String(), string(text))
+
+		var unmarshaled OffsetSemantics
+		require.NoError(t, unmarshaled.UnmarshalText(text))
+		assert.Equal(t, semantics, unmarshaled)
+	}
+	assert.Equal(t, "bytes", OffsetSemanticsBytes.String())
+	assert.Equal(t, "OffsetSemantics(7)", OffsetSemantics(7).String())
+
+	_, err := OffsetSemantics(7).MarshalText()
+	require.EqualError(t, err, "invalid offset semantics 7")
+	var semantics OffsetSemantics
+	require.EqualError(t, semantics.UnmarshalText([]byte("lines")), `unknown offset semantics "lines"`)
+}
+
+func TestPartialDecodeError(t *testing.T) {
+	err := error(NewPartialDecodeError(2, 42, io.ErrUnexpectedEOF))
+	assert.EqualError(t, err, "failed to decode record at offset 42 after 2 decoded records: unexpected EOF")
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	var partialErr *PartialDecodeError
+	assert.ErrorAs(t, err, &partialErr)
+	assert.Equal(t, 2, partialErr.Decoded)
+	assert.Equal(t, int64(42), partialErr.Offset)
+}
+
+func TestEncoderOptions(t *testing.T) {
+	t.Run("Check Defaults", func(t *testing.T) {
+		opts := NewEncoderOptions()
+
+		assert.Equal(t, int64(defaultFlushBytes), opts.FlushBytes)
+		assert.Equal(t, int64(defaultFlushItems), opts.FlushItems)
+		assert.Zero(t, opts.MaxPayloadBytes)
+		assert.False(t, opts.RecordSeparatorSet)
+		assert.False(t, opts.TrailingSeparatorSet)
+	})
+
+	t.Run("Check overrides", func(t *testing.T) {
+		opts := NewEncoderOptions(
+			WithEncoderFlushBytes(100),
+			WithEncoderFlushItems(50),
+			WithMaxPayloadBytes(1024),
+			WithRecordSeparator(""),
+			WithTrailingSeparator(false),
+		)
+
+		assert.Equal(t, int64(100), opts.FlushBytes)
+		assert.Equal(t, int64(50), opts.FlushItems)
+		assert.Equal(t, 1024, opts.MaxPayloadBytes)
+		// Setting the empty separator or no trailing separator overrides the encoding's.
+		assert.Empty(t, opts.RecordSeparator)
+		assert.True(t, opts.RecordSeparatorSet)
+		assert.False(t, opts.AppendTrailingSeparator)
+		assert.True(t, opts.TrailingSeparatorSet)
+	})
+}
+
+func TestOffsetInfo(t *testing.T) {
+	info := OffsetInfo{Generation: 7, Offset: 1234}
+
+	t.Run("int64", func(t *testing.T) {
+		offset, err := info.Int64()
+		require.NoError(t, err)
+		assert.Positive(t, offset)
+		assert.Equal(t, info, OffsetInfoFromInt64(offset))
+
+		limits := OffsetInfo{Generation: MaxCompositeGeneration, Offset: MaxCompositeOffset}
+		offset, err = limits.Int64()
+		require.NoError(t, err)
+		assert.Equal(t, limits, OffsetInfoFromInt64(offset))
+
+		_, err = OffsetInfo{Generation: MaxCompositeGeneration + 1}.Int64()
+		assert.ErrorContains(t, err, "generation")
+		_, err = OffsetInfo{Offset: MaxCompositeOffset + 1}.Int64()
+		assert.ErrorContains(t, err, "offset")
+		_, err = OffsetInfo{Offset: -1}.Int64()
+		assert.ErrorContains(t, err, "offset")
+	})
+
+	t.Run("plain offset", func(t *testing.T) {
+		assert.Equal(t, OffsetInfo{Offset: 42}, OffsetInfoFromInt64(42))
+	})
+
+	t.Run("token", func(t *testing.T) {
+		large := OffsetInfo{Generation: 1 << 40, Offset: 1 << 60}
+		for _, info := range []OffsetInfo{info, large} {
+			parsed, err := ParseOffsetInfo(info.Token())
+			require.NoError(t, err)
+			assert.Equal(t, info, parsed)
+		}
+		assert.Equal(t, "7:1234", info.Token())
+
+		for _, token := range []string{"", "1234", "x:1", "1:x", "1:-1"} {
+			_, err := ParseOffsetInfo(token)
+			assert.Error(t, err, token)
+		}
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		offset, err := info.Int64()
+		require.NoError(t, err)
+		resumed := OffsetInfoFromInt64(NewDecoderOptions(WithOffset(offset)).Offset)
+
+		byteOffset, ok := resumed.ResumeOffset(7)
+		assert.True(t, ok)
+		assert.Equal(t, int64(1234), byteOffset)
+
+		// The file was rotated since the offset was recorded
+		byteOffset, ok = resumed.ResumeOffset(8)
+		assert.False(t, ok)
+		assert.Zero(t, byteOffset)
+	})
+}
+
+func TestDecoderConfig(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		cfg := NewDefaultDecoderConfig()
+		require.NoError(t, cfg.Validate())
+		assert.Equal(t, NewDecoderOptions(), NewDecoderOptions(cfg.ToOptions()...))
+	})
+
+	t.Run("options", func(t *testing.T) {
+		cfg := DecoderConfig{
+			FlushBytes:    1024,
+			FlushItems:    0,
+			FlushInterval: 5 * time.Second,
+			MaxRecordSize: 65536,
+			Offset:        42,
+		}
+		require.NoError(t, cfg.Validate())
+
+		options := NewDecoderOptions(cfg.ToOptions()...)
+		assert.Equal(t, int64(1024), options.FlushBytes)
+		assert.Equal(t, int64(0), options.FlushItems)
+		assert.Equal(t, 5*time.Second, options.FlushInterval)
+		assert.Equal(t, 65536, options.MaxRecordSize)
+		assert.Equal(t, int64(42), options.Offset)
+	})
+
+	t.Run("keys", func(t *testing.T) {
+		var keys []string
+		typ := reflect.TypeFor[DecoderConfig]()
+		for i := range typ.NumField() {
+			if key, ok := typ.Field(i).Tag.Lookup("mapstructure"); ok {
+				keys = append(keys, key)
+			}
+		}
+		assert.Equal(t, []string{"flush_bytes", "flush_items", "flush_interval", "max_record_size", "offset"}, keys)
+	})
+
+	t.Run("negative values", func(t *testing.T) {
+		cfg := DecoderConfig{
+			FlushBytes:    -1,
+			FlushItems:    -2,
+			FlushInterval: -time.Second,
+			MaxRecordSize: -3,
+			Offset:        -4,
+		}
+
+		err := cfg.Validate()
+		require.ErrorContains(t, err, "flush_bytes must not be negative, got -1")
+		require.ErrorContains(t, err, "flush_items must not be negative, got -2")
+		require.ErrorContains(t, err, "flush_interval must not be negative, got -1s")
+		require.ErrorContains(t, err, "max_record_size must not be negative, got -3")
+		require.ErrorContains(t, err, "offset must not be negative, got -4")
 	})
 }
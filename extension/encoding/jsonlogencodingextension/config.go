@@ -16,6 +16,8 @@ type Config struct {
 	// Export raw log string instead of log wrapper
 	Mode      JSONEncodingMode `mapstructure:"mode,omitempty"`
 	ArrayMode bool             `mapstructure:"array_mode,omitempty"`
+	// PreserveNumberTypes unmarshals integral JSON numbers as Int values instead of Double values.
+	PreserveNumberTypes bool `mapstructure:"preserve_number_types,omitempty"`
 
 	// prevent unkeyed literal initialization
 	_ struct{}
@@ -103,24 +103,41 @@ func (e *jsonLogExtension) UnmarshalLogs(buf []byte) (plog.Logs, error) {
 		// Default mode to handle arrays having backward compatibility
 		var jsonLogs []map[string]any
 
-		err := json.Unmarshal(buf, &jsonLogs)
+		var err error
+		if e.config.PreserveNumberTypes {
+			decoder := json.NewDecoder(bytes.NewReader(buf))
+			decoder.UseNumber()
+			err = decoder.Decode(&jsonLogs)
+		} else {
+			err = json.Unmarshal(buf, &jsonLogs)
+		}
 		if err != nil {
 			return p, err
 		}
 
 		for _, r := range jsonLogs {
+			if e.config.PreserveNumberTypes {
+				convertNumbers(r)
+			}
 			if err := sl.LogRecords().AppendEmpty().Body().SetEmptyMap().FromRaw(r); err != nil {
 				return p, err
 			}
 		}
 	} else {
 		reader := newStreamReader(bytes.NewReader(buf))
+		if e.config.PreserveNumberTypes {
+			reader.decoder.UseNumber()
+		}
 		for reader.next() {
 			record, err := reader.value()
 			if err != nil {
 				return plog.Logs{}, err
 			}
 
+			if e.config.PreserveNumberTypes {
+				convertNumbers(record)
+			}
+
 			if err := sl.LogRecords().AppendEmpty().Body().SetEmptyMap().FromRaw(record); err != nil {
 				return p, err
 			}
@@ -180,3 +197,34 @@ func (r *streamReader) next() bool {
 func (r *streamReader) value() (map[string]any, error) {
 	return r.current, r.err
 }
+
+// convertNumbers replaces the json.Number values decoded with UseNumber in record, recursively,
+// with int64 values when they are integral and float64 values otherwise.
+func convertNumbers(record map[string]any) {
+	for k, v := range record {
+		record[k] = convertNumber(v)
+	}
+}
+
+func convertNumber(v any) any {
+	switch value := v.(type) {
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		if f, err := value.Float64(); err == nil {
+			return f
+		}
+		return value.String()
+	case map[string]any:
+		convertNumbers(value)
+		return value
+	case []any:
+		for i, item := range value {
+			value[i] = convertNumber(item)
+		}
+		return value
+	default:
+		return v
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/golden"
@@ -159,3 +160,69 @@ func sampleLog() plog.Logs {
 	rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("log testing")
 	return l
 }
+
+func TestUnmarshalPreserveNumberTypes(t *testing.T) {
+	line := `{"string":"value","int":42,"negative":-7,"float":4.2,"exponent":1e3,"bool":true,"null":null,"nested":{"int":1,"list":[2,2.5]}}`
+
+	for _, arrayMode := range []bool{false, true} {
+		input := line
+		if arrayMode {
+			input = "[" + line + "]"
+		}
+
+		e := &jsonLogExtension{
+			config: &Config{
+				Mode:                JSONEncodingModeBody,
+				ArrayMode:           arrayMode,
+				PreserveNumberTypes: true,
+			},
+		}
+		logs, err := e.UnmarshalLogs([]byte(input))
+		require.NoError(t, err)
+		require.Equal(t, 1, logs.LogRecordCount())
+
+		body := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Map()
+		assertValue := func(key string, valueType pcommon.ValueType) pcommon.Value {
+			v, ok := body.Get(key)
+			require.True(t, ok, key)
+			assert.Equal(t, valueType, v.Type(), key)
+			return v
+		}
+
+		assert.Equal(t, "value", assertValue("string", pcommon.ValueTypeStr).Str())
+		assert.Equal(t, int64(42), assertValue("int", pcommon.ValueTypeInt).Int())
+		assert.Equal(t, int64(-7), assertValue("negative", pcommon.ValueTypeInt).Int())
+		assert.Equal(t, 4.2, assertValue("float", pcommon.ValueTypeDouble).Double())
+		assert.Equal(t, 1000.0, assertValue("exponent", pcommon.ValueTypeDouble).Double())
+		assert.True(t, assertValue("bool", pcommon.ValueTypeBool).Bool())
+		assertValue("null", pcommon.ValueTypeEmpty)
+
+		nested := assertValue("nested", pcommon.ValueTypeMap).Map()
+		nestedInt, ok := nested.Get("int")
+		require.True(t, ok)
+		assert.Equal(t, pcommon.ValueTypeInt, nestedInt.Type())
+		list, ok := nested.Get("list")
+		require.True(t, ok)
+		require.Equal(t, 2, list.Slice().Len())
+		assert.Equal(t, pcommon.ValueTypeInt, list.Slice().At(0).Type())
+		assert.Equal(t, pcommon.ValueTypeDouble, list.Slice().At(1).Type())
+
+		// Integers are marshaled back without a fractional part
+		buf, err := e.MarshalLogs(logs)
+		require.NoError(t, err)
+		assert.Contains(t, string(buf), `"int":42`)
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		e := &jsonLogExtension{
+			config: &Config{
+				Mode: JSONEncodingModeBody,
+			},
+		}
+		logs, err := e.UnmarshalLogs([]byte(line))
+		require.NoError(t, err)
+		v, ok := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Map().Get("int")
+		require.True(t, ok)
+		assert.Equal(t, pcommon.ValueTypeDouble, v.Type())
+	})
+}
@@ -0,0 +1,194 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension // import "github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding/textencodingextension"
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"regexp"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	txt "golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+const (
+	// autoEncoding is the encoding value that enables charset detection.
+	autoEncoding = "auto"
+
+	// defaultSniffBufferSize is the default number of bytes inspected to detect the charset.
+	defaultSniffBufferSize = 4096
+
+	// charsetAttribute is the log record attribute holding the detected charset.
+	charsetAttribute = "log.record.charset"
+)
+
+const (
+	charsetUTF8    = "utf-8"
+	charsetUTF16LE = "utf-16le"
+	charsetUTF16BE = "utf-16be"
+	charsetLatin1  = "iso-8859-1"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// fallbackCharset is the charset of streams without a byte order mark, instead of the detected one.
+type fallbackCharset struct {
+	name     string
+	encoding txt.Encoding
+}
+
+// sniffCharset peeks at up to size bytes of the reader and detects their charset.
+// The returned reader must be used in place of the original one, as it still holds the sniffed bytes.
+func sniffCharset(reader io.Reader, size int, fallback *fallbackCharset) (io.Reader, string, txt.Encoding, error) {
+	bufReader := bufio.NewReaderSize(reader, size)
+	sample, err := bufReader.Peek(size)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, "", nil, err
+	}
+	name, enc := detectCharset(sample, fallback)
+	return bufReader, name, enc, nil
+}
+
+// detectCharset detects the charset of the sample based on its byte order mark, which is stripped when decoding.
+// Without a byte order mark, it returns the fallback charset if not nil, or else falls back to a heuristic between
+// UTF-16, UTF-8 and Latin-1.
+func detectCharset(sample []byte, fallback *fallbackCharset) (string, txt.Encoding) {
+	switch {
+	case bytes.HasPrefix(sample, bomUTF8):
+		return charsetUTF8, unicode.UTF8BOM
+	case bytes.HasPrefix(sample, bomUTF16LE):
+		return charsetUTF16LE, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM)
+	case bytes.HasPrefix(sample, bomUTF16BE):
+		return charsetUTF16BE, unicode.UTF16(unicode.BigEndian, unicode.UseBOM)
+	case fallback != nil:
+		return fallback.name, fallback.encoding
+	}
+
+	// ASCII heavy UTF-16 text has a zero byte in every other position.
+	var evenZeros, oddZeros int
+	pairs := len(sample) / 2
+	for i := 0; i < pairs*2; i += 2 {
+		if sample[i] == 0 {
+			evenZeros++
+		}
+		if sample[i+1] == 0 {
+			oddZeros++
+		}
+	}
+	switch {
+	case pairs > 0 && oddZeros*2 >= pairs && evenZeros*10 < pairs:
+		return charsetUTF16LE, unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	case pairs > 0 && evenZeros*2 >= pairs && oddZeros*10 < pairs:
+		return charsetUTF16BE, unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM)
+	}
+
+	if isUTF8(sample) {
+		return charsetUTF8, unicode.UTF8
+	}
+	return charsetLatin1, charmap.ISO8859_1
+}
+
+// trimBOM removes a leading UTF-8 or UTF-16 byte order mark from b.
+func trimBOM(b []byte) []byte {
+	for _, bom := range [][]byte{bomUTF8, bomUTF16LE, bomUTF16BE} {
+		if bytes.HasPrefix(b, bom) {
+			return b[len(bom):]
+		}
+	}
+	return b
+}
+
+// isUTF8 reports whether b is valid UTF-8, tolerating a rune truncated at the end of the sample.
+func isUTF8(b []byte) bool {
+	for len(b) > 0 {
+		r, size := utf8.DecodeRune(b)
+		if r == utf8.RuneError && size <= 1 {
+			return !utf8.FullRune(b)
+		}
+		b = b[size:]
+	}
+	return true
+}
+
+// utf16ByteOrder returns the byte order of the UTF-16 charset, or nil for other charsets.
+func utf16ByteOrder(charset string) binary.ByteOrder {
+	switch charset {
+	case charsetUTF16LE:
+		return binary.LittleEndian
+	case charsetUTF16BE:
+		return binary.BigEndian
+	}
+	return nil
+}
+
+// splitUTF16 splits UTF-16 text of the given byte order at the matches of separator, which are found in the text
+// decoded to UTF-8, so that separators match whatever their encoding. Tokens hold the raw UTF-16 bytes of records,
+// and advance past the raw bytes of their separator. The text is decoded in windows growing from the start of data,
+// so that short records do not decode the whole buffer.
+func splitUTF16(separator *regexp.Regexp, order binary.ByteOrder, data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	for window := min(256, len(data)); ; window = min(2*window, len(data)) {
+		final := window == len(data)
+		decoded, offsets := decodeUTF16(data[:window], order, final && atEOF)
+		// A separator ending the decoded text may go on in the following bytes.
+		if loc := separator.FindIndex(decoded); loc != nil && (loc[1] < len(decoded) || final && atEOF) {
+			return offsets[loc[1]], data[:offsets[loc[0]]], nil
+		}
+		if final {
+			break
+		}
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil // Request more data
+}
+
+// decodeUTF16 decodes the UTF-16 text of b to UTF-8, along with the offset in b of each byte of the decoded text, and
+// of its end. Invalid code units decode to utf8.RuneError. A surrogate pair or code unit truncated at the end of b is
+// left undecoded, unless atEOF.
+func decodeUTF16(b []byte, order binary.ByteOrder, atEOF bool) (decoded []byte, offsets []int) {
+	decoded = make([]byte, 0, len(b))
+	offsets = make([]int, 0, len(b)+1)
+	i := 0
+	for i < len(b) {
+		r, size := utf8.RuneError, len(b)-i
+		switch {
+		case size < 2:
+			if !atEOF {
+				return decoded, append(offsets, i)
+			}
+		case !utf16.IsSurrogate(rune(order.Uint16(b[i:]))):
+			r, size = rune(order.Uint16(b[i:])), 2
+		case size < 4 && !atEOF:
+			return decoded, append(offsets, i)
+		case size < 4:
+			size = 2
+		default:
+			size = 2
+			if pair := utf16.DecodeRune(rune(order.Uint16(b[i:])), rune(order.Uint16(b[i+2:]))); pair != utf8.RuneError {
+				r, size = pair, 4
+			}
+		}
+		n := len(decoded)
+		decoded = utf8.AppendRune(decoded, r)
+		for range len(decoded) - n {
+			offsets = append(offsets, i)
+		}
+		i += size
+	}
+	return decoded, append(offsets, i)
+}
@@ -0,0 +1,265 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+func TestDetectCharset(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected string
+	}{
+		{
+			name:     "empty",
+			input:    nil,
+			expected: charsetUTF8,
+		},
+		{
+			name:     "utf8 bom",
+			input:    []byte("\xEF\xBB\xBFhello"),
+			expected: charsetUTF8,
+		},
+		{
+			name:     "utf16le bom",
+			input:    []byte("\xFF\xFEh\x00i\x00"),
+			expected: charsetUTF16LE,
+		},
+		{
+			name:     "utf16be bom",
+			input:    []byte("\xFE\xFF\x00h\x00i"),
+			expected: charsetUTF16BE,
+		},
+		{
+			name:     "utf16le no bom",
+			input:    []byte("h\x00e\x00l\x00l\x00o\x00"),
+			expected: charsetUTF16LE,
+		},
+		{
+			name:     "utf8 no bom",
+			input:    []byte("héllo wörld"),
+			expected: charsetUTF8,
+		},
+		{
+			name:     "utf8 truncated rune",
+			input:    []byte("hello \xC3"),
+			expected: charsetUTF8,
+		},
+		{
+			name:     "latin1",
+			input:    []byte("h\xE9llo w\xF6rld"),
+			expected: charsetLatin1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, _ := detectCharset(tt.input, nil)
+			assert.Equal(t, tt.expected, name)
+		})
+	}
+}
+
+func TestSniffCharset_doesNotConsumeBytes(t *testing.T) {
+	input := []byte("\xEF\xBB\xBFfoo\nbar\n")
+	reader, name, _, err := sniffCharset(bytes.NewReader(input), 4, nil)
+	require.NoError(t, err)
+	assert.Equal(t, charsetUTF8, name)
+
+	b, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, input, b)
+}
+
+func TestAutoDetect(t *testing.T) {
+	latin1 := &fallbackCharset{name: charsetLatin1, encoding: charmap.ISO8859_1}
+	tests := []struct {
+		name            string
+		input           []byte
+		fallback        *fallbackCharset
+		expectedBodies  []string
+		expectedCharset string
+	}{
+		{
+			name:            "utf8 bom",
+			input:           []byte("\xEF\xBB\xBFfoo\nbär\n"),
+			expectedBodies:  []string{"foo", "bär"},
+			expectedCharset: charsetUTF8,
+		},
+		{
+			name:            "utf16le bom",
+			input:           []byte("\xFF\xFEf\x00o\x00o\x00"),
+			expectedBodies:  []string{"foo"},
+			expectedCharset: charsetUTF16LE,
+		},
+		{
+			name:            "no bom",
+			input:           []byte("foo\nbär\n"),
+			expectedBodies:  []string{"foo", "bär"},
+			expectedCharset: charsetUTF8,
+		},
+		{
+			name:            "no bom latin1",
+			input:           []byte("foo\nb\xE4r\n"),
+			expectedBodies:  []string{"foo", "bär"},
+			expectedCharset: charsetLatin1,
+		},
+		{
+			name:            "utf16le bom with fallback",
+			input:           []byte("\xFF\xFEf\x00o\x00o\x00"),
+			fallback:        latin1,
+			expectedBodies:  []string{"foo"},
+			expectedCharset: charsetUTF16LE,
+		},
+		{
+			name:            "utf8 bom with fallback",
+			input:           []byte("\xEF\xBB\xBFfoo\nb\xC3\xA4r\n"),
+			fallback:        latin1,
+			expectedBodies:  []string{"foo", "bär"},
+			expectedCharset: charsetUTF8,
+		},
+		{
+			// Valid UTF-8 is still decoded with the fallback charset
+			name:            "no bom with fallback",
+			input:           []byte("foo\nb\xC3\xA4r\n"),
+			fallback:        latin1,
+			expectedBodies:  []string{"foo", "bÃ¤r"},
+			expectedCharset: charsetLatin1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec := &textLogCodec{
+				autoDetect:            true,
+				sniffBufferSize:       defaultSniffBufferSize,
+				autoFallback:          tt.fallback,
+				unmarshalingSeparator: regexp.MustCompile(`\r?\n`),
+			}
+			ld, err := codec.UnmarshalLogs(tt.input)
+			require.NoError(t, err)
+			require.Equal(t, len(tt.expectedBodies), ld.LogRecordCount())
+			for i, expected := range tt.expectedBodies {
+				lr := ld.ResourceLogs().At(i).ScopeLogs().At(0).LogRecords().At(0)
+				assert.Equal(t, expected, lr.Body().Str())
+				charset, ok := lr.Attributes().Get(charsetAttribute)
+				require.True(t, ok)
+				assert.Equal(t, tt.expectedCharset, charset.Str())
+			}
+		})
+	}
+}
+
+func TestAutoDetectUTF16Records(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	lines := []string{"foo", "bär", "emoji 😀", "", long, "last"}
+	text := strings.Join(lines, "\r\n") + "\r\n"
+
+	for _, tt := range []struct {
+		name    string
+		charset string
+		bom     []byte
+		order   unicode.Endianness
+	}{
+		{name: "utf16le bom", charset: charsetUTF16LE, bom: bomUTF16LE, order: unicode.LittleEndian},
+		{name: "utf16be bom", charset: charsetUTF16BE, bom: bomUTF16BE, order: unicode.BigEndian},
+		{name: "utf16le no bom", charset: charsetUTF16LE, order: unicode.LittleEndian},
+		{name: "utf16be no bom", charset: charsetUTF16BE, order: unicode.BigEndian},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := unicode.UTF16(tt.order, unicode.IgnoreBOM).NewEncoder().String(text)
+			require.NoError(t, err)
+			input := append(slices.Clone(tt.bom), encoded...)
+
+			codec := &textLogCodec{
+				autoDetect:            true,
+				sniffBufferSize:       defaultSniffBufferSize,
+				unmarshalingSeparator: regexp.MustCompile(`\r?\n`),
+			}
+			decoder, err := codec.NewLogsDecoder(bytes.NewReader(input), encoding.WithFlushItems(1))
+			require.NoError(t, err)
+
+			// Records are split at the separators encoded in UTF-16, and offsets count the raw bytes consumed.
+			var bodies []string
+			var offsets []int64
+			for {
+				logs, err := decoder.DecodeLogs()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				require.NoError(t, err)
+				lr := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+				bodies = append(bodies, lr.Body().Str())
+				charset, ok := lr.Attributes().Get(charsetAttribute)
+				require.True(t, ok)
+				assert.Equal(t, tt.charset, charset.Str())
+				offsets = append(offsets, decoder.Offset())
+			}
+			assert.Equal(t, lines, bodies)
+			require.Len(t, offsets, len(lines))
+			assert.Equal(t, int64(len(input)), offsets[len(offsets)-1])
+
+			// Resuming from the offset of a record decodes the following ones.
+			decoder, err = codec.NewLogsDecoder(bytes.NewReader(input), encoding.WithOffset(offsets[1]), encoding.WithFlushItems(0))
+			require.NoError(t, err)
+			logs, err := decoder.DecodeLogs()
+			require.NoError(t, err)
+			require.Equal(t, len(lines)-2, logs.LogRecordCount())
+			for i := range logs.ResourceLogs().Len() {
+				assert.Equal(t, lines[i+2], logs.ResourceLogs().At(i).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+			}
+		})
+	}
+}
+
+func TestSplitUTF16(t *testing.T) {
+	separator := regexp.MustCompile(`\r?\n`)
+	encode := func(s string) []byte {
+		b, err := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewEncoder().Bytes([]byte(s))
+		require.NoError(t, err)
+		return b
+	}
+
+	// A separator ending the data may go on, e.g. "\r" before "\n".
+	advance, token, err := splitUTF16(separator, binary.LittleEndian, encode("foo\r"), false)
+	require.NoError(t, err)
+	assert.Zero(t, advance)
+	assert.Nil(t, token)
+
+	advance, token, err = splitUTF16(separator, binary.LittleEndian, encode("foo\r\nbar"), false)
+	require.NoError(t, err)
+	assert.Equal(t, 10, advance)
+	assert.Equal(t, encode("foo"), token)
+
+	// A surrogate pair truncated by the end of the data is not decoded until more data is read.
+	emoji := encode("😀\n")
+	advance, token, err = splitUTF16(separator, binary.LittleEndian, emoji[:3], false)
+	require.NoError(t, err)
+	assert.Zero(t, advance)
+	assert.Nil(t, token)
+	advance, token, err = splitUTF16(separator, binary.LittleEndian, emoji, true)
+	require.NoError(t, err)
+	assert.Equal(t, 6, advance)
+	assert.Equal(t, emoji[:4], token)
+
+	// The remaining bytes form the last record at the end of the stream, an odd byte included.
+	advance, token, err = splitUTF16(separator, binary.LittleEndian, append(encode("foo"), 'x'), true)
+	require.NoError(t, err)
+	assert.Equal(t, 7, advance)
+	assert.Equal(t, append(encode("foo"), 'x'), token)
+}
@@ -0,0 +1,117 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension // import "github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding/textencodingextension"
+
+import (
+	"bytes"
+	"crypto/md5" // #nosec G501 -- MD5 verifies the integrity of records, not their authenticity.
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"strings"
+)
+
+const (
+	// checksumNone does not verify records.
+	checksumNone = "none"
+	// checksumCRC32 verifies the IEEE CRC-32 of records.
+	checksumCRC32 = "crc32"
+	// checksumMD5 verifies the MD5 digest of records.
+	checksumMD5 = "md5"
+
+	// checksumFormatHex reads checksums as hexadecimal, compared case-insensitively.
+	checksumFormatHex = "hex"
+	// checksumFormatBase64 reads checksums as padded standard base64.
+	checksumFormatBase64 = "base64"
+
+	// checksumMismatchError fails the decoding of records whose checksum does not match.
+	checksumMismatchError = "error"
+	// checksumMismatchDeadLetter emits records whose checksum does not match as-is, flagged by checksumErrorAttribute.
+	checksumMismatchDeadLetter = "dead_letter"
+
+	// checksumErrorAttribute is the log record attribute holding why the checksum of a dead-lettered record does not match.
+	checksumErrorAttribute = "log.checksum.error"
+)
+
+var errChecksumMismatch = errors.New("record checksum mismatch")
+
+// recordChecksum verifies and strips the checksum trailing each record split from the stream.
+type recordChecksum struct {
+	newHash func() hash.Hash
+	// encode formats a digest as it trails records.
+	encode func([]byte) string
+	// size is the length of the encoded checksum.
+	size int
+	// separator precedes the checksum, which directly follows the record when empty.
+	separator []byte
+	// foldCase compares checksums case-insensitively.
+	foldCase bool
+	// deadLetter emits records whose checksum does not match instead of failing their decoding.
+	deadLetter bool
+}
+
+// newRecordChecksum returns the verifier of algorithm checksums, encoded in format and preceded by separator.
+// It returns nil when algorithm is empty or checksumNone.
+func newRecordChecksum(algorithm, format, separator, mismatch string) (*recordChecksum, error) {
+	c := &recordChecksum{separator: []byte(separator)}
+	switch algorithm {
+	case "", checksumNone:
+		return nil, nil
+	case checksumCRC32:
+		c.newHash = func() hash.Hash { return crc32.NewIEEE() }
+	case checksumMD5:
+		c.newHash = md5.New
+	default:
+		return nil, fmt.Errorf("unsupported record_checksum %q", algorithm)
+	}
+
+	digestSize := c.newHash().Size()
+	switch format {
+	case "", checksumFormatHex:
+		c.encode = hex.EncodeToString
+		c.size = hex.EncodedLen(digestSize)
+		c.foldCase = true
+	case checksumFormatBase64:
+		c.encode = base64.StdEncoding.EncodeToString
+		c.size = base64.StdEncoding.EncodedLen(digestSize)
+	default:
+		return nil, fmt.Errorf("unsupported record_checksum_format %q", format)
+	}
+
+	switch mismatch {
+	case "", checksumMismatchError:
+	case checksumMismatchDeadLetter:
+		c.deadLetter = true
+	default:
+		return nil, fmt.Errorf("unsupported record_checksum_mismatch %q", mismatch)
+	}
+	return c, nil
+}
+
+// verify returns record stripped of its separator and checksum, or record as-is along with an error wrapping
+// errChecksumMismatch when its checksum is missing or does not match.
+func (c *recordChecksum) verify(record []byte) ([]byte, error) {
+	if len(record) < len(c.separator)+c.size {
+		return record, fmt.Errorf("%w: record is shorter than its %d characters checksum", errChecksumMismatch, c.size)
+	}
+	end := len(record) - c.size
+	stripped, actual := record[:end], string(record[end:])
+	if len(c.separator) > 0 {
+		if !bytes.HasSuffix(stripped, c.separator) {
+			return record, fmt.Errorf("%w: no %q separator before the checksum", errChecksumMismatch, c.separator)
+		}
+		stripped = stripped[:len(stripped)-len(c.separator)]
+	}
+
+	h := c.newHash()
+	_, _ = h.Write(stripped)
+	expected := c.encode(h.Sum(nil))
+	if expected == actual || (c.foldCase && strings.EqualFold(expected, actual)) {
+		return stripped, nil
+	}
+	return record, fmt.Errorf("%w: expected %s, got %s", errChecksumMismatch, expected, actual)
+}
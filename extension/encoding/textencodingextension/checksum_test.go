@@ -0,0 +1,195 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/textutils"
+)
+
+func newChecksumCodec(t *testing.T, algorithm, format, separator, mismatch string) *textLogCodec {
+	enc, err := textutils.LookupEncoding("utf8")
+	require.NoError(t, err)
+	checksum, err := newRecordChecksum(algorithm, format, separator, mismatch)
+	require.NoError(t, err)
+	return &textLogCodec{
+		decoder:               enc.NewDecoder(),
+		unmarshalingSeparator: regexp.MustCompile(`\r?\n`),
+		recordChecksum:        checksum,
+	}
+}
+
+// checksumInputs are "foo", "bar" and "baz" records followed by their checksums, "bar" being corrupted as "bax".
+var checksumInputs = []struct {
+	name      string
+	algorithm string
+	format    string
+	separator string
+	valid     string
+	corrupted string
+}{
+	{
+		name:      "crc32 hex",
+		algorithm: checksumCRC32,
+		separator: " ",
+		valid:     "foo 8c736521\nbar 76FF8CAA\nbaz 78240498\n",
+		corrupted: "foo 8c736521\nbax 76ff8caa\nbaz 78240498\n",
+	},
+	{
+		name:      "crc32 base64 without separator",
+		algorithm: checksumCRC32,
+		format:    checksumFormatBase64,
+		valid:     "foojHNlIQ==\nbardv+Mqg==\nbazeCQEmA==\n",
+		corrupted: "foojHNlIQ==\nbaxdv+Mqg==\nbazeCQEmA==\n",
+	},
+	{
+		name:      "md5 hex",
+		algorithm: checksumMD5,
+		separator: "|",
+		valid:     "foo|acbd18db4cc2f85cedef654fccc4a4d8\nbar|37b51d194a7513e45b56f6524f2d51f2\nbaz|73feffa4b7f6bb68e44cf984c85f6e88\n",
+		corrupted: "foo|acbd18db4cc2f85cedef654fccc4a4d8\nbax|37b51d194a7513e45b56f6524f2d51f2\nbaz|73feffa4b7f6bb68e44cf984c85f6e88\n",
+	},
+	{
+		name:      "md5 base64",
+		algorithm: checksumMD5,
+		format:    checksumFormatBase64,
+		separator: " ",
+		valid:     "foo rL0Y20zC+Fzt72VPzMSk2A==\nbar N7UdGUp1E+RbVvZSTy1R8g==\nbaz c/7/pLf2u2jkTPmEyF9uiA==\n",
+		corrupted: "foo rL0Y20zC+Fzt72VPzMSk2A==\nbax N7UdGUp1E+RbVvZSTy1R8g==\nbaz c/7/pLf2u2jkTPmEyF9uiA==\n",
+	},
+}
+
+func TestRecordChecksum_valid(t *testing.T) {
+	for _, tt := range checksumInputs {
+		for _, mismatch := range []string{checksumMismatchError, checksumMismatchDeadLetter} {
+			t.Run(tt.name+" "+mismatch, func(t *testing.T) {
+				codec := newChecksumCodec(t, tt.algorithm, tt.format, tt.separator, mismatch)
+
+				ld, err := codec.UnmarshalLogs([]byte(tt.valid))
+				require.NoError(t, err)
+				assert.Equal(t, []string{"foo", "bar", "baz"}, bodies(ld))
+				for _, attributes := range recordAttributes(ld) {
+					assert.NotContains(t, attributes, checksumErrorAttribute)
+				}
+			})
+		}
+	}
+}
+
+func TestRecordChecksum_mismatchError(t *testing.T) {
+	for _, tt := range checksumInputs {
+		t.Run(tt.name, func(t *testing.T) {
+			codec := newChecksumCodec(t, tt.algorithm, tt.format, tt.separator, checksumMismatchError)
+			input := []byte(tt.corrupted)
+
+			decoder, err := codec.NewLogsDecoder(bytes.NewReader(input))
+			require.NoError(t, err)
+
+			ld, err := decoder.DecodeLogs()
+			var partialErr *encoding.PartialDecodeError
+			require.ErrorAs(t, err, &partialErr)
+			require.ErrorIs(t, err, errChecksumMismatch)
+			assert.Equal(t, 1, partialErr.Decoded)
+			assert.Equal(t, int64(bytes.IndexByte(input, '\n')+1), partialErr.Offset)
+			assert.Equal(t, []string{"foo"}, bodies(ld))
+
+			// The corrupted record ends at the current offset of the decoder.
+			decoder, err = codec.NewLogsDecoder(bytes.NewReader(input), encoding.WithOffset(decoder.Offset()))
+			require.NoError(t, err)
+			ld, err = decoder.DecodeLogs()
+			require.NoError(t, err)
+			assert.Equal(t, []string{"baz"}, bodies(ld))
+		})
+	}
+}
+
+func TestRecordChecksum_mismatchDeadLetter(t *testing.T) {
+	for _, tt := range checksumInputs {
+		t.Run(tt.name, func(t *testing.T) {
+			codec := newChecksumCodec(t, tt.algorithm, tt.format, tt.separator, checksumMismatchDeadLetter)
+
+			ld, err := codec.UnmarshalLogs([]byte(tt.corrupted))
+			require.NoError(t, err)
+
+			// The corrupted record is emitted with its checksum, flagged for routing to a dead-letter exporter.
+			records := bodies(ld)
+			require.Len(t, records, 3)
+			assert.Equal(t, "foo", records[0])
+			assert.Regexp(t, "^bax"+regexp.QuoteMeta(tt.separator)+".+$", records[1])
+			assert.Equal(t, "baz", records[2])
+
+			attributes := recordAttributes(ld)
+			assert.NotContains(t, attributes[0], checksumErrorAttribute)
+			assert.Contains(t, attributes[1][checksumErrorAttribute], "record checksum mismatch: expected ")
+			assert.NotContains(t, attributes[2], checksumErrorAttribute)
+		})
+	}
+}
+
+func TestRecordChecksum_missing(t *testing.T) {
+	tests := []struct {
+		name   string
+		record string
+		err    string
+	}{
+		{
+			name:   "shorter than checksum",
+			record: "8c73652",
+			err:    "record is shorter than its 8 characters checksum",
+		},
+		{
+			name:   "no separator",
+			record: "foo:8c736521",
+			err:    `no " " separator before the checksum`,
+		},
+		{
+			name:   "invalid checksum",
+			record: "foo 8c73652z",
+			err:    "expected 8c736521, got 8c73652z",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checksum, err := newRecordChecksum(checksumCRC32, "", " ", "")
+			require.NoError(t, err)
+
+			stripped, err := checksum.verify([]byte(tt.record))
+			require.ErrorIs(t, err, errChecksumMismatch)
+			assert.ErrorContains(t, err, tt.err)
+			assert.Equal(t, tt.record, string(stripped))
+		})
+	}
+}
+
+func TestRecordChecksum_multiline(t *testing.T) {
+	codec := newChecksumCodec(t, checksumCRC32, "", " ", checksumMismatchDeadLetter)
+	codec.multilineStart = regexp.MustCompile(`^\S`)
+
+	// Each line carries its own checksum, the dead-lettered line is emitted on its own.
+	decoder, err := codec.NewLogsDecoder(bytes.NewReader([]byte("foo 8c736521\n  bar 7f3f9f2a\nbaz 78240498\n")))
+	require.NoError(t, err)
+
+	ld, err := decoder.DecodeLogs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"  bar 7f3f9f2a", "foo", "baz"}, bodies(ld))
+
+	_, err = decoder.DecodeLogs()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestRecordChecksum_none(t *testing.T) {
+	for _, algorithm := range []string{"", checksumNone} {
+		checksum, err := newRecordChecksum(algorithm, "", "", "")
+		require.NoError(t, err)
+		assert.Nil(t, checksum)
+	}
+}
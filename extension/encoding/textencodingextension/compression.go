@@ -0,0 +1,34 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension // import "github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding/textencodingextension"
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+)
+
+const (
+	// compressionNone decodes streams as-is.
+	compressionNone = "none"
+	// compressionGzip decompresses streams with gzip before decoding them.
+	compressionGzip = "gzip"
+)
+
+// decompress returns a reader decompressing reader according to compression, detected by
+// xstreamencoding.NewDecompressingReader. Streams without the gzip magic bytes, e.g. empty ones, are returned as-is.
+func decompress(reader io.Reader, compression string) (io.Reader, error) {
+	if compression != compressionGzip {
+		return reader, nil
+	}
+	decompressed, detected, err := xstreamencoding.NewDecompressingReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gzip stream: %w", err)
+	}
+	if detected != xstreamencoding.CompressionNone && detected != xstreamencoding.CompressionGzip {
+		return nil, fmt.Errorf("unsupported %s stream with gzip compression", detected)
+	}
+	return decompressed, nil
+}
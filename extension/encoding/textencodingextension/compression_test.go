@@ -0,0 +1,120 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/textutils"
+)
+
+func newGzipCodec(t *testing.T) *textLogCodec {
+	enc, err := textutils.LookupEncoding("utf8")
+	require.NoError(t, err)
+	return &textLogCodec{
+		decoder:               enc.NewDecoder(),
+		unmarshalingSeparator: regexp.MustCompile(`\r?\n`),
+		compression:           compressionGzip,
+	}
+}
+
+func gzipped(t *testing.T, input string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte(input))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func TestCompression_gzip(t *testing.T) {
+	codec := newGzipCodec(t)
+	input := "2024-01-02 INFO starting\n2024-01-02 ERROR failed\r\n2024-01-02 INFO recovered\n"
+
+	decoder, err := codec.NewLogsDecoder(bytes.NewReader(gzipped(t, input)), encoding.WithFlushItems(2))
+	require.NoError(t, err)
+
+	ld, err := decoder.DecodeLogs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2024-01-02 INFO starting", "2024-01-02 ERROR failed"}, lineStartBodies(ld))
+	// Offsets count decompressed bytes
+	assert.Equal(t, int64(len("2024-01-02 INFO starting\n2024-01-02 ERROR failed\r\n")), decoder.Offset())
+
+	ld, err = decoder.DecodeLogs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2024-01-02 INFO recovered"}, lineStartBodies(ld))
+	assert.Equal(t, int64(len(input)), decoder.Offset())
+
+	_, err = decoder.DecodeLogs()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestCompression_gzipResumeFromOffset(t *testing.T) {
+	codec := newGzipCodec(t)
+	input := "first\nsecond\nthird\n"
+
+	decoder, err := codec.NewLogsDecoder(bytes.NewReader(gzipped(t, input)), encoding.WithOffset(int64(len("first\n"))))
+	require.NoError(t, err)
+	ld, err := decoder.DecodeLogs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"second", "third"}, lineStartBodies(ld))
+}
+
+func TestCompression_gzipUnmarshalLogs(t *testing.T) {
+	codec := newGzipCodec(t)
+
+	ld, err := codec.UnmarshalLogs(gzipped(t, "first\nsecond\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, lineStartBodies(ld))
+}
+
+func TestCompression_gzipEmpty(t *testing.T) {
+	codec := newGzipCodec(t)
+
+	// An empty stream decodes as an empty uncompressed stream
+	decoder, err := codec.NewLogsDecoder(bytes.NewReader(nil))
+	require.NoError(t, err)
+	_, err = decoder.DecodeLogs()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestCompression_gzipUncompressed(t *testing.T) {
+	codec := newGzipCodec(t)
+
+	// Streams without the gzip magic bytes decode as-is
+	ld, err := codec.UnmarshalLogs([]byte("not a gzip stream\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"not a gzip stream"}, lineStartBodies(ld))
+}
+
+func TestCompression_gzipInvalid(t *testing.T) {
+	codec := newGzipCodec(t)
+
+	_, err := codec.NewLogsDecoder(bytes.NewReader([]byte{0x1f, 0x8b, 0x00}))
+	assert.ErrorContains(t, err, "failed to read gzip stream")
+}
+
+func TestCompression_gzipZstd(t *testing.T) {
+	codec := newGzipCodec(t)
+
+	_, err := codec.NewLogsDecoder(bytes.NewReader([]byte{0x28, 0xb5, 0x2f, 0xfd, 0x00}))
+	assert.ErrorContains(t, err, "unsupported zstd stream with gzip compression")
+}
+
+func TestCompression_none(t *testing.T) {
+	codec := newGzipCodec(t)
+	codec.compression = compressionNone
+
+	ld, err := codec.UnmarshalLogs([]byte("first\nsecond\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, lineStartBodies(ld))
+}
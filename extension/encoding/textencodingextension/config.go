@@ -3,7 +3,12 @@
 
 package textencodingextension // import "github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding/textencodingextension"
 import (
+	"errors"
+	"fmt"
 	"regexp"
+	"strings"
+
+	txt "golang.org/x/text/encoding"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/textutils"
 )
@@ -12,19 +17,293 @@ type Config struct {
 	Encoding              string `mapstructure:"encoding"`
 	MarshalingSeparator   string `mapstructure:"marshaling_separator"`
 	UnmarshalingSeparator string `mapstructure:"unmarshaling_separator"`
+	// KeepCarriageReturn keeps the trailing carriage return of records split by UnmarshalingSeparator.
+	KeepCarriageReturn bool `mapstructure:"keep_carriage_return"`
+	// TrimWhitespace removes the leading and trailing whitespace of decoded records.
+	TrimWhitespace bool `mapstructure:"trim_whitespace"`
+	// MaxLineSize is the maximum size in bytes of a record split by UnmarshalingSeparator, or of the whole input
+	// when no separator is set. Decoding fails on larger records.
+	MaxLineSize int `mapstructure:"max_line_size"`
+	// MarshalingTrailingSeparator also terminates the last marshaled record with MarshalingSeparator.
+	MarshalingTrailingSeparator bool `mapstructure:"marshaling_trailing_separator"`
+	// LinePrefix and LineSuffix wrap each marshaled record, inside its separators. They are not removed from
+	// decoded records.
+	LinePrefix string `mapstructure:"line_prefix"`
+	LineSuffix string `mapstructure:"line_suffix"`
+	// SniffBufferSize is the number of bytes inspected to detect the charset when Encoding is "auto".
+	SniffBufferSize int `mapstructure:"sniff_buffer_size"`
+	// AutoFallbackEncoding is the encoding of streams without a byte order mark when Encoding is "auto".
+	// When empty, their charset is detected from their content.
+	AutoFallbackEncoding string `mapstructure:"auto_fallback_encoding"`
+	// MultilineStartRegex matches the first line of a record. Lines that do not match are appended to the previous record.
+	MultilineStartRegex string `mapstructure:"multiline_start_regex"`
+	// LineStartPattern splits records at the start of lines matching it, folding the other lines into the previous
+	// record. It is mutually exclusive with UnmarshalingSeparator and MultilineStartRegex.
+	LineStartPattern string `mapstructure:"line_start_pattern"`
+	// DecodeErrorHandling defines how records with bytes invalid in their charset are handled: "strict" keeps
+	// the output of the charset decoder and fails records it cannot decode, "replace" replaces invalid bytes with
+	// the Unicode replacement character and "ignore" drops them.
+	DecodeErrorHandling string `mapstructure:"decode_error_handling"`
+	// PreserveRaw attaches the original bytes of lossy decoded records as a base64 encoded attribute.
+	PreserveRaw bool `mapstructure:"preserve_raw"`
+	// TimestampRegex extracts the event timestamp from each decoded line, using the first capture group if any.
+	TimestampRegex string `mapstructure:"timestamp_regex"`
+	// TimestampLayout is the Go time layout used to parse the value extracted by TimestampRegex.
+	TimestampLayout string `mapstructure:"timestamp_layout"`
+	// TimestampPolicy defines which timestamps are set on decoded log records: "both", "event" or "observed".
+	TimestampPolicy string `mapstructure:"timestamp_policy"`
+	// TimestampParseErrorAttribute records the error of timestamps matched by TimestampRegex but failing to parse
+	// in the "log.timestamp.parse_error" attribute.
+	TimestampParseErrorAttribute bool `mapstructure:"timestamp_parse_error_attribute"`
+	// SeverityRegex extracts the severity level from each decoded line, using the first capture group if any.
+	SeverityRegex string `mapstructure:"severity_regex"`
+	// SeverityMapping maps levels extracted by SeverityRegex, compared case-insensitively, to severity names
+	// such as "warn" or "error2", overriding the built-in mapping of common levels.
+	SeverityMapping map[string]string `mapstructure:"severity_mapping"`
+	// ControlPrefix marks control lines, e.g. "#FLUSH" or "#SET items=100", which adjust batching instead of being decoded as records.
+	ControlPrefix string `mapstructure:"control_prefix"`
+	// BodyField is where records are read from and written to: "body" for the log record body, otherwise
+	// the key of a log record attribute, e.g. "log.raw".
+	BodyField string `mapstructure:"body_field"`
+	// AttributesHeader lists the resource attributes written as a key=value header line leading the marshaled
+	// records, and set back on the resource of the records decoded after it. No header is used when empty.
+	AttributesHeader []string `mapstructure:"attributes_header"`
+	// Compression is the compression of decoded streams: "none" or "gzip". Offsets of decoders count
+	// decompressed bytes.
+	Compression string `mapstructure:"compression"`
+	// BatchDedupCountAttribute collapses identical records decoded within a batch into their first occurrence,
+	// holding the number of occurrences in this log record attribute. Records are not collapsed when empty.
+	BatchDedupCountAttribute string `mapstructure:"batch_dedup_count_attribute"`
+	// SchemaSelector parses records with the parsing profile selected by the first line of each stream, which
+	// is not emitted as a record. Records are not parsed when nil.
+	SchemaSelector *SchemaSelectorConfig `mapstructure:"schema_selector"`
+	// RecordChecksum verifies the checksum trailing each decoded record, before stripping it: "none", "crc32" or
+	// "md5". Records are not verified when empty.
+	RecordChecksum string `mapstructure:"record_checksum"`
+	// RecordChecksumFormat is the encoding of record checksums: "hex", the default, or "base64".
+	RecordChecksumFormat string `mapstructure:"record_checksum_format"`
+	// RecordChecksumSeparator precedes the checksum of records, e.g. " ". Checksums directly follow records when empty.
+	RecordChecksumSeparator string `mapstructure:"record_checksum_separator"`
+	// RecordChecksumMismatch defines how records whose checksum does not match are handled: "error", the default,
+	// fails their decoding and "dead_letter" emits them unstripped with the "log.checksum.error" attribute.
+	RecordChecksumMismatch string `mapstructure:"record_checksum_mismatch"`
 	// prevent unkeyed literal initialization
 	_ struct{}
 }
 
 func (c *Config) Validate() error {
 	if c.UnmarshalingSeparator != "" {
-		if _, err := regexp.Compile(c.UnmarshalingSeparator); err != nil {
+		separator, err := regexp.Compile(c.UnmarshalingSeparator)
+		if err != nil {
 			return err
 		}
+		if separator.MatchString("") {
+			return fmt.Errorf("unmarshaling_separator %q must not match the empty string", c.UnmarshalingSeparator)
+		}
 	}
-	_, err := textutils.LookupEncoding(c.Encoding)
-	if err != nil {
+	if c.MaxLineSize <= 0 {
+		return errors.New("max_line_size must be greater than 0")
+	}
+	if err := c.validateBodyField(); err != nil {
+		return err
+	}
+	if err := c.validateAttributesHeader(); err != nil {
 		return err
 	}
+	if err := c.validateBatchDedupCountAttribute(); err != nil {
+		return err
+	}
+	if err := c.validateSchemaSelector(); err != nil {
+		return err
+	}
+	if err := c.validateRecordChecksum(); err != nil {
+		return err
+	}
+	if c.MultilineStartRegex != "" {
+		if _, err := regexp.Compile(c.MultilineStartRegex); err != nil {
+			return fmt.Errorf("invalid multiline_start_regex: %w", err)
+		}
+	}
+	if err := c.validateLineStartPattern(); err != nil {
+		return err
+	}
+	if err := c.validateTimestamp(); err != nil {
+		return err
+	}
+	if err := c.validateSeverity(); err != nil {
+		return err
+	}
+	switch c.Compression {
+	case "", compressionNone, compressionGzip:
+	default:
+		return fmt.Errorf("unsupported compression %q", c.Compression)
+	}
+	switch c.DecodeErrorHandling {
+	case "", decodeErrorStrict, decodeErrorReplace, decodeErrorIgnore:
+	default:
+		return fmt.Errorf("unsupported decode_error_handling %q", c.DecodeErrorHandling)
+	}
+	if strings.EqualFold(c.Encoding, autoEncoding) {
+		if c.SniffBufferSize <= 0 {
+			return errors.New("sniff_buffer_size must be greater than 0")
+		}
+		return c.validateAutoFallbackEncoding()
+	}
+	if c.AutoFallbackEncoding != "" {
+		return errors.New("auto_fallback_encoding requires encoding to be auto")
+	}
+	_, err := lookupEncoding(c.Encoding)
+	return err
+}
+
+// supportedEncodings are the encoding names accepted besides IANA character set names.
+const supportedEncodings = "auto, utf8, utf8-raw, utf16, ascii, nop"
+
+// lookupEncoding returns the encoding named name, or an error listing the supported encodings.
+func lookupEncoding(name string) (txt.Encoding, error) {
+	enc, err := textutils.LookupEncoding(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: supported encodings are %s, or an IANA character set name such as iso-8859-1 or shift_jis", err, supportedEncodings)
+	}
+	return enc, nil
+}
+
+func (c *Config) validateAutoFallbackEncoding() error {
+	switch {
+	case c.AutoFallbackEncoding == "":
+		return nil
+	case strings.EqualFold(c.AutoFallbackEncoding, autoEncoding):
+		return errors.New("auto_fallback_encoding cannot be auto")
+	}
+	if _, err := lookupEncoding(c.AutoFallbackEncoding); err != nil {
+		return fmt.Errorf("invalid auto_fallback_encoding: %w", err)
+	}
+	return nil
+}
+
+func (c *Config) validateBatchDedupCountAttribute() error {
+	switch c.BatchDedupCountAttribute {
+	case "":
+		return nil
+	case rawBytesAttribute, charsetAttribute, timestampParseErrorAttribute, checksumErrorAttribute, c.BodyField:
+		return fmt.Errorf("batch_dedup_count_attribute %q conflicts with an attribute set by the codec", c.BatchDedupCountAttribute)
+	}
+	if strings.TrimSpace(c.BatchDedupCountAttribute) != c.BatchDedupCountAttribute {
+		return fmt.Errorf("batch_dedup_count_attribute %q must not have leading or trailing spaces", c.BatchDedupCountAttribute)
+	}
+	return nil
+}
+
+func (c *Config) validateBodyField() error {
+	switch c.BodyField {
+	case bodyField:
+		return nil
+	case "":
+		return errors.New("body_field must not be empty")
+	case rawBytesAttribute, charsetAttribute, timestampParseErrorAttribute, checksumErrorAttribute:
+		return fmt.Errorf("body_field %q conflicts with an attribute set by the codec", c.BodyField)
+	}
+	if strings.TrimSpace(c.BodyField) != c.BodyField {
+		return fmt.Errorf("body_field %q must not have leading or trailing spaces", c.BodyField)
+	}
+	return nil
+}
+
+func (c *Config) validateSchemaSelector() error {
+	if c.SchemaSelector == nil {
+		return nil
+	}
+	if len(c.AttributesHeader) > 0 {
+		return errors.New("schema_selector and attributes_header are mutually exclusive, both read the first line of streams")
+	}
+	if c.SchemaSelector.Regex == "" {
+		return errors.New("schema_selector regex must not be empty")
+	}
+	if len(c.SchemaSelector.Profiles) == 0 {
+		return errors.New("schema_selector profiles must not be empty")
+	}
+	if c.SchemaSelector.DefaultProfile != "" {
+		if _, ok := c.SchemaSelector.Profiles[c.SchemaSelector.DefaultProfile]; !ok {
+			return fmt.Errorf("schema_selector default_profile %q is not a profile", c.SchemaSelector.DefaultProfile)
+		}
+	}
+	_, err := newSchemaSelector(c.SchemaSelector)
+	return err
+}
+
+func (c *Config) validateRecordChecksum() error {
+	if c.RecordChecksum == "" || c.RecordChecksum == checksumNone {
+		if c.RecordChecksumFormat != "" || c.RecordChecksumSeparator != "" || c.RecordChecksumMismatch != "" {
+			return errors.New("record_checksum_format, record_checksum_separator and record_checksum_mismatch require record_checksum to be set")
+		}
+		return nil
+	}
+	_, err := newRecordChecksum(c.RecordChecksum, c.RecordChecksumFormat, c.RecordChecksumSeparator, c.RecordChecksumMismatch)
+	return err
+}
+
+func (c *Config) validateAttributesHeader() error {
+	seen := make(map[string]struct{}, len(c.AttributesHeader))
+	for _, key := range c.AttributesHeader {
+		if key == "" {
+			return errors.New("attributes_header must not contain empty keys")
+		}
+		if _, ok := seen[key]; ok {
+			return fmt.Errorf("duplicate attributes_header key %q", key)
+		}
+		seen[key] = struct{}{}
+	}
+	return nil
+}
+
+func (c *Config) validateLineStartPattern() error {
+	if c.LineStartPattern == "" {
+		return nil
+	}
+	if c.UnmarshalingSeparator != "" {
+		return errors.New(`line_start_pattern and unmarshaling_separator are mutually exclusive, set unmarshaling_separator to ""`)
+	}
+	if c.MultilineStartRegex != "" {
+		return errors.New("line_start_pattern and multiline_start_regex are mutually exclusive")
+	}
+	if _, err := regexp.Compile(c.LineStartPattern); err != nil {
+		return fmt.Errorf("invalid line_start_pattern: %w", err)
+	}
+	return nil
+}
+
+func (c *Config) validateSeverity() error {
+	if c.SeverityRegex == "" {
+		if len(c.SeverityMapping) > 0 {
+			return errors.New("severity_mapping requires severity_regex to be set")
+		}
+		return nil
+	}
+	regex, err := regexp.Compile(c.SeverityRegex)
+	if err != nil {
+		return fmt.Errorf("invalid severity_regex: %w", err)
+	}
+	_, err = newSeverityParser(regex, c.SeverityMapping)
+	return err
+}
+
+func (c *Config) validateTimestamp() error {
+	switch c.TimestampPolicy {
+	case "", timestampPolicyBoth, timestampPolicyEvent, timestampPolicyObserved:
+	default:
+		return fmt.Errorf("unsupported timestamp_policy %q", c.TimestampPolicy)
+	}
+	if c.TimestampRegex == "" {
+		if c.TimestampLayout != "" {
+			return errors.New("timestamp_layout requires timestamp_regex to be set")
+		}
+		return nil
+	}
+	if c.TimestampLayout == "" {
+		return errors.New("timestamp_regex requires timestamp_layout to be set")
+	}
+	if _, err := regexp.Compile(c.TimestampRegex); err != nil {
+		return fmt.Errorf("invalid timestamp_regex: %w", err)
+	}
 	return nil
 }
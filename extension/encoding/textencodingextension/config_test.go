@@ -19,8 +19,234 @@ func Test_ConfigValidate_Encoding(t *testing.T) {
 	require.Error(t, c.Validate())
 }
 
+func Test_ConfigValidate_AutoFallbackEncoding(t *testing.T) {
+	c := createDefaultConfig().(*Config)
+	c.AutoFallbackEncoding = "utf-16le"
+	require.ErrorContains(t, c.Validate(), "auto_fallback_encoding requires encoding to be auto")
+
+	c.Encoding = "auto"
+	require.NoError(t, c.Validate())
+
+	c.AutoFallbackEncoding = "auto"
+	require.ErrorContains(t, c.Validate(), "auto_fallback_encoding cannot be auto")
+
+	c.AutoFallbackEncoding = "bbq"
+	require.ErrorContains(t, c.Validate(), "invalid auto_fallback_encoding: unsupported encoding 'bbq'")
+}
+
 func Test_ConfigValidate_Unmarshaler(t *testing.T) {
 	c := createDefaultConfig().(*Config)
 	c.UnmarshalingSeparator = `??\`
 	require.Error(t, c.Validate())
+
+	c.UnmarshalingSeparator = `\n*`
+	require.ErrorContains(t, c.Validate(), "must not match the empty string")
+}
+
+func Test_ConfigValidate_AutoEncoding(t *testing.T) {
+	c := createDefaultConfig().(*Config)
+	c.Encoding = "auto"
+	require.NoError(t, c.Validate())
+
+	c.SniffBufferSize = 0
+	require.Error(t, c.Validate())
+}
+
+func Test_ConfigValidate_Timestamp(t *testing.T) {
+	c := createDefaultConfig().(*Config)
+	c.TimestampRegex = `^(\S+)`
+	c.TimestampLayout = "2006-01-02T15:04:05Z07:00"
+	require.NoError(t, c.Validate())
+
+	c.TimestampPolicy = "bbq"
+	require.ErrorContains(t, c.Validate(), "unsupported timestamp_policy")
+
+	c = createDefaultConfig().(*Config)
+	c.TimestampRegex = `^(\S+)`
+	require.ErrorContains(t, c.Validate(), "requires timestamp_layout")
+
+	c = createDefaultConfig().(*Config)
+	c.TimestampLayout = "2006-01-02T15:04:05Z07:00"
+	require.ErrorContains(t, c.Validate(), "requires timestamp_regex")
+
+	c = createDefaultConfig().(*Config)
+	c.TimestampRegex = `??\`
+	c.TimestampLayout = "2006-01-02T15:04:05Z07:00"
+	require.ErrorContains(t, c.Validate(), "invalid timestamp_regex")
+}
+
+func Test_ConfigValidate_MultilineStartRegex(t *testing.T) {
+	c := createDefaultConfig().(*Config)
+	c.MultilineStartRegex = `^\d{4}-`
+	require.NoError(t, c.Validate())
+
+	c.MultilineStartRegex = `??\`
+	require.ErrorContains(t, c.Validate(), "invalid multiline_start_regex")
+}
+
+func Test_ConfigValidate_LineStartPattern(t *testing.T) {
+	c := createDefaultConfig().(*Config)
+	c.LineStartPattern = `^\d{4}-`
+	require.ErrorContains(t, c.Validate(), "line_start_pattern and unmarshaling_separator are mutually exclusive")
+
+	c.UnmarshalingSeparator = ""
+	require.NoError(t, c.Validate())
+
+	c.MultilineStartRegex = `^\d{4}-`
+	require.ErrorContains(t, c.Validate(), "line_start_pattern and multiline_start_regex are mutually exclusive")
+
+	c.MultilineStartRegex = ""
+	c.LineStartPattern = `??\`
+	require.ErrorContains(t, c.Validate(), "invalid line_start_pattern")
+}
+
+func Test_ConfigValidate_Severity(t *testing.T) {
+	c := createDefaultConfig().(*Config)
+	c.SeverityMapping = map[string]string{"E": "error"}
+	require.ErrorContains(t, c.Validate(), "severity_mapping requires severity_regex to be set")
+
+	c.SeverityRegex = `^\[(\w+)\]`
+	require.NoError(t, c.Validate())
+
+	c.SeverityMapping = map[string]string{"E": "severe"}
+	require.ErrorContains(t, c.Validate(), `severity_mapping "E": unknown severity "severe"`)
+
+	c.SeverityMapping = nil
+	c.SeverityRegex = `(`
+	require.ErrorContains(t, c.Validate(), "invalid severity_regex")
+}
+
+func Test_ConfigValidate_DecodeErrorHandling(t *testing.T) {
+	c := createDefaultConfig().(*Config)
+	for _, handling := range []string{"", decodeErrorStrict, decodeErrorReplace, decodeErrorIgnore} {
+		c.DecodeErrorHandling = handling
+		require.NoError(t, c.Validate())
+	}
+
+	c.DecodeErrorHandling = "skip"
+	require.ErrorContains(t, c.Validate(), `unsupported decode_error_handling "skip"`)
+}
+
+func Test_ConfigValidate_MaxLineSize(t *testing.T) {
+	c := createDefaultConfig().(*Config)
+	c.MaxLineSize = 0
+	require.ErrorContains(t, c.Validate(), "max_line_size must be greater than 0")
+}
+
+func Test_ConfigValidate_BodyField(t *testing.T) {
+	c := createDefaultConfig().(*Config)
+	c.BodyField = "log.raw"
+	require.NoError(t, c.Validate())
+
+	c.BodyField = ""
+	require.ErrorContains(t, c.Validate(), "body_field must not be empty")
+
+	c.BodyField = " log.raw"
+	require.ErrorContains(t, c.Validate(), "must not have leading or trailing spaces")
+
+	c.BodyField = rawBytesAttribute
+	require.ErrorContains(t, c.Validate(), "conflicts with an attribute set by the codec")
+}
+
+func Test_ConfigValidate_AttributesHeader(t *testing.T) {
+	c := createDefaultConfig().(*Config)
+	c.AttributesHeader = []string{"service.name", "host.name"}
+	require.NoError(t, c.Validate())
+
+	c.AttributesHeader = []string{"service.name", ""}
+	require.ErrorContains(t, c.Validate(), "attributes_header must not contain empty keys")
+
+	c.AttributesHeader = []string{"service.name", "service.name"}
+	require.ErrorContains(t, c.Validate(), `duplicate attributes_header key "service.name"`)
+}
+
+func Test_ConfigValidate_Compression(t *testing.T) {
+	c := createDefaultConfig().(*Config)
+	for _, compression := range []string{"", compressionNone, compressionGzip} {
+		c.Compression = compression
+		require.NoError(t, c.Validate())
+	}
+
+	c.Compression = "zstd"
+	require.ErrorContains(t, c.Validate(), `unsupported compression "zstd"`)
+}
+
+func Test_ConfigValidate_BatchDedupCountAttribute(t *testing.T) {
+	c := createDefaultConfig().(*Config)
+	c.BatchDedupCountAttribute = "log.count"
+	require.NoError(t, c.Validate())
+
+	c.BatchDedupCountAttribute = " log.count"
+	require.ErrorContains(t, c.Validate(), "must not have leading or trailing spaces")
+
+	c.BatchDedupCountAttribute = charsetAttribute
+	require.ErrorContains(t, c.Validate(), "conflicts with an attribute set by the codec")
+
+	c.BodyField = "log.count"
+	c.BatchDedupCountAttribute = "log.count"
+	require.ErrorContains(t, c.Validate(), "conflicts with an attribute set by the codec")
+}
+
+func Test_ConfigValidate_SchemaSelector(t *testing.T) {
+	c := createDefaultConfig().(*Config)
+	c.SchemaSelector = newSchemaSelectorConfig()
+	require.NoError(t, c.Validate())
+
+	c.SchemaSelector.DefaultProfile = "4"
+	require.ErrorContains(t, c.Validate(), `schema_selector default_profile "4" is not a profile`)
+
+	c.SchemaSelector = newSchemaSelectorConfig()
+	c.SchemaSelector.Regex = ""
+	require.ErrorContains(t, c.Validate(), "schema_selector regex must not be empty")
+
+	c.SchemaSelector.Regex = "(["
+	require.ErrorContains(t, c.Validate(), "invalid schema_selector regex")
+
+	c.SchemaSelector = newSchemaSelectorConfig()
+	c.SchemaSelector.Profiles = nil
+	require.ErrorContains(t, c.Validate(), "schema_selector profiles must not be empty")
+
+	c.SchemaSelector.Profiles = map[string]ParsingProfileConfig{"1": {Parser: "json"}}
+	require.ErrorContains(t, c.Validate(), `invalid schema_selector profile "1": unsupported parser "json"`)
+
+	c.SchemaSelector.Profiles = map[string]ParsingProfileConfig{"1": {Parser: parserCSV}}
+	require.ErrorContains(t, c.Validate(), "csv_header must not be empty")
+
+	c.SchemaSelector.Profiles = map[string]ParsingProfileConfig{"1": {Parser: parserCSV, CSVHeader: []string{"a"}, CSVDelimiter: `"`}}
+	require.ErrorContains(t, c.Validate(), "must be a single character")
+
+	c.SchemaSelector = newSchemaSelectorConfig()
+	c.AttributesHeader = []string{"service.name"}
+	require.ErrorContains(t, c.Validate(), "schema_selector and attributes_header are mutually exclusive")
+}
+
+func Test_ConfigValidate_RecordChecksum(t *testing.T) {
+	c := createDefaultConfig().(*Config)
+	for _, algorithm := range []string{"", checksumNone, checksumCRC32, checksumMD5} {
+		c.RecordChecksum = algorithm
+		require.NoError(t, c.Validate())
+	}
+
+	c.RecordChecksum = "sha1"
+	require.ErrorContains(t, c.Validate(), `unsupported record_checksum "sha1"`)
+
+	c.RecordChecksum = checksumCRC32
+	c.RecordChecksumFormat = checksumFormatBase64
+	c.RecordChecksumSeparator = " "
+	c.RecordChecksumMismatch = checksumMismatchDeadLetter
+	require.NoError(t, c.Validate())
+
+	c.RecordChecksumFormat = "base32"
+	require.ErrorContains(t, c.Validate(), `unsupported record_checksum_format "base32"`)
+
+	c.RecordChecksumFormat = checksumFormatHex
+	c.RecordChecksumMismatch = "drop"
+	require.ErrorContains(t, c.Validate(), `unsupported record_checksum_mismatch "drop"`)
+
+	c.RecordChecksum = checksumNone
+	require.ErrorContains(t, c.Validate(), "require record_checksum to be set")
+
+	c = createDefaultConfig().(*Config)
+	c.BodyField = checksumErrorAttribute
+	require.ErrorContains(t, c.Validate(), "conflicts with an attribute set by the codec")
 }
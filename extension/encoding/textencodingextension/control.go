@@ -0,0 +1,65 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension // import "github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding/textencodingextension"
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+const (
+	// controlFlush flushes the current batch.
+	controlFlush = "FLUSH"
+	// controlSet changes the flush thresholds for the rest of the stream, e.g. "SET items=100 bytes=65536".
+	controlSet = "SET"
+)
+
+// controlDirective is a control line sent by the producer to adjust decoding.
+type controlDirective struct {
+	flush   bool
+	options []encoding.DecoderOption
+}
+
+// parseControl parses a control line stripped of its prefix.
+func parseControl(line string) (controlDirective, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return controlDirective{}, errors.New("empty control directive")
+	}
+
+	switch strings.ToUpper(fields[0]) {
+	case controlFlush:
+		if len(fields) > 1 {
+			return controlDirective{}, fmt.Errorf("%s control directive takes no arguments", controlFlush)
+		}
+		return controlDirective{flush: true}, nil
+	case controlSet:
+		if len(fields) == 1 {
+			return controlDirective{}, fmt.Errorf("%s control directive requires at least one setting", controlSet)
+		}
+		var directive controlDirective
+		for _, setting := range fields[1:] {
+			key, value, ok := strings.Cut(setting, "=")
+			n, err := strconv.ParseInt(value, 10, 64)
+			if !ok || err != nil || n < 0 {
+				return controlDirective{}, fmt.Errorf("invalid control setting %q", setting)
+			}
+			switch key {
+			case "items":
+				directive.options = append(directive.options, encoding.WithFlushItems(n))
+			case "bytes":
+				directive.options = append(directive.options, encoding.WithFlushBytes(n))
+			default:
+				return controlDirective{}, fmt.Errorf("unsupported control setting %q", key)
+			}
+		}
+		return directive, nil
+	default:
+		return controlDirective{}, fmt.Errorf("unsupported control directive %q", fields[0])
+	}
+}
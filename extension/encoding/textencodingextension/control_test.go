@@ -0,0 +1,158 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/textutils"
+)
+
+func newControlCodec(t *testing.T) *textLogCodec {
+	enc, err := textutils.LookupEncoding("utf8")
+	require.NoError(t, err)
+	return &textLogCodec{
+		decoder:               enc.NewDecoder(),
+		unmarshalingSeparator: regexp.MustCompile(`\r?\n`),
+		controlPrefix:         "#",
+	}
+}
+
+func bodies(ld plog.Logs) []string {
+	var result []string
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		records := ld.ResourceLogs().At(i).ScopeLogs().At(0).LogRecords()
+		for j := 0; j < records.Len(); j++ {
+			result = append(result, records.At(j).Body().Str())
+		}
+	}
+	return result
+}
+
+func TestControl_flush(t *testing.T) {
+	codec := newControlCodec(t)
+	reader := bytes.NewReader([]byte("foo\nbar\n#FLUSH\nbaz\n"))
+
+	decoder, err := codec.NewLogsDecoder(reader)
+	require.NoError(t, err)
+
+	ld, err := decoder.DecodeLogs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo", "bar"}, bodies(ld))
+	assert.Equal(t, int64(15), decoder.Offset())
+
+	ld, err = decoder.DecodeLogs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"baz"}, bodies(ld))
+	assert.Equal(t, int64(19), decoder.Offset())
+
+	_, err = decoder.DecodeLogs()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestControl_flushEmptyBatch(t *testing.T) {
+	codec := newControlCodec(t)
+	reader := bytes.NewReader([]byte("#FLUSH\nfoo\n#FLUSH\n#FLUSH\nbar\n"))
+
+	decoder, err := codec.NewLogsDecoder(reader)
+	require.NoError(t, err)
+
+	ld, err := decoder.DecodeLogs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo"}, bodies(ld))
+
+	ld, err = decoder.DecodeLogs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bar"}, bodies(ld))
+}
+
+func TestControl_set(t *testing.T) {
+	codec := newControlCodec(t)
+	reader := bytes.NewReader([]byte("a\n#SET items=2\nb\nc\nd\n#SET items=0 bytes=0\ne\nf\ng\n"))
+
+	decoder, err := codec.NewLogsDecoder(reader, encoding.WithFlushItems(100))
+	require.NoError(t, err)
+
+	var batches [][]string
+	for {
+		ld, err := decoder.DecodeLogs()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		batches = append(batches, bodies(ld))
+	}
+	assert.Equal(t, [][]string{{"a", "b"}, {"c", "d"}, {"e", "f", "g"}}, batches)
+}
+
+func TestControl_flushMultiline(t *testing.T) {
+	codec := newControlCodec(t)
+	codec.multilineStart = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} `)
+	reader := bytes.NewReader([]byte("2024-01-02 ERROR failed\n\tat main\n#FLUSH\n2024-01-02 INFO recovered\n"))
+
+	decoder, err := codec.NewLogsDecoder(reader)
+	require.NoError(t, err)
+
+	ld, err := decoder.DecodeLogs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2024-01-02 ERROR failed\n\tat main"}, bodies(ld))
+}
+
+func TestControl_unmarshalLogs(t *testing.T) {
+	codec := newControlCodec(t)
+
+	ld, err := codec.UnmarshalLogs([]byte("foo\n#FLUSH\nbar\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo", "bar"}, bodies(ld))
+}
+
+func TestControl_disabled(t *testing.T) {
+	codec := newControlCodec(t)
+	codec.controlPrefix = ""
+
+	ld, err := codec.UnmarshalLogs([]byte("foo\n#FLUSH\nbar\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo", "#FLUSH", "bar"}, bodies(ld))
+}
+
+func TestParseControl(t *testing.T) {
+	tests := []struct {
+		line        string
+		flush       bool
+		options     encoding.DecoderOptions
+		expectedErr string
+	}{
+		{line: "FLUSH", flush: true, options: encoding.NewDecoderOptions()},
+		{line: "flush", flush: true, options: encoding.NewDecoderOptions()},
+		{line: "SET items=100", options: encoding.NewDecoderOptions(encoding.WithFlushItems(100))},
+		{line: "SET items=10 bytes=2048", options: encoding.NewDecoderOptions(encoding.WithFlushItems(10), encoding.WithFlushBytes(2048))},
+		{line: "", expectedErr: "empty control directive"},
+		{line: "FLUSH now", expectedErr: "FLUSH control directive takes no arguments"},
+		{line: "SET", expectedErr: "SET control directive requires at least one setting"},
+		{line: "SET items", expectedErr: `invalid control setting "items"`},
+		{line: "SET items=-1", expectedErr: `invalid control setting "items=-1"`},
+		{line: "SET offset=1", expectedErr: `unsupported control setting "offset"`},
+		{line: "RESET", expectedErr: `unsupported control directive "RESET"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			directive, err := parseControl(tt.line)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.flush, directive.flush)
+			assert.Equal(t, tt.options, encoding.NewDecoderOptions(directive.options...))
+		})
+	}
+}
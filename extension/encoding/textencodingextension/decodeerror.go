@@ -0,0 +1,45 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension // import "github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding/textencodingextension"
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	txt "golang.org/x/text/encoding"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/textutils"
+)
+
+// Decode error handling modes define how records that cannot be decoded in their charset are handled.
+const (
+	// decodeErrorStrict keeps the output of the charset decoder and fails records it cannot decode.
+	decodeErrorStrict = "strict"
+	// decodeErrorReplace replaces invalid bytes with the Unicode replacement character.
+	decodeErrorReplace = "replace"
+	// decodeErrorIgnore drops invalid bytes.
+	decodeErrorIgnore = "ignore"
+)
+
+// decodeRecord decodes the record b with decoder, handling invalid bytes according to decodeErrorHandling.
+// Unless strict, decoding never fails: records the decoder fails on are read as UTF-8, and the decoded records
+// are made valid UTF-8 by replacing or dropping invalid bytes.
+func (r *textLogCodec) decodeRecord(decoder *txt.Decoder, b []byte) (string, error) {
+	decoded, err := textutils.DecodeAsString(decoder, b)
+	switch r.decodeErrorHandling {
+	case decodeErrorReplace:
+		if err != nil {
+			decoded = string(b)
+		}
+		return strings.ToValidUTF8(decoded, string(utf8.RuneError)), nil
+	case decodeErrorIgnore:
+		if err != nil {
+			decoded = string(b)
+		}
+		// Charset decoders already replace the bytes they cannot decode with the replacement character.
+		return strings.ReplaceAll(strings.ToValidUTF8(decoded, ""), string(utf8.RuneError), ""), nil
+	default:
+		return decoded, err
+	}
+}
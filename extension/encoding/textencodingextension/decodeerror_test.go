@@ -0,0 +1,125 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	txt "golang.org/x/text/encoding"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/textutils"
+)
+
+func TestDecodeErrorHandling(t *testing.T) {
+	// utf8Strict is a decoder failing on invalid UTF-8, unlike the utf8 decoder replacing invalid bytes.
+	utf8Strict := &txt.Decoder{Transformer: txt.UTF8Validator}
+	lookup := func(name string) *txt.Decoder {
+		enc, err := textutils.LookupEncoding(name)
+		require.NoError(t, err)
+		return enc.NewDecoder()
+	}
+	input := []byte("ok\nbad \xff\xfe byte\n\xe2\x82 truncated")
+
+	tests := []struct {
+		name        string
+		decoder     *txt.Decoder
+		handling    string
+		expected    []string
+		expectedErr bool
+	}{
+		{
+			name:     "utf8 strict",
+			decoder:  lookup("utf8"),
+			handling: decodeErrorStrict,
+			expected: []string{"ok", "bad �� byte", "� truncated"},
+		},
+		{
+			name:     "utf8 replace",
+			decoder:  lookup("utf8"),
+			handling: decodeErrorReplace,
+			expected: []string{"ok", "bad �� byte", "� truncated"},
+		},
+		{
+			name:     "utf8 ignore",
+			decoder:  lookup("utf8"),
+			handling: decodeErrorIgnore,
+			expected: []string{"ok", "bad  byte", " truncated"},
+		},
+		{
+			name:     "utf8-raw strict",
+			decoder:  lookup("utf8-raw"),
+			handling: decodeErrorStrict,
+			expected: []string{"ok", "bad \xff\xfe byte", "\xe2\x82 truncated"},
+		},
+		{
+			name:     "utf8-raw replace",
+			decoder:  lookup("utf8-raw"),
+			handling: decodeErrorReplace,
+			expected: []string{"ok", "bad � byte", "� truncated"},
+		},
+		{
+			name:     "utf8-raw ignore",
+			decoder:  lookup("utf8-raw"),
+			handling: decodeErrorIgnore,
+			expected: []string{"ok", "bad  byte", " truncated"},
+		},
+		{
+			name:        "failing decoder strict",
+			decoder:     utf8Strict,
+			handling:    decodeErrorStrict,
+			expected:    []string{"ok"},
+			expectedErr: true,
+		},
+		{
+			name:        "failing decoder default",
+			decoder:     utf8Strict,
+			expected:    []string{"ok"},
+			expectedErr: true,
+		},
+		{
+			name:     "failing decoder replace",
+			decoder:  utf8Strict,
+			handling: decodeErrorReplace,
+			expected: []string{"ok", "bad � byte", "� truncated"},
+		},
+		{
+			name:     "failing decoder ignore",
+			decoder:  utf8Strict,
+			handling: decodeErrorIgnore,
+			expected: []string{"ok", "bad  byte", " truncated"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec := &textLogCodec{
+				decoder:               tt.decoder,
+				unmarshalingSeparator: regexp.MustCompile(`\r?\n`),
+				decodeErrorHandling:   tt.handling,
+			}
+
+			decoder, err := codec.NewLogsDecoder(bytes.NewReader(input), encoding.WithFlushItems(0), encoding.WithFlushBytes(0))
+			require.NoError(t, err)
+			ld, err := decoder.DecodeLogs()
+			if tt.expectedErr {
+				// Records are decoded up to the one failing
+				var partialErr *encoding.PartialDecodeError
+				require.ErrorAs(t, err, &partialErr)
+				assert.Equal(t, int64(3), partialErr.Offset)
+			} else {
+				require.NoError(t, err)
+			}
+
+			var bodies []string
+			for i := 0; i < ld.ResourceLogs().Len(); i++ {
+				bodies = append(bodies, ld.ResourceLogs().At(i).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+			}
+			assert.Equal(t, tt.expected, bodies)
+		})
+	}
+}
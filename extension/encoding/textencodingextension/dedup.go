@@ -0,0 +1,48 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension // import "github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding/textencodingextension"
+
+import (
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// batchDedup collapses identical records decoded within a batch into their first occurrence, counting the
+// occurrences in an attribute of the log record.
+type batchDedup struct {
+	countAttribute string
+	// records holds the first occurrence of each record of the batch, by record key.
+	records map[string]plog.LogRecord
+}
+
+func newBatchDedup(countAttribute string) *batchDedup {
+	return &batchDedup{
+		countAttribute: countAttribute,
+		records:        make(map[string]plog.LogRecord),
+	}
+}
+
+// duplicate reports whether a record identified by key was already decoded within the batch, in which case
+// its count is incremented.
+func (d *batchDedup) duplicate(key string) bool {
+	l, ok := d.records[key]
+	if !ok {
+		return false
+	}
+	// The count is looked up each time, as values of an attribute map are invalidated when it grows.
+	if count, found := l.Attributes().Get(d.countAttribute); found {
+		count.SetInt(count.Int() + 1)
+	}
+	return true
+}
+
+// track records l as the first occurrence of the record identified by key within the batch.
+func (d *batchDedup) track(key string, l plog.LogRecord) {
+	l.Attributes().PutInt(d.countAttribute, 1)
+	d.records[key] = l
+}
+
+// reset forgets the records of the previous batch.
+func (d *batchDedup) reset() {
+	clear(d.records)
+}
@@ -0,0 +1,71 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension
+
+import (
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/textutils"
+)
+
+// recordCounts returns the count attribute of the records of ld, by body.
+func recordCounts(t *testing.T, ld plog.Logs) map[string]int64 {
+	counts := make(map[string]int64)
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		lr := ld.ResourceLogs().At(i).ScopeLogs().At(0).LogRecords().At(0)
+		count, ok := lr.Attributes().Get("log.count")
+		require.True(t, ok)
+		counts[lr.Body().Str()] = count.Int()
+	}
+	return counts
+}
+
+func TestBatchDedup(t *testing.T) {
+	enc, err := textutils.LookupEncoding("utf8")
+	require.NoError(t, err)
+	codec := &textLogCodec{
+		decoder:               enc.NewDecoder(),
+		unmarshalingSeparator: regexp.MustCompile(`\r?\n`),
+		trimWhitespace:        true,
+		dedupCountAttribute:   "log.count",
+	}
+
+	t.Run("single batch", func(t *testing.T) {
+		ld, err := codec.UnmarshalLogs([]byte("retry\nretry\nok\n  retry \nfailed\nok\nretry\n"))
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int64{"retry": 4, "ok": 2, "failed": 1}, recordCounts(t, ld))
+
+		// Records keep the order of their first occurrence
+		require.Equal(t, 3, ld.LogRecordCount())
+		assert.Equal(t, "retry", ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+		assert.Equal(t, "ok", ld.ResourceLogs().At(1).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+		assert.Equal(t, "failed", ld.ResourceLogs().At(2).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+	})
+
+	t.Run("records are only collapsed within a batch", func(t *testing.T) {
+		decoder, err := codec.NewLogsDecoder(strings.NewReader("retry\nretry\nretry\nretry\nok\n"), encoding.WithFlushItems(3))
+		require.NoError(t, err)
+
+		var batches []map[string]int64
+		for {
+			ld, err := decoder.DecodeLogs()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			batches = append(batches, recordCounts(t, ld))
+		}
+		// Collapsed records count towards the flush thresholds
+		assert.Equal(t, []map[string]int64{{"retry": 3}, {"retry": 1, "ok": 1}}, batches)
+	})
+}
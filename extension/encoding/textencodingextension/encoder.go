@@ -0,0 +1,126 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension // import "github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding/textencodingextension"
+
+import (
+	"io"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+)
+
+// textLogsEncoder writes log records to a stream with the same delimiters as MarshalLogs.
+type textLogsEncoder struct {
+	codec       *textLogCodec
+	writer      io.Writer
+	batchHelper *xstreamencoding.BatchHelper
+	separator   recordSeparator
+	// maxPayloadBytes is the maximum size of writes, 0 if unbounded.
+	maxPayloadBytes int
+	// buf holds the records encoded since the last flush.
+	buf               []byte
+	appendedLogRecord bool
+	offset            int64
+	// header is the attributes header written before the first record, if any.
+	header string
+}
+
+// NewLogsEncoder implements the encoding.LogsEncoderFactory interface. Tracks offset by bytes written to the stream.
+// Records are delimited as with MarshalLogsWithOptions, and written in chunks of at most MaxPayloadBytes when set.
+func (r *textLogCodec) NewLogsEncoder(writer io.Writer, options ...encoding.EncoderOption) (encoding.LogsEncoder, error) {
+	encoderOptions := encoding.NewEncoderOptions(options...)
+	return &textLogsEncoder{
+		codec:           r,
+		writer:          writer,
+		separator:       r.recordSeparator(encoderOptions),
+		maxPayloadBytes: encoderOptions.MaxPayloadBytes,
+		batchHelper: xstreamencoding.NewBatchHelper(
+			encoding.WithFlushBytes(encoderOptions.FlushBytes),
+			encoding.WithFlushItems(encoderOptions.FlushItems),
+		),
+	}, nil
+}
+
+// EncodeLogs writes the records of ld. With headerAttributes, the attributes header of the first logs
+// is written before their records, and logs with a different header fail to be encoded.
+func (e *textLogsEncoder) EncodeLogs(ld plog.Logs) error {
+	if err := e.encodeAttributesHeader(ld); err != nil {
+		return err
+	}
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		rl := ld.ResourceLogs().At(i)
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			sl := rl.ScopeLogs().At(j)
+			for k := 0; k < sl.LogRecords().Len(); k++ {
+				size := len(e.buf)
+				e.buf = e.codec.appendRecord(e.buf, sl.LogRecords().At(k), e.appendedLogRecord, e.separator)
+				e.appendedLogRecord = true
+				if e.maxPayloadBytes > 0 && len(e.buf) > e.maxPayloadBytes && size > 0 {
+					// The buffered records are written before the record that would make them exceed the maximum.
+					if err := e.flushBefore(size); err != nil {
+						return err
+					}
+					size = 0
+				}
+
+				e.batchHelper.IncrementItems(1)
+				e.batchHelper.IncrementBytes(int64(len(e.buf) - size))
+				if e.batchHelper.ShouldFlush() {
+					if err := e.flush(); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return e.flush()
+}
+
+// encodeAttributesHeader buffers the attributes header of ld, unless one was already written.
+func (e *textLogsEncoder) encodeAttributesHeader(ld plog.Logs) error {
+	if len(e.codec.headerAttributes) == 0 {
+		return nil
+	}
+	header, ok, err := e.codec.attributesHeader(ld)
+	switch {
+	case err != nil || !ok:
+		return err
+	case e.appendedLogRecord:
+		if header != e.header {
+			return errHeaderMismatch
+		}
+		return nil
+	}
+	e.header = header
+	e.buf = append(e.buf, header...)
+	e.buf = append(e.buf, e.separator.separator...)
+	return nil
+}
+
+func (e *textLogsEncoder) Offset() int64 {
+	return e.offset
+}
+
+// flush writes the buffered records to the stream.
+func (e *textLogsEncoder) flush() error {
+	e.batchHelper.Reset()
+	if len(e.buf) == 0 {
+		return nil
+	}
+	n, err := e.writer.Write(e.buf)
+	e.offset += int64(n)
+	e.buf = e.buf[:0]
+	return err
+}
+
+// flushBefore writes the first size bytes of the buffered records to the stream, keeping the last record buffered.
+func (e *textLogsEncoder) flushBefore(size int) error {
+	e.batchHelper.Reset()
+	n, err := e.writer.Write(e.buf[:size])
+	e.offset += int64(n)
+	e.buf = append(e.buf[:0], e.buf[size:]...)
+	return err
+}
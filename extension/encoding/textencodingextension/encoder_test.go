@@ -0,0 +1,153 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/textutils"
+)
+
+// writeRecorder records the payload of each Write call.
+type writeRecorder struct {
+	writes []string
+}
+
+func (w *writeRecorder) Write(p []byte) (int, error) {
+	w.writes = append(w.writes, string(p))
+	return len(p), nil
+}
+
+func newEncoderCodec(t *testing.T, trailing bool) *textLogCodec {
+	enc, err := textutils.LookupEncoding("utf8")
+	require.NoError(t, err)
+	return &textLogCodec{
+		decoder:                     enc.NewDecoder(),
+		unmarshalingSeparator:       regexp.MustCompile(`\r?\n`),
+		marshalingSeparator:         "\n",
+		marshalingTrailingSeparator: trailing,
+	}
+}
+
+func decodeAll(t *testing.T, codec *textLogCodec, reader io.Reader) []string {
+	decoder, err := codec.NewLogsDecoder(reader)
+	require.NoError(t, err)
+
+	var result []string
+	for {
+		ld, err := decoder.DecodeLogs()
+		if err == io.EOF {
+			return result
+		}
+		require.NoError(t, err)
+		result = append(result, bodies(ld)...)
+	}
+}
+
+func TestLogsEncoder_roundtrip(t *testing.T) {
+	input := "foo\nbar\nbaz\nqux\n"
+
+	for _, trailing := range []bool{false, true} {
+		codec := newEncoderCodec(t, trailing)
+
+		decoder, err := codec.NewLogsDecoder(bytes.NewReader([]byte(input)), encoding.WithFlushItems(3))
+		require.NoError(t, err)
+
+		var out bytes.Buffer
+		encoder, err := codec.NewLogsEncoder(&out)
+		require.NoError(t, err)
+
+		// Encode each decoded batch, so that records of consecutive calls are delimited
+		for {
+			ld, err := decoder.DecodeLogs()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			require.NoError(t, encoder.EncodeLogs(ld))
+		}
+
+		if trailing {
+			assert.Equal(t, input, out.String())
+		} else {
+			assert.Equal(t, "foo\nbar\nbaz\nqux", out.String())
+		}
+		assert.Equal(t, int64(out.Len()), encoder.Offset())
+		assert.Equal(t, []string{"foo", "bar", "baz", "qux"}, decodeAll(t, codec, &out))
+	}
+}
+
+func TestLogsEncoder_flush(t *testing.T) {
+	codec := newEncoderCodec(t, true)
+	ld, err := codec.UnmarshalLogs([]byte("a\nb\nc\nd\ne\n"))
+	require.NoError(t, err)
+
+	t.Run("items", func(t *testing.T) {
+		w := &writeRecorder{}
+		encoder, err := codec.NewLogsEncoder(w, encoding.WithEncoderFlushItems(2))
+		require.NoError(t, err)
+		require.NoError(t, encoder.EncodeLogs(ld))
+		assert.Equal(t, []string{"a\nb\n", "c\nd\n", "e\n"}, w.writes)
+		assert.Equal(t, int64(10), encoder.Offset())
+	})
+
+	t.Run("bytes", func(t *testing.T) {
+		w := &writeRecorder{}
+		encoder, err := codec.NewLogsEncoder(w, encoding.WithEncoderFlushBytes(5), encoding.WithEncoderFlushItems(0))
+		require.NoError(t, err)
+		require.NoError(t, encoder.EncodeLogs(ld))
+		assert.Equal(t, []string{"a\nb\nc\n", "d\ne\n"}, w.writes)
+	})
+
+	t.Run("max payload bytes", func(t *testing.T) {
+		w := &writeRecorder{}
+		encoder, err := codec.NewLogsEncoder(w, encoding.WithMaxPayloadBytes(5), encoding.WithEncoderFlushItems(0))
+		require.NoError(t, err)
+		require.NoError(t, encoder.EncodeLogs(ld))
+		assert.Equal(t, []string{"a\nb\n", "c\nd\n", "e\n"}, w.writes)
+		assert.Equal(t, int64(10), encoder.Offset())
+	})
+
+	t.Run("separator", func(t *testing.T) {
+		w := &writeRecorder{}
+		encoder, err := codec.NewLogsEncoder(w, encoding.WithRecordSeparator(","), encoding.WithTrailingSeparator(false))
+		require.NoError(t, err)
+		require.NoError(t, encoder.EncodeLogs(ld))
+		require.NoError(t, encoder.EncodeLogs(ld))
+		assert.Equal(t, []string{"a,b,c,d,e", ",a,b,c,d,e"}, w.writes)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		w := &writeRecorder{}
+		encoder, err := codec.NewLogsEncoder(w)
+		require.NoError(t, err)
+		require.NoError(t, encoder.EncodeLogs(plog.NewLogs()))
+		assert.Empty(t, w.writes)
+		assert.Equal(t, int64(0), encoder.Offset())
+	})
+}
+
+func TestLogsEncoder_linePrefixSuffix(t *testing.T) {
+	codec := newEncoderCodec(t, false)
+	codec.linePrefix, codec.lineSuffix = "<", ">"
+	ld, err := codec.UnmarshalLogs([]byte("a\nb\nc\n"))
+	require.NoError(t, err)
+
+	// Records of consecutive calls are wrapped and delimited the same as within a call
+	var out bytes.Buffer
+	encoder, err := codec.NewLogsEncoder(&out)
+	require.NoError(t, err)
+	require.NoError(t, encoder.EncodeLogs(ld))
+	require.NoError(t, encoder.EncodeLogs(ld))
+	assert.Equal(t, "<a>\n<b>\n<c>\n<a>\n<b>\n<c>", out.String())
+	assert.Equal(t, int64(out.Len()), encoder.Offset())
+}
@@ -7,22 +7,29 @@ import (
 	"context"
 	"io"
 	"regexp"
+	"strings"
 
 	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/extension"
 	"go.opentelemetry.io/collector/pdata/plog"
+	txt "golang.org/x/text/encoding"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
-	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/textutils"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
 )
 
 var (
 	_ encoding.LogsMarshalerExtension   = (*textExtension)(nil)
 	_ encoding.LogsUnmarshalerExtension = (*textExtension)(nil)
 	_ encoding.LogsDecoderExtension     = (*textExtension)(nil)
+	_ encoding.LogsEncoderExtension     = (*textExtension)(nil)
+	_ encoding.LogsSizer                = (*textExtension)(nil)
+	_ encoding.LogsOptionsMarshaler     = (*textExtension)(nil)
 )
 
 type textExtension struct {
 	config      *Config
+	settings    extension.Settings
 	textEncoder *textLogCodec
 }
 
@@ -34,16 +41,45 @@ func (e *textExtension) MarshalLogs(ld plog.Logs) ([]byte, error) {
 	return e.textEncoder.MarshalLogs(ld)
 }
 
+func (e *textExtension) MarshalLogsWithOptions(ld plog.Logs, options ...encoding.EncoderOption) ([]byte, error) {
+	return e.textEncoder.MarshalLogsWithOptions(ld, options...)
+}
+
+func (e *textExtension) LogsSize(ld plog.Logs) int {
+	return e.textEncoder.LogsSize(ld)
+}
+
 func (e *textExtension) NewLogsDecoder(reader io.Reader, options ...encoding.DecoderOption) (encoding.LogsDecoder, error) {
 	return e.textEncoder.NewLogsDecoder(reader, options...)
 }
 
+func (e *textExtension) NewLogsEncoder(writer io.Writer, options ...encoding.EncoderOption) (encoding.LogsEncoder, error) {
+	return e.textEncoder.NewLogsEncoder(writer, options...)
+}
+
 func (e *textExtension) Start(_ context.Context, _ component.Host) error {
-	enc, err := textutils.LookupEncoding(e.config.Encoding)
-	if err != nil {
-		return err
+	autoDetect := strings.EqualFold(e.config.Encoding, autoEncoding)
+
+	var decoder *txt.Decoder
+	var encoder *txt.Encoder
+	if !autoDetect {
+		enc, err := lookupEncoding(e.config.Encoding)
+		if err != nil {
+			return err
+		}
+		decoder, encoder = enc.NewDecoder(), enc.NewEncoder()
+	}
+
+	var autoFallback *fallbackCharset
+	if autoDetect && e.config.AutoFallbackEncoding != "" {
+		enc, err := lookupEncoding(e.config.AutoFallbackEncoding)
+		if err != nil {
+			return err
+		}
+		autoFallback = &fallbackCharset{name: strings.ToLower(e.config.AutoFallbackEncoding), encoding: enc}
 	}
 
+	var err error
 	var unmarshallingSeparator *regexp.Regexp
 
 	if e.config.UnmarshalingSeparator != "" {
@@ -55,10 +91,94 @@ func (e *textExtension) Start(_ context.Context, _ component.Host) error {
 		unmarshallingSeparator = nil
 	}
 
+	var tsParser *timestampParser
+	if e.config.TimestampRegex != "" {
+		tsRegex, err := regexp.Compile(e.config.TimestampRegex)
+		if err != nil {
+			return err
+		}
+		tsParser = &timestampParser{regex: tsRegex, layout: e.config.TimestampLayout}
+	}
+
+	var sevParser *severityParser
+	if e.config.SeverityRegex != "" {
+		sevRegex, err := regexp.Compile(e.config.SeverityRegex)
+		if err != nil {
+			return err
+		}
+		sevParser, err = newSeverityParser(sevRegex, e.config.SeverityMapping)
+		if err != nil {
+			return err
+		}
+	}
+
+	var multilineStart *regexp.Regexp
+	if e.config.MultilineStartRegex != "" {
+		multilineStart, err = regexp.Compile(e.config.MultilineStartRegex)
+		if err != nil {
+			return err
+		}
+	}
+
+	var lineStart *regexp.Regexp
+	if e.config.LineStartPattern != "" {
+		lineStart, err = regexp.Compile(e.config.LineStartPattern)
+		if err != nil {
+			return err
+		}
+	}
+
+	var selector *schemaSelector
+	if e.config.SchemaSelector != nil {
+		selector, err = newSchemaSelector(e.config.SchemaSelector)
+		if err != nil {
+			return err
+		}
+	}
+
+	checksum, err := newRecordChecksum(e.config.RecordChecksum, e.config.RecordChecksumFormat, e.config.RecordChecksumSeparator, e.config.RecordChecksumMismatch)
+	if err != nil {
+		return err
+	}
+
+	var bodyAttribute string
+	if e.config.BodyField != bodyField {
+		bodyAttribute = e.config.BodyField
+	}
+
 	e.textEncoder = &textLogCodec{
-		decoder:               enc.NewDecoder(),
-		marshalingSeparator:   e.config.MarshalingSeparator,
-		unmarshalingSeparator: unmarshallingSeparator,
+		decoder:                     decoder,
+		marshalingSeparator:         e.config.MarshalingSeparator,
+		marshalingTrailingSeparator: e.config.MarshalingTrailingSeparator,
+		linePrefix:                  e.config.LinePrefix,
+		lineSuffix:                  e.config.LineSuffix,
+		unmarshalingSeparator:       unmarshallingSeparator,
+		keepCarriageReturn:          e.config.KeepCarriageReturn,
+		trimWhitespace:              e.config.TrimWhitespace,
+		maxLineSize:                 e.config.MaxLineSize,
+		autoDetect:                  autoDetect,
+		sniffBufferSize:             e.config.SniffBufferSize,
+		autoFallback:                autoFallback,
+		decodeErrorHandling:         e.config.DecodeErrorHandling,
+		preserveRaw:                 e.config.PreserveRaw,
+		encoder:                     encoder,
+		timestampParser:             tsParser,
+		timestampPolicy:             e.config.TimestampPolicy,
+		timestampParseErrorAttr:     e.config.TimestampParseErrorAttribute,
+		severityParser:              sevParser,
+		multilineStart:              multilineStart,
+		lineStart:                   lineStart,
+		controlPrefix:               e.config.ControlPrefix,
+		bodyAttribute:               bodyAttribute,
+		headerAttributes:            e.config.AttributesHeader,
+		compression:                 e.config.Compression,
+		dedupCountAttribute:         e.config.BatchDedupCountAttribute,
+		schemaSelector:              selector,
+		recordChecksum:              checksum,
+		decoderOptions: []encoding.DecoderOption{
+			xstreamencoding.WithTelemetry(e.settings.TelemetrySettings),
+			xstreamencoding.WithEncodingID(e.settings.ID),
+		},
 	}
 
 	return err
@@ -4,6 +4,10 @@
 package textencodingextension // import "github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding/textencodingextension"
 
 import (
+	"context"
+	"errors"
+	"io"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -11,7 +15,9 @@ import (
 	"go.opentelemetry.io/collector/extension"
 	"go.opentelemetry.io/collector/extension/extensiontest"
 	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/pdatatest/plogtest"
 )
 
@@ -37,6 +43,15 @@ func TestExtension_Start(t *testing.T) {
 				return factory.Create(t.Context(), extensiontest.NewNopSettings(factory.Type()), cfg)
 			},
 		},
+		{
+			name: "text_auto",
+			getExtension: func() (extension.Extension, error) {
+				factory := NewFactory()
+				cfg := factory.CreateDefaultConfig()
+				cfg.(*Config).Encoding = "auto"
+				return factory.Create(t.Context(), extensiontest.NewNopSettings(factory.Type()), cfg)
+			},
+		},
 		{
 			name: "text_blabla",
 			getExtension: func() (extension.Extension, error) {
@@ -53,9 +68,9 @@ func TestExtension_Start(t *testing.T) {
 			ext, err := test.getExtension()
 			if test.expectedErr != "" && err != nil {
 				require.ErrorContains(t, err, test.expectedErr)
-			} else {
-				require.NoError(t, err)
+				return
 			}
+			require.NoError(t, err)
 			err = ext.Start(t.Context(), componenttest.NewNopHost())
 			if test.expectedErr != "" && err != nil {
 				require.ErrorContains(t, err, test.expectedErr)
@@ -66,6 +81,15 @@ func TestExtension_Start(t *testing.T) {
 	}
 }
 
+func TestFactory_CreateUnknownEncoding(t *testing.T) {
+	factory := NewFactory()
+	cfg := factory.CreateDefaultConfig()
+	cfg.(*Config).Encoding = "blabla"
+	ext, err := factory.Create(t.Context(), extensiontest.NewNopSettings(factory.Type()), cfg)
+	require.ErrorContains(t, err, "unsupported encoding 'blabla': supported encodings are auto, utf8, utf8-raw, utf16, ascii, nop")
+	require.Nil(t, ext)
+}
+
 func Test_MarshalUnmarshal(t *testing.T) {
 	factory := NewFactory()
 	ext, err := factory.Create(t.Context(), extensiontest.NewNopSettings(factory.Type()), factory.CreateDefaultConfig())
@@ -93,3 +117,43 @@ func Test_StartSeparatorConfig(t *testing.T) {
 	require.Equal(t, e.config.MarshalingSeparator, e.textEncoder.marshalingSeparator)
 	require.Equal(t, e.config.UnmarshalingSeparator, e.textEncoder.unmarshalingSeparator.String())
 }
+
+func TestExtension_Telemetry(t *testing.T) {
+	tel := componenttest.NewTelemetry()
+	t.Cleanup(func() { require.NoError(t, tel.Shutdown(context.Background())) })
+
+	factory := NewFactory()
+	set := extensiontest.NewNopSettings(factory.Type())
+	set.TelemetrySettings = tel.NewTelemetrySettings()
+	ext, err := factory.Create(t.Context(), set, factory.CreateDefaultConfig())
+	require.NoError(t, err)
+	require.NoError(t, ext.Start(t.Context(), componenttest.NewNopHost()))
+
+	input := "foo\nbar\nbaz\n"
+	decoder, err := ext.(*textExtension).NewLogsDecoder(strings.NewReader(input), encoding.WithFlushItems(2))
+	require.NoError(t, err)
+	for {
+		_, err = decoder.DecodeLogs()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	for name, expected := range map[string]int64{
+		"otelcol_decoder_flushed_batches": 2,
+		"otelcol_decoder_read_bytes":      int64(len(input)),
+		"otelcol_decoder_records":         3,
+	} {
+		m, err := tel.GetMetric(name)
+		require.NoError(t, err, name)
+		sum, ok := m.Data.(metricdata.Sum[int64])
+		require.True(t, ok, name)
+		require.Len(t, sum.DataPoints, 1, name)
+		require.Equal(t, expected, sum.DataPoints[0].Value, name)
+
+		encodingID, ok := sum.DataPoints[0].Attributes.Value("encoding")
+		require.True(t, ok, name)
+		require.Equal(t, set.ID.String(), encodingID.AsString(), name)
+	}
+}
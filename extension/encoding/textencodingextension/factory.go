@@ -5,6 +5,7 @@ package textencodingextension // import "github.com/open-telemetry/opentelemetry
 
 import (
 	"context"
+	"strings"
 
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/extension"
@@ -21,12 +22,30 @@ func NewFactory() extension.Factory {
 	)
 }
 
-func createExtension(_ context.Context, _ extension.Settings, config component.Config) (extension.Extension, error) {
+func createExtension(_ context.Context, set extension.Settings, config component.Config) (extension.Extension, error) {
+	cfg := config.(*Config)
+	// Fail at creation rather than when the extension starts, so that an unknown encoding is reported up front.
+	if !strings.EqualFold(cfg.Encoding, autoEncoding) {
+		if _, err := lookupEncoding(cfg.Encoding); err != nil {
+			return nil, err
+		}
+	}
 	return &textExtension{
-		config: config.(*Config),
+		config:   cfg,
+		settings: set,
 	}, nil
 }
 
 func createDefaultConfig() component.Config {
-	return &Config{Encoding: "utf8", MarshalingSeparator: "\n", UnmarshalingSeparator: "\r?\n"}
+	return &Config{
+		Encoding:              "utf8",
+		MarshalingSeparator:   "\n",
+		UnmarshalingSeparator: "\r?\n",
+		MaxLineSize:           defaultMaxLineSize,
+		SniffBufferSize:       defaultSniffBufferSize,
+		TimestampPolicy:       timestampPolicyBoth,
+		DecodeErrorHandling:   decodeErrorStrict,
+		BodyField:             bodyField,
+		Compression:           compressionNone,
+	}
 }
@@ -0,0 +1,51 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/unicode"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding/fuzztest"
+)
+
+// fuzzRegressions are inputs at the limits of the text codec, kept as regression seeds.
+var fuzzRegressions = [][]byte{
+	// A "\r\n" separator split across the initial scanner buffer.
+	append(bytes.Repeat([]byte("a"), 4095), "\r\nb\n"...),
+	// A record of exactly max_line_size bytes, followed by the separator.
+	append(bytes.Repeat([]byte("a"), 1024), '\n'),
+	// A record just over max_line_size bytes, without a separator.
+	bytes.Repeat([]byte("a"), 1025),
+}
+
+func FuzzTextDecoder(f *testing.F) {
+	codec := &textLogCodec{
+		decoder:                     unicode.UTF8.NewDecoder(),
+		marshalingSeparator:         "\n",
+		marshalingTrailingSeparator: true,
+		unmarshalingSeparator:       regexp.MustCompile(`\n`),
+		keepCarriageReturn:          true,
+		maxLineSize:                 1024,
+	}
+	fuzztest.AddCorpus(f, fuzztest.CorpusConfig{GiganticRecordSize: 2048}, fuzzRegressions...)
+	fuzztest.Fuzz(f, codec.NewLogsDecoder, fuzztest.WithRoundTrip(codec.MarshalLogs, func(data []byte) bool {
+		// Invalid sequences are replaced, and the last record is always terminated when marshaled
+		return utf8.Valid(data) && (len(data) == 0 || data[len(data)-1] == '\n')
+	}))
+}
+
+func FuzzTextDecoderLineStart(f *testing.F) {
+	codec := &textLogCodec{
+		decoder:     unicode.UTF8.NewDecoder(),
+		lineStart:   regexp.MustCompile(`^\d{4}-\d{2}-\d{2} `),
+		maxLineSize: 1024,
+	}
+	fuzztest.AddCorpus(f, fuzztest.CorpusConfig{GiganticRecordSize: 2048}, []byte("2024-01-02 a\n\tat b\n2024-01-02 c\n"))
+	fuzztest.Fuzz(f, codec.NewLogsDecoder)
+}
@@ -0,0 +1,84 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension // import "github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding/textencodingextension"
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// errHeaderMismatch is returned when marshaling logs whose resources hold different values for the header attributes.
+var errHeaderMismatch = errors.New("resources have different values for the attributes_header attributes")
+
+// attributesHeader returns the header line holding the headerAttributes of the resources of ld, e.g.
+// "service.name=api host.name=host-1", with keys and values query escaped. Attributes missing from the resources
+// are left out. ok is false when ld has no log records, and so no header.
+func (r *textLogCodec) attributesHeader(ld plog.Logs) (header string, ok bool, err error) {
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		rl := ld.ResourceLogs().At(i)
+		if !hasLogRecords(rl) {
+			continue
+		}
+		h := r.resourceHeader(rl.Resource())
+		if ok && h != header {
+			return "", false, errHeaderMismatch
+		}
+		header, ok = h, true
+	}
+	return header, ok, nil
+}
+
+// resourceHeader returns the header line holding the headerAttributes of resource.
+func (r *textLogCodec) resourceHeader(resource pcommon.Resource) string {
+	var b strings.Builder
+	for _, key := range r.headerAttributes {
+		v, ok := resource.Attributes().Get(key)
+		if !ok {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(url.QueryEscape(key))
+		b.WriteByte('=')
+		b.WriteString(url.QueryEscape(v.AsString()))
+	}
+	return b.String()
+}
+
+// parseAttributesHeader parses a header line written by attributesHeader into attributes.
+func parseAttributesHeader(line string) (pcommon.Map, error) {
+	attributes := pcommon.NewMap()
+	for _, pair := range strings.Fields(line) {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return pcommon.Map{}, fmt.Errorf("invalid attributes header pair %q", pair)
+		}
+		key, err := url.QueryUnescape(k)
+		if err != nil {
+			return pcommon.Map{}, fmt.Errorf("invalid attributes header key %q: %w", k, err)
+		}
+		value, err := url.QueryUnescape(v)
+		if err != nil {
+			return pcommon.Map{}, fmt.Errorf("invalid attributes header value %q: %w", v, err)
+		}
+		attributes.PutStr(key, value)
+	}
+	return attributes, nil
+}
+
+// hasLogRecords reports whether rl holds at least one log record.
+func hasLogRecords(rl plog.ResourceLogs) bool {
+	for j := 0; j < rl.ScopeLogs().Len(); j++ {
+		if rl.ScopeLogs().At(j).LogRecords().Len() > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+func newHeaderLogs(t *testing.T, resources ...map[string]any) plog.Logs {
+	t.Helper()
+	ld := plog.NewLogs()
+	for i, attributes := range resources {
+		rl := ld.ResourceLogs().AppendEmpty()
+		require.NoError(t, rl.Resource().Attributes().FromRaw(attributes))
+		records := rl.ScopeLogs().AppendEmpty().LogRecords()
+		records.AppendEmpty().Body().SetStr(strings.Repeat("a", i+1))
+		records.AppendEmpty().Body().SetStr(strings.Repeat("b", i+1))
+	}
+	return ld
+}
+
+func TestAttributesHeader_roundtrip(t *testing.T) {
+	codec := newEncoderCodec(t, false)
+	codec.headerAttributes = []string{"service.name", "host.name", "missing"}
+
+	ld := newHeaderLogs(t,
+		map[string]any{"service.name": "checkout api", "host.name": "host=1&2", "cloud.region": "eu-west-1"},
+		map[string]any{"service.name": "checkout api", "host.name": "host=1&2"},
+	)
+	b, err := codec.MarshalLogs(ld)
+	require.NoError(t, err)
+	assert.Equal(t, "service.name=checkout+api host.name=host%3D1%262\na\nb\naa\nbb", string(b))
+	assert.Equal(t, len(b), codec.LogsSize(ld))
+
+	decoded, err := codec.UnmarshalLogs(b)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "aa", "bb"}, bodies(decoded))
+	for i := 0; i < decoded.ResourceLogs().Len(); i++ {
+		assert.Equal(t, map[string]any{"service.name": "checkout api", "host.name": "host=1&2"},
+			decoded.ResourceLogs().At(i).Resource().Attributes().AsRaw())
+	}
+
+	reencoded, err := codec.MarshalLogs(decoded)
+	require.NoError(t, err)
+	assert.Equal(t, string(b), string(reencoded))
+}
+
+func TestAttributesHeader_trailingSeparator(t *testing.T) {
+	codec := newEncoderCodec(t, true)
+	codec.headerAttributes = []string{"service.name"}
+
+	ld := newHeaderLogs(t, map[string]any{"service.name": "api"})
+	b, err := codec.MarshalLogs(ld)
+	require.NoError(t, err)
+	assert.Equal(t, "service.name=api\na\nb\n", string(b))
+	assert.Equal(t, len(b), codec.LogsSize(ld))
+
+	decoded, err := codec.UnmarshalLogs(b)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, bodies(decoded))
+	assert.Equal(t, map[string]any{"service.name": "api"}, decoded.ResourceLogs().At(0).Resource().Attributes().AsRaw())
+}
+
+func TestAttributesHeader_noAttributes(t *testing.T) {
+	codec := newEncoderCodec(t, false)
+	codec.headerAttributes = []string{"service.name"}
+
+	b, err := codec.MarshalLogs(newHeaderLogs(t, map[string]any{}))
+	require.NoError(t, err)
+	assert.Equal(t, "\na\nb", string(b))
+
+	decoded, err := codec.UnmarshalLogs(b)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, bodies(decoded))
+	assert.Equal(t, 0, decoded.ResourceLogs().At(0).Resource().Attributes().Len())
+
+	b, err = codec.MarshalLogs(plog.NewLogs())
+	require.NoError(t, err)
+	assert.Empty(t, b)
+}
+
+func TestAttributesHeader_mismatch(t *testing.T) {
+	codec := newEncoderCodec(t, false)
+	codec.headerAttributes = []string{"service.name"}
+
+	ld := newHeaderLogs(t, map[string]any{"service.name": "api"}, map[string]any{"service.name": "db"})
+	_, err := codec.MarshalLogs(ld)
+	require.ErrorIs(t, err, errHeaderMismatch)
+
+	// Attributes not listed in the header do not matter.
+	ld = newHeaderLogs(t, map[string]any{"service.name": "api", "host.name": "1"}, map[string]any{"service.name": "api", "host.name": "2"})
+	_, err = codec.MarshalLogs(ld)
+	require.NoError(t, err)
+}
+
+func TestAttributesHeader_invalid(t *testing.T) {
+	codec := newEncoderCodec(t, false)
+	codec.headerAttributes = []string{"service.name"}
+
+	_, err := codec.UnmarshalLogs([]byte("service.name\na"))
+	require.ErrorContains(t, err, `invalid attributes header pair "service.name"`)
+
+	_, err = codec.UnmarshalLogs([]byte("service.name=%zz\na"))
+	require.ErrorContains(t, err, "invalid attributes header value")
+}
+
+func TestAttributesHeader_encoder(t *testing.T) {
+	codec := newEncoderCodec(t, false)
+	codec.headerAttributes = []string{"service.name"}
+
+	var buf bytes.Buffer
+	encoder, err := codec.NewLogsEncoder(&buf)
+	require.NoError(t, err)
+	require.NoError(t, encoder.EncodeLogs(plog.NewLogs()))
+	require.NoError(t, encoder.EncodeLogs(newHeaderLogs(t, map[string]any{"service.name": "api"})))
+	require.NoError(t, encoder.EncodeLogs(newHeaderLogs(t, map[string]any{"service.name": "api"})))
+	require.ErrorIs(t, encoder.EncodeLogs(newHeaderLogs(t, map[string]any{"service.name": "db"})), errHeaderMismatch)
+	assert.Equal(t, "service.name=api\na\nb\na\nb", buf.String())
+
+	decoder, err := codec.NewLogsDecoder(bytes.NewReader(buf.Bytes()), encoding.WithFlushItems(2))
+	require.NoError(t, err)
+	for range 2 {
+		ld, err := decoder.DecodeLogs()
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a", "b"}, bodies(ld))
+		assert.Equal(t, map[string]any{"service.name": "api"}, ld.ResourceLogs().At(0).Resource().Attributes().AsRaw())
+	}
+
+	// Decoders resuming from an offset do not read the header.
+	decoder, err = codec.NewLogsDecoder(bytes.NewReader(buf.Bytes()), encoding.WithOffset(int64(len("service.name=api\n"))))
+	require.NoError(t, err)
+	ld, err := decoder.DecodeLogs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "a", "b"}, bodies(ld))
+	assert.Equal(t, 0, ld.ResourceLogs().At(0).Resource().Attributes().Len())
+}
@@ -0,0 +1,39 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension // import "github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding/textencodingextension"
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// splitLineStart returns the record at the start of data, spanning the lines up to the next one matching lineStart,
+// and the number of bytes it takes in data. The first line always starts a record, even if it does not match.
+// Lines are matched once complete, so more data is requested until the line following the record is, or until EOF.
+func splitLineStart(lineStart *regexp.Regexp, data []byte, atEOF bool) (advance int, record []byte, more bool) {
+	for end := bytes.IndexByte(data, '\n'); end >= 0; {
+		next := end + 1
+		if next == len(data) {
+			break
+		}
+		lineLen := bytes.IndexByte(data[next:], '\n')
+		if lineLen < 0 {
+			if !atEOF {
+				return 0, nil, true
+			}
+			lineLen = len(data) - next
+		}
+		if lineStart.Match(bytes.TrimSuffix(data[next:next+lineLen], []byte{'\r'})) {
+			return next, data[:end], false
+		}
+		end = next + lineLen
+		if end == len(data) {
+			break
+		}
+	}
+	if !atEOF {
+		return 0, nil, true
+	}
+	return len(data), bytes.TrimSuffix(data, []byte{'\n'}), false
+}
@@ -0,0 +1,115 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/textutils"
+)
+
+func newLineStartCodec(t *testing.T) *textLogCodec {
+	enc, err := textutils.LookupEncoding("utf8")
+	require.NoError(t, err)
+	return &textLogCodec{
+		decoder:   enc.NewDecoder(),
+		lineStart: regexp.MustCompile(`^\d{4}-\d{2}-\d{2} `),
+	}
+}
+
+func lineStartBodies(ld plog.Logs) []string {
+	var bodies []string
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		records := ld.ResourceLogs().At(i).ScopeLogs().At(0).LogRecords()
+		for j := 0; j < records.Len(); j++ {
+			bodies = append(bodies, records.At(j).Body().Str())
+		}
+	}
+	return bodies
+}
+
+func TestLineStart_stackTrace(t *testing.T) {
+	codec := newLineStartCodec(t)
+	input := "2024-01-02 INFO starting\n" +
+		"2024-01-02 ERROR failed\n" +
+		"java.lang.IllegalStateException: boom\n" +
+		"\tat com.example.Foo.bar(Foo.java:10)\n" +
+		"\tat com.example.Foo.main(Foo.java:5)\n" +
+		"Caused by: java.io.IOException: closed\n" +
+		"\t... 2 more\n" +
+		"2024-01-02 INFO recovered\n"
+
+	ld, err := codec.UnmarshalLogs([]byte(input))
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"2024-01-02 INFO starting",
+		"2024-01-02 ERROR failed\n" +
+			"java.lang.IllegalStateException: boom\n" +
+			"\tat com.example.Foo.bar(Foo.java:10)\n" +
+			"\tat com.example.Foo.main(Foo.java:5)\n" +
+			"Caused by: java.io.IOException: closed\n" +
+			"\t... 2 more",
+		"2024-01-02 INFO recovered",
+	}, lineStartBodies(ld))
+}
+
+func TestLineStart_streaming(t *testing.T) {
+	codec := newLineStartCodec(t)
+	input := "2024-01-02 ERROR failed\r\n" +
+		"java.lang.IllegalStateException: boom\r\n" +
+		"\tat com.example.Foo.bar(Foo.java:10)\r\n" +
+		"2024-01-02 ERROR failed again\r\n" +
+		"\tat com.example.Foo.bar(Foo.java:10)"
+
+	// Reading a byte at a time checks that records only end once the next line is complete
+	decoder, err := codec.NewLogsDecoder(iotest.OneByteReader(bytes.NewReader([]byte(input))), encoding.WithFlushItems(1))
+	require.NoError(t, err)
+
+	ld, err := decoder.DecodeLogs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"2024-01-02 ERROR failed\r\n" +
+			"java.lang.IllegalStateException: boom\r\n" +
+			"\tat com.example.Foo.bar(Foo.java:10)",
+	}, lineStartBodies(ld))
+	assert.Equal(t, int64(102), decoder.Offset(), "offset should be at the start of the next record")
+
+	// The last record is flushed at EOF
+	ld, err = decoder.DecodeLogs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2024-01-02 ERROR failed again\r\n\tat com.example.Foo.bar(Foo.java:10)"}, lineStartBodies(ld))
+	assert.Equal(t, int64(len(input)), decoder.Offset())
+
+	ld, err = decoder.DecodeLogs()
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, 0, ld.LogRecordCount())
+}
+
+func TestLineStart_leadingContinuationLines(t *testing.T) {
+	codec := newLineStartCodec(t)
+
+	ld, err := codec.UnmarshalLogs([]byte("\tat continued\n\tat continued\n2024-01-02 INFO next\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"\tat continued\n\tat continued", "2024-01-02 INFO next"}, lineStartBodies(ld))
+}
+
+func TestLineStart_resumeFromOffset(t *testing.T) {
+	codec := newLineStartCodec(t)
+	input := "2024-01-02 ERROR failed\n\tat Foo.bar\n2024-01-02 INFO next\n"
+
+	decoder, err := codec.NewLogsDecoder(bytes.NewReader([]byte(input)), encoding.WithOffset(36))
+	require.NoError(t, err)
+	ld, err := decoder.DecodeLogs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2024-01-02 INFO next"}, lineStartBodies(ld))
+}
@@ -0,0 +1,40 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension // import "github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding/textencodingextension"
+
+import (
+	"strings"
+)
+
+// multilineRecord buffers the physical lines of a logical record until the next start line is found.
+type multilineRecord struct {
+	buffered bool
+	raw      []byte
+	decoded  strings.Builder
+	// offset is the stream offset of the first physical line of the record.
+	offset int64
+}
+
+// start discards the buffered record, if any, and starts a new one with the given line.
+func (m *multilineRecord) start(raw []byte, decoded string, offset int64) {
+	m.buffered = true
+	m.raw = append(m.raw[:0], raw...)
+	m.decoded.Reset()
+	m.decoded.WriteString(decoded)
+	m.offset = offset
+}
+
+// append adds a continuation line to the buffered record, separated by a new line.
+func (m *multilineRecord) append(raw []byte, decoded string) {
+	m.raw = append(append(m.raw, '\n'), raw...)
+	m.decoded.WriteByte('\n')
+	m.decoded.WriteString(decoded)
+}
+
+// take returns the buffered record and clears the buffer.
+// The returned raw bytes are only valid until the next call to start.
+func (m *multilineRecord) take() ([]byte, string) {
+	m.buffered = false
+	return m.raw, m.decoded.String()
+}
@@ -0,0 +1,86 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/textutils"
+)
+
+func newMultilineCodec(t *testing.T) *textLogCodec {
+	enc, err := textutils.LookupEncoding("utf8")
+	require.NoError(t, err)
+	return &textLogCodec{
+		decoder:               enc.NewDecoder(),
+		unmarshalingSeparator: regexp.MustCompile(`\r?\n`),
+		multilineStart:        regexp.MustCompile(`^\d{4}-\d{2}-\d{2} `),
+	}
+}
+
+func TestMultiline_stackTrace(t *testing.T) {
+	codec := newMultilineCodec(t)
+	input := "2024-01-02 INFO starting\n" +
+		"2024-01-02 ERROR failed\n" +
+		"java.lang.IllegalStateException: boom\n" +
+		"\tat com.example.Foo.bar(Foo.java:10)\n" +
+		"\tat com.example.Foo.main(Foo.java:5)\n" +
+		"2024-01-02 INFO recovered\n"
+
+	ld, err := codec.UnmarshalLogs([]byte(input))
+	require.NoError(t, err)
+	require.Equal(t, 3, ld.LogRecordCount())
+	assert.Equal(t, "2024-01-02 INFO starting", ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+	assert.Equal(t, "2024-01-02 ERROR failed\n"+
+		"java.lang.IllegalStateException: boom\n"+
+		"\tat com.example.Foo.bar(Foo.java:10)\n"+
+		"\tat com.example.Foo.main(Foo.java:5)",
+		ld.ResourceLogs().At(1).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+	assert.Equal(t, "2024-01-02 INFO recovered", ld.ResourceLogs().At(2).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+}
+
+func TestMultiline_trailingUnterminatedRecord(t *testing.T) {
+	codec := newMultilineCodec(t)
+	input := "2024-01-02 INFO starting\n" +
+		"2024-01-02 ERROR failed\n" +
+		"\tat com.example.Foo.bar(Foo.java:10)"
+
+	decoder, err := codec.NewLogsDecoder(bytes.NewReader([]byte(input)), encoding.WithFlushItems(1))
+	require.NoError(t, err)
+
+	// The first record is only emitted once the next start line is read
+	ld, err := decoder.DecodeLogs()
+	require.NoError(t, err)
+	require.Equal(t, 1, ld.LogRecordCount())
+	assert.Equal(t, "2024-01-02 INFO starting", ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+	assert.Equal(t, int64(25), decoder.Offset(), "offset should exclude the buffered record")
+
+	// The buffered record is flushed at EOF
+	ld, err = decoder.DecodeLogs()
+	require.NoError(t, err)
+	require.Equal(t, 1, ld.LogRecordCount())
+	assert.Equal(t, "2024-01-02 ERROR failed\n\tat com.example.Foo.bar(Foo.java:10)", ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+	assert.Equal(t, int64(len(input)), decoder.Offset())
+
+	ld, err = decoder.DecodeLogs()
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, 0, ld.LogRecordCount())
+}
+
+func TestMultiline_leadingContinuationLines(t *testing.T) {
+	codec := newMultilineCodec(t)
+
+	ld, err := codec.UnmarshalLogs([]byte("\tat continued\n2024-01-02 INFO next\n"))
+	require.NoError(t, err)
+	require.Equal(t, 2, ld.LogRecordCount())
+	assert.Equal(t, "\tat continued", ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+	assert.Equal(t, "2024-01-02 INFO next", ld.ResourceLogs().At(1).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+}
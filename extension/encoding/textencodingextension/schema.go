@@ -0,0 +1,243 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension // import "github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding/textencodingextension"
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+)
+
+const (
+	parserRegex  = "regex"
+	parserLogfmt = "logfmt"
+	parserCSV    = "csv"
+)
+
+// SchemaSelectorConfig selects how the records of a stream are parsed from the schema or version declared by the
+// first line of the stream.
+type SchemaSelectorConfig struct {
+	// Regex matches the first line of streams, its first capturing group, or the whole match without groups,
+	// being the name of the profile parsing the rest of the stream, e.g. "^#version: (\\S+)$".
+	Regex string `mapstructure:"regex"`
+	// Profiles are the parsing profiles by name.
+	Profiles map[string]ParsingProfileConfig `mapstructure:"profiles"`
+	// DefaultProfile parses streams whose first line selects no profile, and streams decoded from an offset,
+	// whose first line is not read. Without it, such streams fail to decode.
+	DefaultProfile string `mapstructure:"default_profile"`
+	// prevent unkeyed literal initialization
+	_ struct{}
+}
+
+// ParsingProfileConfig defines how records are parsed into log record attributes.
+type ParsingProfileConfig struct {
+	// Parser is the format of records: "regex", "logfmt" or "csv".
+	Parser string `mapstructure:"parser"`
+	// Regex parses records into attributes named after its named capturing groups, with the regex parser.
+	Regex string `mapstructure:"regex"`
+	// CSVHeader names the attributes of the columns of records, with the csv parser.
+	CSVHeader []string `mapstructure:"csv_header"`
+	// CSVDelimiter is the delimiter of the columns of records with the csv parser, "," if empty.
+	CSVDelimiter string `mapstructure:"csv_delimiter"`
+	// prevent unkeyed literal initialization
+	_ struct{}
+}
+
+// recordParser parses records into log record attributes.
+type recordParser interface {
+	// parse sets the attributes parsed from record on attributes, and reports whether record could be parsed.
+	// Nothing is set when record cannot be parsed.
+	parse(record string, attributes pcommon.Map) bool
+}
+
+// schemaSelector selects the recordParser of a stream from its first line.
+type schemaSelector struct {
+	regex    *regexp.Regexp
+	parsers  map[string]recordParser
+	fallback recordParser
+}
+
+func newSchemaSelector(cfg *SchemaSelectorConfig) (*schemaSelector, error) {
+	regex, err := regexp.Compile(cfg.Regex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema_selector regex: %w", err)
+	}
+	s := &schemaSelector{regex: regex, parsers: make(map[string]recordParser, len(cfg.Profiles))}
+	for name, profile := range cfg.Profiles {
+		parser, err := newRecordParser(profile)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema_selector profile %q: %w", name, err)
+		}
+		s.parsers[name] = parser
+	}
+	if cfg.DefaultProfile != "" {
+		s.fallback = s.parsers[cfg.DefaultProfile]
+	}
+	return s, nil
+}
+
+// selectParser returns the parser of the profile declared by the first line of a stream.
+func (s *schemaSelector) selectParser(line string) (recordParser, error) {
+	match := s.regex.FindStringSubmatch(line)
+	if match == nil {
+		return s.defaultParser(fmt.Errorf("first line %q does not declare a schema", line))
+	}
+	name := match[0]
+	if len(match) > 1 {
+		name = match[1]
+	}
+	if parser, ok := s.parsers[name]; ok {
+		return parser, nil
+	}
+	return s.defaultParser(fmt.Errorf("no parsing profile for schema %q", name))
+}
+
+// defaultParser returns the parser of the default profile, or err without one.
+func (s *schemaSelector) defaultParser(err error) (recordParser, error) {
+	if s.fallback == nil {
+		return nil, err
+	}
+	return s.fallback, nil
+}
+
+func newRecordParser(cfg ParsingProfileConfig) (recordParser, error) {
+	switch cfg.Parser {
+	case parserRegex:
+		regex, err := regexp.Compile(cfg.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex: %w", err)
+		}
+		return &regexParser{regex: regex}, nil
+	case parserLogfmt:
+		return logfmtParser{}, nil
+	case parserCSV:
+		if len(cfg.CSVHeader) == 0 {
+			return nil, errors.New("csv_header must not be empty")
+		}
+		delimiter := ','
+		if cfg.CSVDelimiter != "" {
+			var size int
+			delimiter, size = utf8.DecodeRuneInString(cfg.CSVDelimiter)
+			if size != len(cfg.CSVDelimiter) || strings.ContainsRune("\"\r\n", delimiter) {
+				return nil, fmt.Errorf("csv_delimiter %q must be a single character other than a quote or line break", cfg.CSVDelimiter)
+			}
+		}
+		return &csvParser{header: cfg.CSVHeader, delimiter: delimiter}, nil
+	default:
+		return nil, fmt.Errorf("unsupported parser %q", cfg.Parser)
+	}
+}
+
+// regexParser sets the named capturing groups of regex matching records as attributes.
+type regexParser struct {
+	regex *regexp.Regexp
+}
+
+func (p *regexParser) parse(record string, attributes pcommon.Map) bool {
+	match := p.regex.FindStringSubmatch(record)
+	if match == nil {
+		return false
+	}
+	for i, name := range p.regex.SubexpNames() {
+		if name != "" {
+			attributes.PutStr(name, match[i])
+		}
+	}
+	return true
+}
+
+// logfmtParser sets the key=value pairs of records as attributes, e.g. `level=info msg="user logged in"`.
+// Keys without a value are set to an empty string.
+type logfmtParser struct{}
+
+func (logfmtParser) parse(record string, attributes pcommon.Map) bool {
+	var keys, values []string
+	for rest := strings.TrimLeft(record, " \t"); rest != ""; rest = strings.TrimLeft(rest, " \t") {
+		end := strings.IndexAny(rest, "= \t")
+		if end == 0 {
+			return false
+		}
+		if end < 0 {
+			end = len(rest)
+		}
+		key, value := rest[:end], ""
+		rest = rest[end:]
+		if strings.HasPrefix(rest, "=") {
+			var ok bool
+			if value, rest, ok = logfmtValue(rest[1:]); !ok {
+				return false
+			}
+		}
+		keys, values = append(keys, key), append(values, value)
+	}
+	if len(keys) == 0 {
+		return false
+	}
+	for i, key := range keys {
+		attributes.PutStr(key, values[i])
+	}
+	return true
+}
+
+// logfmtValue returns the value leading s, unquoted, and the rest of s.
+func logfmtValue(s string) (value, rest string, ok bool) {
+	if !strings.HasPrefix(s, `"`) {
+		end := strings.IndexAny(s, " \t")
+		if end < 0 {
+			end = len(s)
+		}
+		return s[:end], s[end:], true
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			return b.String(), s[i+1:], true
+		case '\\':
+			if i+1 == len(s) {
+				return "", "", false
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				b.WriteByte(s[i])
+			}
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	// Unterminated quoted value
+	return "", "", false
+}
+
+// csvParser sets the columns of records as attributes named after header. Records must have as many columns
+// as header.
+type csvParser struct {
+	header    []string
+	delimiter rune
+}
+
+func (p *csvParser) parse(record string, attributes pcommon.Map) bool {
+	reader := csv.NewReader(strings.NewReader(record))
+	reader.Comma = p.delimiter
+	reader.FieldsPerRecord = len(p.header)
+	reader.LazyQuotes = true
+	fields, err := reader.Read()
+	if err != nil {
+		return false
+	}
+	for i, key := range p.header {
+		attributes.PutStr(key, fields[i])
+	}
+	return true
+}
@@ -0,0 +1,169 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/textutils"
+)
+
+func newSchemaSelectorConfig() *SchemaSelectorConfig {
+	return &SchemaSelectorConfig{
+		Regex: `^#version: (\S+)$`,
+		Profiles: map[string]ParsingProfileConfig{
+			"1": {Parser: parserRegex, Regex: `^(?P<time>\S+) (?P<level>\S+) (?P<msg>.*)$`},
+			"2": {Parser: parserLogfmt},
+			"3": {Parser: parserCSV, CSVHeader: []string{"time", "level", "msg"}, CSVDelimiter: ";"},
+		},
+	}
+}
+
+func newSchemaCodec(t *testing.T, cfg *SchemaSelectorConfig) *textLogCodec {
+	t.Helper()
+	enc, err := textutils.LookupEncoding("utf8")
+	require.NoError(t, err)
+	selector, err := newSchemaSelector(cfg)
+	require.NoError(t, err)
+	return &textLogCodec{
+		decoder:               enc.NewDecoder(),
+		unmarshalingSeparator: regexp.MustCompile(`\r?\n`),
+		schemaSelector:        selector,
+	}
+}
+
+// recordAttributes returns the attributes of the records of ld.
+func recordAttributes(ld plog.Logs) []map[string]any {
+	var result []map[string]any
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		records := ld.ResourceLogs().At(i).ScopeLogs().At(0).LogRecords()
+		for j := 0; j < records.Len(); j++ {
+			result = append(result, records.At(j).Attributes().AsRaw())
+		}
+	}
+	return result
+}
+
+func TestSchemaSelector(t *testing.T) {
+	codec := newSchemaCodec(t, newSchemaSelectorConfig())
+
+	tests := []struct {
+		file       string
+		bodies     []string
+		attributes []map[string]any
+	}{
+		{
+			file:   "schema_v1.log",
+			bodies: []string{"2024-05-01T10:00:00Z INFO user logged in", "2024-05-01T10:00:01Z WARN disk almost full"},
+			attributes: []map[string]any{
+				{"time": "2024-05-01T10:00:00Z", "level": "INFO", "msg": "user logged in"},
+				{"time": "2024-05-01T10:00:01Z", "level": "WARN", "msg": "disk almost full"},
+			},
+		},
+		{
+			file:   "schema_v2.log",
+			bodies: []string{`level=info msg="user logged in" user=alice`, `level=warn msg="disk almost full" free=5%`},
+			attributes: []map[string]any{
+				{"level": "info", "msg": "user logged in", "user": "alice"},
+				{"level": "warn", "msg": "disk almost full", "free": "5%"},
+			},
+		},
+		{
+			file:   "schema_v3.log",
+			bodies: []string{"2024-05-01T10:00:00Z;INFO;user logged in"},
+			attributes: []map[string]any{
+				{"time": "2024-05-01T10:00:00Z", "level": "INFO", "msg": "user logged in"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			b, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			require.NoError(t, err)
+
+			ld, err := codec.UnmarshalLogs(b)
+			require.NoError(t, err)
+			assert.Equal(t, tt.bodies, bodies(ld))
+			assert.Equal(t, tt.attributes, recordAttributes(ld))
+		})
+	}
+}
+
+func TestSchemaSelector_unparsedRecords(t *testing.T) {
+	codec := newSchemaCodec(t, newSchemaSelectorConfig())
+
+	// Records the profile cannot parse are emitted without attributes
+	ld, err := codec.UnmarshalLogs([]byte("#version: 3\na;b;c\nnot csv\na;\"b\";c;d\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a;b;c", "not csv", `a;"b";c;d`}, bodies(ld))
+	assert.Equal(t, []map[string]any{{"time": "a", "level": "b", "msg": "c"}, {}, {}}, recordAttributes(ld))
+}
+
+func TestSchemaSelector_unknownSchema(t *testing.T) {
+	codec := newSchemaCodec(t, newSchemaSelectorConfig())
+
+	_, err := codec.UnmarshalLogs([]byte("#version: 4\na\n"))
+	require.ErrorContains(t, err, `no parsing profile for schema "4"`)
+
+	_, err = codec.UnmarshalLogs([]byte("a b c\n"))
+	require.ErrorContains(t, err, `first line "a b c" does not declare a schema`)
+
+	cfg := newSchemaSelectorConfig()
+	cfg.DefaultProfile = "2"
+	codec = newSchemaCodec(t, cfg)
+
+	ld, err := codec.UnmarshalLogs([]byte("#version: 4\nlevel=info\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]any{{"level": "info"}}, recordAttributes(ld))
+}
+
+func TestSchemaSelector_resumeFromOffset(t *testing.T) {
+	cfg := newSchemaSelectorConfig()
+	cfg.DefaultProfile = "2"
+	codec := newSchemaCodec(t, cfg)
+
+	// Decoders resuming from an offset do not read the schema line, and use the default profile
+	stream := "#version: 1\nlevel=info\n"
+	decoder, err := codec.NewLogsDecoder(strings.NewReader(stream), encoding.WithOffset(int64(len("#version: 1\n"))))
+	require.NoError(t, err)
+	ld, err := decoder.DecodeLogs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"level=info"}, bodies(ld))
+	assert.Equal(t, []map[string]any{{"level": "info"}}, recordAttributes(ld))
+}
+
+func TestLogfmtParser(t *testing.T) {
+	tests := []struct {
+		record   string
+		expected map[string]any
+	}{
+		{record: "a=1 b=2", expected: map[string]any{"a": "1", "b": "2"}},
+		{record: `  msg="quoted \"value\"\tend"   flag  empty= `, expected: map[string]any{"msg": "quoted \"value\"\tend", "flag": "", "empty": ""}},
+		{record: "a==1", expected: map[string]any{"a": "=1"}},
+		{record: `msg="unterminated`},
+		{record: "=1"},
+		{record: "   "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.record, func(t *testing.T) {
+			attributes := pcommon.NewMap()
+			ok := logfmtParser{}.parse(tt.record, attributes)
+			assert.Equal(t, tt.expected != nil, ok)
+			assert.Equal(t, len(tt.expected), attributes.Len())
+			if tt.expected != nil {
+				assert.Equal(t, tt.expected, attributes.AsRaw())
+			}
+		})
+	}
+}
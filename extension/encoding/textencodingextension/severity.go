@@ -0,0 +1,84 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension // import "github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding/textencodingextension"
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// defaultSeverityMapping maps the level tokens commonly found in plain-text logs, lowercased, to severity numbers.
+var defaultSeverityMapping = map[string]plog.SeverityNumber{
+	"trace":       plog.SeverityNumberTrace,
+	"debug":       plog.SeverityNumberDebug,
+	"info":        plog.SeverityNumberInfo,
+	"information": plog.SeverityNumberInfo,
+	"notice":      plog.SeverityNumberInfo2,
+	"warn":        plog.SeverityNumberWarn,
+	"warning":     plog.SeverityNumberWarn,
+	"error":       plog.SeverityNumberError,
+	"err":         plog.SeverityNumberError,
+	"critical":    plog.SeverityNumberFatal,
+	"crit":        plog.SeverityNumberFatal,
+	"fatal":       plog.SeverityNumberFatal,
+	"panic":       plog.SeverityNumberFatal,
+	"emergency":   plog.SeverityNumberFatal4,
+}
+
+// severityParser extracts the severity from a decoded log line.
+type severityParser struct {
+	regex *regexp.Regexp
+	// mapping maps lowercased level tokens to severity numbers.
+	mapping map[string]plog.SeverityNumber
+}
+
+// newSeverityParser creates a severityParser applying regex, with the mapping overriding the default one.
+// The mapping maps level tokens, compared case-insensitively, to severity names such as "warn" or "error2".
+func newSeverityParser(regex *regexp.Regexp, mapping map[string]string) (*severityParser, error) {
+	m := make(map[string]plog.SeverityNumber, len(defaultSeverityMapping)+len(mapping))
+	maps.Copy(m, defaultSeverityMapping)
+	for level, name := range mapping {
+		number, err := parseSeverityName(name)
+		if err != nil {
+			return nil, fmt.Errorf("severity_mapping %q: %w", level, err)
+		}
+		m[strings.ToLower(level)] = number
+	}
+	return &severityParser{regex: regex, mapping: m}, nil
+}
+
+// parseSeverityName returns the severity number named name, from "trace" to "fatal4", case-insensitively.
+func parseSeverityName(name string) (plog.SeverityNumber, error) {
+	for number := plog.SeverityNumberTrace; number <= plog.SeverityNumberFatal4; number++ {
+		if strings.EqualFold(number.String(), name) {
+			return number, nil
+		}
+	}
+	return plog.SeverityNumberUnspecified, fmt.Errorf("unknown severity %q", name)
+}
+
+// setSeverity is the post-decode hook setting the severity of a decoded log record from the level matched in the line.
+// The first capture group of the regex, or the whole match if it has none, is the level. Lines that do not match
+// are left without severity, and levels missing from the mapping only set the severity text.
+func (r *textLogCodec) setSeverity(l plog.LogRecord, decoded string) {
+	if r.severityParser == nil {
+		return
+	}
+	match := r.severityParser.regex.FindStringSubmatch(decoded)
+	if match == nil {
+		return
+	}
+	level := match[0]
+	if len(match) > 1 {
+		level = match[1]
+	}
+	l.SetSeverityText(level)
+	if number, ok := r.severityParser.mapping[strings.ToLower(level)]; ok {
+		l.SetSeverityNumber(number)
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/textutils"
+)
+
+func TestSeverity(t *testing.T) {
+	tests := []struct {
+		name           string
+		mapping        map[string]string
+		line           string
+		expectedNumber plog.SeverityNumber
+		expectedText   string
+	}{
+		{
+			name:           "trace",
+			line:           "2024-01-02 TRACE entering loop",
+			expectedNumber: plog.SeverityNumberTrace,
+			expectedText:   "TRACE",
+		},
+		{
+			name:           "debug",
+			line:           "2024-01-02 DEBUG cache miss",
+			expectedNumber: plog.SeverityNumberDebug,
+			expectedText:   "DEBUG",
+		},
+		{
+			name:           "info",
+			line:           "2024-01-02 INFO started",
+			expectedNumber: plog.SeverityNumberInfo,
+			expectedText:   "INFO",
+		},
+		{
+			name:           "warning",
+			line:           "2024-01-02 Warning disk almost full",
+			expectedNumber: plog.SeverityNumberWarn,
+			expectedText:   "Warning",
+		},
+		{
+			name:           "error",
+			line:           "2024-01-02 ERROR connection refused",
+			expectedNumber: plog.SeverityNumberError,
+			expectedText:   "ERROR",
+		},
+		{
+			name:           "fatal",
+			line:           "2024-01-02 FATAL out of memory",
+			expectedNumber: plog.SeverityNumberFatal,
+			expectedText:   "FATAL",
+		},
+		{
+			name:           "custom mapping",
+			mapping:        map[string]string{"E": "error2", "W": "Warn"},
+			line:           "2024-01-02 E connection refused",
+			expectedNumber: plog.SeverityNumberError2,
+			expectedText:   "E",
+		},
+		{
+			name:           "custom mapping overrides built-in",
+			mapping:        map[string]string{"info": "debug4"},
+			line:           "2024-01-02 INFO started",
+			expectedNumber: plog.SeverityNumberDebug4,
+			expectedText:   "INFO",
+		},
+		{
+			name:           "unmapped level",
+			line:           "2024-01-02 VERBOSE started",
+			expectedNumber: plog.SeverityNumberUnspecified,
+			expectedText:   "VERBOSE",
+		},
+		{
+			name:           "no match",
+			line:           "\tat com.example.Main.run(Main.java:10)",
+			expectedNumber: plog.SeverityNumberUnspecified,
+			expectedText:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, err := textutils.LookupEncoding("utf8")
+			require.NoError(t, err)
+			parser, err := newSeverityParser(regexp.MustCompile(`^\S+ ([A-Za-z]+) `), tt.mapping)
+			require.NoError(t, err)
+			codec := &textLogCodec{
+				decoder:               enc.NewDecoder(),
+				unmarshalingSeparator: regexp.MustCompile(`\r?\n`),
+				severityParser:        parser,
+			}
+
+			ld, err := codec.UnmarshalLogs([]byte(tt.line))
+			require.NoError(t, err)
+			require.Equal(t, 1, ld.LogRecordCount())
+			lr := ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+			assert.Equal(t, tt.line, lr.Body().Str())
+			assert.Equal(t, tt.expectedNumber, lr.SeverityNumber())
+			assert.Equal(t, tt.expectedText, lr.SeverityText())
+		})
+	}
+}
+
+func TestSeverityWholeMatch(t *testing.T) {
+	enc, err := textutils.LookupEncoding("utf8")
+	require.NoError(t, err)
+	parser, err := newSeverityParser(regexp.MustCompile(`\b(?:ERROR|WARN)\b`), nil)
+	require.NoError(t, err)
+	codec := &textLogCodec{
+		decoder:               enc.NewDecoder(),
+		unmarshalingSeparator: regexp.MustCompile(`\r?\n`),
+		severityParser:        parser,
+	}
+
+	ld, err := codec.UnmarshalLogs([]byte("first\nsomething WARN happened\nERROR at the end"))
+	require.NoError(t, err)
+	require.Equal(t, 3, ld.LogRecordCount())
+
+	expected := []plog.SeverityNumber{plog.SeverityNumberUnspecified, plog.SeverityNumberWarn, plog.SeverityNumberError}
+	for i, number := range expected {
+		lr := ld.ResourceLogs().At(i).ScopeLogs().At(0).LogRecords().At(0)
+		assert.Equal(t, number, lr.SeverityNumber())
+	}
+}
+
+func TestParseSeverityName(t *testing.T) {
+	number, err := parseSeverityName("Fatal4")
+	require.NoError(t, err)
+	assert.Equal(t, plog.SeverityNumberFatal4, number)
+
+	number, err = parseSeverityName("trace")
+	require.NoError(t, err)
+	assert.Equal(t, plog.SeverityNumberTrace, number)
+
+	_, err = parseSeverityName("unspecified")
+	require.ErrorContains(t, err, `unknown severity "unspecified"`)
+}
@@ -6,28 +6,96 @@ package textencodingextension // import "github.com/open-telemetry/opentelemetry
 import (
 	"bufio"
 	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"io"
 	"regexp"
-	"time"
+	"slices"
+	"strings"
 
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
 	txt "golang.org/x/text/encoding"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
-	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/textutils"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
 )
 
+const (
+	// rawBytesAttribute is the log record attribute holding the base64 encoded original bytes of a lossy decoded record.
+	rawBytesAttribute = "log.raw_bytes"
+
+	// bodyField is the body_field value reading and writing records from and to the log record body.
+	bodyField = "body"
+
+	// defaultMaxLineSize is the default maximum size in bytes of a record split from the stream.
+	defaultMaxLineSize = 10 * 1024 * 1024
+	// separatorSlack is the room left in the scanner buffer for the separator following a record of the maximum size.
+	separatorSlack = 64
+)
+
 type textLogCodec struct {
-	decoder               *txt.Decoder
-	marshalingSeparator   string
+	decoder             *txt.Decoder
+	marshalingSeparator string
+	// marshalingTrailingSeparator also terminates the last marshaled record with marshalingSeparator.
+	marshalingTrailingSeparator bool
+	// linePrefix and lineSuffix wrap each marshaled record.
+	linePrefix            string
+	lineSuffix            string
 	unmarshalingSeparator *regexp.Regexp
+	// keepCarriageReturn keeps the trailing carriage return of records split by unmarshalingSeparator.
+	keepCarriageReturn bool
+	// trimWhitespace removes the leading and trailing whitespace of decoded records.
+	trimWhitespace bool
+	// maxLineSize is the maximum size in bytes of a record split from the stream, defaultMaxLineSize if not positive.
+	maxLineSize int
+	// autoDetect enables charset detection per stream, in which case decoder and encoder are ignored.
+	autoDetect      bool
+	sniffBufferSize int
+	// autoFallback is the charset of auto-detected streams without a byte order mark, detected from their
+	// content when nil.
+	autoFallback *fallbackCharset
+	// decodeErrorHandling defines how invalid bytes of records are handled, decodeErrorStrict if empty.
+	decodeErrorHandling string
+	// preserveRaw attaches the original bytes to records whose decoding does not round-trip through encoder.
+	preserveRaw bool
+	encoder     *txt.Encoder
+	// timestampParser is nil when no event timestamp is parsed from the log line.
+	timestampParser *timestampParser
+	timestampPolicy string
+	// timestampParseErrorAttr records why a matched timestamp failed to parse as an attribute.
+	timestampParseErrorAttr bool
+	// severityParser is nil when no severity is parsed from the log line.
+	severityParser *severityParser
+	// multilineStart is nil when each line is a record, otherwise lines not matching it are appended to the previous record.
+	multilineStart *regexp.Regexp
+	// lineStart splits records at the start of lines matching it, instead of unmarshalingSeparator, when not nil.
+	lineStart *regexp.Regexp
+	// controlPrefix marks control lines adjusting decoding, which are not emitted as records. Empty disables them.
+	controlPrefix string
+	// bodyAttribute is the key of the attribute records are read from and written to, the body if empty.
+	bodyAttribute string
+	// headerAttributes are the resource attributes written to and read from a header line leading the records.
+	// No header is written nor read when empty.
+	headerAttributes []string
+	// compression of the decoded streams, which are decompressed before being scanned.
+	compression string
+	// dedupCountAttribute is the attribute counting the occurrences of identical records collapsed within a batch.
+	// Records are not collapsed when empty.
+	dedupCountAttribute string
+	// schemaSelector is nil when records are not parsed, otherwise it selects their parser from the first line of
+	// the stream.
+	schemaSelector *schemaSelector
+	// recordChecksum is nil when records are not verified, otherwise it verifies and strips their trailing checksum.
+	recordChecksum *recordChecksum
+	// decoderOptions are applied before the options of each decoder, e.g. to report telemetry.
+	decoderOptions []encoding.DecoderOption
 }
 
 func (r *textLogCodec) UnmarshalLogs(buf []byte) (plog.Logs, error) {
-	// Decode as a stream but flush all at once using flush options
-	decoder, err := r.NewLogsDecoder(bytes.NewReader(buf), encoding.WithOffset(0), encoding.WithFlushBytes(0))
+	// Decode as a stream but flush all at once, disabling flushing by byte and item count
+	decoder, err := r.NewLogsDecoder(bytes.NewReader(buf), encoding.WithOffset(0), encoding.WithFlushBytes(0), encoding.WithFlushItems(0))
 	if err != nil {
 		return plog.Logs{}, err
 	}
@@ -37,14 +105,32 @@ func (r *textLogCodec) UnmarshalLogs(buf []byte) (plog.Logs, error) {
 		return plog.Logs{}, err
 	}
 
+	// Control lines may split the buffer into several batches.
+	for r.controlPrefix != "" {
+		batch, err := decoder.DecodeLogs()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return plog.Logs{}, err
+		}
+		batch.ResourceLogs().MoveAndAppendTo(logs.ResourceLogs())
+	}
+
 	return logs, nil
 }
 
 // NewLogsDecoder implements the encoding.LogsCodec interface. Tracks offset by bytes read from the stream.
 func (r *textLogCodec) NewLogsDecoder(reader io.Reader, options ...encoding.DecoderOption) (encoding.LogsDecoder, error) {
-	batchHelper := xstreamencoding.NewBatchHelper(options...)
+	batchHelper := xstreamencoding.NewBatchHelper(append(slices.Clone(r.decoderOptions), options...)...)
 	offsetTracker := batchHelper.Options().Offset
 
+	// Offsets count decompressed bytes, as compressed streams cannot be resumed from an arbitrary position.
+	reader, err := decompress(reader, r.compression)
+	if err != nil {
+		return nil, err
+	}
+
 	// Discard non-zero offset from the reader before scanning for log records
 	if offsetTracker > 0 {
 		if _, err := io.CopyN(io.Discard, reader, offsetTracker); err != nil {
@@ -52,69 +138,232 @@ func (r *textLogCodec) NewLogsDecoder(reader io.Reader, options ...encoding.Deco
 		}
 	}
 
-	s := bufio.NewScanner(reader)
+	decoder, encoder := r.decoder, r.encoder
+	var charset string
+	if r.autoDetect {
+		var enc txt.Encoding
+		reader, charset, enc, err = sniffCharset(reader, r.sniffBufferSize, r.autoFallback)
+		if err != nil {
+			return nil, err
+		}
+		decoder, encoder = enc.NewDecoder(), enc.NewEncoder()
+	}
 
-	const maxLogMessageSize = 10 * 1024 * 1024
-	s.Buffer(make([]byte, 0, 64*1024), maxLogMessageSize+1)
+	maxLineSize := r.maxLineSize
+	if maxLineSize <= 0 {
+		maxLineSize = defaultMaxLineSize
+	}
+	// scan returns the next record token, advancing the offset, or io.EOF at the end of the stream.
+	scan, err := r.newRecordScanner(reader, charset, maxLineSize, &offsetTracker)
+	if err != nil {
+		return nil, err
+	}
 
-	if r.unmarshalingSeparator != nil {
-		s.Split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
-			if atEOF && len(data) == 0 {
-				return 0, nil, nil
-			}
-			if loc := r.unmarshalingSeparator.FindIndex(data); len(loc) > 0 && loc[0] >= 0 {
-				offsetTracker += int64(loc[1])
-				return loc[1], data[0:loc[0]], nil
-			}
-			if atEOF {
-				offsetTracker += int64(len(data))
-				return len(data), data, nil
-			}
-			return 0, nil, nil
-		})
-	} else {
-		s.Split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
-			if atEOF && len(data) == 0 {
-				return 0, nil, nil
-			}
-			if atEOF {
-				offsetTracker += int64(len(data))
-				return len(data), data, nil
-			}
-			return 0, nil, nil // Request more data until EOF
-		})
+	// The attributes header is only read at the start of the stream, decoders resuming from an offset skip it.
+	header := pcommon.NewMap()
+	headerRead := len(r.headerAttributes) == 0 || offsetTracker > 0
+
+	// Likewise the schema line, decoders resuming from an offset parse records with the default profile, if any.
+	var parser recordParser
+	schemaRead := r.schemaSelector == nil || offsetTracker > 0
+	if r.schemaSelector != nil && schemaRead {
+		parser = r.schemaSelector.fallback
 	}
 
+	// Lines buffered into a multiline record are only accounted in the offset once the record is emitted.
+	var multiline multilineRecord
 	offsetF := func() int64 {
+		if multiline.buffered {
+			return multiline.offset
+		}
 		return offsetTracker
 	}
 
-	decodeF := func() (plog.Logs, error) {
+	// sizer estimates the memory of the batch under construction, with encoding.WithMaxBatchMemory.
+	var sizer plog.ProtoMarshaler
+	var dedup *batchDedup
+	if r.dedupCountAttribute != "" {
+		dedup = newBatchDedup(r.dedupCountAttribute)
+	}
+
+	decodeBatch := func() (plog.Logs, error) {
 		p := plog.NewLogs()
-		now := pcommon.NewTimestampFromTime(time.Now())
+		if dedup != nil {
+			dedup.reset()
+		}
 
-		for s.Scan() {
-			l := p.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
-			l.SetObservedTimestamp(now)
+		// checksumErr is why the checksum of the dead-lettered record being emitted does not match, if any.
+		var checksumErr error
 
-			b := s.Bytes()
-			decoded, err := textutils.DecodeAsString(r.decoder, b)
-			if err != nil {
-				return p, err
+		// emit appends a log record to the batch, unless it collapses into an identical one, and reports whether
+		// the batch should be flushed.
+		emit := func(b []byte, decoded string) bool {
+			record := decoded
+			if r.trimWhitespace {
+				record = strings.TrimSpace(decoded)
+			}
+			// Records decoded from different bytes differ by their raw bytes attribute, if any.
+			key := record
+			if r.preserveRaw {
+				key += "\x00" + string(b)
 			}
-			l.Body().SetStr(decoded)
 
+			if dedup == nil || !dedup.duplicate(key) {
+				// Records are observed when decoded rather than when the batch started, which may be long before.
+				now := pcommon.NewTimestampFromTime(timeNow())
+				rl := p.ResourceLogs().AppendEmpty()
+				if header.Len() > 0 {
+					header.CopyTo(rl.Resource().Attributes())
+				}
+				l := rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+				r.setRecord(l, record)
+				r.setTimestamps(l, record, now)
+				r.setSeverity(l, record)
+				if parser != nil {
+					// Records the profile cannot parse are emitted unparsed.
+					parser.parse(record, l.Attributes())
+				}
+				if r.preserveRaw && encoder != nil && isLossy(encoder, b, decoded) {
+					l.Attributes().PutStr(rawBytesAttribute, base64.StdEncoding.EncodeToString(b))
+				}
+				if charset != "" {
+					l.Attributes().PutStr(charsetAttribute, charset)
+				}
+				if checksumErr != nil {
+					l.Attributes().PutStr(checksumErrorAttribute, checksumErr.Error())
+				}
+				if dedup != nil {
+					dedup.track(key, l)
+				}
+				if batchHelper.Options().MaxBatchMemory > 0 {
+					// The decoded record, along with its resource and attributes, may be larger than the bytes read
+					batchHelper.IncrementMemory(int64(sizer.ResourceLogsSize(rl)))
+				}
+			}
+
+			// Collapsed records still count towards the flush thresholds, which bound the input of a batch.
 			batchHelper.IncrementItems(1)
-			batchHelper.IncrementBytes(int64(len(b)))
 
 			if batchHelper.ShouldFlush() {
 				batchHelper.Reset()
-				return p, nil
+				return true
 			}
+			return false
 		}
 
-		if err := s.Err(); err != nil {
-			return p, err
+		// fail reports the records of the batch decoded so far along with the failure of the record at offset.
+		fail := func(offset int64, err error) (plog.Logs, error) {
+			return p, encoding.NewPartialDecodeError(p.LogRecordCount(), offset, err)
+		}
+
+		for {
+			lineOffset := offsetTracker
+			b, err := scan()
+			// All the bytes consumed are read, separators and lines not emitted as records included, as with
+			// xstreamencoding.ScannerHelper.
+			if n := offsetTracker - lineOffset; n > 0 {
+				batchHelper.IncrementBytes(n)
+			}
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				if errors.Is(err, bufio.ErrTooLong) {
+					err = fmt.Errorf("record exceeds max_line_size of %d bytes: %w", maxLineSize, err)
+				}
+				return fail(offsetF(), err)
+			}
+
+			// Records are resumed from the start of the multiline record buffering them, if any.
+			failedOffset := lineOffset
+			if multiline.buffered {
+				failedOffset = multiline.offset
+			}
+
+			if r.recordChecksum != nil {
+				b, checksumErr = r.recordChecksum.verify(b)
+				if checksumErr != nil && !r.recordChecksum.deadLetter {
+					return fail(failedOffset, checksumErr)
+				}
+			}
+			decoded, err := r.decodeRecord(decoder, b)
+			if err != nil {
+				return fail(failedOffset, err)
+			}
+
+			// Dead-lettered records are emitted on their own, as they cannot be trusted to be header, schema,
+			// control or continuation lines.
+			if checksumErr != nil {
+				flush := emit(b, decoded)
+				checksumErr = nil
+				if flush {
+					return p, nil
+				}
+				continue
+			}
+
+			if !headerRead {
+				header, err = parseAttributesHeader(decoded)
+				if err != nil {
+					return fail(failedOffset, err)
+				}
+				headerRead = true
+				continue
+			}
+
+			if !schemaRead {
+				parser, err = r.schemaSelector.selectParser(decoded)
+				if err != nil {
+					return fail(failedOffset, err)
+				}
+				schemaRead = true
+				continue
+			}
+
+			if r.controlPrefix != "" && strings.HasPrefix(decoded, r.controlPrefix) {
+				directive, err := parseControl(decoded[len(r.controlPrefix):])
+				if err != nil {
+					return fail(failedOffset, err)
+				}
+				batchHelper.UpdateOptions(directive.options...)
+				if !directive.flush {
+					continue
+				}
+				if multiline.buffered {
+					emit(multiline.take())
+				}
+				if p.LogRecordCount() > 0 {
+					batchHelper.Reset()
+					return p, nil
+				}
+				continue
+			}
+
+			if r.multilineStart == nil {
+				if emit(b, decoded) {
+					return p, nil
+				}
+				continue
+			}
+
+			if multiline.buffered && !r.multilineStart.MatchString(decoded) {
+				multiline.append(b, decoded)
+				continue
+			}
+			if multiline.buffered {
+				flush := emit(multiline.take())
+				multiline.start(b, decoded, lineOffset)
+				if flush {
+					return p, nil
+				}
+				continue
+			}
+			multiline.start(b, decoded, lineOffset)
+		}
+
+		// flush the last buffered multiline record at EOF
+		if multiline.buffered {
+			emit(multiline.take())
 		}
 
 		// check for stream EOF which results in empty log batch
@@ -122,14 +371,158 @@ func (r *textLogCodec) NewLogsDecoder(reader io.Reader, options ...encoding.Deco
 			return p, io.EOF
 		}
 
+		// the end of the stream flushes the last batch
+		batchHelper.Reset()
 		return p, nil
 	}
 
-	return xstreamencoding.NewLogsDecoderAdapter(decodeF, offsetF), nil
+	// decodeF finalizes each batch, including partial ones returned along with a decoding error.
+	decodeF := func() (plog.Logs, error) {
+		p, err := decodeBatch()
+		batchHelper.SetLogsBatchID(p)
+		return p, err
+	}
+
+	// Offsets count bytes of the stream, once decompressed.
+	return xstreamencoding.NewLogsDecoderAdapterWithOptions(decodeF, offsetF,
+		xstreamencoding.WithStatsFunc(batchHelper.Stats),
+		xstreamencoding.WithOffsetSemantics(encoding.OffsetSemanticsBytes),
+		xstreamencoding.WithLogsMiddlewares(xstreamencoding.Middlewares(batchHelper.Options())...),
+	), nil
+}
+
+// newRecordScanner returns a function scanning the record tokens of reader, no larger than maxLineSize, and
+// advancing offset past each of them along with its separator. It returns io.EOF at the end of the stream.
+// Records split by unmarshalingSeparator are scanned by xstreamencoding.RegexpScannerHelper, unless the detected
+// charset is UTF-16, whose records are split by splitUTF16, the others by a bufio.Scanner.
+func (r *textLogCodec) newRecordScanner(reader io.Reader, charset string, maxLineSize int, offset *int64) (func() ([]byte, error), error) {
+	order := utf16ByteOrder(charset)
+	if r.lineStart == nil && r.unmarshalingSeparator != nil && order == nil {
+		// Records are scanned from the current offset, the reader being already positioned at it.
+		helper, err := xstreamencoding.NewRegexpScannerHelper(reader, r.unmarshalingSeparator,
+			encoding.WithMaxRecordSize(maxLineSize),
+			encoding.WithReaderBufferSize(min(64*1024, maxLineSize)),
+		)
+		if err != nil {
+			return nil, err
+		}
+		start := *offset
+		var atEOF bool
+		return func() ([]byte, error) {
+			if atEOF {
+				return nil, io.EOF
+			}
+			token, _, err := helper.ScanBytes()
+			*offset = start + helper.Offset()
+			if errors.Is(err, io.EOF) {
+				// The last record is returned along with io.EOF, unless the stream ends with a separator.
+				atEOF = true
+				if token == nil {
+					return nil, io.EOF
+				}
+				err = nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			return r.trimCarriageReturn(token), nil
+		}, nil
+	}
+
+	s := bufio.NewScanner(reader)
+	s.Buffer(make([]byte, 0, min(64*1024, maxLineSize)), maxLineSize+separatorSlack)
+
+	// split returns the record token, advancing the offset unless it exceeds maxLineSize.
+	split := func(advance int, token []byte) (int, []byte, error) {
+		if len(token) > maxLineSize {
+			return 0, nil, bufio.ErrTooLong
+		}
+		*offset += int64(advance)
+		return advance, token, nil
+	}
+
+	switch {
+	case r.lineStart != nil:
+		s.Split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+			if atEOF && len(data) == 0 {
+				return 0, nil, nil
+			}
+			advance, record, more := splitLineStart(r.lineStart, data, atEOF)
+			if more {
+				return 0, nil, nil
+			}
+			return split(advance, r.trimCarriageReturn(record))
+		})
+	case r.unmarshalingSeparator != nil:
+		// Separators are matched in the decoded text, as UTF-16 does not encode them as their UTF-8 bytes.
+		carriageReturn := make([]byte, 2)
+		order.PutUint16(carriageReturn, '\r')
+		s.Split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+			advance, record, err := splitUTF16(r.unmarshalingSeparator, order, data, atEOF)
+			if advance == 0 || err != nil {
+				return advance, record, err
+			}
+			if !r.keepCarriageReturn {
+				record = bytes.TrimSuffix(record, carriageReturn)
+			}
+			return split(advance, record)
+		})
+	default:
+		s.Split(func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+			if atEOF && len(data) == 0 {
+				return 0, nil, nil
+			}
+			if atEOF {
+				return split(len(data), data)
+			}
+			return 0, nil, nil // Request more data until EOF
+		})
+	}
+
+	return func() ([]byte, error) {
+		if s.Scan() {
+			return s.Bytes(), nil
+		}
+		if err := s.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}, nil
+}
+
+// trimCarriageReturn removes the trailing carriage return left on a token, e.g. by a "\n" separator splitting
+// "\r\n" delimited lines, unless keepCarriageReturn is set.
+func (r *textLogCodec) trimCarriageReturn(token []byte) []byte {
+	if r.keepCarriageReturn {
+		return token
+	}
+	return bytes.TrimSuffix(token, []byte{'\r'})
+}
+
+// isLossy reports whether decoded does not encode back to the raw bytes it was decoded from.
+// Byte order marks are ignored since they are stripped by decoders and may be added by encoders.
+func isLossy(encoder *txt.Encoder, raw []byte, decoded string) bool {
+	reencoded, err := encoder.String(decoded)
+	if err != nil {
+		return true
+	}
+	return !bytes.Equal(trimBOM([]byte(reencoded)), trimBOM(raw))
 }
 
 func (r *textLogCodec) MarshalLogs(ld plog.Logs) ([]byte, error) {
-	var b []byte
+	return r.MarshalLogsWithOptions(ld)
+}
+
+// MarshalLogsWithOptions implements encoding.LogsOptionsMarshaler, marshaling ld as MarshalLogs does, with the
+// separator and trailing separator of options when set, and failing with encoding.ErrPayloadTooLarge when the
+// payload exceeds options.MaxPayloadBytes.
+func (r *textLogCodec) MarshalLogsWithOptions(ld plog.Logs, options ...encoding.EncoderOption) ([]byte, error) {
+	encoderOptions := encoding.NewEncoderOptions(options...)
+	separator := r.recordSeparator(encoderOptions)
+	b, err := r.appendAttributesHeader(nil, ld, separator)
+	if err != nil {
+		return nil, err
+	}
 	appendedLogRecord := false
 
 	for i := 0; i < ld.ResourceLogs().Len(); i++ {
@@ -137,14 +530,111 @@ func (r *textLogCodec) MarshalLogs(ld plog.Logs) ([]byte, error) {
 		for j := 0; j < rl.ScopeLogs().Len(); j++ {
 			sl := rl.ScopeLogs().At(j)
 			for k := 0; k < sl.LogRecords().Len(); k++ {
-				lr := sl.LogRecords().At(k)
-				if appendedLogRecord {
-					b = append(b, []byte(r.marshalingSeparator)...)
-				}
-				b = append(b, []byte(lr.Body().AsString())...)
+				b = r.appendRecord(b, sl.LogRecords().At(k), appendedLogRecord, separator)
 				appendedLogRecord = true
 			}
 		}
 	}
+	if maxBytes := encoderOptions.MaxPayloadBytes; maxBytes > 0 && len(b) > maxBytes {
+		return nil, fmt.Errorf("%w: %d bytes, max %d", encoding.ErrPayloadTooLarge, len(b), maxBytes)
+	}
 	return b, nil
 }
+
+// recordSeparator delimits marshaled records.
+type recordSeparator struct {
+	separator string
+	// trailing also terminates the last record with separator.
+	trailing bool
+}
+
+// recordSeparator returns the separator of options when set, marshalingSeparator and marshalingTrailingSeparator
+// otherwise.
+func (r *textLogCodec) recordSeparator(options encoding.EncoderOptions) recordSeparator {
+	separator := recordSeparator{separator: r.marshalingSeparator, trailing: r.marshalingTrailingSeparator}
+	if options.RecordSeparatorSet {
+		separator.separator = options.RecordSeparator
+	}
+	if options.TrailingSeparatorSet {
+		separator.trailing = options.AppendTrailingSeparator
+	}
+	return separator
+}
+
+// LogsSize returns the size in bytes of ld once marshaled: the length of its wrapped records, plus their separators.
+func (r *textLogCodec) LogsSize(ld plog.Logs) int {
+	size := 0
+	records := 0
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		rl := ld.ResourceLogs().At(i)
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			sl := rl.ScopeLogs().At(j)
+			for k := 0; k < sl.LogRecords().Len(); k++ {
+				size += len(r.linePrefix) + len(r.record(sl.LogRecords().At(k))) + len(r.lineSuffix)
+				records++
+			}
+		}
+	}
+	separators := records - 1
+	if r.marshalingTrailingSeparator {
+		separators = records
+	}
+	if separators > 0 {
+		size += separators * len(r.marshalingSeparator)
+	}
+	if len(r.headerAttributes) > 0 && records > 0 {
+		header, _, _ := r.attributesHeader(ld)
+		size += len(header) + len(r.marshalingSeparator)
+	}
+	return size
+}
+
+// appendAttributesHeader appends the attributes header of ld to b, followed by the separator, when headerAttributes
+// is set and ld has log records.
+func (r *textLogCodec) appendAttributesHeader(b []byte, ld plog.Logs, separator recordSeparator) ([]byte, error) {
+	if len(r.headerAttributes) == 0 {
+		return b, nil
+	}
+	header, ok, err := r.attributesHeader(ld)
+	if err != nil || !ok {
+		return b, err
+	}
+	b = append(b, header...)
+	return append(b, separator.separator...), nil
+}
+
+// appendRecord appends the record of lr to b, wrapped by linePrefix and lineSuffix and delimited from the records
+// appended before it, if any.
+func (r *textLogCodec) appendRecord(b []byte, lr plog.LogRecord, appendedLogRecord bool, separator recordSeparator) []byte {
+	if appendedLogRecord && !separator.trailing {
+		b = append(b, separator.separator...)
+	}
+	b = append(b, r.linePrefix...)
+	b = append(b, r.record(lr)...)
+	b = append(b, r.lineSuffix...)
+	if separator.trailing {
+		b = append(b, separator.separator...)
+	}
+	return b
+}
+
+// setRecord sets decoded as the body of l, or as its bodyAttribute attribute if set.
+func (r *textLogCodec) setRecord(l plog.LogRecord, decoded string) {
+	if r.bodyAttribute == "" {
+		l.Body().SetStr(decoded)
+		return
+	}
+	l.Attributes().PutStr(r.bodyAttribute, decoded)
+}
+
+// record returns the body of lr as a string, or its bodyAttribute attribute if set. Records without
+// the attribute are empty.
+func (r *textLogCodec) record(lr plog.LogRecord) string {
+	if r.bodyAttribute == "" {
+		return lr.Body().AsString()
+	}
+	if v, ok := lr.Attributes().Get(r.bodyAttribute); ok {
+		return v.AsString()
+	}
+	return ""
+}
@@ -4,16 +4,26 @@
 package textencodingextension
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/base64"
+	"errors"
 	"io"
 	"regexp"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	txt "golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/textutils"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
 )
 
 func TestTextRoundtrip(t *testing.T) {
@@ -44,6 +54,346 @@ func TestTextRoundtripMissingNewline(t *testing.T) {
 	require.Equal(t, "foo\nbar", string(b))
 }
 
+func TestUnmarshalLogsSingleBatch(t *testing.T) {
+	enc, err := textutils.LookupEncoding("utf8")
+	require.NoError(t, err)
+	r := regexp.MustCompile(`\r?\n`)
+	codec := &textLogCodec{decoder: enc.NewDecoder(), unmarshalingSeparator: r, marshalingSeparator: "\n"}
+
+	// More lines than the default number of items after which decoders flush
+	input := strings.Repeat("line\n", 2500)
+	ld, err := codec.UnmarshalLogs([]byte(input))
+	require.NoError(t, err)
+	assert.Equal(t, 2500, ld.LogRecordCount())
+}
+
+func TestDecoderOffsetSemantics(t *testing.T) {
+	enc, err := textutils.LookupEncoding("utf8")
+	require.NoError(t, err)
+	codec := &textLogCodec{decoder: enc.NewDecoder(), unmarshalingSeparator: regexp.MustCompile(`\r?\n`)}
+
+	decoder, err := codec.NewLogsDecoder(strings.NewReader("foo\nbar\n"))
+	require.NoError(t, err)
+	assert.Equal(t, encoding.OffsetSemanticsBytes, decoder.(encoding.OffsetAware).OffsetSemantics())
+	assert.True(t, encoding.CanRestoreOffset(decoder, encoding.OffsetSemanticsBytes))
+	assert.False(t, encoding.CanRestoreOffset(decoder, encoding.OffsetSemanticsRecords))
+}
+
+func TestDecoderStats(t *testing.T) {
+	enc, err := textutils.LookupEncoding("utf8")
+	require.NoError(t, err)
+	r := regexp.MustCompile(`\r?\n`)
+	codec := &textLogCodec{decoder: enc.NewDecoder(), unmarshalingSeparator: r, marshalingSeparator: "\n"}
+
+	decoder, err := codec.NewLogsDecoder(strings.NewReader("foo\nbar\nbaz\n"), encoding.WithFlushItems(2))
+	require.NoError(t, err)
+	reporter, ok := encoding.DecoderAs[encoding.StatsReporter](decoder)
+	require.True(t, ok)
+
+	for {
+		_, err = decoder.DecodeLogs()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	stats := reporter.Stats()
+	assert.Equal(t, int64(12), stats.BytesRead)
+	assert.Equal(t, int64(3), stats.RecordsDecoded)
+	assert.Equal(t, int64(2), stats.BatchesFlushed)
+	assert.Zero(t, stats.RecordsSkipped)
+	assert.False(t, stats.LastFlushTime.IsZero())
+}
+
+func TestBodyField(t *testing.T) {
+	enc, err := textutils.LookupEncoding("utf8")
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		name          string
+		bodyAttribute string
+	}{
+		{name: "body"},
+		{name: "attribute", bodyAttribute: "log.raw"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			codec := &textLogCodec{
+				decoder:               enc.NewDecoder(),
+				unmarshalingSeparator: regexp.MustCompile(`\r?\n`),
+				marshalingSeparator:   "\n",
+				bodyAttribute:         tt.bodyAttribute,
+			}
+			ld, err := codec.UnmarshalLogs([]byte("foo\nbar\n"))
+			require.NoError(t, err)
+			require.Equal(t, 2, ld.LogRecordCount())
+
+			for i, expected := range []string{"foo", "bar"} {
+				lr := ld.ResourceLogs().At(i).ScopeLogs().At(0).LogRecords().At(0)
+				if tt.bodyAttribute == "" {
+					assert.Equal(t, expected, lr.Body().Str())
+					assert.Equal(t, 0, lr.Attributes().Len())
+					continue
+				}
+				assert.Equal(t, pcommon.ValueTypeEmpty, lr.Body().Type())
+				v, ok := lr.Attributes().Get(tt.bodyAttribute)
+				require.True(t, ok)
+				assert.Equal(t, expected, v.Str())
+			}
+
+			b, err := codec.MarshalLogs(ld)
+			require.NoError(t, err)
+			require.Equal(t, "foo\nbar", string(b))
+		})
+	}
+
+	// Records without the attribute are marshaled as empty records, regardless of their body
+	codec := &textLogCodec{marshalingSeparator: "\n", bodyAttribute: "log.raw"}
+	ld := plog.NewLogs()
+	lrs := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	lrs.AppendEmpty().Attributes().PutStr("log.raw", "foo")
+	lrs.AppendEmpty().Body().SetStr("ignored")
+	lrs.AppendEmpty().Attributes().PutInt("log.raw", 42)
+	b, err := codec.MarshalLogs(ld)
+	require.NoError(t, err)
+	require.Equal(t, "foo\n\n42", string(b))
+	require.Equal(t, len(b), codec.LogsSize(ld))
+}
+
+func TestLogsSize_split(t *testing.T) {
+	codec := &textLogCodec{marshalingSeparator: "\r\n"}
+	ld := plog.NewLogs()
+	lrs := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	for _, body := range []string{"first", "second", "third", "fourth"} {
+		lrs.AppendEmpty().Body().SetStr(body)
+	}
+	require.Equal(t, 28, codec.LogsSize(ld))
+
+	// Separators are accounted in the size of the chunks
+	chunks, report := xstreamencoding.SplitLogsBySize(ld, 12, codec)
+	require.Empty(t, report.Oversized)
+	require.Len(t, chunks, 4)
+	for _, chunk := range chunks {
+		b, err := codec.MarshalLogs(chunk)
+		require.NoError(t, err)
+		require.LessOrEqual(t, len(b), 12)
+	}
+
+	chunks, _ = xstreamencoding.SplitLogsBySize(ld, 13, codec)
+	require.Len(t, chunks, 2)
+	b, err := codec.MarshalLogs(chunks[0])
+	require.NoError(t, err)
+	require.Equal(t, "first\r\nsecond", string(b))
+}
+
+func TestCarriageReturn(t *testing.T) {
+	enc, err := textutils.LookupEncoding("utf8")
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		name      string
+		separator string
+		keep      bool
+		expected  []string
+	}{
+		{name: "newline separator", separator: `\n`, expected: []string{"foo", "bar", "baz", "qux"}},
+		{name: "default separator", separator: `\r?\n`, expected: []string{"foo", "bar", "baz", "qux"}},
+		{name: "keep carriage return", separator: `\n`, keep: true, expected: []string{"foo\r", "bar", "baz\r", "qux\r"}},
+		{name: "keep carriage return with default separator", separator: `\r?\n`, keep: true, expected: []string{"foo", "bar", "baz", "qux\r"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			codec := &textLogCodec{
+				decoder:               enc.NewDecoder(),
+				unmarshalingSeparator: regexp.MustCompile(tt.separator),
+				marshalingSeparator:   "\n",
+				keepCarriageReturn:    tt.keep,
+			}
+			input := "foo\r\nbar\nbaz\r\nqux\r"
+
+			ld, err := codec.UnmarshalLogs([]byte(input))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, bodies(ld))
+
+			// Offsets account for the removed carriage returns
+			decoder, err := codec.NewLogsDecoder(bytes.NewReader([]byte(input)))
+			require.NoError(t, err)
+			_, err = decoder.DecodeLogs()
+			require.NoError(t, err)
+			assert.Equal(t, int64(len(input)), decoder.Offset())
+		})
+	}
+}
+
+func TestTrimWhitespace(t *testing.T) {
+	enc, err := textutils.LookupEncoding("utf8")
+	require.NoError(t, err)
+
+	for _, tt := range []struct {
+		name      string
+		separator string
+		multiline string
+		input     string
+		trim      bool
+		expected  []string
+	}{
+		{name: "untrimmed", separator: `\r?\n`, expected: []string{"  foo\t", "bar ", "", "\tbaz"}},
+		{name: "trimmed", separator: `\r?\n`, trim: true, expected: []string{"foo", "bar", "", "baz"}},
+		{name: "trimmed with keep carriage return", separator: `\n`, trim: true, expected: []string{"foo", "bar", "", "baz"}},
+		{name: "untrimmed separator regex", separator: `\s*\n\s*`, expected: []string{"  foo", "bar", "baz"}},
+		{name: "trimmed multiline", separator: `\r?\n`, multiline: `^\S`, input: "error \n\tat frame\n", trim: true, expected: []string{"error \n\tat frame"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			codec := &textLogCodec{
+				decoder:               enc.NewDecoder(),
+				unmarshalingSeparator: regexp.MustCompile(tt.separator),
+				keepCarriageReturn:    true,
+				trimWhitespace:        tt.trim,
+			}
+			if tt.multiline != "" {
+				codec.multilineStart = regexp.MustCompile(tt.multiline)
+			}
+
+			input := tt.input
+			if input == "" {
+				input = "  foo\t\r\nbar \n\n\tbaz"
+			}
+			ld, err := codec.UnmarshalLogs([]byte(input))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, bodies(ld))
+		})
+	}
+}
+
+func TestUnmarshalSeparatorAcrossReads(t *testing.T) {
+	enc, err := textutils.LookupEncoding("utf8")
+	require.NoError(t, err)
+	codec := &textLogCodec{
+		decoder:               enc.NewDecoder(),
+		unmarshalingSeparator: regexp.MustCompile(`\n\s*\n`),
+	}
+	input := "first\nrecord\n \n\nsecond\nrecord\n\n"
+
+	// Reading a byte at a time makes the separators straddle the reads of the scanner, which must not split
+	// them into a record separator followed by an empty record.
+	decoder, err := codec.NewLogsDecoder(iotest.OneByteReader(bytes.NewReader([]byte(input))))
+	require.NoError(t, err)
+	ld, err := decoder.DecodeLogs()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first\nrecord", "second\nrecord"}, bodies(ld))
+	assert.Equal(t, int64(len(input)), decoder.Offset())
+
+	_, err = decoder.DecodeLogs()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestMarshalTrailingSeparator(t *testing.T) {
+	enc, err := textutils.LookupEncoding("utf8")
+	require.NoError(t, err)
+	r := regexp.MustCompile(`\r?\n`)
+
+	for _, tt := range []struct {
+		name     string
+		trailing bool
+		prefix   string
+		suffix   string
+		input    string
+		expected string
+	}{
+		{name: "two records", input: "foo\nbar\n", expected: "foo\nbar"},
+		{name: "single record", input: "foo\n", expected: "foo"},
+		{name: "two records with trailing separator", trailing: true, input: "foo\nbar\n", expected: "foo\nbar\n"},
+		{name: "single record with trailing separator", trailing: true, input: "foo\n", expected: "foo\n"},
+		{name: "prefix", prefix: "> ", input: "foo\nbar\n", expected: "> foo\n> bar"},
+		{name: "suffix", suffix: ";", input: "foo\nbar\n", expected: "foo;\nbar;"},
+		{name: "prefix and suffix", prefix: "[", suffix: "]", input: "foo\nbar\n", expected: "[foo]\n[bar]"},
+		{name: "prefix and suffix with trailing separator", trailing: true, prefix: "[", suffix: "]", input: "foo\nbar\n", expected: "[foo]\n[bar]\n"},
+		{name: "empty record with prefix and suffix", prefix: "[", suffix: "]", input: "foo\n\n", expected: "[foo]\n[]"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			codec := &textLogCodec{
+				decoder:                     enc.NewDecoder(),
+				unmarshalingSeparator:       r,
+				marshalingSeparator:         "\n",
+				marshalingTrailingSeparator: tt.trailing,
+				linePrefix:                  tt.prefix,
+				lineSuffix:                  tt.suffix,
+			}
+			ld, err := codec.UnmarshalLogs([]byte(tt.input))
+			require.NoError(t, err)
+			b, err := codec.MarshalLogs(ld)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(b))
+			require.Equal(t, len(b), codec.LogsSize(ld))
+		})
+	}
+
+	t.Run("no record with trailing separator", func(t *testing.T) {
+		codec := &textLogCodec{marshalingSeparator: "\n", marshalingTrailingSeparator: true, linePrefix: "[", lineSuffix: "]"}
+		b, err := codec.MarshalLogs(plog.NewLogs())
+		require.NoError(t, err)
+		require.Empty(t, b)
+		require.Zero(t, codec.LogsSize(plog.NewLogs()))
+	})
+}
+
+func TestMarshalLogsWithOptions(t *testing.T) {
+	codec := &textLogCodec{marshalingSeparator: "\n"}
+	ld := plog.NewLogs()
+	records := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	records.AppendEmpty().Body().SetStr("foo")
+	records.AppendEmpty().Body().SetStr("bar")
+
+	tests := []struct {
+		name     string
+		options  []encoding.EncoderOption
+		expected string
+	}{
+		{
+			name:     "defaults of the codec",
+			expected: "foo\nbar",
+		},
+		{
+			name:     "separator",
+			options:  []encoding.EncoderOption{encoding.WithRecordSeparator("\r\n")},
+			expected: "foo\r\nbar",
+		},
+		{
+			name:     "trailing separator",
+			options:  []encoding.EncoderOption{encoding.WithRecordSeparator(";"), encoding.WithTrailingSeparator(true)},
+			expected: "foo;bar;",
+		},
+		{
+			name:     "empty separator",
+			options:  []encoding.EncoderOption{encoding.WithRecordSeparator("")},
+			expected: "foobar",
+		},
+		{
+			name:     "payload within maximum",
+			options:  []encoding.EncoderOption{encoding.WithMaxPayloadBytes(7)},
+			expected: "foo\nbar",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := codec.MarshalLogsWithOptions(ld, tt.options...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(b))
+		})
+	}
+
+	t.Run("payload too large", func(t *testing.T) {
+		_, err := codec.MarshalLogsWithOptions(ld, encoding.WithMaxPayloadBytes(6))
+		require.ErrorIs(t, err, encoding.ErrPayloadTooLarge)
+	})
+
+	t.Run("trailing separator disabled", func(t *testing.T) {
+		trailing := &textLogCodec{marshalingSeparator: "\n", marshalingTrailingSeparator: true}
+		b, err := trailing.MarshalLogsWithOptions(ld, encoding.WithTrailingSeparator(false))
+		require.NoError(t, err)
+		assert.Equal(t, "foo\nbar", string(b))
+	})
+}
+
 func TestNoSeparator(t *testing.T) {
 	enc, err := textutils.LookupEncoding("utf8")
 	require.NoError(t, err)
@@ -80,6 +430,86 @@ func TestNoSeparatorLargeMessage(t *testing.T) {
 	require.Equal(t, largeMessage, b)
 }
 
+func TestMaxLineSize(t *testing.T) {
+	enc, err := textutils.LookupEncoding("utf8")
+	require.NoError(t, err)
+	const maxLineSize = 100
+	atLimit := strings.Repeat("a", maxLineSize)
+	overLimit := strings.Repeat("b", maxLineSize+1)
+
+	tests := []struct {
+		name      string
+		separator *regexp.Regexp
+		input     string
+		expected  []string
+		tooLong   bool
+		// offset is the offset of the record exceeding the limit
+		offset int64
+	}{
+		{
+			name:      "line at the limit",
+			separator: regexp.MustCompile(`\r?\n`),
+			input:     "foo\n" + atLimit + "\r\nbar\n",
+			expected:  []string{"foo", atLimit, "bar"},
+		},
+		{
+			name:      "last line at the limit",
+			separator: regexp.MustCompile(`\r?\n`),
+			input:     "foo\n" + atLimit,
+			expected:  []string{"foo", atLimit},
+		},
+		{
+			name:      "line over the limit",
+			separator: regexp.MustCompile(`\r?\n`),
+			input:     "foo\n" + overLimit + "\nbar\n",
+			expected:  []string{"foo"},
+			tooLong:   true,
+			offset:    4,
+		},
+		{
+			name:      "last line over the limit",
+			separator: regexp.MustCompile(`\r?\n`),
+			input:     "foo\n" + overLimit,
+			expected:  []string{"foo"},
+			tooLong:   true,
+			offset:    4,
+		},
+		{
+			name:     "no separator at the limit",
+			input:    atLimit,
+			expected: []string{atLimit},
+		},
+		{
+			name:    "no separator over the limit",
+			input:   overLimit,
+			tooLong: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec := &textLogCodec{decoder: enc.NewDecoder(), unmarshalingSeparator: tt.separator, maxLineSize: maxLineSize}
+			decoder, err := codec.NewLogsDecoder(strings.NewReader(tt.input), encoding.WithFlushItems(0), encoding.WithFlushBytes(0))
+			require.NoError(t, err)
+
+			ld, err := decoder.DecodeLogs()
+			var bodies []string
+			for i := 0; i < ld.ResourceLogs().Len(); i++ {
+				bodies = append(bodies, ld.ResourceLogs().At(i).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+			}
+			assert.Equal(t, tt.expected, bodies)
+			if !tt.tooLong {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, bufio.ErrTooLong)
+			assert.ErrorContains(t, err, "record exceeds max_line_size of 100 bytes")
+			var partialErr *encoding.PartialDecodeError
+			require.ErrorAs(t, err, &partialErr)
+			assert.Equal(t, tt.offset, partialErr.Offset)
+		})
+	}
+}
+
 func TestStreamDecoding_singleFlush(t *testing.T) {
 	enc, err := textutils.LookupEncoding("utf8")
 	require.NoError(t, err)
@@ -176,3 +606,214 @@ func TestStreamDecoding_flushAll(t *testing.T) {
 	assert.ErrorIs(t, err, io.EOF)
 	assert.Equal(t, 0, ld.LogRecordCount())
 }
+
+func TestStreamDecoding_batchIDAttribute(t *testing.T) {
+	enc, err := textutils.LookupEncoding("utf8")
+	require.NoError(t, err)
+	r := regexp.MustCompile(`\r?\n`)
+	codec := &textLogCodec{decoder: enc.NewDecoder(), unmarshalingSeparator: r, marshalingSeparator: "\n"}
+
+	reader := bytes.NewReader([]byte("foo\nbar\nbaz\nqux\nquux\n"))
+	decoder, err := codec.NewLogsDecoder(reader, encoding.WithFlushItems(2), encoding.WithBatchIDAttribute("batch.id"))
+	require.NoError(t, err)
+
+	// Each record is in its own resource, all stamped with the id of their batch
+	var batches [][]int64
+	for {
+		ld, err := decoder.DecodeLogs()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		var ids []int64
+		for i := 0; i < ld.ResourceLogs().Len(); i++ {
+			id, ok := ld.ResourceLogs().At(i).Resource().Attributes().Get("batch.id")
+			require.True(t, ok)
+			ids = append(ids, id.Int())
+		}
+		batches = append(batches, ids)
+	}
+	assert.Equal(t, [][]int64{{1, 1}, {2, 2}, {3}}, batches)
+}
+
+// errInvalidByte is returned by invalidByteTransformer on 0xff bytes.
+var errInvalidByte = errors.New("invalid byte")
+
+// invalidByteTransformer copies its input, failing on 0xff bytes.
+type invalidByteTransformer struct {
+	transform.NopResetter
+}
+
+func (invalidByteTransformer) Transform(dst, src []byte, _ bool) (nDst, nSrc int, err error) {
+	if bytes.IndexByte(src, 0xff) >= 0 {
+		return 0, 0, errInvalidByte
+	}
+	if len(dst) < len(src) {
+		return 0, 0, transform.ErrShortDst
+	}
+	return copy(dst, src), len(src), nil
+}
+
+func TestStreamDecoding_partialFailure(t *testing.T) {
+	codec := &textLogCodec{
+		decoder:               &txt.Decoder{Transformer: invalidByteTransformer{}},
+		unmarshalingSeparator: regexp.MustCompile(`\r?\n`),
+		marshalingSeparator:   "\n",
+	}
+	input := []byte("foo\nbar\n\xffbaz\nqux\n")
+
+	decoder, err := codec.NewLogsDecoder(bytes.NewReader(input), encoding.WithFlushItems(0), encoding.WithFlushBytes(0))
+	require.NoError(t, err)
+
+	ld, err := decoder.DecodeLogs()
+	var partialErr *encoding.PartialDecodeError
+	require.ErrorAs(t, err, &partialErr)
+	require.ErrorIs(t, err, errInvalidByte)
+	assert.Equal(t, 2, partialErr.Decoded)
+	assert.Equal(t, int64(8), partialErr.Offset)
+	require.Equal(t, 2, ld.LogRecordCount())
+	assert.Equal(t, "foo", ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().AsString())
+	assert.Equal(t, "bar", ld.ResourceLogs().At(1).ScopeLogs().At(0).LogRecords().At(0).Body().AsString())
+
+	t.Run("resume after failed record", func(t *testing.T) {
+		// Skip the failed record, which ends at the current offset of the decoder
+		decoder, err := codec.NewLogsDecoder(bytes.NewReader(input), encoding.WithOffset(decoder.Offset()))
+		require.NoError(t, err)
+
+		ld, err := decoder.DecodeLogs()
+		require.NoError(t, err)
+		require.Equal(t, 1, ld.LogRecordCount())
+		assert.Equal(t, "qux", ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().AsString())
+
+		_, err = decoder.DecodeLogs()
+		assert.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("resume at failed record", func(t *testing.T) {
+		decoder, err := codec.NewLogsDecoder(bytes.NewReader(input), encoding.WithOffset(partialErr.Offset))
+		require.NoError(t, err)
+
+		ld, err := decoder.DecodeLogs()
+		require.ErrorAs(t, err, &partialErr)
+		assert.Equal(t, 0, partialErr.Decoded)
+		assert.Equal(t, int64(8), partialErr.Offset)
+		assert.Equal(t, 0, ld.LogRecordCount())
+	})
+
+	t.Run("multiline record", func(t *testing.T) {
+		codec := &textLogCodec{
+			decoder:               &txt.Decoder{Transformer: invalidByteTransformer{}},
+			unmarshalingSeparator: regexp.MustCompile(`\n`),
+			multilineStart:        regexp.MustCompile(`^\S`),
+		}
+		decoder, err := codec.NewLogsDecoder(bytes.NewReader([]byte("foo\nbar\n \xff\nbaz\n")))
+		require.NoError(t, err)
+
+		ld, err := decoder.DecodeLogs()
+		require.ErrorAs(t, err, &partialErr)
+		assert.Equal(t, 1, partialErr.Decoded)
+		// The failure is reported at the start of the multiline record it belongs to
+		assert.Equal(t, int64(4), partialErr.Offset)
+		assert.Equal(t, 1, ld.LogRecordCount())
+	})
+}
+
+func TestPreserveRaw(t *testing.T) {
+	enc, err := textutils.LookupEncoding("utf8")
+	require.NoError(t, err)
+	r := regexp.MustCompile(`\r?\n`)
+	input := []byte("valid\nin\xffvalid\n")
+
+	t.Run("enabled", func(t *testing.T) {
+		codec := &textLogCodec{
+			decoder:               enc.NewDecoder(),
+			encoder:               enc.NewEncoder(),
+			unmarshalingSeparator: r,
+			preserveRaw:           true,
+		}
+		ld, err := codec.UnmarshalLogs(input)
+		require.NoError(t, err)
+		require.Equal(t, 2, ld.LogRecordCount())
+
+		valid := ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+		assert.Equal(t, "valid", valid.Body().Str())
+		_, ok := valid.Attributes().Get(rawBytesAttribute)
+		assert.False(t, ok)
+
+		lossy := ld.ResourceLogs().At(1).ScopeLogs().At(0).LogRecords().At(0)
+		assert.Equal(t, "in\uFFFDvalid", lossy.Body().Str())
+		raw, ok := lossy.Attributes().Get(rawBytesAttribute)
+		require.True(t, ok)
+		assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("in\xffvalid")), raw.Str())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		codec := &textLogCodec{
+			decoder:               enc.NewDecoder(),
+			encoder:               enc.NewEncoder(),
+			unmarshalingSeparator: r,
+		}
+		ld, err := codec.UnmarshalLogs(input)
+		require.NoError(t, err)
+		require.Equal(t, 2, ld.LogRecordCount())
+
+		lossy := ld.ResourceLogs().At(1).ScopeLogs().At(0).LogRecords().At(0)
+		assert.Equal(t, "in\uFFFDvalid", lossy.Body().Str())
+		_, ok := lossy.Attributes().Get(rawBytesAttribute)
+		assert.False(t, ok)
+	})
+
+	t.Run("byte order mark is not lossy", func(t *testing.T) {
+		codec := &textLogCodec{
+			autoDetect:            true,
+			sniffBufferSize:       defaultSniffBufferSize,
+			unmarshalingSeparator: r,
+			preserveRaw:           true,
+		}
+		ld, err := codec.UnmarshalLogs([]byte("\xEF\xBB\xBFfoo\nbar\n"))
+		require.NoError(t, err)
+		require.Equal(t, 2, ld.LogRecordCount())
+		for i := 0; i < ld.ResourceLogs().Len(); i++ {
+			_, ok := ld.ResourceLogs().At(i).ScopeLogs().At(0).LogRecords().At(0).Attributes().Get(rawBytesAttribute)
+			assert.False(t, ok)
+		}
+	})
+}
+
+func TestMaxBatchMemory(t *testing.T) {
+	enc, err := textutils.LookupEncoding("utf8")
+	require.NoError(t, err)
+	codec := &textLogCodec{
+		decoder:               enc.NewDecoder(),
+		unmarshalingSeparator: regexp.MustCompile(`\r?\n`),
+	}
+
+	// 100KB of single-byte lines, whose decoded records are much larger than the bytes read
+	const maxBatchMemory, lines = 1 << 14, 50_000
+	input := bytes.Repeat([]byte("a\n"), lines)
+	decoder, err := codec.NewLogsDecoder(bytes.NewReader(input),
+		encoding.WithFlushBytes(0), encoding.WithFlushItems(0), encoding.WithMaxBatchMemory(maxBatchMemory))
+	require.NoError(t, err)
+
+	var sizer plog.ProtoMarshaler
+	var records, batches int
+	for {
+		logs, err := decoder.DecodeLogs()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		batches++
+		records += logs.LogRecordCount()
+
+		// The batch exceeds the cap by at most its last record
+		var size, last int
+		for i := 0; i < logs.ResourceLogs().Len(); i++ {
+			last = sizer.ResourceLogsSize(logs.ResourceLogs().At(i))
+			size += last
+		}
+		assert.LessOrEqual(t, size-last, maxBatchMemory)
+	}
+	assert.Equal(t, lines, records)
+	assert.Greater(t, batches, 1)
+}
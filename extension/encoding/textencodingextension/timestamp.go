@@ -0,0 +1,79 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension // import "github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding/textencodingextension"
+
+import (
+	"errors"
+	"regexp"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// Timestamp policies define which timestamps are set on decoded log records.
+const (
+	// timestampPolicyBoth sets Timestamp from the parsed field and ObservedTimestamp from the decode time.
+	timestampPolicyBoth = "both"
+	// timestampPolicyEvent only sets Timestamp from the parsed field.
+	timestampPolicyEvent = "event"
+	// timestampPolicyObserved only sets ObservedTimestamp from the decode time.
+	timestampPolicyObserved = "observed"
+)
+
+// timeNow returns the time records are observed at, replaced in tests.
+var timeNow = time.Now
+
+// timestampParseErrorAttribute is the log record attribute holding the error of a timestamp failing to parse.
+const timestampParseErrorAttribute = "log.timestamp.parse_error"
+
+// errNoTimestampMatch is returned when the timestamp regex does not match the log line.
+var errNoTimestampMatch = errors.New("timestamp_regex did not match")
+
+// timestampParser extracts an event timestamp from a decoded log line.
+type timestampParser struct {
+	regex  *regexp.Regexp
+	layout string
+}
+
+// parse applies the regex to the line and parses the first capture group, or the whole match
+// if the regex has no capture group, with the layout.
+func (p *timestampParser) parse(line string) (time.Time, bool) {
+	ts, err := p.parseLine(line)
+	return ts, err == nil
+}
+
+// parseLine is like parse, returning errNoTimestampMatch if the regex does not match, or the parsing error.
+func (p *timestampParser) parseLine(line string) (time.Time, error) {
+	match := p.regex.FindStringSubmatch(line)
+	if match == nil {
+		return time.Time{}, errNoTimestampMatch
+	}
+	value := match[0]
+	if len(match) > 1 {
+		value = match[1]
+	}
+	return time.Parse(p.layout, value)
+}
+
+// setTimestamps is the post-decode hook applying the timestamp policy to a decoded log record.
+func (r *textLogCodec) setTimestamps(l plog.LogRecord, decoded string, now pcommon.Timestamp) {
+	if r.timestampPolicy != timestampPolicyEvent {
+		l.SetObservedTimestamp(now)
+	}
+	if r.timestampPolicy == timestampPolicyObserved || r.timestampParser == nil {
+		return
+	}
+	ts, err := r.timestampParser.parseLine(decoded)
+	if err == nil {
+		l.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+		return
+	}
+
+	// Fall back to the decode time so that the record is not left without any timestamp
+	l.SetObservedTimestamp(now)
+	if r.timestampParseErrorAttr && !errors.Is(err, errNoTimestampMatch) {
+		l.Attributes().PutStr(timestampParseErrorAttribute, err.Error())
+	}
+}
@@ -0,0 +1,267 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package textencodingextension
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/internal/coreinternal/textutils"
+)
+
+func TestTimestampPolicy(t *testing.T) {
+	eventTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	line := "2024-01-02T03:04:05Z something happened"
+
+	tests := []struct {
+		name              string
+		policy            string
+		expectTimestamp   bool
+		expectObservedNow bool
+	}{
+		{
+			name:              "default",
+			policy:            "",
+			expectTimestamp:   true,
+			expectObservedNow: true,
+		},
+		{
+			name:              "both",
+			policy:            timestampPolicyBoth,
+			expectTimestamp:   true,
+			expectObservedNow: true,
+		},
+		{
+			name:            "event",
+			policy:          timestampPolicyEvent,
+			expectTimestamp: true,
+		},
+		{
+			name:              "observed",
+			policy:            timestampPolicyObserved,
+			expectObservedNow: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, err := textutils.LookupEncoding("utf8")
+			require.NoError(t, err)
+			codec := &textLogCodec{
+				decoder:               enc.NewDecoder(),
+				unmarshalingSeparator: regexp.MustCompile(`\r?\n`),
+				timestampParser: &timestampParser{
+					regex:  regexp.MustCompile(`^(\S+)`),
+					layout: time.RFC3339,
+				},
+				timestampPolicy: tt.policy,
+			}
+
+			before := time.Now()
+			ld, err := codec.UnmarshalLogs([]byte(line))
+			require.NoError(t, err)
+			after := time.Now()
+
+			require.Equal(t, 1, ld.LogRecordCount())
+			lr := ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+			assert.Equal(t, line, lr.Body().Str())
+
+			if tt.expectTimestamp {
+				assert.Equal(t, pcommon.NewTimestampFromTime(eventTime), lr.Timestamp())
+			} else {
+				assert.Zero(t, lr.Timestamp())
+			}
+
+			if tt.expectObservedNow {
+				observed := lr.ObservedTimestamp().AsTime()
+				assert.False(t, observed.Before(before))
+				assert.False(t, observed.After(after))
+			} else {
+				assert.Zero(t, lr.ObservedTimestamp())
+			}
+		})
+	}
+}
+
+func TestTimestampParser(t *testing.T) {
+	tests := []struct {
+		name     string
+		regex    string
+		line     string
+		expected time.Time
+		ok       bool
+	}{
+		{
+			name:     "capture group",
+			regex:    `ts=(\S+)`,
+			line:     "level=info ts=2024-01-02T03:04:05Z msg=hello",
+			expected: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			ok:       true,
+		},
+		{
+			name:     "whole match",
+			regex:    `^\S+`,
+			line:     "2024-01-02T03:04:05Z hello",
+			expected: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+			ok:       true,
+		},
+		{
+			name:  "no match",
+			regex: `ts=(\S+)`,
+			line:  "hello",
+		},
+		{
+			name:  "malformed",
+			regex: `^(\S+)`,
+			line:  "not-a-timestamp hello",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &timestampParser{regex: regexp.MustCompile(tt.regex), layout: time.RFC3339}
+			ts, ok := p.parse(tt.line)
+			assert.Equal(t, tt.ok, ok)
+			assert.True(t, tt.expected.Equal(ts))
+		})
+	}
+}
+
+func TestTimestampFallback(t *testing.T) {
+	eventTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		regex           string
+		line            string
+		policy          string
+		expectTimestamp bool
+		expectError     string
+	}{
+		{
+			name:            "parsed",
+			line:            "2024-01-02T03:04:05Z something happened",
+			policy:          timestampPolicyEvent,
+			expectTimestamp: true,
+		},
+		{
+			name:   "no match",
+			regex:  `^ts=(\S+)`,
+			line:   "something happened",
+			policy: timestampPolicyEvent,
+		},
+		{
+			name:        "malformed",
+			line:        "ts=2024-13-45T03:04:05Z something happened",
+			policy:      timestampPolicyEvent,
+			expectError: `parsing time "2024-13-45T03:04:05Z": month out of range`,
+		},
+		{
+			name:        "malformed with both policy",
+			line:        "ts=yesterday something happened",
+			policy:      timestampPolicyBoth,
+			expectError: `parsing time "yesterday" as "2006-01-02T15:04:05Z07:00": cannot parse "yesterday" as "2006"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, err := textutils.LookupEncoding("utf8")
+			require.NoError(t, err)
+			regex := tt.regex
+			if regex == "" {
+				regex = `^(?:ts=)?(\S+)`
+			}
+			codec := &textLogCodec{
+				decoder:               enc.NewDecoder(),
+				unmarshalingSeparator: regexp.MustCompile(`\r?\n`),
+				timestampParser: &timestampParser{
+					regex:  regexp.MustCompile(regex),
+					layout: time.RFC3339,
+				},
+				timestampPolicy:         tt.policy,
+				timestampParseErrorAttr: true,
+			}
+
+			before := time.Now()
+			ld, err := codec.UnmarshalLogs([]byte(tt.line))
+			require.NoError(t, err)
+			after := time.Now()
+
+			require.Equal(t, 1, ld.LogRecordCount())
+			lr := ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+
+			parseErr, hasParseErr := lr.Attributes().Get(timestampParseErrorAttribute)
+			if tt.expectTimestamp {
+				assert.Equal(t, pcommon.NewTimestampFromTime(eventTime), lr.Timestamp())
+				assert.Zero(t, lr.ObservedTimestamp())
+				assert.False(t, hasParseErr)
+				return
+			}
+
+			// Falls back to the decode time, even with the event policy
+			assert.Zero(t, lr.Timestamp())
+			observed := lr.ObservedTimestamp().AsTime()
+			assert.False(t, observed.Before(before))
+			assert.False(t, observed.After(after))
+
+			if tt.expectError == "" {
+				assert.False(t, hasParseErr)
+				return
+			}
+			require.True(t, hasParseErr)
+			assert.Equal(t, tt.expectError, parseErr.Str())
+		})
+	}
+
+	t.Run("attribute disabled", func(t *testing.T) {
+		enc, err := textutils.LookupEncoding("utf8")
+		require.NoError(t, err)
+		codec := &textLogCodec{
+			decoder:               enc.NewDecoder(),
+			unmarshalingSeparator: regexp.MustCompile(`\r?\n`),
+			timestampParser: &timestampParser{
+				regex:  regexp.MustCompile(`^(\S+)`),
+				layout: time.RFC3339,
+			},
+		}
+		ld, err := codec.UnmarshalLogs([]byte("yesterday something happened"))
+		require.NoError(t, err)
+		lr := ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+		assert.Zero(t, lr.Timestamp())
+		assert.NotZero(t, lr.ObservedTimestamp())
+		assert.Equal(t, 0, lr.Attributes().Len())
+	})
+}
+
+func TestObservedTimestampPerRecord(t *testing.T) {
+	oldTimeNow := timeNow
+	defer func() {
+		timeNow = oldTimeNow
+	}()
+	// The clock advances by a second each time it is read
+	clock := time.Unix(1700000000, 0)
+	timeNow = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	enc, err := textutils.LookupEncoding("utf8")
+	require.NoError(t, err)
+	codec := &textLogCodec{
+		decoder:               enc.NewDecoder(),
+		unmarshalingSeparator: regexp.MustCompile(`\r?\n`),
+		timestampPolicy:       timestampPolicyBoth,
+	}
+
+	ld, err := codec.UnmarshalLogs([]byte("first\nsecond\nthird\n"))
+	require.NoError(t, err)
+	require.Equal(t, 3, ld.LogRecordCount())
+	for i := range 3 {
+		lr := ld.ResourceLogs().At(i).ScopeLogs().At(0).LogRecords().At(0)
+		assert.Equal(t, pcommon.NewTimestampFromTime(time.Unix(1700000001+int64(i), 0)), lr.ObservedTimestamp())
+	}
+}
@@ -95,6 +95,20 @@ func Hash64(opts ...HashOption) uint64 {
 	return xxhash.Sum64(hash[:])
 }
 
+// Sum64 computes the hash of the provided options with sum instead of xxhash, e.g. to use another hash algorithm.
+// sum is called with the canonical encoding of the options, which is only valid until sum returns.
+func Sum64(sum func([]byte) uint64, opts ...HashOption) uint64 {
+	hw := hashWriterPool.Get().(*hashWriter)
+	defer hashWriterPool.Put(hw)
+	hw.byteBuf = hw.byteBuf[:0]
+
+	for _, o := range opts {
+		o(hw)
+	}
+
+	return sum(hw.byteBuf)
+}
+
 // MapHash return a hash for the provided map.
 // Maps with the same underlying key/value pairs in different order produce the same deterministic hash value.
 func MapHash(m pcommon.Map) [16]byte {
@@ -6,6 +6,7 @@ package pdatautil
 import (
 	"testing"
 
+	"github.com/cespare/xxhash/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/collector/pdata/pcommon"
@@ -333,6 +334,21 @@ func TestMapValueHashNotEqual(t *testing.T) {
 	}
 }
 
+func TestSum64(t *testing.T) {
+	m := pcommon.NewMap()
+	m.PutStr("k", "v")
+	var input []byte
+	sum := func(b []byte) uint64 {
+		input = append(input[:0], b...)
+		return uint64(len(b))
+	}
+
+	assert.Equal(t, uint64(6), Sum64(sum, WithMap(m), WithString("s")))
+	assert.Equal(t, []byte("\xf4k\xf7v\xf7s"), input)
+	assert.Equal(t, Sum64(xxhash.Sum64, WithMap(m)), Sum64(xxhash.Sum64, WithMap(m)))
+	assert.NotEqual(t, Sum64(xxhash.Sum64, WithMap(m)), Sum64(xxhash.Sum64, WithString("s")))
+}
+
 func BenchmarkMapHashFourItems(b *testing.B) {
 	m := pcommon.NewMap()
 	m.PutStr("test-string-key2", "test-value-2")
@@ -0,0 +1,173 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xk8stest // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xk8stest"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+)
+
+// Distribution is the Kubernetes distribution of a cluster, as detected by ClusterInfo.
+type Distribution string
+
+const (
+	// DistributionKind is a cluster created by kind, whose nodes have a kind:// provider ID.
+	DistributionKind Distribution = "kind"
+	// DistributionK3d is a cluster created by k3d, whose nodes are named after k3d.
+	DistributionK3d Distribution = "k3d"
+	// DistributionOther is any other cluster.
+	DistributionOther Distribution = "other"
+)
+
+// Capability is a feature of a cluster that e2e tests may depend on, as detected by ClusterInfo.
+type Capability string
+
+const (
+	// CapabilityNativeSidecars is the support of init containers with an Always restart policy running as sidecars,
+	// enabled by default from Kubernetes 1.29.
+	CapabilityNativeSidecars Capability = "native_sidecars"
+	// CapabilityValidatingAdmissionPolicy is the admissionregistration.k8s.io/v1 ValidatingAdmissionPolicy API.
+	CapabilityValidatingAdmissionPolicy Capability = "validating_admission_policy"
+	// CapabilityFlowControlV1 is the flowcontrol.apiserver.k8s.io/v1 API.
+	CapabilityFlowControlV1 Capability = "flow_control_v1"
+)
+
+// nativeSidecarsVersion is the version from which native sidecars are enabled by default.
+var nativeSidecarsVersion = version.MajorMinor(1, 29)
+
+// capabilityAPIs are the APIs whose presence determines capabilities.
+var capabilityAPIs = map[Capability]schema.GroupVersionKind{
+	CapabilityValidatingAdmissionPolicy: {Group: "admissionregistration.k8s.io", Version: "v1", Kind: "ValidatingAdmissionPolicy"},
+	CapabilityFlowControlV1:             {Group: "flowcontrol.apiserver.k8s.io", Version: "v1", Kind: "FlowSchema"},
+}
+
+var nodesGVR = schema.GroupVersionResource{Version: "v1", Resource: "nodes"}
+
+// Cluster describes the cluster e2e tests run against, so that suites running against several Kubernetes versions
+// can adapt to it, e.g. with SkipIfBelow and RequireAPI.
+type Cluster struct {
+	// Version is the version of the API server, without pre-release or build metadata, e.g. 1.29.4 for v1.29.4+k3s1.
+	Version *version.Version
+	// GitVersion is the version reported by the API server, e.g. v1.29.4+k3s1.
+	GitVersion string
+	// Distribution is the detected distribution of the cluster.
+	Distribution Distribution
+	// Capabilities holds the capabilities of the cluster.
+	Capabilities map[Capability]bool
+	// discovery caches the APIs served by the cluster.
+	discovery discovery.CachedDiscoveryInterface
+}
+
+// ClusterInfo returns the version, distribution and capabilities of the cluster client connects to.
+// APIs are discovered once, and cached for the lifetime of the returned Cluster.
+func ClusterInfo(ctx context.Context, client *K8sClient) (*Cluster, error) {
+	return newCluster(ctx, memory.NewMemCacheClient(client.DiscoveryClient), client.DynamicClient)
+}
+
+func newCluster(ctx context.Context, discoveryClient discovery.CachedDiscoveryInterface, dynamicClient dynamic.Interface) (*Cluster, error) {
+	info, err := discoveryClient.ServerVersion()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server version: %w", err)
+	}
+	serverVersion, err := version.ParseGeneric(info.GitVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse server version: %w", err)
+	}
+	nodes, err := dynamicClient.Resource(nodesGVR).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	cluster := &Cluster{
+		Version:      serverVersion,
+		GitVersion:   info.GitVersion,
+		Distribution: DistributionOther,
+		Capabilities: map[Capability]bool{
+			CapabilityNativeSidecars: serverVersion.AtLeast(nativeSidecarsVersion),
+		},
+		discovery: discoveryClient,
+	}
+	for _, node := range nodes.Items {
+		providerID, _, _ := unstructured.NestedString(node.Object, "spec", "providerID")
+		switch {
+		case strings.HasPrefix(providerID, "kind://"):
+			cluster.Distribution = DistributionKind
+		case strings.HasPrefix(node.GetName(), "k3d-"):
+			cluster.Distribution = DistributionK3d
+		}
+	}
+	for capability, gvk := range capabilityAPIs {
+		if cluster.Capabilities[capability], err = cluster.HasAPI(gvk); err != nil {
+			return nil, err
+		}
+	}
+	return cluster, nil
+}
+
+// HasAPI reports whether the cluster serves the kind gvk, using the cached discovery of the cluster.
+func (c *Cluster) HasAPI(gvk schema.GroupVersionKind) (bool, error) {
+	resources, err := c.discovery.ServerResourcesForGroupVersion(gvk.GroupVersion().String())
+	if errors.Is(err, memory.ErrCacheNotFound) || apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to discover %s: %w", gvk.GroupVersion(), err)
+	}
+	for _, resource := range resources.APIResources {
+		// Subresources, e.g. pods/log, share the kind of their resource.
+		if resource.Kind == gvk.Kind && !strings.Contains(resource.Name, "/") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Has reports whether the cluster has capability.
+func (c *Cluster) Has(capability Capability) bool {
+	return c.Capabilities[capability]
+}
+
+// String describes the cluster in skip and failure messages, e.g. "Kubernetes v1.29.4+k3s1 (k3d)".
+func (c *Cluster) String() string {
+	return fmt.Sprintf("Kubernetes %s (%s)", c.GitVersion, c.Distribution)
+}
+
+// SkipIfBelow skips the test when the cluster runs a Kubernetes version older than minVersion, e.g. "1.29".
+// It fails the test when minVersion is not a valid version.
+func SkipIfBelow(t testing.TB, info *Cluster, minVersion string) {
+	t.Helper()
+	minimum, err := version.ParseGeneric(minVersion)
+	if err != nil {
+		t.Fatalf("invalid minimum Kubernetes version %q: %v", minVersion, err)
+		return
+	}
+	if !info.Version.AtLeast(minimum) {
+		t.Skipf("requires Kubernetes %s or later, cluster runs %s", minVersion, info)
+	}
+}
+
+// RequireAPI fails the test when the cluster does not serve the kind gvk, or when it cannot be discovered.
+func RequireAPI(t testing.TB, info *Cluster, gvk schema.GroupVersionKind) {
+	t.Helper()
+	ok, err := info.HasAPI(gvk)
+	if err != nil {
+		t.Fatalf("requires API %s %s, failed to discover it on %s: %v", gvk.GroupVersion(), gvk.Kind, info, err)
+		return
+	}
+	if !ok {
+		t.Fatalf("requires API %s %s, not served by %s", gvk.GroupVersion(), gvk.Kind, info)
+	}
+}
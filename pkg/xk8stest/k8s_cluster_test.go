@@ -0,0 +1,201 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xk8stest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8sversion "k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery/cached/memory"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var (
+	coreResources = &metav1.APIResourceList{
+		GroupVersion: "v1",
+		APIResources: []metav1.APIResource{
+			{Name: "pods", Kind: "Pod", Namespaced: true},
+			{Name: "pods/log", Kind: "Pod", Namespaced: true},
+			{Name: "nodes", Kind: "Node"},
+		},
+	}
+	admissionPolicyResources = &metav1.APIResourceList{
+		GroupVersion: "admissionregistration.k8s.io/v1",
+		APIResources: []metav1.APIResource{{Name: "validatingadmissionpolicies", Kind: "ValidatingAdmissionPolicy"}},
+	}
+	flowControlResources = &metav1.APIResourceList{
+		GroupVersion: "flowcontrol.apiserver.k8s.io/v1",
+		APIResources: []metav1.APIResource{{Name: "flowschemas", Kind: "FlowSchema"}},
+	}
+	podLogGVK  = schema.GroupVersionKind{Version: "v1", Kind: "PodLog"}
+	podGVK     = schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	cronJobGVK = schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "CronJob"}
+)
+
+// newTestNode returns a node named name with the given provider ID, if any.
+func newTestNode(name, providerID string) *unstructured.Unstructured {
+	node := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Node",
+		"metadata":   map[string]any{"name": name},
+	}}
+	if providerID != "" {
+		node.Object["spec"] = map[string]any{"providerID": providerID}
+	}
+	return node
+}
+
+// newTestCluster returns the Cluster detected from fake discovery responses and nodes, along with the fake
+// recording discovery requests.
+func newTestCluster(t *testing.T, gitVersion string, resources []*metav1.APIResourceList, nodes ...runtime.Object) (*Cluster, *k8stesting.Fake) {
+	t.Helper()
+	fake := &k8stesting.Fake{Resources: resources}
+	discoveryClient := &fakediscovery.FakeDiscovery{
+		Fake:               fake,
+		FakedServerVersion: &k8sversion.Info{GitVersion: gitVersion},
+	}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{nodesGVR: "NodeList"}, nodes...)
+	cluster, err := newCluster(t.Context(), memory.NewMemCacheClient(discoveryClient), dynamicClient)
+	require.NoError(t, err)
+	return cluster, fake
+}
+
+func TestClusterInfo(t *testing.T) {
+	tests := []struct {
+		name                 string
+		gitVersion           string
+		resources            []*metav1.APIResourceList
+		nodes                []runtime.Object
+		expectedVersion      string
+		expectedDistribution Distribution
+		expectedCapabilities map[Capability]bool
+	}{
+		{
+			name:                 "kind 1.27",
+			gitVersion:           "v1.27.3",
+			resources:            []*metav1.APIResourceList{coreResources},
+			nodes:                []runtime.Object{newTestNode("kind-control-plane", "kind://docker/kind/kind-control-plane")},
+			expectedVersion:      "1.27.3",
+			expectedDistribution: DistributionKind,
+			expectedCapabilities: map[Capability]bool{
+				CapabilityNativeSidecars:            false,
+				CapabilityValidatingAdmissionPolicy: false,
+				CapabilityFlowControlV1:             false,
+			},
+		},
+		{
+			name:                 "k3d 1.29",
+			gitVersion:           "v1.29.4+k3s1",
+			resources:            []*metav1.APIResourceList{coreResources, flowControlResources},
+			nodes:                []runtime.Object{newTestNode("k3d-e2e-server-0", "k3s://k3d-e2e-server-0")},
+			expectedVersion:      "1.29.4",
+			expectedDistribution: DistributionK3d,
+			expectedCapabilities: map[Capability]bool{
+				CapabilityNativeSidecars:            true,
+				CapabilityValidatingAdmissionPolicy: false,
+				CapabilityFlowControlV1:             true,
+			},
+		},
+		{
+			name:                 "managed 1.31",
+			gitVersion:           "v1.31.2-eks-7f9249a",
+			resources:            []*metav1.APIResourceList{coreResources, admissionPolicyResources, flowControlResources},
+			nodes:                []runtime.Object{newTestNode("ip-10-0-1-2.ec2.internal", "aws:///us-east-1a/i-0123456789")},
+			expectedVersion:      "1.31.2",
+			expectedDistribution: DistributionOther,
+			expectedCapabilities: map[Capability]bool{
+				CapabilityNativeSidecars:            true,
+				CapabilityValidatingAdmissionPolicy: true,
+				CapabilityFlowControlV1:             true,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster, fake := newTestCluster(t, tt.gitVersion, tt.resources, tt.nodes...)
+			requests := len(fake.Actions())
+			require.NotZero(t, requests)
+			assert.Equal(t, tt.expectedVersion, cluster.Version.String())
+			assert.Equal(t, tt.gitVersion, cluster.GitVersion)
+			assert.Equal(t, tt.expectedDistribution, cluster.Distribution)
+			assert.Equal(t, tt.expectedCapabilities, cluster.Capabilities)
+			for capability, expected := range tt.expectedCapabilities {
+				assert.Equal(t, expected, cluster.Has(capability), capability)
+			}
+
+			ok, err := cluster.HasAPI(podGVK)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			// Subresources are not kinds of their own
+			ok, err = cluster.HasAPI(podLogGVK)
+			require.NoError(t, err)
+			assert.False(t, ok)
+			ok, err = cluster.HasAPI(cronJobGVK)
+			require.NoError(t, err)
+			assert.False(t, ok)
+
+			// APIs are only discovered once
+			assert.Len(t, fake.Actions(), requests)
+		})
+	}
+}
+
+// recordingTB records the skip and failure messages of the helpers under test.
+type recordingTB struct {
+	testing.TB
+	skipped string
+	failed  string
+}
+
+func (*recordingTB) Helper() {}
+
+func (r *recordingTB) Skipf(format string, args ...any) {
+	r.skipped = fmt.Sprintf(format, args...)
+}
+
+func (r *recordingTB) Fatalf(format string, args ...any) {
+	r.failed = fmt.Sprintf(format, args...)
+}
+
+func TestSkipIfBelow(t *testing.T) {
+	cluster, _ := newTestCluster(t, "v1.28.9", []*metav1.APIResourceList{coreResources}, newTestNode("kind-control-plane", "kind://docker/kind/kind-control-plane"))
+
+	for _, minVersion := range []string{"1.27", "1.28", "1.28.9"} {
+		tb := &recordingTB{}
+		SkipIfBelow(tb, cluster, minVersion)
+		assert.Empty(t, tb.skipped, minVersion)
+		assert.Empty(t, tb.failed, minVersion)
+	}
+
+	tb := &recordingTB{}
+	SkipIfBelow(tb, cluster, "1.29")
+	assert.Equal(t, "requires Kubernetes 1.29 or later, cluster runs Kubernetes v1.28.9 (kind)", tb.skipped)
+
+	tb = &recordingTB{}
+	SkipIfBelow(tb, cluster, "latest")
+	assert.Contains(t, tb.failed, `invalid minimum Kubernetes version "latest"`)
+	assert.Empty(t, tb.skipped)
+}
+
+func TestRequireAPI(t *testing.T) {
+	cluster, _ := newTestCluster(t, "v1.30.0", []*metav1.APIResourceList{coreResources}, newTestNode("k3d-e2e-server-0", ""))
+
+	tb := &recordingTB{}
+	RequireAPI(tb, cluster, podGVK)
+	assert.Empty(t, tb.failed)
+
+	tb = &recordingTB{}
+	RequireAPI(tb, cluster, cronJobGVK)
+	assert.Equal(t, "requires API batch/v1 CronJob, not served by Kubernetes v1.30.0 (k3d)", tb.failed)
+}
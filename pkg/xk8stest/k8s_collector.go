@@ -20,8 +20,10 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
 )
 
@@ -134,6 +136,55 @@ func logCollectorPodDiagnostics(t *testing.T, client *K8sClient, namespace strin
 	}
 }
 
+// PodsReadyPollInterval is the period at which WaitForPodsReady lists pods.
+var PodsReadyPollInterval = 2 * time.Second
+
+// WaitForPodsReady polls the pods of namespace matching selector every PodsReadyPollInterval until they are all
+// running and Ready, returning nil, or until timeout elapses. On timeout, the returned error lists the pods not ready,
+// or reports that no pod matches selector. Errors listing pods are returned immediately.
+func WaitForPodsReady(ctx context.Context, client kubernetes.Interface, namespace string, selector labels.Selector, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ticker := time.NewTicker(PodsReadyPollInterval)
+	defer ticker.Stop()
+
+	listOptions := metav1.ListOptions{LabelSelector: selector.String()}
+	// notReady holds the pods not ready as of the last successful list, nil if none matched.
+	var notReady []string
+	for {
+		pods, err := client.CoreV1().Pods(namespace).List(ctx, listOptions)
+		switch {
+		case err == nil:
+			notReady = podsNotReady(pods.Items)
+			if len(pods.Items) > 0 && len(notReady) == 0 {
+				return nil
+			}
+		case ctx.Err() == nil:
+			return fmt.Errorf("failed to list pods in namespace %s: %w", namespace, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			if notReady == nil {
+				return fmt.Errorf("no pods matching %q in namespace %s after %s", selector, namespace, timeout)
+			}
+			return fmt.Errorf("pods not ready in namespace %s after %s: %s", namespace, timeout, strings.Join(notReady, ", "))
+		case <-ticker.C:
+		}
+	}
+}
+
+// podsNotReady describes the pods which are not running and Ready, as "name (phase)".
+func podsNotReady(pods []v1.Pod) []string {
+	var notReady []string
+	for i := range pods {
+		if !podReady(&pods[i]) {
+			notReady = append(notReady, fmt.Sprintf("%s (%s)", pods[i].Name, pods[i].Status.Phase))
+		}
+	}
+	return notReady
+}
+
 func podReady(pod *v1.Pod) bool {
 	if pod.Status.Phase != v1.PodRunning {
 		return false
@@ -0,0 +1,87 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xk8stest
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newPod(name string, labels map[string]string, phase v1.PodPhase, ready bool) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "e2e", Labels: labels},
+		Status:     v1.PodStatus{Phase: phase},
+	}
+	if ready {
+		pod.Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}
+	}
+	return pod
+}
+
+func setPodsReadyPollInterval(t *testing.T, interval time.Duration) {
+	previous := PodsReadyPollInterval
+	PodsReadyPollInterval = interval
+	t.Cleanup(func() { PodsReadyPollInterval = previous })
+}
+
+func TestWaitForPodsReady(t *testing.T) {
+	setPodsReadyPollInterval(t, time.Millisecond)
+	labels := map[string]string{"app": "otelcol"}
+	client := fake.NewClientset(
+		newPod("otelcol-0", labels, v1.PodPending, false),
+		newPod("otelcol-1", labels, v1.PodRunning, false),
+		newPod("other", map[string]string{"app": "other"}, v1.PodPending, false),
+	)
+
+	// Pods flip to Ready on the third poll
+	var polls atomic.Int32
+	client.PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		if polls.Add(1) == 3 {
+			for _, name := range []string{"otelcol-0", "otelcol-1"} {
+				require.NoError(t, client.Tracker().Update(v1.SchemeGroupVersion.WithResource("pods"), newPod(name, labels, v1.PodRunning, true), "e2e"))
+			}
+		}
+		return false, nil, nil
+	})
+
+	selector := SelectorFromMap(map[string]any{"app": "otelcol"})
+	require.NoError(t, WaitForPodsReady(t.Context(), client, "e2e", selector, time.Minute))
+	assert.Equal(t, int32(3), polls.Load())
+}
+
+func TestWaitForPodsReady_timeout(t *testing.T) {
+	setPodsReadyPollInterval(t, time.Millisecond)
+	labels := map[string]string{"app": "otelcol"}
+	client := fake.NewClientset(
+		newPod("otelcol-0", labels, v1.PodRunning, true),
+		newPod("otelcol-1", labels, v1.PodRunning, false),
+		newPod("otelcol-2", labels, v1.PodPending, false),
+	)
+
+	err := WaitForPodsReady(t.Context(), client, "e2e", SelectorFromMap(map[string]any{"app": "otelcol"}), 20*time.Millisecond)
+	require.EqualError(t, err, "pods not ready in namespace e2e after 20ms: otelcol-1 (Running), otelcol-2 (Pending)")
+
+	err = WaitForPodsReady(t.Context(), client, "e2e", SelectorFromMap(map[string]any{"app": "missing"}), 20*time.Millisecond)
+	require.EqualError(t, err, `no pods matching "app=missing" in namespace e2e after 20ms`)
+}
+
+func TestWaitForPodsReady_listError(t *testing.T) {
+	client := fake.NewClientset()
+	client.PrependReactor("list", "pods", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("forbidden")
+	})
+
+	err := WaitForPodsReady(t.Context(), client, "e2e", SelectorFromMap(map[string]any{"app": "otelcol"}), time.Minute)
+	require.ErrorContains(t, err, "failed to list pods in namespace e2e: forbidden")
+}
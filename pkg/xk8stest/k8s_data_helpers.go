@@ -5,16 +5,61 @@ package xk8stest // import "github.com/open-telemetry/opentelemetry-collector-co
 
 import (
 	"context"
+	"fmt"
 	"runtime"
 	"testing"
 	"time"
 
+	dockernetwork "github.com/moby/moby/api/types/network"
 	dockerclient "github.com/moby/moby/client"
 	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/labels"
 )
 
+// IPFamily is the IP family of a host endpoint.
+type IPFamily string
+
+const (
+	// IPFamilyIPv4 selects the IPv4 gateway of the kind network.
+	IPFamilyIPv4 IPFamily = "IPv4"
+	// IPFamilyIPv6 selects the IPv6 gateway of the kind network.
+	IPFamilyIPv6 IPFamily = "IPv6"
+)
+
+// kindNetwork is the name of the Docker network of kind clusters.
+const kindNetwork = "kind"
+
+// NetworkInspectTimeout is the time host endpoint helpers wait for Docker to inspect the cluster network.
+// Slow CI runners may extend it.
+var NetworkInspectTimeout = 5 * time.Second
+
+// networkInspector inspects Docker networks, implemented by the Docker client.
+type networkInspector interface {
+	NetworkInspect(ctx context.Context, networkID string, options dockerclient.NetworkInspectOptions) (dockerclient.NetworkInspectResult, error)
+}
+
+// HostEndpoint returns the endpoint of the host as seen from the pods of a kind cluster, preferring the IPv4
+// gateway of the kind network and falling back to its IPv6 gateway. Use HostEndpointForFamily to require a family.
 func HostEndpoint(t *testing.T) string {
+	return HostEndpointForNetwork(t, kindNetwork)
+}
+
+// HostEndpointForNetwork returns the endpoint of the host as seen from the pods of a cluster whose nodes are attached
+// to the Docker network of the given name, e.g. a custom-named kind network or the network of a k3d cluster.
+// As HostEndpoint, it prefers the IPv4 gateway of the network and falls back to its IPv6 gateway.
+func HostEndpointForNetwork(t *testing.T, networkName string) string {
+	return hostEndpoint(t, networkName, "")
+}
+
+// HostEndpointForFamily returns the endpoint of the host as seen from the pods of a kind cluster, using the gateway
+// of the kind network of the given family, e.g. for IPv6-only clusters. The test fails when the kind network has
+// no gateway of this family.
+func HostEndpointForFamily(t *testing.T, family IPFamily) string {
+	return hostEndpoint(t, kindNetwork, family)
+}
+
+// hostEndpoint returns the endpoint of the host using the gateway of family of the network, preferring IPv4 when empty.
+func hostEndpoint(t *testing.T, networkName string, family IPFamily) string {
 	if runtime.GOOS == "darwin" {
 		return "host.docker.internal"
 	}
@@ -25,31 +70,60 @@ func HostEndpoint(t *testing.T) string {
 		NegotiateAPIVersion: true,
 	})
 	require.NoError(t, err)
-	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Second)
+
+	endpoint, err := networkGatewayEndpoint(t.Context(), client, networkName, family)
+	require.NoError(t, err, "failed to find host endpoint")
+	return endpoint
+}
+
+// networkGatewayEndpoint inspects the network of the given name, within NetworkInspectTimeout, and returns its
+// gateway of family, see gatewayEndpoint.
+func networkGatewayEndpoint(ctx context.Context, inspector networkInspector, networkName string, family IPFamily) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, NetworkInspectTimeout)
 	defer cancel()
-	network, err := client.NetworkInspect(ctx, "kind", dockerclient.NetworkInspectOptions{})
-	require.NoError(t, err)
+	network, err := inspector.NetworkInspect(ctx, networkName, dockerclient.NetworkInspectOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect %s network: %w", networkName, err)
+	}
+	return gatewayEndpoint(networkName, network.Network.IPAM.Config, family)
+}
+
+// gatewayEndpoint returns the first gateway of configs of the network of the given name of the given family.
+// Without family, IPv4 gateways are preferred, falling back to IPv6 ones. IPv6 addresses are wrapped in brackets so
+// that callers can safely append ":port" (e.g. [fc00:f853:ccd:e793::1]:4317).
+func gatewayEndpoint(networkName string, configs []dockernetwork.IPAMConfig, family IPFamily) (string, error) {
+	var ipv4, ipv6 string
+	for _, ipam := range configs {
+		switch {
+		case !ipam.Gateway.IsValid():
+		case ipam.Gateway.Is4() && ipv4 == "":
+			ipv4 = ipam.Gateway.String()
+		case !ipam.Gateway.Is4() && ipv6 == "":
+			ipv6 = "[" + ipam.Gateway.String() + "]"
+		}
+	}
 
-	// Prefer IPv4 gateways, but fallback to IPv6 if no IPv4 gateway is found.
-	// IPv6 addresses are wrapped in brackets so that callers can safely append
-	// ":port" (e.g. [fc00:f853:ccd:e793::1]:4317).
-	var ipv6Fallback string
-	for _, ipam := range network.Network.IPAM.Config {
-		if ipam.Gateway.String() == "" {
-			continue
+	switch family {
+	case "":
+		if ipv4 != "" {
+			return ipv4, nil
 		}
-		if ipam.Gateway.Is4() {
-			return ipam.Gateway.String()
+		if ipv6 != "" {
+			return ipv6, nil
 		}
-		if ipv6Fallback == "" {
-			ipv6Fallback = "[" + ipam.Gateway.String() + "]"
+		return "", fmt.Errorf("%s network has no gateway", networkName)
+	case IPFamilyIPv4:
+		if ipv4 != "" {
+			return ipv4, nil
 		}
+	case IPFamilyIPv6:
+		if ipv6 != "" {
+			return ipv6, nil
+		}
+	default:
+		return "", fmt.Errorf("unsupported IP family %q, expected %q or %q", family, IPFamilyIPv4, IPFamilyIPv6)
 	}
-	if ipv6Fallback != "" {
-		return ipv6Fallback
-	}
-	require.Fail(t, "failed to find host endpoint")
-	return ""
+	return "", fmt.Errorf("%s network has no %s gateway", networkName, family)
 }
 
 func SelectorFromMap(labelMap map[string]any) labels.Selector {
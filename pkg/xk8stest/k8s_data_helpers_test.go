@@ -0,0 +1,106 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xk8stest
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+	"time"
+
+	dockernetwork "github.com/moby/moby/api/types/network"
+	dockerclient "github.com/moby/moby/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGatewayEndpoint(t *testing.T) {
+	ipv4 := dockernetwork.IPAMConfig{
+		Subnet:  netip.MustParsePrefix("172.18.0.0/16"),
+		Gateway: netip.MustParseAddr("172.18.0.1"),
+	}
+	ipv6 := dockernetwork.IPAMConfig{
+		Subnet:  netip.MustParsePrefix("fc00:f853:ccd:e793::/64"),
+		Gateway: netip.MustParseAddr("fc00:f853:ccd:e793::1"),
+	}
+	noGateway := dockernetwork.IPAMConfig{Subnet: netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name     string
+		configs  []dockernetwork.IPAMConfig
+		family   IPFamily
+		expected string
+		err      string
+	}{
+		{name: "default prefers IPv4", configs: []dockernetwork.IPAMConfig{ipv6, ipv4}, expected: "172.18.0.1"},
+		{name: "default falls back to IPv6", configs: []dockernetwork.IPAMConfig{noGateway, ipv6}, expected: "[fc00:f853:ccd:e793::1]"},
+		{name: "default without gateway", configs: []dockernetwork.IPAMConfig{noGateway}, err: "kind network has no gateway"},
+		{name: "IPv4", configs: []dockernetwork.IPAMConfig{ipv6, ipv4}, family: IPFamilyIPv4, expected: "172.18.0.1"},
+		{name: "IPv6", configs: []dockernetwork.IPAMConfig{ipv4, ipv6}, family: IPFamilyIPv6, expected: "[fc00:f853:ccd:e793::1]"},
+		{name: "IPv4 missing", configs: []dockernetwork.IPAMConfig{ipv6}, family: IPFamilyIPv4, err: "kind network has no IPv4 gateway"},
+		{name: "IPv6 missing", configs: []dockernetwork.IPAMConfig{ipv4, noGateway}, family: IPFamilyIPv6, err: "kind network has no IPv6 gateway"},
+		{name: "unsupported family", configs: []dockernetwork.IPAMConfig{ipv4}, family: "IPv5", err: `unsupported IP family "IPv5"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpoint, err := gatewayEndpoint("kind", tt.configs, tt.family)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, endpoint)
+		})
+	}
+}
+
+// mockNetworkInspector records the networks inspected and returns the result of the network of the given name.
+type mockNetworkInspector struct {
+	networkName string
+	result      dockerclient.NetworkInspectResult
+	inspected   []string
+	deadline    time.Duration
+}
+
+func (m *mockNetworkInspector) NetworkInspect(ctx context.Context, networkID string, _ dockerclient.NetworkInspectOptions) (dockerclient.NetworkInspectResult, error) {
+	m.inspected = append(m.inspected, networkID)
+	if deadline, ok := ctx.Deadline(); ok {
+		m.deadline = time.Until(deadline)
+	}
+	if networkID != m.networkName {
+		return dockerclient.NetworkInspectResult{}, errors.New("network not found")
+	}
+	return m.result, nil
+}
+
+func TestNetworkGatewayEndpoint(t *testing.T) {
+	inspector := &mockNetworkInspector{networkName: "k3d-ci"}
+	inspector.result.Network.IPAM.Config = []dockernetwork.IPAMConfig{{
+		Subnet:  netip.MustParsePrefix("172.19.0.0/16"),
+		Gateway: netip.MustParseAddr("172.19.0.1"),
+	}}
+
+	endpoint, err := networkGatewayEndpoint(t.Context(), inspector, "k3d-ci", "")
+	require.NoError(t, err)
+	assert.Equal(t, "172.19.0.1", endpoint)
+	assert.Equal(t, []string{"k3d-ci"}, inspector.inspected)
+
+	_, err = networkGatewayEndpoint(t.Context(), inspector, "k3d-ci", IPFamilyIPv6)
+	require.ErrorContains(t, err, "k3d-ci network has no IPv6 gateway")
+
+	_, err = networkGatewayEndpoint(t.Context(), inspector, "kind", "")
+	require.ErrorContains(t, err, "failed to inspect kind network: network not found")
+}
+
+func TestNetworkGatewayEndpoint_timeout(t *testing.T) {
+	defer func(timeout time.Duration) { NetworkInspectTimeout = timeout }(NetworkInspectTimeout)
+	NetworkInspectTimeout = time.Minute
+
+	inspector := &mockNetworkInspector{networkName: "kind"}
+	_, err := networkGatewayEndpoint(t.Context(), inspector, "kind", "")
+	require.ErrorContains(t, err, "kind network has no gateway")
+	assert.Greater(t, inspector.deadline, 5*time.Second)
+	assert.LessOrEqual(t, inspector.deadline, time.Minute)
+}
@@ -0,0 +1,341 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xk8stest // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xk8stest"
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"text/template"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+const (
+	// DefaultOTLPSinkImage is the collector image receiving the data in the OTLP sink, loaded into kind by e2e tests.
+	DefaultOTLPSinkImage = "otelcontribcol:latest"
+	// DefaultOTLPSinkRetrievalImage is the image serving the received data of the OTLP sink.
+	DefaultOTLPSinkRetrievalImage = "busybox:1.37"
+
+	otlpSinkGRPCPort      = 4317
+	otlpSinkHTTPPort      = 4318
+	otlpSinkRetrievalPort = 8080
+	// otlpSinkDataDir is where the received data is written, and served from by the retrieval container.
+	otlpSinkDataDir = "/www/data"
+)
+
+//go:embed otlpsink/*.yaml
+var otlpSinkManifests embed.FS
+
+// OTLPSink is an OTLP gRPC/HTTP endpoint deployed in the cluster, recording the data it receives
+// so that e2e tests can assert on what the collector exported.
+type OTLPSink struct {
+	Name      string
+	Namespace string
+	client    *K8sClient
+	objects   []*unstructured.Unstructured
+}
+
+type otlpSinkOptions struct {
+	name           string
+	image          string
+	retrievalImage string
+}
+
+// OTLPSinkOption configures the OTLP sink created by CreateOTLPSink.
+type OTLPSinkOption func(*otlpSinkOptions)
+
+// WithOTLPSinkName sets the name of the OTLP sink objects, "otlp-sink" by default.
+func WithOTLPSinkName(name string) OTLPSinkOption {
+	return func(o *otlpSinkOptions) {
+		o.name = name
+	}
+}
+
+// WithOTLPSinkImage sets the collector image receiving the data, DefaultOTLPSinkImage by default.
+// The image must include the OTLP receiver and the file exporter.
+func WithOTLPSinkImage(image string) OTLPSinkOption {
+	return func(o *otlpSinkOptions) {
+		o.image = image
+	}
+}
+
+// WithOTLPSinkRetrievalImage sets the image serving the received data, DefaultOTLPSinkRetrievalImage by default.
+// The image must provide a busybox httpd supporting CGI scripts.
+func WithOTLPSinkRetrievalImage(image string) OTLPSinkOption {
+	return func(o *otlpSinkOptions) {
+		o.retrievalImage = image
+	}
+}
+
+// CreateOTLPSink deploys an OTLP sink in namespace, exposed by a service of the same name.
+// The received data is written as OTLP JSON by a collector, and served to FetchReceived by a sidecar through
+// port-forward. Use WaitForSinkReady before sending data to it and Delete to remove it.
+func CreateOTLPSink(ctx context.Context, client *K8sClient, namespace string, opts ...OTLPSinkOption) (*OTLPSink, error) {
+	options := otlpSinkOptions{
+		name:           "otlp-sink",
+		image:          DefaultOTLPSinkImage,
+		retrievalImage: DefaultOTLPSinkRetrievalImage,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	manifests, err := renderOTLPSinkManifests(namespace, options)
+	if err != nil {
+		return nil, err
+	}
+
+	sink := &OTLPSink{Name: options.name, Namespace: namespace, client: client}
+	for _, manifest := range manifests {
+		if err := ctx.Err(); err != nil {
+			return nil, errors.Join(err, sink.Delete())
+		}
+		obj, err := CreateObject(client, manifest)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("failed to create OTLP sink object: %w", err), sink.Delete())
+		}
+		sink.objects = append(sink.objects, obj)
+	}
+	return sink, nil
+}
+
+// renderOTLPSinkManifests renders the manifests of the OTLP sink objects, in creation order.
+func renderOTLPSinkManifests(namespace string, options otlpSinkOptions) ([][]byte, error) {
+	values := map[string]any{
+		"Name":           options.name,
+		"Namespace":      namespace,
+		"Image":          options.image,
+		"RetrievalImage": options.retrievalImage,
+		"GRPCPort":       otlpSinkGRPCPort,
+		"HTTPPort":       otlpSinkHTTPPort,
+		"RetrievalPort":  otlpSinkRetrievalPort,
+		"DataDir":        otlpSinkDataDir,
+	}
+
+	var manifests [][]byte
+	for _, name := range []string{"configmap.yaml", "deployment.yaml", "service.yaml"} {
+		tmpl, err := template.ParseFS(otlpSinkManifests, path.Join("otlpsink", name))
+		if err != nil {
+			return nil, err
+		}
+		manifest := &bytes.Buffer{}
+		if err := tmpl.Execute(manifest, values); err != nil {
+			return nil, fmt.Errorf("failed to render OTLP sink manifest %s: %w", name, err)
+		}
+		manifests = append(manifests, manifest.Bytes())
+	}
+	return manifests, nil
+}
+
+// GRPCEndpoint returns the in-cluster OTLP gRPC endpoint of the sink.
+func (s *OTLPSink) GRPCEndpoint() string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local:%d", s.Name, s.Namespace, otlpSinkGRPCPort)
+}
+
+// HTTPEndpoint returns the in-cluster OTLP HTTP endpoint of the sink.
+func (s *OTLPSink) HTTPEndpoint() string {
+	return fmt.Sprintf("http://%s.%s.svc.cluster.local:%d", s.Name, s.Namespace, otlpSinkHTTPPort)
+}
+
+// Delete deletes the OTLP sink objects.
+func (s *OTLPSink) Delete() error {
+	return DeleteObjects(s.client, s.objects)
+}
+
+// WaitForSinkReady waits until the pod of the OTLP sink is ready, or ctx is done.
+func WaitForSinkReady(ctx context.Context, sink *OTLPSink) error {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		if _, err := sink.readyPod(ctx); err == nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("OTLP sink %s/%s is not ready: %w", sink.Namespace, sink.Name, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// FetchReceived returns the data received by the sink since it was created or last reset.
+// Each element is the content of an export request received by the sink.
+func (s *OTLPSink) FetchReceived(ctx context.Context) ([]ptrace.Traces, []plog.Logs, []pmetric.Metrics, error) {
+	var traces, logs, metrics []byte
+	err := s.withRetrievalPort(ctx, func(baseURL string) error {
+		var err error
+		if traces, err = fetchOTLPSinkURL(ctx, baseURL+"/data/traces.json", true); err != nil {
+			return err
+		}
+		if logs, err = fetchOTLPSinkURL(ctx, baseURL+"/data/logs.json", true); err != nil {
+			return err
+		}
+		metrics, err = fetchOTLPSinkURL(ctx, baseURL+"/data/metrics.json", true)
+		return err
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return parseReceived(traces, logs, metrics)
+}
+
+// Reset clears the data received by the sink, e.g. between test cases.
+func (s *OTLPSink) Reset(ctx context.Context) error {
+	return s.withRetrievalPort(ctx, func(baseURL string) error {
+		_, err := fetchOTLPSinkURL(ctx, baseURL+"/cgi-bin/reset", false)
+		return err
+	})
+}
+
+// parseReceived parses the OTLP JSON files written by the sink, holding one export request per line.
+func parseReceived(traces, logs, metrics []byte) ([]ptrace.Traces, []plog.Logs, []pmetric.Metrics, error) {
+	tracesUnmarshaler := &ptrace.JSONUnmarshaler{}
+	receivedTraces, err := unmarshalJSONLines(traces, tracesUnmarshaler.UnmarshalTraces)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse received traces: %w", err)
+	}
+	logsUnmarshaler := &plog.JSONUnmarshaler{}
+	receivedLogs, err := unmarshalJSONLines(logs, logsUnmarshaler.UnmarshalLogs)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse received logs: %w", err)
+	}
+	metricsUnmarshaler := &pmetric.JSONUnmarshaler{}
+	receivedMetrics, err := unmarshalJSONLines(metrics, metricsUnmarshaler.UnmarshalMetrics)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to parse received metrics: %w", err)
+	}
+	return receivedTraces, receivedLogs, receivedMetrics, nil
+}
+
+func unmarshalJSONLines[T any](data []byte, unmarshal func([]byte) (T, error)) ([]T, error) {
+	var result []T
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		value, err := unmarshal(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+// fetchOTLPSinkURL returns the body of url. If optional is set, it returns nil if url does not exist,
+// e.g. when no data was received yet.
+func fetchOTLPSinkURL(ctx context.Context, url string, optional bool) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case resp.StatusCode == http.StatusNotFound && optional:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unexpected status %q fetching %s", resp.Status, url)
+	}
+}
+
+// readyPod returns the name of the ready pod of the sink.
+func (s *OTLPSink) readyPod(ctx context.Context) (string, error) {
+	coreClient, err := corev1client.NewForConfig(s.client.restConfig)
+	if err != nil {
+		return "", err
+	}
+	pods, err := coreClient.Pods(s.Namespace).List(ctx, metav1.ListOptions{LabelSelector: "app=" + s.Name})
+	if err != nil {
+		return "", err
+	}
+	for i := range pods.Items {
+		if podReady(&pods.Items[i]) {
+			return pods.Items[i].Name, nil
+		}
+	}
+	return "", fmt.Errorf("no ready pod found for OTLP sink %s/%s", s.Namespace, s.Name)
+}
+
+// withRetrievalPort calls f with the base URL of the retrieval endpoint, port-forwarded from the sink pod.
+func (s *OTLPSink) withRetrievalPort(ctx context.Context, f func(baseURL string) error) error {
+	localPort, stop, err := s.portForward(ctx, otlpSinkRetrievalPort)
+	if err != nil {
+		return err
+	}
+	defer stop()
+	return f("http://127.0.0.1:" + strconv.Itoa(int(localPort)))
+}
+
+// portForward forwards a random local port to port of the sink pod, until stop is called.
+func (s *OTLPSink) portForward(ctx context.Context, port int) (localPort uint16, stop func(), err error) {
+	podName, err := s.readyPod(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	coreClient, err := corev1client.NewForConfig(s.client.restConfig)
+	if err != nil {
+		return 0, nil, err
+	}
+	transport, upgrader, err := spdy.RoundTripperFor(s.client.restConfig)
+	if err != nil {
+		return 0, nil, err
+	}
+	url := coreClient.RESTClient().Post().Resource("pods").Namespace(s.Namespace).Name(podName).SubResource("portforward").URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, url)
+
+	stopCh, readyCh := make(chan struct{}), make(chan struct{})
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, []string{"0:" + strconv.Itoa(port)}, stopCh, readyCh, io.Discard, io.Discard)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- forwarder.ForwardPorts()
+	}()
+
+	select {
+	case <-readyCh:
+	case err := <-errCh:
+		return 0, nil, fmt.Errorf("failed to port-forward to pod %s: %w", podName, err)
+	case <-ctx.Done():
+		close(stopCh)
+		return 0, nil, ctx.Err()
+	}
+
+	ports, err := forwarder.GetPorts()
+	if err != nil {
+		close(stopCh)
+		return 0, nil, fmt.Errorf("failed to get forwarded port for pod %s: %w", podName, err)
+	}
+	if len(ports) == 0 {
+		close(stopCh)
+		return 0, nil, fmt.Errorf("no port forwarded for pod %s", podName)
+	}
+	return ports[0].Local, func() { close(stopCh) }, nil
+}
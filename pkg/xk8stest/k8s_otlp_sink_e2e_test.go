@@ -0,0 +1,66 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+//go:build e2e
+
+package xk8stest
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/plog/plogotlp"
+)
+
+const testKubeConfig = "/tmp/kube-config-otelcol-e2e-testing"
+
+func TestOTLPSink(t *testing.T) {
+	client, err := NewK8sClient(testKubeConfig)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 5*time.Minute)
+	defer cancel()
+
+	sink, err := CreateOTLPSink(ctx, client, "default", WithOTLPSinkName("otlp-sink-e2e"))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, sink.Delete()) })
+	require.NoError(t, WaitForSinkReady(ctx, sink))
+
+	// Send logs to the OTLP HTTP receiver of the sink from the test
+	localPort, stop, err := sink.portForward(ctx, otlpSinkHTTPPort)
+	require.NoError(t, err)
+	defer stop()
+
+	logs := plog.NewLogs()
+	logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("e2e")
+	body, err := plogotlp.NewExportRequestFromLogs(logs).MarshalJSON()
+	require.NoError(t, err)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://127.0.0.1:"+strconv.Itoa(int(localPort))+"/v1/logs", bytes.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.EventuallyWithT(t, func(tt *assert.CollectT) {
+		traces, received, metrics, err := sink.FetchReceived(ctx)
+		require.NoError(tt, err)
+		assert.Empty(tt, traces)
+		assert.Empty(tt, metrics)
+		require.Len(tt, received, 1)
+		assert.Equal(tt, "e2e", received[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+	}, time.Minute, time.Second)
+
+	require.NoError(t, sink.Reset(ctx))
+	_, received, _, err := sink.FetchReceived(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, received)
+}
@@ -0,0 +1,97 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xk8stest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/serializer/yaml"
+)
+
+func TestParseReceived(t *testing.T) {
+	traces := ptrace.NewTraces()
+	traces.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty().SetName("span")
+	tracesJSON, err := (&ptrace.JSONMarshaler{}).MarshalTraces(traces)
+	require.NoError(t, err)
+
+	logs := plog.NewLogs()
+	logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("log")
+	logsJSON, err := (&plog.JSONMarshaler{}).MarshalLogs(logs)
+	require.NoError(t, err)
+
+	metrics := pmetric.NewMetrics()
+	metrics.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty().SetName("metric")
+	metricsJSON, err := (&pmetric.JSONMarshaler{}).MarshalMetrics(metrics)
+	require.NoError(t, err)
+
+	t.Run("one request per line", func(t *testing.T) {
+		logsFile := bytes.Join([][]byte{logsJSON, logsJSON, nil}, []byte("\n"))
+		receivedTraces, receivedLogs, receivedMetrics, err := parseReceived(tracesJSON, logsFile, metricsJSON)
+		require.NoError(t, err)
+
+		require.Len(t, receivedTraces, 1)
+		assert.Equal(t, "span", receivedTraces[0].ResourceSpans().At(0).ScopeSpans().At(0).Spans().At(0).Name())
+		require.Len(t, receivedLogs, 2)
+		for _, received := range receivedLogs {
+			assert.Equal(t, "log", received.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+		}
+		require.Len(t, receivedMetrics, 1)
+		assert.Equal(t, "metric", receivedMetrics[0].ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics().At(0).Name())
+	})
+
+	t.Run("nothing received", func(t *testing.T) {
+		receivedTraces, receivedLogs, receivedMetrics, err := parseReceived(nil, []byte("\n"), nil)
+		require.NoError(t, err)
+		assert.Empty(t, receivedTraces)
+		assert.Empty(t, receivedLogs)
+		assert.Empty(t, receivedMetrics)
+	})
+
+	t.Run("invalid line", func(t *testing.T) {
+		_, _, _, err := parseReceived(nil, bytes.Join([][]byte{logsJSON, []byte("{")}, []byte("\n")), nil)
+		require.ErrorContains(t, err, "failed to parse received logs: line 2")
+	})
+}
+
+func TestRenderOTLPSinkManifests(t *testing.T) {
+	manifests, err := renderOTLPSinkManifests("e2e", otlpSinkOptions{
+		name:           "sink",
+		image:          "collector:test",
+		retrievalImage: "busybox:test",
+	})
+	require.NoError(t, err)
+
+	decoder := yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme)
+	var kinds []string
+	for _, manifest := range manifests {
+		obj := &unstructured.Unstructured{}
+		_, _, err := decoder.Decode(manifest, nil, obj)
+		require.NoError(t, err)
+		assert.Equal(t, "sink", obj.GetName())
+		assert.Equal(t, "e2e", obj.GetNamespace())
+		kinds = append(kinds, obj.GetKind())
+
+		if obj.GetKind() != "Deployment" {
+			continue
+		}
+		containers, found, err := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Len(t, containers, 2)
+		assert.Equal(t, "collector:test", containers[0].(map[string]any)["image"])
+		assert.Equal(t, "busybox:test", containers[1].(map[string]any)["image"])
+	}
+	assert.Equal(t, []string{"ConfigMap", "Deployment", "Service"}, kinds)
+
+	sink := &OTLPSink{Name: "sink", Namespace: "e2e"}
+	assert.Equal(t, "sink.e2e.svc.cluster.local:4317", sink.GRPCEndpoint())
+	assert.Equal(t, "http://sink.e2e.svc.cluster.local:4318", sink.HTTPEndpoint())
+}
@@ -0,0 +1,165 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xk8stest // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xk8stest"
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+)
+
+// NormalizationRule removes a field from the objects of a snapshot, e.g. a field set by the cluster which
+// changes on every run.
+type NormalizationRule struct {
+	// Name describes the rule.
+	Name string
+	// Path is the path of the removed field, e.g. ["metadata", "annotations", "deployment.kubernetes.io/revision"].
+	Path []string
+}
+
+// DefaultNormalizationRules are the rules applied to snapshots unless WithNormalizationRules is set. They remove
+// the fields set by the cluster, so that snapshots of the same objects are equal across runs.
+var DefaultNormalizationRules = []NormalizationRule{
+	{Name: "managed fields", Path: []string{"metadata", "managedFields"}},
+	{Name: "resource version", Path: []string{"metadata", "resourceVersion"}},
+	{Name: "uid", Path: []string{"metadata", "uid"}},
+	{Name: "creation timestamp", Path: []string{"metadata", "creationTimestamp"}},
+	{Name: "generation", Path: []string{"metadata", "generation"}},
+	{Name: "last applied configuration", Path: []string{"metadata", "annotations", "kubectl.kubernetes.io/last-applied-configuration"}},
+	{Name: "deployment revision", Path: []string{"metadata", "annotations", "deployment.kubernetes.io/revision"}},
+	{Name: "status", Path: []string{"status"}},
+}
+
+type snapshotOptions struct {
+	rules []NormalizationRule
+}
+
+// SnapshotOption configures SnapshotObjects.
+type SnapshotOption func(*snapshotOptions)
+
+// WithNormalizationRules replaces DefaultNormalizationRules with rules, e.g. to keep the status of objects:
+//
+//	rules := slices.DeleteFunc(slices.Clone(xk8stest.DefaultNormalizationRules), func(r xk8stest.NormalizationRule) bool {
+//		return r.Name == "status"
+//	})
+//	snapshot, err := xk8stest.SnapshotObjects(ctx, client, namespace, gvks, xk8stest.WithNormalizationRules(rules...))
+func WithNormalizationRules(rules ...NormalizationRule) SnapshotOption {
+	return func(o *snapshotOptions) {
+		o.rules = rules
+	}
+}
+
+// SnapshotObjects returns a YAML snapshot of the objects of the given kinds in namespace, e.g. to compare the objects
+// existing before and after an action with DiffSnapshots. Cluster-scoped kinds are snapshotted across the cluster.
+// Objects are normalized by removing the fields matched by the normalization rules, and sorted by kind, in the order
+// of gvks, then by namespace and name, so that snapshots of the same objects are equal.
+func SnapshotObjects(ctx context.Context, client *K8sClient, namespace string, gvks []schema.GroupVersionKind, opts ...SnapshotOption) (string, error) {
+	options := snapshotOptions{rules: DefaultNormalizationRules}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var objs []*unstructured.Unstructured
+	for _, gvk := range gvks {
+		mapping, err := client.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return "", fmt.Errorf("failed to map %s: %w", gvk, err)
+		}
+		var resource dynamic.ResourceInterface
+		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+			resource = client.DynamicClient.Resource(mapping.Resource).Namespace(namespace)
+		} else {
+			// cluster-scoped resources
+			resource = client.DynamicClient.Resource(mapping.Resource)
+		}
+		list, err := resource.List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to list %s: %w", gvk, err)
+		}
+		kindObjs := make([]*unstructured.Unstructured, 0, len(list.Items))
+		for i := range list.Items {
+			obj := &list.Items[i]
+			// Items of lists may omit their kind.
+			obj.SetGroupVersionKind(gvk)
+			kindObjs = append(kindObjs, obj)
+		}
+		sortObjects(kindObjs)
+		objs = append(objs, kindObjs...)
+	}
+	return snapshot(objs, options.rules)
+}
+
+// DiffSnapshots returns a unified diff of the snapshots a and b returned by SnapshotObjects,
+// or an empty string if they are equal.
+func DiffSnapshots(a, b string) string {
+	if a == b {
+		return ""
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(a),
+		B:        difflib.SplitLines(b),
+		FromFile: "before",
+		ToFile:   "after",
+		Context:  3,
+	})
+	if err != nil {
+		return fmt.Sprintf("failed to diff snapshots: %v", err)
+	}
+	return diff
+}
+
+// sortObjects sorts objs by namespace then name.
+func sortObjects(objs []*unstructured.Unstructured) {
+	slices.SortStableFunc(objs, func(a, b *unstructured.Unstructured) int {
+		return cmp.Or(
+			cmp.Compare(a.GetNamespace(), b.GetNamespace()),
+			cmp.Compare(a.GetName(), b.GetName()),
+		)
+	})
+}
+
+// snapshot returns the YAML documents of objs, in order, normalized with rules.
+func snapshot(objs []*unstructured.Unstructured, rules []NormalizationRule) (string, error) {
+	var b strings.Builder
+	for _, obj := range objs {
+		normalized := normalizeObject(obj, rules)
+		doc, err := yaml.Marshal(normalized.Object)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal %s %s/%s: %w", obj.GetKind(), obj.GetNamespace(), obj.GetName(), err)
+		}
+		b.WriteString("---\n")
+		b.Write(doc)
+	}
+	return b.String(), nil
+}
+
+// normalizeObject returns a copy of obj without the fields matched by rules. Maps left empty by the removal of
+// a field, such as the annotations, are removed as well.
+func normalizeObject(obj *unstructured.Unstructured, rules []NormalizationRule) *unstructured.Unstructured {
+	normalized := obj.DeepCopy()
+	for _, rule := range rules {
+		if len(rule.Path) == 0 {
+			continue
+		}
+		unstructured.RemoveNestedField(normalized.Object, rule.Path...)
+		for parent := rule.Path[:len(rule.Path)-1]; len(parent) > 0; parent = parent[:len(parent)-1] {
+			m, found, err := unstructured.NestedMap(normalized.Object, parent...)
+			if err != nil || !found || len(m) > 0 {
+				break
+			}
+			unstructured.RemoveNestedField(normalized.Object, parent...)
+		}
+	}
+	return normalized
+}
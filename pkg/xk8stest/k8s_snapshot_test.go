@@ -0,0 +1,166 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xk8stest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/serializer/yaml"
+)
+
+func readObject(t *testing.T, path string) *unstructured.Unstructured {
+	t.Helper()
+	manifest, err := os.ReadFile(path)
+	require.NoError(t, err)
+	obj := &unstructured.Unstructured{}
+	_, _, err = yaml.NewDecodingSerializer(unstructured.UnstructuredJSONScheme).Decode(manifest, nil, obj)
+	require.NoError(t, err)
+	return obj
+}
+
+func TestNormalizeObject(t *testing.T) {
+	newObject := func() *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata": map[string]any{
+				"name":            "config",
+				"resourceVersion": "1",
+				"managedFields":   []any{map[string]any{"manager": "kubectl"}},
+				"annotations": map[string]any{
+					"kubectl.kubernetes.io/last-applied-configuration": "{}",
+				},
+				"labels": map[string]any{"app": "otelcol"},
+			},
+			"data":   map[string]any{"key": "value"},
+			"status": map[string]any{"phase": "Active"},
+		}}
+	}
+
+	for _, tt := range []struct {
+		name     string
+		rules    []NormalizationRule
+		removed  [][]string
+		retained [][]string
+	}{
+		{
+			name:  "default rules",
+			rules: DefaultNormalizationRules,
+			removed: [][]string{
+				{"metadata", "resourceVersion"},
+				{"metadata", "managedFields"},
+				{"metadata", "annotations"},
+				{"status"},
+			},
+			retained: [][]string{{"metadata", "name"}, {"metadata", "labels", "app"}, {"data", "key"}},
+		},
+		{
+			name:     "no rules",
+			rules:    nil,
+			retained: [][]string{{"metadata", "resourceVersion"}, {"metadata", "managedFields"}, {"status", "phase"}},
+		},
+		{
+			name:     "custom rule",
+			rules:    []NormalizationRule{{Name: "data key", Path: []string{"data", "key"}}},
+			removed:  [][]string{{"data"}},
+			retained: [][]string{{"metadata", "resourceVersion"}, {"status", "phase"}},
+		},
+		{
+			name:     "parents left empty are removed",
+			rules:    []NormalizationRule{{Name: "app label", Path: []string{"metadata", "labels", "app"}}},
+			removed:  [][]string{{"metadata", "labels"}},
+			retained: [][]string{{"metadata", "name"}, {"metadata", "annotations"}},
+		},
+		{
+			name:     "missing field",
+			rules:    []NormalizationRule{{Name: "missing", Path: []string{"spec", "replicas"}}, {Name: "empty"}},
+			retained: [][]string{{"metadata", "resourceVersion"}, {"data", "key"}, {"status", "phase"}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := newObject()
+			normalized := normalizeObject(obj, tt.rules)
+			for _, path := range tt.removed {
+				_, found, err := unstructured.NestedFieldNoCopy(normalized.Object, path...)
+				require.NoError(t, err)
+				assert.False(t, found, "%v should be removed", path)
+			}
+			for _, path := range tt.retained {
+				_, found, err := unstructured.NestedFieldNoCopy(normalized.Object, path...)
+				require.NoError(t, err)
+				assert.True(t, found, "%v should be retained", path)
+			}
+			assert.Equal(t, newObject(), obj, "the object should not be modified")
+		})
+	}
+}
+
+func TestSnapshotGolden(t *testing.T) {
+	for _, name := range []string{"deployment", "configmap", "service"} {
+		t.Run(name, func(t *testing.T) {
+			obj := readObject(t, filepath.Join("testdata", "snapshot", name+".yaml"))
+			got, err := snapshot([]*unstructured.Unstructured{obj}, DefaultNormalizationRules)
+			require.NoError(t, err)
+
+			want, err := os.ReadFile(filepath.Join("testdata", "snapshot", name+".golden.yaml"))
+			require.NoError(t, err)
+			assert.Equal(t, string(want), got)
+		})
+	}
+}
+
+func TestSnapshotOrder(t *testing.T) {
+	newConfigMap := func(namespace, name string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("v1")
+		obj.SetKind("ConfigMap")
+		obj.SetNamespace(namespace)
+		obj.SetName(name)
+		return obj
+	}
+	objs := []*unstructured.Unstructured{newConfigMap("b", "a"), newConfigMap("a", "b"), newConfigMap("a", "a")}
+	sortObjects(objs)
+
+	got, err := snapshot(objs, DefaultNormalizationRules)
+	require.NoError(t, err)
+	assert.Equal(t, `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+  namespace: a
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: b
+  namespace: a
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: a
+  namespace: b
+`, got)
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	before, err := snapshot([]*unstructured.Unstructured{readObject(t, filepath.Join("testdata", "snapshot", "service.yaml"))}, DefaultNormalizationRules)
+	require.NoError(t, err)
+	assert.Empty(t, DiffSnapshots(before, before))
+
+	changed := readObject(t, filepath.Join("testdata", "snapshot", "service.yaml"))
+	require.NoError(t, unstructured.SetNestedField(changed.Object, "LoadBalancer", "spec", "type"))
+	after, err := snapshot([]*unstructured.Unstructured{changed}, DefaultNormalizationRules)
+	require.NoError(t, err)
+
+	diff := DiffSnapshots(before, after)
+	assert.Contains(t, diff, "--- before\n+++ after\n")
+	assert.Contains(t, diff, "-  type: ClusterIP\n+  type: LoadBalancer\n")
+}
@@ -0,0 +1,202 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"io"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+// DecoderAdapterOption configures optional hooks of the decoder adapters.
+type DecoderAdapterOption func(*decoderAdapterOptions)
+
+type decoderAdapterOptions struct {
+	closeFunc       func() error
+	skippedFunc     func() int64
+	tokenFunc       func() string
+	statsFunc       func() encoding.DecoderStats
+	maxBatchBytes   int
+	offsetSemantics encoding.OffsetSemantics
+	middlewares     []LogsDecoderMiddleware
+}
+
+// WithCloseFunc sets the function called when the adapter is closed.
+// Without this option, closing the adapter is a no-op.
+func WithCloseFunc(f func() error) DecoderAdapterOption {
+	return func(o *decoderAdapterOptions) {
+		o.closeFunc = f
+	}
+}
+
+// WithSkippedFunc sets the function reporting the number of skipped records.
+// The adapter provides encoding.SkipReporting, see encoding.DecoderAs, only when this option is provided.
+func WithSkippedFunc(f func() int64) DecoderAdapterOption {
+	return func(o *decoderAdapterOptions) {
+		o.skippedFunc = f
+	}
+}
+
+// WithOffsetTokenFunc sets the function returning the opaque position of the adapter in the stream.
+// The adapter provides encoding.OpaqueOffsetDecoder, see encoding.DecoderAs, only when this option is provided.
+func WithOffsetTokenFunc(f func() string) DecoderAdapterOption {
+	return func(o *decoderAdapterOptions) {
+		o.tokenFunc = f
+	}
+}
+
+// WithStatsFunc sets the function returning the cumulative statistics of the adapter, e.g. BatchHelper.Stats.
+// The adapter provides encoding.StatsReporter, see encoding.DecoderAs, only when this option is provided.
+func WithStatsFunc(f func() encoding.DecoderStats) DecoderAdapterOption {
+	return func(o *decoderAdapterOptions) {
+		o.statsFunc = f
+	}
+}
+
+// WithMaxBatchBytes splits the batches decoded by logs decoder adapters with SplitLogs, so that the log records
+// of each returned batch add up to at most maxBytes in the OTLP protobuf encoding. While chunks of a batch remain
+// to be returned, the offset and offset token of the adapter are the ones before the batch, so that resuming from
+// them does not lose records. It has no effect on metrics decoder adapters, or when maxBytes is not positive.
+func WithMaxBatchBytes(maxBytes int) DecoderAdapterOption {
+	return func(o *decoderAdapterOptions) {
+		o.maxBatchBytes = maxBytes
+	}
+}
+
+// WithOffsetSemantics sets the semantics of the offsets of the decoder, declared through encoding.OffsetAware, e.g.
+// encoding.OffsetSemanticsBytes for decoders built on ScannerHelper. Adapters declare encoding.OffsetSemanticsOpaque
+// by default.
+func WithOffsetSemantics(semantics encoding.OffsetSemantics) DecoderAdapterOption {
+	return func(o *decoderAdapterOptions) {
+		o.offsetSemantics = semantics
+	}
+}
+
+// adapterHooks holds the optional hooks of the decoder adapters. It provides the optional decoder interfaces matching
+// the hooks that are set through its As method, rather than implementing them, so that a single decoder type covers
+// every combination of hooks.
+type adapterHooks struct {
+	closeFunc   func() error
+	skippedFunc func() int64
+	tokenFunc   func() string
+	statsFunc   func() encoding.DecoderStats
+}
+
+// Close calls the close hook, if any.
+func (h adapterHooks) Close() error {
+	if h.closeFunc == nil {
+		return nil
+	}
+	return h.closeFunc()
+}
+
+// As sets target to the implementation of encoding.SkipReporting, encoding.OpaqueOffsetDecoder or
+// encoding.StatsReporter it points to, when the matching hook is set, see encoding.DecoderAs.
+func (h adapterHooks) As(target any) bool {
+	switch target := target.(type) {
+	case *encoding.SkipReporting:
+		if h.skippedFunc == nil {
+			return false
+		}
+		*target = skippedHook(h.skippedFunc)
+	case *encoding.OpaqueOffsetDecoder:
+		if h.tokenFunc == nil {
+			return false
+		}
+		*target = tokenHook(h.tokenFunc)
+	case *encoding.StatsReporter:
+		if h.statsFunc == nil {
+			return false
+		}
+		*target = statsHook(h.statsFunc)
+	default:
+		return false
+	}
+	return true
+}
+
+type skippedHook func() int64
+
+func (f skippedHook) SkippedRecords() int64 {
+	return f()
+}
+
+type tokenHook func() string
+
+func (f tokenHook) OffsetToken() string {
+	return f()
+}
+
+type statsHook func() encoding.DecoderStats
+
+func (f statsHook) Stats() encoding.DecoderStats {
+	return f()
+}
+
+var (
+	_ io.Closer            = logsDecoderWithHooks{}
+	_ encoding.OffsetAware = logsDecoderWithHooks{}
+	_ io.Closer            = metricsDecoderWithHooks{}
+	_ encoding.OffsetAware = metricsDecoderWithHooks{}
+)
+
+type logsDecoderWithHooks struct {
+	LogsDecoderAdapter
+	adapterHooks
+}
+
+type metricsDecoderWithHooks struct {
+	MetricsDecoderAdapter
+	adapterHooks
+}
+
+func (o decoderAdapterOptions) hooks() adapterHooks {
+	return adapterHooks{closeFunc: o.closeFunc, skippedFunc: o.skippedFunc, tokenFunc: o.tokenFunc, statsFunc: o.statsFunc}
+}
+
+// NewLogsDecoderAdapterWithOptions creates an encoding.LogsDecoder from the provided decode and offset functions.
+// The returned decoder implements io.Closer and encoding.OffsetAware, and provides encoding.SkipReporting,
+// encoding.OpaqueOffsetDecoder and encoding.StatsReporter, see encoding.DecoderAs, when WithSkippedFunc,
+// WithOffsetTokenFunc and WithStatsFunc are provided, respectively. When WithLogsMiddlewares is provided, the decoder
+// is returned wrapped by the middlewares, through which encoding.DecoderAs still finds them.
+func NewLogsDecoderAdapterWithOptions(decode func() (plog.Logs, error), offset func() int64, opts ...DecoderAdapterOption) encoding.LogsDecoder {
+	o := decoderAdapterOptions{offsetSemantics: encoding.OffsetSemanticsOpaque}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.maxBatchBytes > 0 {
+		splitter := &logsSplitter{decode: decode, offset: offset, token: o.tokenFunc, maxBytes: o.maxBatchBytes}
+		decode, offset = splitter.DecodeLogs, splitter.Offset
+		if o.tokenFunc != nil {
+			o.tokenFunc = splitter.OffsetToken
+		}
+	}
+
+	adapter := NewLogsDecoderAdapter(decode, offset)
+	adapter.offsetSemantics = o.offsetSemantics
+	var decoder encoding.LogsDecoder = logsDecoderWithHooks{adapter, o.hooks()}
+	if len(o.middlewares) > 0 {
+		return ChainLogsDecoderMiddlewares(o.middlewares...)(decoder)
+	}
+	return decoder
+}
+
+// NewMetricsDecoderAdapterWithOptions creates an encoding.MetricsDecoder from the provided decode and offset functions.
+// The returned decoder implements io.Closer and encoding.OffsetAware, and provides encoding.SkipReporting,
+// encoding.OpaqueOffsetDecoder and encoding.StatsReporter, see encoding.DecoderAs, when WithSkippedFunc,
+// WithOffsetTokenFunc and WithStatsFunc are provided, respectively.
+func NewMetricsDecoderAdapterWithOptions(decode func() (pmetric.Metrics, error), offset func() int64, opts ...DecoderAdapterOption) encoding.MetricsDecoder {
+	o := decoderAdapterOptions{offsetSemantics: encoding.OffsetSemanticsOpaque}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	adapter := NewMetricsDecoderAdapter(decode, offset)
+	adapter.offsetSemantics = o.offsetSemantics
+	return metricsDecoderWithHooks{adapter, o.hooks()}
+}
@@ -0,0 +1,205 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+// hookCombination is a combination of the optional hooks of the decoder adapters.
+type hookCombination struct {
+	name          string
+	opts          []DecoderAdapterOption
+	expectSkipped bool
+	expectToken   bool
+	expectStats   bool
+}
+
+// hookCombinations returns every combination of the close, skipped, token and stats hooks.
+func hookCombinations(closeFunc func() error) []hookCombination {
+	skippedFunc := func() int64 { return 3 }
+	tokenFunc := func() string { return "page-2" }
+	statsFunc := func() encoding.DecoderStats { return encoding.DecoderStats{RecordsDecoded: 7} }
+
+	combinations := []hookCombination{{name: "no hooks"}}
+	for _, hook := range []struct {
+		name  string
+		opt   DecoderAdapterOption
+		apply func(*hookCombination)
+	}{
+		{name: "close", opt: WithCloseFunc(closeFunc), apply: func(*hookCombination) {}},
+		{name: "skipped", opt: WithSkippedFunc(skippedFunc), apply: func(c *hookCombination) { c.expectSkipped = true }},
+		{name: "token", opt: WithOffsetTokenFunc(tokenFunc), apply: func(c *hookCombination) { c.expectToken = true }},
+		{name: "stats", opt: WithStatsFunc(statsFunc), apply: func(c *hookCombination) { c.expectStats = true }},
+	} {
+		for _, c := range slices.Clone(combinations) {
+			c.name = strings.TrimPrefix(strings.TrimPrefix(c.name, "no hooks")+" "+hook.name, " ")
+			c.opts = append(slices.Clone(c.opts), hook.opt)
+			hook.apply(&c)
+			combinations = append(combinations, c)
+		}
+	}
+	return combinations
+}
+
+// assertHooks asserts that decoder provides the optional interfaces of the hooks of c, and only them.
+func assertHooks(t *testing.T, decoder any, c hookCombination) {
+	reporter, ok := encoding.DecoderAs[encoding.SkipReporting](decoder)
+	require.Equal(t, c.expectSkipped, ok)
+	if ok {
+		assert.Equal(t, int64(3), reporter.SkippedRecords())
+	}
+
+	opaque, ok := encoding.DecoderAs[encoding.OpaqueOffsetDecoder](decoder)
+	require.Equal(t, c.expectToken, ok)
+	if ok {
+		assert.Equal(t, "page-2", opaque.OffsetToken())
+	}
+
+	stats, ok := encoding.DecoderAs[encoding.StatsReporter](decoder)
+	require.Equal(t, c.expectStats, ok)
+	if ok {
+		assert.Equal(t, int64(7), stats.Stats().RecordsDecoded)
+	}
+}
+
+func TestLogsDecoderAdapterWithOptions(t *testing.T) {
+	decode := func() (plog.Logs, error) { return plog.NewLogs(), io.EOF }
+	offset := func() int64 { return 42 }
+
+	var closed bool
+	closeFunc := func() error {
+		closed = true
+		return nil
+	}
+
+	combinations := hookCombinations(closeFunc)
+	require.Len(t, combinations, 16)
+	for _, c := range combinations {
+		t.Run(c.name, func(t *testing.T) {
+			closed = false
+			decoder := NewLogsDecoderAdapterWithOptions(decode, offset, c.opts...)
+
+			// Every combination declares the semantics of its offsets, opaque by default
+			assert.Equal(t, encoding.OffsetSemanticsOpaque, decoder.(encoding.OffsetAware).OffsetSemantics())
+			withSemantics := NewLogsDecoderAdapterWithOptions(decode, offset, append(slices.Clone(c.opts), WithOffsetSemantics(encoding.OffsetSemanticsRecords))...)
+			assert.Equal(t, encoding.OffsetSemanticsRecords, withSemantics.(encoding.OffsetAware).OffsetSemantics())
+
+			_, err := decoder.DecodeLogs()
+			assert.ErrorIs(t, err, io.EOF)
+			assert.Equal(t, int64(42), decoder.Offset())
+
+			// Every combination can be closed, calling the close hook when set
+			require.NoError(t, decoder.(io.Closer).Close())
+			assert.Equal(t, strings.Contains(c.name, "close"), closed)
+
+			assertHooks(t, decoder, c)
+			// The hooks are still found through middlewares
+			wrapped := NewLogsDecoderAdapterWithOptions(decode, offset, append(slices.Clone(c.opts), WithLogsMiddlewares(NewSplitMiddleware(1024)))...)
+			assertHooks(t, wrapped, c)
+		})
+	}
+}
+
+func TestMetricsDecoderAdapterWithOptions(t *testing.T) {
+	decode := func() (pmetric.Metrics, error) { return pmetric.NewMetrics(), io.EOF }
+	offset := func() int64 { return 42 }
+
+	closeFunc := func() error { return assert.AnError }
+
+	for _, c := range hookCombinations(closeFunc) {
+		t.Run(c.name, func(t *testing.T) {
+			decoder := NewMetricsDecoderAdapterWithOptions(decode, offset, c.opts...)
+
+			// Every combination declares the semantics of its offsets, opaque by default
+			assert.Equal(t, encoding.OffsetSemanticsOpaque, decoder.(encoding.OffsetAware).OffsetSemantics())
+			withSemantics := NewMetricsDecoderAdapterWithOptions(decode, offset, append(slices.Clone(c.opts), WithOffsetSemantics(encoding.OffsetSemanticsRecords))...)
+			assert.Equal(t, encoding.OffsetSemanticsRecords, withSemantics.(encoding.OffsetAware).OffsetSemantics())
+
+			_, err := decoder.DecodeMetrics()
+			assert.ErrorIs(t, err, io.EOF)
+			assert.Equal(t, int64(42), decoder.Offset())
+
+			// Every combination can be closed, returning the error of the close hook when set
+			err = decoder.(io.Closer).Close()
+			if strings.Contains(c.name, "close") {
+				assert.ErrorIs(t, err, assert.AnError)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assertHooks(t, decoder, c)
+		})
+	}
+}
+
+// newPagedLogsDecoder decodes pages of records, one batch per page, identifying its position by a page token.
+func newPagedLogsDecoder(pages map[string][]string, next map[string]string, options ...encoding.DecoderOption) encoding.LogsDecoder {
+	token := "first"
+	if t := encoding.NewDecoderOptions(options...).OffsetToken; t != "" {
+		token = t
+	}
+	decode := func() (plog.Logs, error) {
+		page, ok := pages[token]
+		if !ok {
+			return plog.NewLogs(), io.EOF
+		}
+		logs := plog.NewLogs()
+		records := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+		for _, body := range page {
+			records.AppendEmpty().Body().SetStr(body)
+		}
+		token = next[token]
+		return logs, nil
+	}
+	return NewLogsDecoderAdapterWithOptions(decode, func() int64 { return 0 }, WithOffsetTokenFunc(func() string { return token }))
+}
+
+func TestOffsetTokenResume(t *testing.T) {
+	pages := map[string][]string{"first": {"a", "b"}, "cursor-x": {"c"}, "cursor-y": {"d", "e"}}
+	next := map[string]string{"first": "cursor-x", "cursor-x": "cursor-y", "cursor-y": "end"}
+
+	decoder := newPagedLogsDecoder(pages, next)
+	logs, err := decoder.DecodeLogs()
+	require.NoError(t, err)
+	assert.Equal(t, 2, logs.LogRecordCount())
+
+	// Restart from the position of the first decoder
+	resumeOption := encoding.ResumeOption(decoder)
+	assert.Equal(t, "cursor-x", encoding.NewDecoderOptions(resumeOption).OffsetToken)
+	decoder = newPagedLogsDecoder(pages, next, resumeOption)
+
+	var bodies []string
+	for {
+		logs, err := decoder.DecodeLogs()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		records := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+		for i := 0; i < records.Len(); i++ {
+			bodies = append(bodies, records.At(i).Body().Str())
+		}
+	}
+	assert.Equal(t, []string{"c", "d", "e"}, bodies)
+	assert.Equal(t, "end", opaqueOffsetToken(t, decoder))
+}
+
+// opaqueOffsetToken returns the offset token of decoder, which must provide encoding.OpaqueOffsetDecoder.
+func opaqueOffsetToken(t *testing.T, decoder any) string {
+	opaque, ok := encoding.DecoderAs[encoding.OpaqueOffsetDecoder](decoder)
+	require.True(t, ok)
+	return opaque.OffsetToken()
+}
@@ -0,0 +1,127 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+// timeNow is replaced in tests to simulate decoding time.
+var timeNow = time.Now
+
+const (
+	// adaptiveMinScale is the lowest fraction of the configured thresholds adaptive batching goes down to.
+	adaptiveMinScale = 1.0 / 64
+	// adaptiveDecrease is the factor applied to the thresholds after a batch slower than the target.
+	adaptiveDecrease = 0.5
+	// adaptiveIncrease is the factor applied to the thresholds after a batch faster than half the target.
+	adaptiveIncrease = 1.25
+
+	// latencyMinScale and latencyMaxScale bound the fraction of the configured thresholds latency-driven batching
+	// scales them to.
+	latencyMinScale = 1.0 / 16
+	latencyMaxScale = 16
+	// latencyIncrease is the factor applied to the thresholds after a batch flushed while downstream is slower than
+	// the target.
+	latencyIncrease = 2
+	// latencyDecrease is the factor applied to the thresholds after a batch flushed while downstream is faster than
+	// half the target.
+	latencyDecrease = 0.75
+)
+
+// adaptiveThresholds scales the flush thresholds of a BatchHelper with the time its batches take to decode.
+//
+// The control loop runs once per batch: the decode time of a batch is measured from its first increment to Reset.
+// A batch slower than the target halves the thresholds, down to 1/64 of the configured ones, so that the decoder
+// yields more often. A batch faster than half the target raises them by a quarter, up to the configured ones.
+// Batches in between leave them unchanged, which keeps the thresholds from oscillating around the target.
+type adaptiveThresholds struct {
+	target time.Duration
+	// scale is the fraction of the configured thresholds in effect, in [adaptiveMinScale, 1].
+	scale float64
+	// batchStart is the time the current batch was first incremented, zero before then.
+	batchStart time.Time
+}
+
+// newAdaptiveThresholds returns the adaptiveThresholds for target, or nil when adaptive batching is disabled.
+func newAdaptiveThresholds(target time.Duration) *adaptiveThresholds {
+	if target <= 0 {
+		return nil
+	}
+	return &adaptiveThresholds{target: target, scale: 1}
+}
+
+// start records the start of the current batch, if not already started.
+func (a *adaptiveThresholds) start() {
+	if a.batchStart.IsZero() {
+		a.batchStart = timeNow()
+	}
+}
+
+// adjust scales the thresholds according to the decode time of the batch being reset.
+func (a *adaptiveThresholds) adjust() {
+	if a.batchStart.IsZero() {
+		return
+	}
+	elapsed := timeNow().Sub(a.batchStart)
+	a.batchStart = time.Time{}
+	switch {
+	case elapsed > a.target:
+		a.scale = max(a.scale*adaptiveDecrease, adaptiveMinScale)
+	case elapsed < a.target/2:
+		a.scale = min(a.scale*adaptiveIncrease, 1)
+	}
+}
+
+// apply returns threshold scaled to the current fraction, keeping at least 1 for enabled thresholds.
+func (a *adaptiveThresholds) apply(threshold int64) int64 {
+	if a == nil || threshold <= 0 {
+		return threshold
+	}
+	return max(int64(float64(threshold)*a.scale), 1)
+}
+
+// latencyThresholds scales the flush thresholds of a BatchHelper with the latency of the consumers of its batches.
+//
+// The control loop runs once per batch, on Reset, reading the latency reported by the control signal. A latency
+// above the target doubles the thresholds, up to 16 times the configured ones, so that slow consumers receive fewer
+// but larger batches. A latency under half the target lowers them by a quarter, down to 1/16 of the configured ones,
+// so that fast consumers receive data sooner. Latencies in between, or unknown, leave them unchanged.
+type latencyThresholds struct {
+	target  time.Duration
+	latency encoding.DownstreamLatencyFunc
+	// scale is the fraction of the configured thresholds in effect, in [latencyMinScale, latencyMaxScale].
+	scale float64
+}
+
+// newLatencyThresholds returns the latencyThresholds for target and latency, or nil when latency-driven batching
+// is disabled.
+func newLatencyThresholds(target time.Duration, latency encoding.DownstreamLatencyFunc) *latencyThresholds {
+	if target <= 0 || latency == nil {
+		return nil
+	}
+	return &latencyThresholds{target: target, latency: latency, scale: 1}
+}
+
+// adjust scales the thresholds according to the current downstream latency.
+func (l *latencyThresholds) adjust() {
+	latency := l.latency()
+	switch {
+	case latency <= 0:
+	case latency > l.target:
+		l.scale = min(l.scale*latencyIncrease, latencyMaxScale)
+	case latency < l.target/2:
+		l.scale = max(l.scale*latencyDecrease, latencyMinScale)
+	}
+}
+
+// apply returns threshold scaled to the current fraction, keeping at least 1 for enabled thresholds.
+func (l *latencyThresholds) apply(threshold int64) int64 {
+	if l == nil || threshold <= 0 {
+		return threshold
+	}
+	return max(int64(float64(threshold)*l.scale), 1)
+}
@@ -0,0 +1,193 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+// decodeBatch simulates decoding a batch taking perItem for each item, until the helper flushes.
+// It returns the size of the batch.
+func decodeBatch(helper *BatchHelper, now *time.Time, perItem time.Duration) int64 {
+	var items int64
+	for {
+		helper.IncrementItems(1)
+		*now = now.Add(perItem)
+		items++
+		if helper.ShouldFlush() {
+			helper.Reset()
+			return items
+		}
+	}
+}
+
+func TestStreamBatchHelper_AdaptiveBatching(t *testing.T) {
+	now := time.Unix(0, 0)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	helper := NewBatchHelper(
+		encoding.WithFlushBytes(0),
+		encoding.WithFlushItems(100),
+		encoding.WithAdaptiveBatching(50*time.Millisecond),
+	)
+	_, flushItems := helper.FlushThresholds()
+	assert.Equal(t, int64(100), flushItems)
+
+	// Slow decoding lowers the thresholds until batches fit the target.
+	var slow []int64
+	for range 7 {
+		slow = append(slow, decodeBatch(helper, &now, 10*time.Millisecond))
+	}
+	assert.Equal(t, []int64{100, 50, 25, 12, 6, 3, 3}, slow)
+	assert.Equal(t, int64(100), helper.Options().FlushItems)
+
+	// Fast decoding raises them back up to the configured ones.
+	var last int64
+	for range 20 {
+		size := decodeBatch(helper, &now, 100*time.Microsecond)
+		assert.GreaterOrEqual(t, size, last)
+		last = size
+	}
+	assert.Equal(t, int64(100), last)
+	_, flushItems = helper.FlushThresholds()
+	assert.Equal(t, int64(100), flushItems)
+}
+
+func TestStreamBatchHelper_AdaptiveBatchingDisabled(t *testing.T) {
+	now := time.Unix(0, 0)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	helper := NewBatchHelper(encoding.WithFlushBytes(0), encoding.WithFlushItems(10))
+	for range 3 {
+		assert.Equal(t, int64(10), decodeBatch(helper, &now, time.Second))
+	}
+}
+
+func TestStreamBatchHelper_AdaptiveBatchingOptions(t *testing.T) {
+	now := time.Unix(0, 0)
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = time.Now })
+
+	helper := NewBatchHelper(
+		encoding.WithFlushBytes(1000),
+		encoding.WithFlushItems(10),
+		encoding.WithAdaptiveBatching(time.Millisecond),
+	)
+	decodeBatch(helper, &now, time.Second)
+	flushBytes, flushItems := helper.FlushThresholds()
+	assert.Equal(t, int64(500), flushBytes)
+	assert.Equal(t, int64(5), flushItems)
+
+	// Updating thresholds keeps the adapted scale.
+	helper.UpdateOptions(encoding.WithFlushItems(20))
+	_, flushItems = helper.FlushThresholds()
+	assert.Equal(t, int64(10), flushItems)
+
+	// Changing the target starts over from the configured thresholds.
+	helper.UpdateOptions(encoding.WithAdaptiveBatching(time.Minute))
+	_, flushItems = helper.FlushThresholds()
+	assert.Equal(t, int64(20), flushItems)
+
+	// Empty batches do not adapt the thresholds.
+	now = now.Add(time.Hour)
+	helper.Reset()
+	_, flushItems = helper.FlushThresholds()
+	assert.Equal(t, int64(20), flushItems)
+}
+
+func TestStreamBatchHelper_DownstreamLatencyBatching(t *testing.T) {
+	var latency time.Duration
+	helper := NewBatchHelper(
+		encoding.WithFlushBytes(1600),
+		encoding.WithFlushItems(16),
+		encoding.WithDownstreamLatencyBatching(100*time.Millisecond, func() time.Duration { return latency }),
+	)
+	flush := func() (int64, int64) {
+		helper.IncrementItems(1)
+		helper.Reset()
+		return helper.FlushThresholds()
+	}
+
+	// An unknown latency leaves the thresholds unchanged.
+	flushBytes, flushItems := flush()
+	assert.Equal(t, int64(1600), flushBytes)
+	assert.Equal(t, int64(16), flushItems)
+
+	// A slow downstream raises the thresholds, up to 16 times the configured ones.
+	latency = time.Second
+	var raised []int64
+	for range 6 {
+		_, flushItems = flush()
+		raised = append(raised, flushItems)
+	}
+	assert.Equal(t, []int64{32, 64, 128, 256, 256, 256}, raised)
+	flushBytes, _ = helper.FlushThresholds()
+	assert.Equal(t, int64(25600), flushBytes)
+	assert.Equal(t, int64(16), helper.Options().FlushItems)
+
+	// A latency between half the target and the target leaves them unchanged.
+	latency = 75 * time.Millisecond
+	_, flushItems = flush()
+	assert.Equal(t, int64(256), flushItems)
+
+	// A fast downstream lowers them, down to 1/16 of the configured ones.
+	latency = 10 * time.Millisecond
+	var last int64 = 256
+	for range 30 {
+		_, flushItems = flush()
+		assert.LessOrEqual(t, flushItems, last)
+		last = flushItems
+	}
+	flushBytes, flushItems = helper.FlushThresholds()
+	assert.Equal(t, int64(100), flushBytes)
+	assert.Equal(t, int64(1), flushItems)
+}
+
+func TestStreamBatchHelper_DownstreamLatencyBatchingOptions(t *testing.T) {
+	var calls int
+	slow := func() time.Duration {
+		calls++
+		return time.Second
+	}
+	helper := NewBatchHelper(
+		encoding.WithFlushItems(10),
+		encoding.WithDownstreamLatencyBatching(time.Millisecond, slow),
+	)
+
+	// Empty batches neither query the latency nor adjust the thresholds.
+	helper.Reset()
+	assert.Zero(t, calls)
+	helper.IncrementItems(1)
+	helper.Reset()
+	assert.Equal(t, 1, calls)
+	_, flushItems := helper.FlushThresholds()
+	assert.Equal(t, int64(20), flushItems)
+
+	// Replacing the control signal keeps the scale reached.
+	helper.UpdateOptions(encoding.WithDownstreamLatencyBatching(time.Millisecond, func() time.Duration { return 0 }))
+	helper.IncrementItems(1)
+	helper.Reset()
+	_, flushItems = helper.FlushThresholds()
+	assert.Equal(t, int64(20), flushItems)
+	assert.Equal(t, 1, calls)
+
+	// Changing the target starts over from the configured thresholds.
+	helper.UpdateOptions(encoding.WithDownstreamLatencyBatching(time.Minute, slow))
+	_, flushItems = helper.FlushThresholds()
+	assert.Equal(t, int64(10), flushItems)
+
+	// Without a control signal, thresholds are not adjusted.
+	helper = NewBatchHelper(encoding.WithFlushItems(10), encoding.WithDownstreamLatencyBatching(time.Millisecond, nil))
+	helper.IncrementItems(1)
+	helper.Reset()
+	_, flushItems = helper.FlushThresholds()
+	assert.Equal(t, int64(10), flushItems)
+}
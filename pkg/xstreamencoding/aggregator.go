@@ -0,0 +1,194 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+const (
+	defaultAggregateFlushItems    = 8192
+	defaultAggregateFlushBytes    = 4 * 1024 * 1024 // 4MiB
+	defaultAggregateFlushInterval = time.Second
+)
+
+// ErrAggregatorClosed is returned by BatchAggregator.Add once the aggregator is closed.
+var ErrAggregatorClosed = errors.New("batch aggregator is closed")
+
+// AggregatorOption configures a BatchAggregator.
+type AggregatorOption func(*aggregatorOptions)
+
+type aggregatorOptions struct {
+	flushItems    int64
+	flushBytes    int64
+	flushInterval time.Duration
+}
+
+// WithAggregateFlushItems sets the number of log records from which a combined batch is emitted, 8192 by default.
+// Zero or negative disables the threshold.
+func WithAggregateFlushItems(items int64) AggregatorOption {
+	return func(o *aggregatorOptions) {
+		o.flushItems = items
+	}
+}
+
+// WithAggregateFlushBytes sets the size in bytes from which a combined batch is emitted, as estimated by
+// EstimateLogsSize, 4MiB by default. Zero or negative disables the threshold.
+func WithAggregateFlushBytes(bytes int64) AggregatorOption {
+	return func(o *aggregatorOptions) {
+		o.flushBytes = bytes
+	}
+}
+
+// WithAggregateFlushInterval sets the maximum time log records wait in a combined batch before it is emitted,
+// 1s by default. Zero or negative disables the interval.
+func WithAggregateFlushInterval(interval time.Duration) AggregatorOption {
+	return func(o *aggregatorOptions) {
+		o.flushInterval = interval
+	}
+}
+
+// BatchAggregator coalesces the batches of several decoders, e.g. one per file of a receiver, into combined batches
+// emitted to a shared sink once they reach the flush items or bytes thresholds, or once the flush interval elapsed
+// since their first log records were added. This avoids emitting many tiny batches from sources each holding few
+// records. Log records keep their resource and scope grouping.
+//
+// Batches are emitted one at a time and in order. Adding to a full batch blocks until the sink consumed the
+// previous batch, so that a slow sink applies backpressure to the decoders.
+// Safe for concurrent use.
+type BatchAggregator struct {
+	sink    func(context.Context, plog.Logs) error
+	options aggregatorOptions
+	timer   *time.Timer
+
+	// mu guards the combined batch under construction.
+	mu      sync.Mutex
+	pending plog.Logs
+	items   int64
+	bytes   int64
+	closed  bool
+	// err holds the errors of the sink for batches emitted on interval, returned by the next call.
+	err error
+
+	// sinkMu serializes the calls to sink. It is acquired while holding mu so that batches are emitted in order.
+	sinkMu sync.Mutex
+}
+
+// NewBatchAggregator creates a BatchAggregator emitting combined batches to sink, which is never called concurrently.
+func NewBatchAggregator(sink func(context.Context, plog.Logs) error, opts ...AggregatorOption) *BatchAggregator {
+	a := &BatchAggregator{
+		sink: sink,
+		options: aggregatorOptions{
+			flushItems:    defaultAggregateFlushItems,
+			flushBytes:    defaultAggregateFlushBytes,
+			flushInterval: defaultAggregateFlushInterval,
+		},
+		pending: plog.NewLogs(),
+	}
+	for _, opt := range opts {
+		opt(&a.options)
+	}
+	a.timer = time.AfterFunc(time.Hour, a.flushOnInterval)
+	a.timer.Stop()
+	return a
+}
+
+// Add moves the log records of ld into the combined batch, leaving ld empty, and emits the combined batch to the
+// sink when it reaches a flush threshold. It returns the error of the sink, along with the errors of batches emitted
+// on interval since the previous call.
+func (a *BatchAggregator) Add(ctx context.Context, ld plog.Logs) error {
+	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return ErrAggregatorClosed
+	}
+	err := a.takeErr()
+	count := int64(ld.LogRecordCount())
+	if count == 0 {
+		a.mu.Unlock()
+		return err
+	}
+
+	if a.items == 0 && a.options.flushInterval > 0 {
+		a.timer.Reset(a.options.flushInterval)
+	}
+	a.items += count
+	if a.options.flushBytes > 0 {
+		a.bytes += int64(EstimateLogsSize(ld))
+	}
+	ld.ResourceLogs().MoveAndAppendTo(a.pending.ResourceLogs())
+
+	if !a.shouldFlush() {
+		a.mu.Unlock()
+		return err
+	}
+	return errors.Join(err, a.emit(ctx))
+}
+
+// Flush emits the combined batch to the sink, if not empty.
+func (a *BatchAggregator) Flush(ctx context.Context) error {
+	a.mu.Lock()
+	err := a.takeErr()
+	if a.items == 0 {
+		a.mu.Unlock()
+		return err
+	}
+	return errors.Join(err, a.emit(ctx))
+}
+
+// Close emits the combined batch to the sink, if not empty, and stops the aggregator. Later calls to Add return
+// ErrAggregatorClosed. It is safe to call multiple times.
+func (a *BatchAggregator) Close(ctx context.Context) error {
+	a.mu.Lock()
+	a.closed = true
+	a.timer.Stop()
+	a.mu.Unlock()
+	return a.Flush(ctx)
+}
+
+// shouldFlush reports whether the combined batch reached a flush threshold. It must be called with mu held.
+func (a *BatchAggregator) shouldFlush() bool {
+	return (a.options.flushItems > 0 && a.items >= a.options.flushItems) ||
+		(a.options.flushBytes > 0 && a.bytes >= a.options.flushBytes)
+}
+
+// emit takes the combined batch and emits it to the sink. It must be called with mu held, which it releases.
+func (a *BatchAggregator) emit(ctx context.Context) error {
+	batch := a.pending
+	a.pending = plog.NewLogs()
+	a.items, a.bytes = 0, 0
+	a.timer.Stop()
+
+	a.sinkMu.Lock()
+	a.mu.Unlock()
+	defer a.sinkMu.Unlock()
+	return a.sink(ctx, batch)
+}
+
+// flushOnInterval emits the combined batch once the flush interval elapsed, recording the error of the sink.
+func (a *BatchAggregator) flushOnInterval() {
+	a.mu.Lock()
+	if a.items == 0 {
+		// The batch was emitted by Add or Flush while the timer fired.
+		a.mu.Unlock()
+		return
+	}
+	if err := a.emit(context.Background()); err != nil {
+		a.mu.Lock()
+		a.err = errors.Join(a.err, err)
+		a.mu.Unlock()
+	}
+}
+
+// takeErr returns and clears the errors of batches emitted on interval. It must be called with mu held.
+func (a *BatchAggregator) takeErr() error {
+	err := a.err
+	a.err = nil
+	return err
+}
@@ -0,0 +1,206 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+// recordingSink records the batches emitted by a BatchAggregator.
+type recordingSink struct {
+	mu      sync.Mutex
+	batches []plog.Logs
+	err     error
+}
+
+func (s *recordingSink) consume(_ context.Context, ld plog.Logs) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, ld)
+	return s.err
+}
+
+func (s *recordingSink) counts() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make([]int, 0, len(s.batches))
+	for _, batch := range s.batches {
+		counts = append(counts, batch.LogRecordCount())
+	}
+	return counts
+}
+
+// newLinesDecoder returns a logs decoder emitting a log record per line of input, under a resource named after source.
+func newLinesDecoder(t *testing.T, source, input string, opts ...encoding.DecoderOption) encoding.LogsDecoder {
+	helper, err := NewScannerHelper(strings.NewReader(input), opts...)
+	require.NoError(t, err)
+	decode := func() (plog.Logs, error) {
+		ld := plog.NewLogs()
+		rl := ld.ResourceLogs().AppendEmpty()
+		rl.Resource().Attributes().PutStr("log.file.name", source)
+		records := rl.ScopeLogs().AppendEmpty().LogRecords()
+		for {
+			line, flush, err := helper.ScanString()
+			if line != "" {
+				records.AppendEmpty().Body().SetStr(line)
+			}
+			if err != nil {
+				if errors.Is(err, io.EOF) && records.Len() > 0 {
+					return ld, nil
+				}
+				return ld, err
+			}
+			if flush {
+				return ld, nil
+			}
+		}
+	}
+	return NewLogsDecoderAdapter(decode, helper.Offset)
+}
+
+func TestBatchAggregator_multipleDecoders(t *testing.T) {
+	sink := &recordingSink{}
+	aggregator := NewBatchAggregator(sink.consume, WithAggregateFlushItems(100), WithAggregateFlushInterval(0))
+
+	const decoders, lines = 8, 130
+	var wg sync.WaitGroup
+	for i := range decoders {
+		wg.Go(func() {
+			// Each decoder flushes tiny batches of 3 records
+			decoder := newLinesDecoder(t, fmt.Sprintf("file-%d.log", i), strings.Repeat("line\n", lines), encoding.WithFlushItems(3))
+			for {
+				ld, err := decoder.DecodeLogs()
+				if errors.Is(err, io.EOF) {
+					return
+				}
+				assert.NoError(t, err)
+				assert.NoError(t, aggregator.Add(t.Context(), ld))
+			}
+		})
+	}
+	wg.Wait()
+	require.NoError(t, aggregator.Close(t.Context()))
+
+	// Batches are emitted at the threshold, or past it by less than the batch of one decoder, except the last one
+	counts := sink.counts()
+	total := 0
+	for i, count := range counts {
+		total += count
+		if i < len(counts)-1 {
+			assert.GreaterOrEqual(t, count, 100)
+			assert.Less(t, count, 103)
+		}
+	}
+	assert.Equal(t, decoders*lines, total)
+}
+
+func TestBatchAggregator_interleavedDecoders(t *testing.T) {
+	sink := &recordingSink{}
+	aggregator := NewBatchAggregator(sink.consume, WithAggregateFlushItems(12), WithAggregateFlushInterval(0))
+
+	decoders := make([]encoding.LogsDecoder, 4)
+	for i := range decoders {
+		decoders[i] = newLinesDecoder(t, fmt.Sprintf("file-%d.log", i), "a\nb\nc\nd\ne\nf\n", encoding.WithFlushItems(3))
+	}
+	// Decoders take turns, as when reading files at the same pace
+	for range 2 {
+		for _, decoder := range decoders {
+			ld, err := decoder.DecodeLogs()
+			require.NoError(t, err)
+			require.NoError(t, aggregator.Add(t.Context(), ld))
+		}
+	}
+	require.NoError(t, aggregator.Close(t.Context()))
+	require.Equal(t, []int{12, 12}, sink.counts())
+
+	// Combined batches hold the resources of all decoders, in the order they were added
+	for _, batch := range sink.batches {
+		var sources []string
+		for i := 0; i < batch.ResourceLogs().Len(); i++ {
+			source, ok := batch.ResourceLogs().At(i).Resource().Attributes().Get("log.file.name")
+			require.True(t, ok)
+			sources = append(sources, source.Str())
+		}
+		assert.Equal(t, []string{"file-0.log", "file-1.log", "file-2.log", "file-3.log"}, sources)
+	}
+}
+
+func TestBatchAggregator_flushBytes(t *testing.T) {
+	ld := sizedLogs(1, 500)
+	size := int64(EstimateLogsSize(ld))
+
+	sink := &recordingSink{}
+	aggregator := NewBatchAggregator(sink.consume, WithAggregateFlushItems(0), WithAggregateFlushBytes(2*size+1), WithAggregateFlushInterval(0))
+	require.NoError(t, aggregator.Add(t.Context(), ld))
+	assert.Zero(t, ld.LogRecordCount(), "added logs are moved")
+	require.NoError(t, aggregator.Add(t.Context(), sizedLogs(1, 500)))
+	assert.Empty(t, sink.counts())
+
+	require.NoError(t, aggregator.Add(t.Context(), sizedLogs(1, 500)))
+	assert.Equal(t, []int{3}, sink.counts())
+}
+
+func TestBatchAggregator_flushInterval(t *testing.T) {
+	sink := &recordingSink{}
+	aggregator := NewBatchAggregator(sink.consume, WithAggregateFlushItems(100), WithAggregateFlushInterval(20*time.Millisecond))
+	defer func() { assert.NoError(t, aggregator.Close(t.Context())) }()
+
+	require.NoError(t, aggregator.Add(t.Context(), sizedLogs(2, 10)))
+	require.NoError(t, aggregator.Add(t.Context(), sizedLogs(3, 10)))
+	assert.Eventually(t, func() bool {
+		return len(sink.counts()) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []int{5}, sink.counts())
+
+	// The interval starts over with the next batch
+	require.NoError(t, aggregator.Add(t.Context(), sizedLogs(1, 10)))
+	assert.Eventually(t, func() bool {
+		return len(sink.counts()) == 2
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []int{5, 1}, sink.counts())
+}
+
+func TestBatchAggregator_sinkErrors(t *testing.T) {
+	errSink := errors.New("sink failed")
+	sink := &recordingSink{err: errSink}
+	aggregator := NewBatchAggregator(sink.consume, WithAggregateFlushItems(2), WithAggregateFlushInterval(10*time.Millisecond))
+
+	require.NoError(t, aggregator.Add(t.Context(), sizedLogs(1, 10)))
+	require.ErrorIs(t, aggregator.Add(t.Context(), sizedLogs(1, 10)), errSink)
+
+	// Errors of batches emitted on interval are returned by the next call
+	require.NoError(t, aggregator.Add(t.Context(), sizedLogs(1, 10)))
+	require.Eventually(t, func() bool {
+		return len(sink.counts()) == 2
+	}, time.Second, 5*time.Millisecond)
+	require.ErrorIs(t, aggregator.Flush(t.Context()), errSink)
+	require.NoError(t, aggregator.Flush(t.Context()))
+}
+
+func TestBatchAggregator_close(t *testing.T) {
+	sink := &recordingSink{}
+	aggregator := NewBatchAggregator(sink.consume)
+
+	require.NoError(t, aggregator.Add(t.Context(), plog.NewLogs()))
+	require.NoError(t, aggregator.Add(t.Context(), sizedLogs(2, 10)))
+	require.NoError(t, aggregator.Close(t.Context()))
+	assert.Equal(t, []int{2}, sink.counts())
+
+	require.ErrorIs(t, aggregator.Add(t.Context(), sizedLogs(1, 10)), ErrAggregatorClosed)
+	require.NoError(t, aggregator.Close(t.Context()))
+	assert.Equal(t, []int{2}, sink.counts())
+}
@@ -0,0 +1,149 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+// smallFile is the content of a small file, as processed by receivers decoding many of them per minute.
+var smallFile = strings.Repeat("2024-01-02T03:04:05Z INFO request served in 12ms\n", 8)
+
+// scanToEOF scans all the records of helper.
+func scanToEOF(b *testing.B, helper *ScannerHelper) {
+	for {
+		_, _, err := helper.ScanBytes()
+		if err == io.EOF {
+			return
+		}
+		require.NoError(b, err)
+	}
+}
+
+func BenchmarkScannerHelper_fresh(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		helper, err := NewScannerHelper(strings.NewReader(smallFile), encoding.WithFlushItems(100))
+		require.NoError(b, err)
+		scanToEOF(b, helper)
+	}
+}
+
+func BenchmarkScannerHelper_pooled(b *testing.B) {
+	pool := sync.Pool{}
+	b.ReportAllocs()
+	for b.Loop() {
+		reader := strings.NewReader(smallFile)
+		helper, ok := pool.Get().(*ScannerHelper)
+		if ok {
+			require.NoError(b, helper.Reset(reader, encoding.WithFlushItems(100)))
+		} else {
+			var err error
+			helper, err = NewScannerHelper(reader, encoding.WithFlushItems(100))
+			require.NoError(b, err)
+		}
+		scanToEOF(b, helper)
+		pool.Put(helper)
+	}
+}
+
+// sizedLogs returns a batch of records whose bodies are bodySize bytes long.
+func sizedLogs(records, bodySize int) plog.Logs {
+	ld := plog.NewLogs()
+	sl := ld.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty()
+	for range records {
+		lr := sl.LogRecords().AppendEmpty()
+		lr.SetObservedTimestamp(pcommon.Timestamp(1))
+		lr.Body().SetStr(strings.Repeat("x", bodySize))
+		lr.Attributes().PutStr("log.file.name", "access.log")
+	}
+	return ld
+}
+
+func BenchmarkEstimateLogsSize(b *testing.B) {
+	for _, bodySize := range []int{50, 4096} {
+		ld := sizedLogs(1000, bodySize)
+		b.Run(fmt.Sprintf("estimate/body=%d", bodySize), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				EstimateLogsSize(ld)
+			}
+		})
+		b.Run(fmt.Sprintf("marshal/body=%d", bodySize), func(b *testing.B) {
+			var marshaler plog.ProtoMarshaler
+			b.ReportAllocs()
+			for b.Loop() {
+				_, err := marshaler.MarshalLogs(ld)
+				require.NoError(b, err)
+			}
+		})
+	}
+}
+
+// latencyReader returns at most chunkSize bytes of reader per read, each taking latency, e.g. reads from object
+// storage over TLS.
+type latencyReader struct {
+	reader    io.Reader
+	chunkSize int
+	latency   time.Duration
+}
+
+func (r *latencyReader) Read(p []byte) (int, error) {
+	time.Sleep(r.latency)
+	return r.reader.Read(p[:min(len(p), r.chunkSize)])
+}
+
+// BenchmarkScannerHelper_readAhead scans a stream whose reads take 20ms, parsing each batch of records read by
+// a single read for 20ms as well. Reading ahead overlaps reads with parsing, roughly halving the time per stream.
+func BenchmarkScannerHelper_readAhead(b *testing.B) {
+	const (
+		latency   = 20 * time.Millisecond
+		chunkSize = 64 * 1024
+		reads     = 8
+	)
+	line := strings.Repeat("x", 63) + "\n"
+	recordsPerRead := chunkSize / len(line)
+	stream := strings.Repeat(line, recordsPerRead*reads)
+
+	for _, bc := range []struct {
+		name string
+		opts []encoding.DecoderOption
+	}{
+		{name: "sequential"},
+		{name: "pipelined", opts: []encoding.DecoderOption{encoding.WithReadAhead(2, chunkSize)}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			b.SetBytes(int64(len(stream)))
+			for b.Loop() {
+				reader := &latencyReader{reader: strings.NewReader(stream), chunkSize: chunkSize, latency: latency}
+				opts := append([]encoding.DecoderOption{encoding.WithFlushItems(int64(recordsPerRead)), encoding.WithFlushBytes(0), encoding.WithReaderBufferSize(chunkSize)}, bc.opts...)
+				helper, err := NewScannerHelper(reader, opts...)
+				require.NoError(b, err)
+				for {
+					_, flush, err := helper.ScanBytes()
+					if flush {
+						// Parsing the batch
+						time.Sleep(latency)
+					}
+					if err == io.EOF {
+						break
+					}
+					require.NoError(b, err)
+				}
+				require.NoError(b, helper.Close())
+			}
+		})
+	}
+}
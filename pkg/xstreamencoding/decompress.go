@@ -0,0 +1,132 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression is the compression format of a stream.
+type Compression int
+
+const (
+	// CompressionNone is an uncompressed stream.
+	CompressionNone Compression = iota
+	// CompressionGzip is a gzip compressed stream.
+	CompressionGzip
+	// CompressionZstd is a zstd compressed stream.
+	CompressionZstd
+)
+
+// String returns the name of the compression format.
+func (c Compression) String() string {
+	switch c {
+	case CompressionGzip:
+		return "gzip"
+	case CompressionZstd:
+		return "zstd"
+	default:
+		return "none"
+	}
+}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// CompressedOffsetReader is implemented by readers decompressing a stream.
+type CompressedOffsetReader interface {
+	io.Reader
+	// CompressedOffset returns the number of bytes consumed from the compressed stream.
+	CompressedOffset() int64
+}
+
+// DecompressingReader decompresses a stream while tracking the position in the compressed stream.
+// It implements CompressedOffsetReader, allowing ScannerHelper to report offsets in the compressed domain.
+type DecompressingReader struct {
+	counter     *countingReader
+	source      *bufio.Reader
+	reader      io.Reader
+	compression Compression
+	closeFunc   func()
+}
+
+// NewDecompressingReader sniffs the gzip and zstd magic bytes of the reader and returns a reader
+// decompressing it accordingly, along with the detected compression. Uncompressed streams are returned as-is,
+// wrapped to report their position. The returned reader is a *DecompressingReader,
+// which should be closed to release the decompressor resources.
+func NewDecompressingReader(r io.Reader) (io.Reader, Compression, error) {
+	counter := &countingReader{reader: r}
+	source := bufio.NewReader(counter)
+	d := &DecompressingReader{
+		counter: counter,
+		source:  source,
+		reader:  source,
+	}
+
+	magic, err := source.Peek(len(zstdMagic))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, CompressionNone, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(source)
+		if err != nil {
+			return nil, CompressionGzip, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		d.reader, d.compression = gz, CompressionGzip
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(source, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, CompressionZstd, fmt.Errorf("failed to create zstd reader: %w", err)
+		}
+		d.reader, d.compression, d.closeFunc = zr, CompressionZstd, zr.Close
+	}
+	return d, d.compression, nil
+}
+
+// Read reads decompressed bytes.
+func (d *DecompressingReader) Read(p []byte) (int, error) {
+	return d.reader.Read(p)
+}
+
+// CompressedOffset returns the number of bytes consumed from the compressed stream.
+// As decompressors read ahead, it may be ahead of the decompressed bytes read so far.
+func (d *DecompressingReader) CompressedOffset() int64 {
+	return d.counter.n - int64(d.source.Buffered())
+}
+
+// Compression returns the compression format detected for the stream.
+func (d *DecompressingReader) Compression() Compression {
+	return d.compression
+}
+
+// Close releases the decompressor resources. It does not close the underlying reader.
+func (d *DecompressingReader) Close() error {
+	if d.closeFunc != nil {
+		d.closeFunc()
+	}
+	return nil
+}
+
+// countingReader counts the bytes read from the wrapped reader.
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.n += int64(n)
+	return n, err
+}
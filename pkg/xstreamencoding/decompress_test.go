@@ -0,0 +1,163 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+const decompressInput = "line1\nline2\nline3\n"
+
+func gzipData(t *testing.T, data string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func zstdData(t *testing.T, data string) []byte {
+	w, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	defer w.Close()
+	return w.EncodeAll([]byte(data), nil)
+}
+
+func TestNewDecompressingReader(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []byte
+		compression Compression
+	}{
+		{name: "none", input: []byte(decompressInput), compression: CompressionNone},
+		{name: "gzip", input: gzipData(t, decompressInput), compression: CompressionGzip},
+		{name: "zstd", input: zstdData(t, decompressInput), compression: CompressionZstd},
+		{name: "empty", input: nil, compression: CompressionNone},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader, compression, err := NewDecompressingReader(bytes.NewReader(tt.input))
+			require.NoError(t, err)
+			assert.Equal(t, tt.compression, compression)
+
+			d, ok := reader.(*DecompressingReader)
+			require.True(t, ok)
+			defer d.Close()
+			assert.Equal(t, tt.compression, d.Compression())
+
+			data, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			if tt.input == nil {
+				assert.Empty(t, data)
+			} else {
+				assert.Equal(t, decompressInput, string(data))
+			}
+			assert.Equal(t, int64(len(tt.input)), d.CompressedOffset())
+		})
+	}
+
+	t.Run("invalid gzip header", func(t *testing.T) {
+		_, compression, err := NewDecompressingReader(bytes.NewReader([]byte{0x1f, 0x8b, 0x00}))
+		require.ErrorContains(t, err, "failed to create gzip reader")
+		assert.Equal(t, CompressionGzip, compression)
+	})
+}
+
+func TestCompressionString(t *testing.T) {
+	assert.Equal(t, "none", CompressionNone.String())
+	assert.Equal(t, "gzip", CompressionGzip.String())
+	assert.Equal(t, "zstd", CompressionZstd.String())
+}
+
+func TestScannerHelper_OffsetDomain(t *testing.T) {
+	compressed := gzipData(t, decompressInput)
+
+	t.Run("uncompressed", func(t *testing.T) {
+		reader, _, err := NewDecompressingReader(bytes.NewReader(compressed))
+		require.NoError(t, err)
+
+		helper, err := NewScannerHelper(reader)
+		require.NoError(t, err)
+
+		line, _, err := helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "line1", line)
+		assert.Equal(t, int64(6), helper.Offset())
+	})
+
+	t.Run("uncompressed resumes with offset", func(t *testing.T) {
+		reader, _, err := NewDecompressingReader(bytes.NewReader(compressed))
+		require.NoError(t, err)
+
+		helper, err := NewScannerHelper(reader, encoding.WithOffset(6))
+		require.NoError(t, err)
+
+		line, _, err := helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "line2", line)
+		assert.Equal(t, int64(12), helper.Offset())
+	})
+
+	t.Run("compressed", func(t *testing.T) {
+		reader, _, err := NewDecompressingReader(bytes.NewReader(compressed))
+		require.NoError(t, err)
+
+		helper, err := NewScannerHelper(reader, encoding.WithOffsetDomain(encoding.OffsetDomainCompressed))
+		require.NoError(t, err)
+		// The gzip header has been consumed when creating the reader
+		assert.Equal(t, int64(10), helper.Offset())
+
+		var lines []string
+		for {
+			line, _, err := helper.ScanString()
+			if line != "" {
+				lines = append(lines, line)
+			}
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+		}
+		assert.Equal(t, []string{"line1", "line2", "line3"}, lines)
+		assert.Equal(t, int64(len(compressed)), helper.Offset())
+	})
+
+	t.Run("compressed starts from positioned input", func(t *testing.T) {
+		// A second gzip member, positioned at its boundary in the concatenated stream
+		second := gzipData(t, "line4\n")
+		reader, _, err := NewDecompressingReader(bytes.NewReader(second))
+		require.NoError(t, err)
+
+		offset := int64(len(compressed))
+		helper, err := NewScannerHelper(reader, encoding.WithOffsetDomain(encoding.OffsetDomainCompressed), encoding.WithOffset(offset))
+		require.NoError(t, err)
+		assert.Equal(t, offset+10, helper.Offset())
+
+		line, _, err := helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "line4", line)
+		assert.Equal(t, offset+int64(len(second)), helper.Offset())
+	})
+
+	t.Run("compressed domain on plain reader", func(t *testing.T) {
+		helper, err := NewScannerHelper(strings.NewReader(decompressInput), encoding.WithOffsetDomain(encoding.OffsetDomainCompressed), encoding.WithOffset(6))
+		require.NoError(t, err)
+
+		line, _, err := helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "line2", line)
+		assert.Equal(t, int64(12), helper.Offset())
+	})
+}
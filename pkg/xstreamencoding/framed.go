@@ -0,0 +1,180 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+// LengthSource returns the length in bytes of the next record of a framed stream, or io.EOF once all records
+// were returned, e.g. reading the entries of an index.
+type LengthSource func() (int64, error)
+
+// LengthsSource returns a LengthSource returning lengths in order, then io.EOF.
+func LengthsSource(lengths []int64) LengthSource {
+	return func() (int64, error) {
+		if len(lengths) == 0 {
+			return 0, io.EOF
+		}
+		length := lengths[0]
+		lengths = lengths[1:]
+		return length, nil
+	}
+}
+
+// FramedScannerHelper is a helper to scan records whose boundaries are supplied out-of-band from io.Reader and
+// determine when to flush, e.g. for indexed archives. Each record is made of exactly the number of bytes returned
+// by its LengthSource, delimiters being ignored.
+// Not safe for concurrent use.
+type FramedScannerHelper struct {
+	batchHelper *BatchHelper
+	bufReader   *bufio.Reader
+	lengths     LengthSource
+	offset      int64
+	// record holds the bytes of the record being read, of which remaining are left to read when reading is set.
+	record    bytes.Buffer
+	remaining int64
+	reading   bool
+}
+
+// NewFramedScannerHelper creates a new FramedScannerHelper that reads the records of the provided io.Reader,
+// the length of each being returned in order by lengths. It accepts optional encoding.DecoderOption to configure
+// batch flushing behavior. As with ScannerHelper, a bufio.Reader is used as-is, otherwise one is derived with the
+// buffer size configured through encoding.WithReaderBufferSize.
+//
+// An offset configured through encoding.WithOffset skips the records it covers, and must therefore be at a record
+// boundary, as reported by Offset.
+func NewFramedScannerHelper(reader io.Reader, lengths LengthSource, opts ...encoding.DecoderOption) (*FramedScannerHelper, error) {
+	batchHelper := NewBatchHelper(opts...)
+	h := &FramedScannerHelper{batchHelper: batchHelper, lengths: lengths}
+	if br, ok := reader.(*bufio.Reader); ok {
+		h.bufReader = br
+	} else {
+		size := batchHelper.options.ReaderBufferSize
+		if size <= 0 {
+			size = defaultReaderBufferSize
+		}
+		h.bufReader = bufio.NewReaderSize(reader, size)
+	}
+
+	offset := batchHelper.options.Offset
+	for h.offset < offset {
+		length, err := h.nextLength()
+		if err != nil {
+			return nil, fmt.Errorf("failed to skip to offset %d: %w", offset, err)
+		}
+		if h.offset+length > offset {
+			return nil, fmt.Errorf("offset %d is not at a record boundary", offset)
+		}
+		if _, err := h.bufReader.Discard(int(length)); err != nil {
+			return nil, fmt.Errorf("failed to discard offset %d: %w", offset, err)
+		}
+		h.offset += length
+	}
+	return h, nil
+}
+
+// ScanString scans the next record from the stream and returns it as a string.
+// flush indicates whether the batch should be flushed after processing this string.
+// err is non-nil if an error occurred during scanning. Once lengths returns io.EOF, err will be io.EOF, while
+// data ending before the length of a record results in io.ErrUnexpectedEOF. Bytes following the last record are
+// not read. As with ScannerHelper, a record interrupted by an error, e.g. a *QuotaExceededError, is completed
+// by the next call.
+func (h *FramedScannerHelper) ScanString() (record string, flush bool, err error) {
+	b, flush, err := h.batchHelper.scan(h.scanInternal)
+	return string(b), flush, err
+}
+
+// ScanBytes scans the next record from the stream and returns it as a byte slice.
+// It has the same semantics as ScanString.
+func (h *FramedScannerHelper) ScanBytes() (record []byte, flush bool, err error) {
+	b, flush, err := h.batchHelper.scan(h.scanInternal)
+	if b != nil {
+		cpy := make([]byte, len(b))
+		copy(cpy, b)
+		return cpy, flush, err
+	}
+	return nil, flush, err
+}
+
+func (h *FramedScannerHelper) scanInternal() ([]byte, bool, error) {
+	for {
+		if !h.reading {
+			length, err := h.nextLength()
+			if err != nil {
+				if err == io.EOF {
+					return nil, true, io.EOF
+				}
+				return nil, false, err
+			}
+			h.record.Reset()
+			h.remaining, h.reading = length, true
+		}
+
+		n, err := io.CopyN(&h.record, h.bufReader, h.remaining)
+		h.remaining -= n
+		if err != nil {
+			if errors.Is(err, ErrQuotaExceeded) {
+				return nil, false, &QuotaExceededError{Offset: h.offset}
+			}
+			if err == io.EOF {
+				return nil, false, fmt.Errorf("record at offset %d is missing %d bytes: %w", h.offset, h.remaining, io.ErrUnexpectedEOF)
+			}
+			return nil, false, err
+		}
+		h.reading = false
+
+		b := h.record.Bytes()
+		h.offset += int64(len(b))
+		h.batchHelper.IncrementBytes(int64(len(b)))
+
+		flush, skip := h.batchHelper.track(b)
+		if skip {
+			// The offset still moves past skipped records so that decoding can be resumed.
+			continue
+		}
+		return b, flush, nil
+	}
+}
+
+// nextLength returns the length of the next record.
+func (h *FramedScannerHelper) nextLength() (int64, error) {
+	length, err := h.lengths()
+	if err != nil {
+		return 0, err
+	}
+	if length < 0 {
+		return 0, fmt.Errorf("invalid length %d of record at offset %d", length, h.offset)
+	}
+	return length, nil
+}
+
+// Offset returns the current byte offset read from the stream, always at a record boundary.
+func (h *FramedScannerHelper) Offset() int64 {
+	return h.offset
+}
+
+// Options returns the DecoderOptions used by the FramedScannerHelper's BatchHelper.
+func (h *FramedScannerHelper) Options() encoding.DecoderOptions {
+	return h.batchHelper.Options()
+}
+
+// Stats returns the cumulative statistics of the FramedScannerHelper, see BatchHelper.Stats.
+// It is safe to call concurrently with scanning.
+func (h *FramedScannerHelper) Stats() encoding.DecoderStats {
+	return h.batchHelper.Stats()
+}
+
+// SetLogsBatchID stamps the resources of logs with the id of the batch, see BatchHelper.SetLogsBatchID.
+func (h *FramedScannerHelper) SetLogsBatchID(logs plog.Logs) {
+	h.batchHelper.SetLogsBatchID(logs)
+}
@@ -0,0 +1,175 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+// framedBlob holds the records "first", "multi\nline", "" and "{\"k\":1}\n", back to back without delimiters.
+const framedBlob = "firstmulti\nline{\"k\":1}\n"
+
+var framedLengths = []int64{5, 10, 0, 8}
+
+func scanFramed(t *testing.T, helper *FramedScannerHelper) []scanResult {
+	var results []scanResult
+	for {
+		record, flush, err := helper.ScanString()
+		if err == io.EOF {
+			return results
+		}
+		require.NoError(t, err)
+		results = append(results, scanResult{line: record, flush: flush, offset: helper.Offset()})
+	}
+}
+
+func TestFramedScannerHelper_Scan(t *testing.T) {
+	helper, err := NewFramedScannerHelper(strings.NewReader(framedBlob), LengthsSource(framedLengths), encoding.WithFlushItems(2))
+	require.NoError(t, err)
+
+	assert.Equal(t, []scanResult{
+		{line: "first", offset: 5},
+		{line: "multi\nline", flush: true, offset: 15},
+		{line: "", offset: 15},
+		{line: "{\"k\":1}\n", flush: true, offset: 23},
+	}, scanFramed(t, helper))
+
+	_, _, err = helper.ScanString()
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, int64(23), helper.Offset())
+}
+
+func TestFramedScannerHelper_ScanBytes(t *testing.T) {
+	helper, err := NewFramedScannerHelper(strings.NewReader(framedBlob), LengthsSource(framedLengths))
+	require.NoError(t, err)
+
+	first, _, err := helper.ScanBytes()
+	require.NoError(t, err)
+	second, _, err := helper.ScanBytes()
+	require.NoError(t, err)
+
+	// Records are copies, not overwritten by the following ones
+	assert.Equal(t, []byte("first"), first)
+	assert.Equal(t, []byte("multi\nline"), second)
+}
+
+func TestFramedScannerHelper_SkipEmptyRecords(t *testing.T) {
+	helper, err := NewFramedScannerHelper(strings.NewReader(framedBlob), LengthsSource(framedLengths), encoding.WithSkipEmptyRecords(true))
+	require.NoError(t, err)
+
+	assert.Equal(t, []scanResult{
+		{line: "first", offset: 5},
+		{line: "multi\nline", offset: 15},
+		{line: "{\"k\":1}\n", offset: 23},
+	}, scanFramed(t, helper))
+}
+
+func TestFramedScannerHelper_LengthSource(t *testing.T) {
+	// Lengths read from an index one at a time
+	index := strings.NewReader("5 10 0 8")
+	lengths := func() (int64, error) {
+		var length int64
+		_, err := fmt.Fscan(index, &length)
+		return length, err
+	}
+	helper, err := NewFramedScannerHelper(strings.NewReader(framedBlob), lengths)
+	require.NoError(t, err)
+
+	results := scanFramed(t, helper)
+	require.Len(t, results, 4)
+	assert.Equal(t, "{\"k\":1}\n", results[3].line)
+}
+
+func TestFramedScannerHelper_InitialOffset(t *testing.T) {
+	t.Run("record boundary", func(t *testing.T) {
+		helper, err := NewFramedScannerHelper(strings.NewReader(framedBlob), LengthsSource(framedLengths), encoding.WithOffset(15))
+		require.NoError(t, err)
+
+		assert.Equal(t, []scanResult{
+			{line: "", offset: 15},
+			{line: "{\"k\":1}\n", offset: 23},
+		}, scanFramed(t, helper))
+	})
+
+	t.Run("within a record", func(t *testing.T) {
+		_, err := NewFramedScannerHelper(strings.NewReader(framedBlob), LengthsSource(framedLengths), encoding.WithOffset(7))
+		assert.ErrorContains(t, err, "offset 7 is not at a record boundary")
+	})
+
+	t.Run("beyond the records", func(t *testing.T) {
+		_, err := NewFramedScannerHelper(strings.NewReader(framedBlob), LengthsSource(framedLengths), encoding.WithOffset(30))
+		assert.ErrorIs(t, err, io.EOF)
+	})
+}
+
+func TestFramedScannerHelper_Errors(t *testing.T) {
+	t.Run("truncated data", func(t *testing.T) {
+		helper, err := NewFramedScannerHelper(strings.NewReader("first"), LengthsSource([]int64{5, 10}))
+		require.NoError(t, err)
+
+		record, _, err := helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "first", record)
+
+		_, _, err = helper.ScanString()
+		assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		assert.ErrorContains(t, err, "record at offset 5 is missing 10 bytes")
+		assert.Equal(t, int64(5), helper.Offset())
+	})
+
+	t.Run("negative length", func(t *testing.T) {
+		helper, err := NewFramedScannerHelper(strings.NewReader(framedBlob), LengthsSource([]int64{-1}))
+		require.NoError(t, err)
+
+		_, _, err = helper.ScanString()
+		assert.ErrorContains(t, err, "invalid length -1 of record at offset 0")
+	})
+
+	t.Run("length source error", func(t *testing.T) {
+		helper, err := NewFramedScannerHelper(strings.NewReader(framedBlob), func() (int64, error) {
+			return 0, assert.AnError
+		})
+		require.NoError(t, err)
+
+		_, _, err = helper.ScanString()
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+
+	t.Run("trailing data is not read", func(t *testing.T) {
+		helper, err := NewFramedScannerHelper(strings.NewReader(framedBlob), LengthsSource([]int64{5}))
+		require.NoError(t, err)
+
+		assert.Equal(t, []scanResult{{line: "first", offset: 5}}, scanFramed(t, helper))
+	})
+}
+
+func TestFramedScannerHelper_QuotaExceeded(t *testing.T) {
+	quota := &tokenBucket{tokens: 8}
+	helper, err := NewFramedScannerHelper(NewQuotaReader(strings.NewReader(framedBlob), quota), LengthsSource(framedLengths))
+	require.NoError(t, err)
+
+	record, _, err := helper.ScanString()
+	require.NoError(t, err)
+	assert.Equal(t, "first", record)
+
+	// "mul" of the second record was granted but the record is incomplete
+	_, _, err = helper.ScanString()
+	var quotaErr *QuotaExceededError
+	require.ErrorAs(t, err, &quotaErr)
+	assert.Equal(t, int64(5), quotaErr.Offset)
+
+	quota.refill(100)
+	record, _, err = helper.ScanString()
+	require.NoError(t, err)
+	assert.Equal(t, "multi\nline", record)
+	assert.Equal(t, int64(15), helper.Offset())
+}
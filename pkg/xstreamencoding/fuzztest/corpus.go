@@ -0,0 +1,247 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+// Package fuzztest generates inputs that streaming decoders are prone to mishandle, and provides fuzz harness
+// helpers checking the invariants every decoder must hold on them.
+package fuzztest // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding/fuzztest"
+
+import (
+	"bytes"
+
+	txt "golang.org/x/text/encoding"
+)
+
+// Class is a class of inputs that streaming decoders are prone to mishandle.
+type Class string
+
+const (
+	// ClassBoundaryDelimiter places delimiters across common buffer boundaries.
+	ClassBoundaryDelimiter Class = "boundary_delimiter"
+	// ClassPartialCharacter cuts multi-byte characters at the end of records and at the end of the input.
+	ClassPartialCharacter Class = "partial_character"
+	// ClassEmptyRecords has empty records, from consecutive, leading or trailing delimiters, or an empty input.
+	ClassEmptyRecords Class = "empty_records"
+	// ClassGiganticRecord has records larger than common scanner buffers.
+	ClassGiganticRecord Class = "gigantic_record"
+	// ClassTruncatedTail ends with an unterminated record or a partial delimiter.
+	ClassTruncatedTail Class = "truncated_tail"
+	// ClassRegression holds inputs that broke decoders in the past.
+	ClassRegression Class = "regression"
+)
+
+const (
+	// DefaultGiganticRecordSize is the default size in bytes of gigantic records, twice bufio.MaxScanTokenSize.
+	DefaultGiganticRecordSize = 128 * 1024
+)
+
+var (
+	// DefaultRecordSizes are the default sizes in characters of generated records.
+	DefaultRecordSizes = []int{1, 7, 64, 513}
+	// DefaultBufferSizes are the default buffer sizes delimiters are placed across: the default size of
+	// bufio.Reader and bufio.MaxScanTokenSize.
+	DefaultBufferSizes = []int{4096, 64 * 1024}
+
+	// Regressions are inputs that broke decoders in the past, such as carriage returns without a line feed,
+	// byte order marks without any record, truncated UTF-8 sequences and NUL bytes.
+	Regressions = [][]byte{
+		[]byte("\r"),
+		[]byte("a\r"),
+		[]byte("a\r\n\r\n"),
+		[]byte("\r\n\r"),
+		[]byte("\xef\xbb\xbf"),
+		[]byte("\xef\xbb\xbfa\n"),
+		[]byte("\xff\xfe"),
+		[]byte("\xfe\xffa\x00\n"),
+		[]byte("\xe2\x82"),
+		[]byte("a\xc3\nb\n"),
+		[]byte("a\x00b\n"),
+		[]byte("\x00\x00\x00\x00"),
+	}
+)
+
+// multiByteText holds characters encoded with more than one byte by common charsets.
+const multiByteText = "é€日😀"
+
+// Input is a generated input.
+type Input struct {
+	Class Class
+	Data  []byte
+}
+
+// CorpusConfig parameterizes the generated inputs.
+type CorpusConfig struct {
+	// Delimiter terminates records, already encoded in Charset. Defaults to "\n".
+	Delimiter []byte
+	// Charset encodes the text of records, which is UTF-8 if nil. Characters it cannot represent are replaced.
+	Charset txt.Encoding
+	// RecordSizes are the sizes in characters of generated records. Defaults to DefaultRecordSizes.
+	RecordSizes []int
+	// BufferSizes are the buffer sizes delimiters are placed across. Defaults to DefaultBufferSizes.
+	BufferSizes []int
+	// GiganticRecordSize is the size in bytes of gigantic records. Defaults to DefaultGiganticRecordSize.
+	GiganticRecordSize int
+}
+
+// corpus generates the inputs of a CorpusConfig with its defaults applied.
+type corpus struct {
+	delimiter          []byte
+	encoder            *txt.Encoder
+	recordSizes        []int
+	bufferSizes        []int
+	giganticRecordSize int
+}
+
+func newCorpus(cfg CorpusConfig) *corpus {
+	c := &corpus{
+		delimiter:          cfg.Delimiter,
+		recordSizes:        cfg.RecordSizes,
+		bufferSizes:        cfg.BufferSizes,
+		giganticRecordSize: cfg.GiganticRecordSize,
+	}
+	if len(c.delimiter) == 0 {
+		c.delimiter = []byte("\n")
+	}
+	if cfg.Charset != nil {
+		c.encoder = txt.ReplaceUnsupported(cfg.Charset.NewEncoder())
+	}
+	if len(c.recordSizes) == 0 {
+		c.recordSizes = DefaultRecordSizes
+	}
+	if len(c.bufferSizes) == 0 {
+		c.bufferSizes = DefaultBufferSizes
+	}
+	if c.giganticRecordSize <= 0 {
+		c.giganticRecordSize = DefaultGiganticRecordSize
+	}
+	return c
+}
+
+// Generate returns the inputs of every class for cfg, including Regressions.
+func Generate(cfg CorpusConfig) []Input {
+	c := newCorpus(cfg)
+	var inputs []Input
+	add := func(class Class, data ...[]byte) {
+		for _, d := range data {
+			inputs = append(inputs, Input{Class: class, Data: d})
+		}
+	}
+	add(ClassBoundaryDelimiter, c.boundaryDelimiters()...)
+	add(ClassPartialCharacter, c.partialCharacters()...)
+	add(ClassEmptyRecords, c.emptyRecords()...)
+	add(ClassGiganticRecord, c.giganticRecords()...)
+	add(ClassTruncatedTail, Truncate(c.records(c.recordSizes...), c.delimiter)...)
+	add(ClassRegression, Regressions...)
+	return inputs
+}
+
+// Truncate returns the mutations of data cutting its tail: without its final delimiter, if any, with only part of
+// it, and cut within its last record.
+func Truncate(data, delimiter []byte) [][]byte {
+	var mutations [][]byte
+	body, terminated := bytes.CutSuffix(data, delimiter)
+	if terminated {
+		mutations = append(mutations, body)
+		for i := 1; i < len(delimiter); i++ {
+			mutations = append(mutations, data[:len(body)+i])
+		}
+	}
+	start := 0
+	if i := bytes.LastIndex(body, delimiter); i >= 0 {
+		start = i + len(delimiter)
+	}
+	if last := len(body) - start; last > 2 {
+		mutations = append(mutations, body[:len(body)-1], body[:start+(last+1)/2])
+	}
+	return mutations
+}
+
+// encode encodes text in the charset of the corpus.
+func (c *corpus) encode(text string) []byte {
+	if c.encoder == nil {
+		return []byte(text)
+	}
+	b, err := c.encoder.Bytes([]byte(text))
+	if err != nil {
+		// Unsupported characters are replaced, so this only happens with broken charsets.
+		panic(err)
+	}
+	return b
+}
+
+// text returns n characters of printable ASCII text.
+func (*corpus) text(n int) string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz0123456789 "
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = alphabet[i%len(alphabet)]
+	}
+	return string(b)
+}
+
+// records returns records of the given sizes in characters, each followed by the delimiter.
+func (c *corpus) records(sizes ...int) []byte {
+	var b []byte
+	for _, size := range sizes {
+		b = append(append(b, c.encode(c.text(size))...), c.delimiter...)
+	}
+	return b
+}
+
+// boundaryDelimiters returns inputs with the first delimiter straddling or adjacent to each buffer size.
+func (c *corpus) boundaryDelimiters() [][]byte {
+	width := max(len(c.encode("a")), 1)
+	var inputs [][]byte
+	for _, size := range c.bufferSizes {
+		for pos := size - len(c.delimiter); pos <= size+1; pos++ {
+			if pos < 0 {
+				continue
+			}
+			data := c.encode(c.text(pos / width))
+			// Pad with zero bytes when the position falls within a character
+			data = append(data, make([]byte, pos%width)...)
+			data = append(data, c.delimiter...)
+			inputs = append(inputs, append(data, c.records(c.recordSizes[0])...))
+		}
+	}
+	return inputs
+}
+
+// partialCharacters returns inputs with records ending with a multi-byte character cut at every byte.
+func (c *corpus) partialCharacters() [][]byte {
+	var inputs [][]byte
+	for _, r := range multiByteText {
+		char := c.encode(string(r))
+		for cut := 1; cut < len(char); cut++ {
+			record := append(c.encode(c.text(c.recordSizes[0])), char[:cut]...)
+			// The cut character ends a record, then the input
+			inputs = append(inputs, append(append(record, c.delimiter...), c.records(c.recordSizes[0])...), record)
+		}
+	}
+	// Complete characters are decodable as-is
+	return append(inputs, append(c.encode(multiByteText), c.delimiter...))
+}
+
+// emptyRecords returns inputs with empty records.
+func (c *corpus) emptyRecords() [][]byte {
+	record := c.encode(c.text(c.recordSizes[0]))
+	d := c.delimiter
+	join := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+	return [][]byte{
+		{},
+		join(d),
+		join(d, d, d),
+		join(d, record, d),
+		join(record, d, d, record, d),
+		join(record, d, d),
+	}
+}
+
+// giganticRecords returns inputs with a record of the gigantic size, terminated or not.
+func (c *corpus) giganticRecords() [][]byte {
+	width := max(len(c.encode("a")), 1)
+	gigantic := c.encode(c.text(c.giganticRecordSize / width))
+	terminated := append(append(append([]byte{}, gigantic...), c.delimiter...), c.records(c.recordSizes[0])...)
+	return [][]byte{terminated, gigantic}
+}
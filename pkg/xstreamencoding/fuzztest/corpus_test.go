@@ -0,0 +1,79 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package fuzztest
+
+import (
+	"bytes"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+func classInputs(inputs []Input, class Class) [][]byte {
+	var data [][]byte
+	for _, input := range inputs {
+		if input.Class == class {
+			data = append(data, input.Data)
+		}
+	}
+	return data
+}
+
+func TestGenerate_boundaryDelimiter(t *testing.T) {
+	delimiter := []byte("\r\n")
+	inputs := classInputs(Generate(CorpusConfig{Delimiter: delimiter, BufferSizes: []int{16}}), ClassBoundaryDelimiter)
+
+	// The first delimiter starts at every position from fully before to just after the buffer size
+	var positions []int
+	for _, data := range inputs {
+		positions = append(positions, bytes.Index(data, delimiter))
+	}
+	assert.Equal(t, []int{14, 15, 16, 17}, positions)
+}
+
+func TestGenerate_partialCharacter(t *testing.T) {
+	inputs := classInputs(Generate(CorpusConfig{}), ClassPartialCharacter)
+	require.NotEmpty(t, inputs)
+	for _, data := range inputs[:len(inputs)-1] {
+		assert.False(t, utf8.Valid(data), "%q", data)
+	}
+	assert.True(t, utf8.Valid(inputs[len(inputs)-1]))
+}
+
+func TestGenerate_charset(t *testing.T) {
+	utf16 := unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM)
+	delimiter, err := utf16.NewEncoder().Bytes([]byte("\n"))
+	require.NoError(t, err)
+
+	inputs := classInputs(Generate(CorpusConfig{Delimiter: delimiter, Charset: utf16, RecordSizes: []int{3}}), ClassTruncatedTail)
+	require.NotEmpty(t, inputs)
+	assert.Equal(t, []byte("a\x00b\x00c\x00"), inputs[0])
+
+	// Characters missing from the charset are replaced
+	inputs = classInputs(Generate(CorpusConfig{Charset: charmap.ISO8859_1}), ClassPartialCharacter)
+	assert.Equal(t, []byte("\xe9\x1a\x1a\x1a\n"), inputs[len(inputs)-1])
+}
+
+func TestGenerate_giganticRecord(t *testing.T) {
+	inputs := classInputs(Generate(CorpusConfig{GiganticRecordSize: 100}), ClassGiganticRecord)
+	require.Len(t, inputs, 2)
+	assert.Equal(t, 100, bytes.IndexByte(inputs[0], '\n'))
+	assert.Len(t, inputs[1], 100)
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, [][]byte{
+		[]byte("abc\r\ndefgh"),
+		[]byte("abc\r\ndefgh\r"),
+		[]byte("abc\r\ndefg"),
+		[]byte("abc\r\ndef"),
+	}, Truncate([]byte("abc\r\ndefgh\r\n"), []byte("\r\n")))
+
+	// Short unterminated records are not cut
+	assert.Empty(t, Truncate([]byte("ab\nc"), []byte("\n")))
+}
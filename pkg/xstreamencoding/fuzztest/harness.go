@@ -0,0 +1,109 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package fuzztest // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding/fuzztest"
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+// NewDecoderFunc creates the decoder under test, e.g. the NewLogsDecoder method of a codec.
+type NewDecoderFunc func(reader io.Reader, options ...encoding.DecoderOption) (encoding.LogsDecoder, error)
+
+// HarnessOption configures the invariants checked on decoders.
+type HarnessOption func(*harness)
+
+type harness struct {
+	decoderOptions []encoding.DecoderOption
+	marshal        func(plog.Logs) ([]byte, error)
+	roundTrips     func(data []byte) bool
+}
+
+// WithDecoderOptions sets the options decoders are created with, e.g. encoding.WithFlushItems(1) to return
+// a batch per record.
+func WithDecoderOptions(options ...encoding.DecoderOption) HarnessOption {
+	return func(h *harness) {
+		h.decoderOptions = options
+	}
+}
+
+// WithRoundTrip checks that marshaling the records decoded from an input, concatenated across batches, returns
+// the input when roundTrips reports that it should, e.g. when it is valid in the charset of the decoder.
+func WithRoundTrip(marshal func(plog.Logs) ([]byte, error), roundTrips func(data []byte) bool) HarnessOption {
+	return func(h *harness) {
+		h.marshal = marshal
+		h.roundTrips = roundTrips
+	}
+}
+
+// AddCorpus adds the inputs generated for cfg, followed by extra inputs, e.g. codec specific regressions,
+// to the seed corpus of f.
+func AddCorpus(f *testing.F, cfg CorpusConfig, extra ...[]byte) {
+	for _, input := range Generate(cfg) {
+		f.Add(input.Data)
+	}
+	for _, data := range extra {
+		f.Add(data)
+	}
+}
+
+// Fuzz fuzzes the decoders created by newDecoder, checking the invariants of Check on every input.
+func Fuzz(f *testing.F, newDecoder NewDecoderFunc, options ...HarnessOption) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Check(t, newDecoder, data, options...)
+	})
+}
+
+// Check decodes data until io.EOF or an error, checking that the decoder:
+//   - does not panic,
+//   - returns at most one batch per byte of data, plus one, before io.EOF or an error,
+//   - reports offsets that never decrease nor exceed the length of data,
+//   - returns records marshaling back to data, if WithRoundTrip is set and data round-trips.
+//
+// Errors are valid results, e.g. for records exceeding the maximum size of the decoder, and end the checks.
+func Check(t testing.TB, newDecoder NewDecoderFunc, data []byte, options ...HarnessOption) {
+	var h harness
+	for _, option := range options {
+		option(&h)
+	}
+
+	decoder, err := newDecoder(bytes.NewReader(data), h.decoderOptions...)
+	if err != nil {
+		return
+	}
+
+	decoded := plog.NewLogs()
+	previous := decoder.Offset()
+	for batch := 0; ; batch++ {
+		require.LessOrEqual(t, batch, len(data)+1, "decoder did not end after %d batches", batch)
+
+		logs, err := decoder.DecodeLogs()
+		offset := decoder.Offset()
+		require.GreaterOrEqual(t, offset, previous, "offset decreased at batch %d", batch)
+		require.LessOrEqual(t, offset, int64(len(data)), "offset exceeds the input at batch %d", batch)
+		previous = offset
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return
+		}
+		logs.ResourceLogs().MoveAndAppendTo(decoded.ResourceLogs())
+	}
+
+	if h.marshal == nil || !h.roundTrips(data) {
+		return
+	}
+	marshaled, err := h.marshal(decoded)
+	require.NoError(t, err)
+	require.Equal(t, string(data), string(marshaled), "decoded records do not marshal back to the input")
+}
@@ -0,0 +1,128 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package fuzztest
+
+import (
+	"bytes"
+	"io"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+)
+
+// newLineDecoder creates a decoder of newline-delimited records built on xstreamencoding.ScannerHelper.
+func newLineDecoder(reader io.Reader, options ...encoding.DecoderOption) (encoding.LogsDecoder, error) {
+	helper, err := xstreamencoding.NewScannerHelper(reader, options...)
+	if err != nil {
+		return nil, err
+	}
+	return xstreamencoding.NewLogsDecoderAdapter(func() (plog.Logs, error) {
+		logs := plog.NewLogs()
+		records := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+		for {
+			line, flush, err := helper.ScanString()
+			if err == nil || line != "" {
+				records.AppendEmpty().Body().SetStr(line)
+			}
+			if err == io.EOF && records.Len() > 0 {
+				return logs, nil
+			}
+			if err != nil || flush {
+				return logs, err
+			}
+		}
+	}, helper.Offset), nil
+}
+
+// marshalLines marshals the bodies of logs as newline-terminated records.
+func marshalLines(logs plog.Logs) ([]byte, error) {
+	var b []byte
+	for i := 0; i < logs.ResourceLogs().Len(); i++ {
+		records := logs.ResourceLogs().At(i).ScopeLogs().At(0).LogRecords()
+		for j := 0; j < records.Len(); j++ {
+			b = append(append(b, records.At(j).Body().Str()...), '\n')
+		}
+	}
+	return b, nil
+}
+
+// isCanonical reports whether data is made of newline-terminated records the line decoder returns as-is.
+func isCanonical(data []byte) bool {
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if len(line) > 0 && (line[len(line)-1] != '\n' || !bytes.Equal(bytes.TrimSpace(line), line[:len(line)-1])) {
+			return false
+		}
+	}
+	return true
+}
+
+func FuzzScannerHelper(f *testing.F) {
+	AddCorpus(f, CorpusConfig{}, []byte("a\n\n"))
+	Fuzz(f, newLineDecoder, WithDecoderOptions(encoding.WithFlushItems(1)), WithRoundTrip(marshalLines, isCanonical))
+}
+
+// failureTB records whether a check failed, stopping the goroutine running it like testing.T.
+type failureTB struct {
+	testing.TB
+	failed bool
+}
+
+func (*failureTB) Helper() {}
+
+func (t *failureTB) Errorf(string, ...any) {
+	t.failed = true
+}
+
+func (t *failureTB) FailNow() {
+	t.failed = true
+	runtime.Goexit()
+}
+
+// checkFails reports whether Check fails for data.
+func checkFails(t *testing.T, newDecoder NewDecoderFunc, data []byte, options ...HarnessOption) bool {
+	tb := &failureTB{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Check(tb, newDecoder, data, options...)
+	}()
+	<-done
+	return tb.failed
+}
+
+// newFakeDecoder creates a decoder returning a single record per call with the given offsets, then io.EOF.
+func newFakeDecoder(offsets ...int64) NewDecoderFunc {
+	return func(io.Reader, ...encoding.DecoderOption) (encoding.LogsDecoder, error) {
+		var calls int
+		var offset int64
+		return xstreamencoding.NewLogsDecoderAdapter(func() (plog.Logs, error) {
+			if calls == len(offsets) {
+				return plog.NewLogs(), io.EOF
+			}
+			offset = offsets[calls]
+			calls++
+			logs := plog.NewLogs()
+			logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty().Body().SetStr("a")
+			return logs, nil
+		}, func() int64 { return offset }), nil
+	}
+}
+
+func TestCheck(t *testing.T) {
+	data := []byte("a\na\n")
+
+	assert.False(t, checkFails(t, newFakeDecoder(2, 4), data))
+	assert.True(t, checkFails(t, newFakeDecoder(4, 2), data), "offsets must not decrease")
+	assert.True(t, checkFails(t, newFakeDecoder(2, 5), data), "offsets must not exceed the input")
+	assert.True(t, checkFails(t, newFakeDecoder(1, 2, 3, 4, 4, 4), data), "decoders must end")
+
+	roundTrip := WithRoundTrip(marshalLines, isCanonical)
+	assert.False(t, checkFails(t, newFakeDecoder(2, 4), data, roundTrip))
+	assert.True(t, checkFails(t, newFakeDecoder(4), data, roundTrip), "records must marshal back to the input")
+}
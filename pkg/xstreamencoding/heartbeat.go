@@ -0,0 +1,71 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// HeartbeatReader is an io.Reader calling a heartbeat function every interval a read waits for data without
+// receiving any, e.g. so that consumers tell quiet but healthy streams from dead ones. Heartbeats stop as soon
+// as a read returns, and time spent between reads, such as processing decoded records, does not count as quiet.
+// The heartbeat function is called from another goroutine than the reader's.
+// Not safe for concurrent use.
+type HeartbeatReader struct {
+	reader    io.Reader
+	timer     *time.Timer
+	interval  time.Duration
+	heartbeat func()
+	// mu guards waiting, set while a read waits for data.
+	mu      sync.Mutex
+	waiting bool
+}
+
+// NewHeartbeatReader creates a new HeartbeatReader calling heartbeat every interval reader is quiet.
+func NewHeartbeatReader(reader io.Reader, interval time.Duration, heartbeat func()) *HeartbeatReader {
+	r := &HeartbeatReader{
+		reader:    reader,
+		interval:  interval,
+		heartbeat: heartbeat,
+	}
+	r.timer = time.AfterFunc(interval, r.beat)
+	r.timer.Stop()
+	return r
+}
+
+// Read reads from the wrapped reader, calling the heartbeat function while it waits for data.
+func (r *HeartbeatReader) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	r.waiting = true
+	r.timer.Reset(r.interval)
+	r.mu.Unlock()
+
+	n, err := r.reader.Read(p)
+
+	r.mu.Lock()
+	r.waiting = false
+	r.timer.Stop()
+	r.mu.Unlock()
+	return n, err
+}
+
+// beat calls the heartbeat function and rearms the timer, unless the read it was armed for returned.
+func (r *HeartbeatReader) beat() {
+	r.mu.Lock()
+	waiting := r.waiting
+	r.mu.Unlock()
+	if !waiting {
+		return
+	}
+
+	r.heartbeat()
+
+	r.mu.Lock()
+	if r.waiting {
+		r.timer.Reset(r.interval)
+	}
+	r.mu.Unlock()
+}
@@ -0,0 +1,87 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+func TestScannerHelper_Heartbeat(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	client, server := io.Pipe()
+	defer server.Close()
+
+	var heartbeats atomic.Int64
+	helper, err := NewScannerHelper(client, encoding.WithHeartbeat(interval, func() { heartbeats.Add(1) }))
+	require.NoError(t, err)
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		for {
+			line, _, err := helper.ScanString()
+			if err != nil {
+				return
+			}
+			lines <- line
+		}
+	}()
+
+	// Heartbeats fire repeatedly while the stream is quiet
+	require.Eventually(t, func() bool { return heartbeats.Load() >= 3 }, 5*time.Second, 10*time.Millisecond)
+
+	// Heartbeats stop while data flows faster than the interval
+	_, err = server.Write([]byte("line0\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "line0", <-lines)
+	quiet := heartbeats.Load()
+	for i := 1; i <= 10; i++ {
+		time.Sleep(interval / 5)
+		_, err = server.Write([]byte("line" + strings.Repeat("x", i) + "\n"))
+		require.NoError(t, err)
+		<-lines
+	}
+	assert.Equal(t, quiet, heartbeats.Load())
+
+	// Heartbeats resume once the stream is quiet again
+	require.Eventually(t, func() bool { return heartbeats.Load() > quiet }, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, server.Close())
+	_, ok := <-lines
+	assert.False(t, ok)
+}
+
+func TestHeartbeatReader(t *testing.T) {
+	t.Run("time between reads is not quiet", func(t *testing.T) {
+		var heartbeats atomic.Int64
+		reader := NewHeartbeatReader(strings.NewReader("data"), 10*time.Millisecond, func() { heartbeats.Add(1) })
+
+		buf := make([]byte, 2)
+		n, err := reader.Read(buf)
+		require.NoError(t, err)
+		assert.Equal(t, 2, n)
+		time.Sleep(50 * time.Millisecond)
+		assert.Zero(t, heartbeats.Load())
+
+		_, err = io.ReadAll(reader)
+		require.NoError(t, err)
+		time.Sleep(50 * time.Millisecond)
+		assert.Zero(t, heartbeats.Load())
+	})
+
+	t.Run("disabled without a heartbeat function", func(t *testing.T) {
+		helper, err := NewScannerHelper(strings.NewReader("line\n"), encoding.WithHeartbeat(time.Millisecond, nil))
+		require.NoError(t, err)
+		assert.IsType(t, &strings.Reader{}, helper.source)
+	})
+}
@@ -0,0 +1,72 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrIdleTimeout is returned by IdleCloseReader once it closed the wrapped reader for being idle.
+var ErrIdleTimeout = errors.New("stream closed after idle timeout")
+
+// IdleCloseReader is an io.ReadCloser closing the wrapped reader when a read waits for data longer than
+// the idle timeout, e.g. to free the resources of an abandoned connection. Time spent between reads,
+// such as processing decoded records, does not count as idle.
+// Not safe for concurrent use, except for Close.
+type IdleCloseReader struct {
+	reader    io.ReadCloser
+	timer     *time.Timer
+	timeout   time.Duration
+	idle      atomic.Bool
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewIdleCloseReader creates a new IdleCloseReader closing reader after timeout without data.
+func NewIdleCloseReader(reader io.ReadCloser, timeout time.Duration) *IdleCloseReader {
+	r := &IdleCloseReader{
+		reader:  reader,
+		timeout: timeout,
+	}
+	r.timer = time.AfterFunc(timeout, func() {
+		r.idle.Store(true)
+		_ = r.Close()
+	})
+	r.timer.Stop()
+	return r
+}
+
+// Read reads from the wrapped reader. It returns ErrIdleTimeout once the wrapped reader was closed for being idle.
+func (r *IdleCloseReader) Read(p []byte) (int, error) {
+	if r.idle.Load() {
+		return 0, ErrIdleTimeout
+	}
+
+	r.timer.Reset(r.timeout)
+	n, err := r.reader.Read(p)
+	r.timer.Stop()
+
+	if err != nil && r.idle.Load() {
+		return n, ErrIdleTimeout
+	}
+	return n, err
+}
+
+// Idle reports whether the wrapped reader was closed for being idle.
+func (r *IdleCloseReader) Idle() bool {
+	return r.idle.Load()
+}
+
+// Close stops the idle timer and closes the wrapped reader. It is safe to call multiple times.
+func (r *IdleCloseReader) Close() error {
+	r.closeOnce.Do(func() {
+		r.timer.Stop()
+		r.closeErr = r.reader.Close()
+	})
+	return r.closeErr
+}
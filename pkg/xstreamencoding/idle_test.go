@@ -0,0 +1,108 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+func TestScannerHelper_IdleCloseTimeout(t *testing.T) {
+	t.Run("stalled connection", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer server.Close()
+
+		helper, err := NewScannerHelper(client, encoding.WithIdleCloseTimeout(50*time.Millisecond))
+		require.NoError(t, err)
+
+		go func() {
+			// The connection stalls after the first record and a partial one
+			_, _ = server.Write([]byte("line1\nline"))
+		}()
+
+		line, _, err := helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "line1", line)
+
+		start := time.Now()
+		_, _, err = helper.ScanString()
+		require.ErrorIs(t, err, ErrIdleTimeout)
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+		assert.Equal(t, int64(6), helper.Offset())
+
+		// The connection was closed
+		_, err = server.Write([]byte("2\n"))
+		require.ErrorIs(t, err, io.ErrClosedPipe)
+
+		_, _, err = helper.ScanString()
+		require.ErrorIs(t, err, ErrIdleTimeout)
+	})
+
+	t.Run("slow but active connection", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer server.Close()
+		defer client.Close()
+
+		helper, err := NewScannerHelper(client, encoding.WithIdleCloseTimeout(200*time.Millisecond))
+		require.NoError(t, err)
+
+		go func() {
+			for range 3 {
+				time.Sleep(50 * time.Millisecond)
+				_, _ = server.Write([]byte("line\n"))
+			}
+			server.Close()
+		}()
+
+		var lines []string
+		for {
+			line, _, err := helper.ScanString()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			// Time spent between reads is not idle
+			time.Sleep(250 * time.Millisecond)
+			lines = append(lines, line)
+		}
+		assert.Equal(t, []string{"line", "line", "line"}, lines)
+	})
+
+	t.Run("not a closer", func(t *testing.T) {
+		helper, err := NewScannerHelper(strings.NewReader("line1\n"), encoding.WithIdleCloseTimeout(time.Millisecond))
+		require.NoError(t, err)
+
+		line, _, err := helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "line1", line)
+	})
+}
+
+func TestIdleCloseReader(t *testing.T) {
+	server, client := net.Pipe()
+	defer server.Close()
+
+	reader := NewIdleCloseReader(client, time.Hour)
+	go func() {
+		_, _ = server.Write([]byte("data"))
+	}()
+
+	buf := make([]byte, 4)
+	n, err := reader.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(buf[:n]))
+	assert.False(t, reader.Idle())
+
+	require.NoError(t, reader.Close())
+	require.NoError(t, reader.Close())
+	assert.False(t, reader.Idle())
+}
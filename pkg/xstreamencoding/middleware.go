@@ -0,0 +1,225 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+// LogsDecoderMiddleware wraps a LogsDecoder, e.g. to instrument, validate or transform the batches it returns,
+// without reimplementing the decoder. Middlewares build their decoder with WrapLogsDecoder, so that the Offset and
+// Close of the wrapped decoder pass through, and encoding.DecoderAs still finds its optional interfaces.
+type LogsDecoderMiddleware func(encoding.LogsDecoder) encoding.LogsDecoder
+
+// ChainLogsDecoderMiddlewares returns the middleware applying middlewares in order: the first one wraps the decoder,
+// and each following one wraps the previous one. The batches returned by the decoder therefore go through the
+// middlewares in the order they are listed, the last one returning them to the caller.
+func ChainLogsDecoderMiddlewares(middlewares ...LogsDecoderMiddleware) LogsDecoderMiddleware {
+	return func(decoder encoding.LogsDecoder) encoding.LogsDecoder {
+		for _, middleware := range middlewares {
+			decoder = middleware(decoder)
+		}
+		return decoder
+	}
+}
+
+// WrapLogsDecoder returns a LogsDecoder returning the batches of decode, typically a function of decoder.DecodeLogs,
+// and the offsets of offset, or of decoder when nil. The returned decoder implements io.Closer, closing decoder when
+// it implements io.Closer, and encoding.OffsetAware, declaring the semantics of decoder, encoding.OffsetSemanticsOpaque
+// by default. The other optional interfaces of decoder, e.g. encoding.StatsReporter, are still found by
+// encoding.DecoderAs, through the Unwrap method of the returned decoder.
+func WrapLogsDecoder(decoder encoding.LogsDecoder, decode func() (plog.Logs, error), offset func() int64) encoding.LogsDecoder {
+	if offset == nil {
+		offset = decoder.Offset
+	}
+	return &wrappedLogsDecoder{decoder: decoder, decode: decode, offset: offset}
+}
+
+var (
+	_ io.Closer            = (*wrappedLogsDecoder)(nil)
+	_ encoding.OffsetAware = (*wrappedLogsDecoder)(nil)
+)
+
+type wrappedLogsDecoder struct {
+	decoder encoding.LogsDecoder
+	decode  func() (plog.Logs, error)
+	offset  func() int64
+}
+
+func (d *wrappedLogsDecoder) DecodeLogs() (plog.Logs, error) {
+	return d.decode()
+}
+
+func (d *wrappedLogsDecoder) Offset() int64 {
+	return d.offset()
+}
+
+// Close closes the wrapped decoder when it implements io.Closer.
+func (d *wrappedLogsDecoder) Close() error {
+	if closer, ok := d.decoder.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// OffsetSemantics declares the semantics of the wrapped decoder.
+func (d *wrappedLogsDecoder) OffsetSemantics() encoding.OffsetSemantics {
+	if aware, ok := d.decoder.(encoding.OffsetAware); ok {
+		return aware.OffsetSemantics()
+	}
+	return encoding.OffsetSemanticsOpaque
+}
+
+// Unwrap returns the wrapped decoder.
+func (d *wrappedLogsDecoder) Unwrap() encoding.LogsDecoder {
+	return d.decoder
+}
+
+// middlewaresKey is the key of the middlewares set with WithMiddlewares in encoding.DecoderOptions.
+type middlewaresKey struct{}
+
+// WithMiddlewares appends middlewares to the ones wrapping the logs decoders created with the options. They are
+// applied in order, the first one wrapping the decoder, so that the batches it returns go through them in order, see
+// ChainLogsDecoderMiddlewares. Decoders that do not support them ignore them.
+func WithMiddlewares(middlewares ...LogsDecoderMiddleware) encoding.DecoderOption {
+	return func(o *encoding.DecoderOptions) {
+		encoding.WithValue(middlewaresKey{}, append(Middlewares(*o), middlewares...))(o)
+	}
+}
+
+// Middlewares returns the middlewares set with WithMiddlewares in options.
+func Middlewares(options encoding.DecoderOptions) []LogsDecoderMiddleware {
+	middlewares, _ := options.Value(middlewaresKey{}).([]LogsDecoderMiddleware)
+	return slices.Clip(middlewares)
+}
+
+// WithLogsMiddlewares wraps logs decoder adapters with middlewares, applied in order, see
+// ChainLogsDecoderMiddlewares, e.g. with the Middlewares of the options of the decoder.
+// It has no effect on metrics decoder adapters.
+func WithLogsMiddlewares(middlewares ...LogsDecoderMiddleware) DecoderAdapterOption {
+	return func(o *decoderAdapterOptions) {
+		o.middlewares = append(o.middlewares, middlewares...)
+	}
+}
+
+// NewTelemetryMiddleware returns a middleware counting the non-empty batches and the log records returned by
+// decoders, as well as the errors other than io.EOF, with the meter provider of settings. The metrics are
+// attributed to encodingID. It returns decoders as-is when settings has no meter provider.
+func NewTelemetryMiddleware(settings component.TelemetrySettings, encodingID component.ID) LogsDecoderMiddleware {
+	if settings.MeterProvider == nil {
+		return func(decoder encoding.LogsDecoder) encoding.LogsDecoder { return decoder }
+	}
+
+	meter := settings.MeterProvider.Meter(scopeName)
+	batches, errBatches := meter.Int64Counter(
+		"otelcol_decoder_returned_batches",
+		metric.WithDescription("Number of non-empty batches returned by stream decoders."),
+		metric.WithUnit("{batches}"),
+	)
+	records, errRecords := meter.Int64Counter(
+		"otelcol_decoder_returned_log_records",
+		metric.WithDescription("Number of log records returned by stream decoders."),
+		metric.WithUnit("{records}"),
+	)
+	failures, errFailures := meter.Int64Counter(
+		"otelcol_decoder_errors",
+		metric.WithDescription("Number of errors returned by stream decoders, excluding the end of streams."),
+		metric.WithUnit("{errors}"),
+	)
+	if err := errors.Join(errBatches, errRecords, errFailures); err != nil && settings.Logger != nil {
+		// The returned instruments are still usable, possibly as no-ops
+		settings.Logger.Warn("failed to create decoder middleware metrics", zap.Error(err))
+	}
+	attributes := metric.WithAttributeSet(attribute.NewSet(attribute.String(encodingAttribute, encodingID.String())))
+
+	return func(decoder encoding.LogsDecoder) encoding.LogsDecoder {
+		return WrapLogsDecoder(decoder, func() (plog.Logs, error) {
+			logs, err := decoder.DecodeLogs()
+			ctx := context.Background()
+			if !isZeroLogs(logs) {
+				if n := logs.LogRecordCount(); n > 0 {
+					batches.Add(ctx, 1, attributes)
+					records.Add(ctx, int64(n), attributes)
+				}
+			}
+			if err != nil && !errors.Is(err, io.EOF) {
+				failures.Add(ctx, 1, attributes)
+			}
+			return logs, err
+		}, nil)
+	}
+}
+
+// NewSplitMiddleware returns a middleware splitting the batches returned by decoders with SplitLogs, so that the
+// log records of each batch add up to at most maxBytes in the OTLP protobuf encoding. As with WithMaxBatchBytes,
+// the offset and offset token of the decoder are the ones before a batch while chunks of it remain to be
+// returned. It returns decoders
+// as-is when maxBytes is not positive.
+func NewSplitMiddleware(maxBytes int) LogsDecoderMiddleware {
+	return func(decoder encoding.LogsDecoder) encoding.LogsDecoder {
+		if maxBytes <= 0 {
+			return decoder
+		}
+		splitter := &logsSplitter{decode: decoder.DecodeLogs, offset: decoder.Offset, maxBytes: maxBytes}
+		if opaque, ok := encoding.DecoderAs[encoding.OpaqueOffsetDecoder](decoder); ok {
+			splitter.token = opaque.OffsetToken
+		}
+		return splitLogsDecoder{WrapLogsDecoder(decoder, splitter.DecodeLogs, splitter.Offset).(*wrappedLogsDecoder), splitter}
+	}
+}
+
+// splitLogsDecoder is the decoder of NewSplitMiddleware. It provides the offset token of the splitter in place of the
+// one of the decoder it wraps, when that decoder provides encoding.OpaqueOffsetDecoder.
+type splitLogsDecoder struct {
+	*wrappedLogsDecoder
+	splitter *logsSplitter
+}
+
+// As sets target to the splitter when it points to an encoding.OpaqueOffsetDecoder and the wrapped decoder provides
+// one, see encoding.DecoderAs.
+func (d splitLogsDecoder) As(target any) bool {
+	opaque, ok := target.(*encoding.OpaqueOffsetDecoder)
+	if !ok || d.splitter.token == nil {
+		return false
+	}
+	*opaque = d.splitter
+	return true
+}
+
+// NewResourceAttributesMiddleware returns a middleware setting attributes on every resource of the batches returned
+// by decoders, overwriting the attributes of the same keys, e.g. to stamp the origin of the stream.
+func NewResourceAttributesMiddleware(attributes map[string]string) LogsDecoderMiddleware {
+	return func(decoder encoding.LogsDecoder) encoding.LogsDecoder {
+		return WrapLogsDecoder(decoder, func() (plog.Logs, error) {
+			logs, err := decoder.DecodeLogs()
+			if isZeroLogs(logs) {
+				return logs, err
+			}
+			for i := 0; i < logs.ResourceLogs().Len(); i++ {
+				resourceAttributes := logs.ResourceLogs().At(i).Resource().Attributes()
+				for key, value := range attributes {
+					resourceAttributes.PutStr(key, value)
+				}
+			}
+			return logs, err
+		}, nil)
+	}
+}
+
+// isZeroLogs returns whether logs is the zero value, as returned by decoders along with errors, which must not be
+// accessed.
+func isZeroLogs(logs plog.Logs) bool {
+	return logs == (plog.Logs{})
+}
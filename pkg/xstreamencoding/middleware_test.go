@@ -0,0 +1,300 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/collector/pdata/plog"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+func TestLogsDecoderAdapterWithOptions_Middlewares(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	settings := component.TelemetrySettings{
+		Logger:        zap.NewNop(),
+		MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	}
+
+	record := plog.NewLogRecord()
+	record.Body().SetStr("record")
+	var sizer plog.ProtoMarshaler
+	recordSize := sizer.LogRecordSize(record)
+
+	errDecode := errors.New("decode error")
+	batches := []plog.Logs{
+		newSplitTestLogs([]string{"record", "record", "record"}),
+		newSplitTestLogs([]string{"record", "record"}),
+	}
+	errs := []error{nil, errDecode}
+	var calls int
+	var offset int64
+	decode := func() (plog.Logs, error) {
+		if calls == len(batches) {
+			// The zero value returned along with errors goes through the middlewares as-is.
+			return plog.Logs{}, io.EOF
+		}
+		logs, err := batches[calls], errs[calls]
+		calls++
+		offset = int64(calls * 10)
+		return logs, err
+	}
+
+	// The batches go through the middlewares in order: resources are stamped, then split, then counted.
+	decoder := NewLogsDecoderAdapterWithOptions(decode, func() int64 { return offset },
+		WithOffsetSemantics(encoding.OffsetSemanticsBytes),
+		WithLogsMiddlewares(
+			NewResourceAttributesMiddleware(map[string]string{"origin": "stream"}),
+			NewSplitMiddleware(2*recordSize),
+		),
+		WithLogsMiddlewares(NewTelemetryMiddleware(settings, component.MustNewIDWithName("text_encoding", "test"))),
+	)
+
+	expected := []struct {
+		records int
+		offset  int64
+		err     error
+	}{
+		// The offset of the adapter passes through the middlewares, and stays before a batch until all its chunks
+		// are returned.
+		{records: 2, offset: 0},
+		{records: 1, offset: 10},
+		{records: 2, offset: 20, err: errDecode},
+	}
+	for i, e := range expected {
+		logs, err := decoder.DecodeLogs()
+		if e.err != nil {
+			require.ErrorIs(t, err, e.err, "batch %d", i)
+		} else {
+			require.NoError(t, err, "batch %d", i)
+		}
+		assert.Equal(t, e.records, logs.LogRecordCount(), "batch %d", i)
+		assert.Equal(t, e.offset, decoder.Offset(), "batch %d", i)
+		for j := 0; j < logs.ResourceLogs().Len(); j++ {
+			origin, ok := logs.ResourceLogs().At(j).Resource().Attributes().Get("origin")
+			require.True(t, ok, "batch %d", i)
+			assert.Equal(t, "stream", origin.Str(), "batch %d", i)
+		}
+	}
+	logs, err := decoder.DecodeLogs()
+	require.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, plog.Logs{}, logs)
+
+	assert.Equal(t, encoding.OffsetSemanticsBytes, decoder.(encoding.OffsetAware).OffsetSemantics())
+	assert.NoError(t, decoder.(io.Closer).Close())
+
+	// The chunks are counted, being split before the telemetry middleware.
+	assert.Equal(t, map[string]int64{
+		"otelcol_decoder_returned_batches":     3,
+		"otelcol_decoder_returned_log_records": 5,
+		"otelcol_decoder_errors":               1,
+	}, collectCounters(t, reader))
+}
+
+func TestMiddlewares_OptionalInterfaces(t *testing.T) {
+	record := plog.NewLogRecord()
+	record.Body().SetStr("record")
+	var sizer plog.ProtoMarshaler
+	recordSize := sizer.LogRecordSize(record)
+
+	var calls int
+	decode := func() (plog.Logs, error) {
+		if calls == 1 {
+			return plog.NewLogs(), io.EOF
+		}
+		calls++
+		return newSplitTestLogs([]string{"record", "record", "record"}), nil
+	}
+	decoder := NewLogsDecoderAdapterWithOptions(decode, func() int64 { return int64(calls) },
+		WithStatsFunc(func() encoding.DecoderStats { return encoding.DecoderStats{RecordsDecoded: int64(3 * calls)} }),
+		WithOffsetTokenFunc(func() string { return "token-" + strconv.Itoa(calls) }),
+		WithSkippedFunc(func() int64 { return 1 }),
+		WithLogsMiddlewares(
+			NewResourceAttributesMiddleware(map[string]string{"origin": "stream"}),
+			NewSplitMiddleware(2*recordSize),
+			NewTelemetryMiddleware(component.TelemetrySettings{Logger: zap.NewNop(), MeterProvider: sdkmetric.NewMeterProvider()}, component.MustNewID("text_encoding")),
+		),
+	)
+
+	// The optional interfaces of the adapter are found through the three middlewares wrapping it.
+	stats, ok := encoding.DecoderAs[encoding.StatsReporter](decoder)
+	require.True(t, ok)
+	skipped, ok := encoding.DecoderAs[encoding.SkipReporting](decoder)
+	require.True(t, ok)
+	assert.Equal(t, int64(1), skipped.SkippedRecords())
+	assert.Equal(t, "token-0", opaqueOffsetToken(t, decoder))
+
+	// The token is the one of the split middleware, staying before the batch until all its chunks are returned.
+	expected := []struct {
+		records int
+		token   string
+	}{
+		{records: 2, token: "token-0"},
+		{records: 1, token: "token-1"},
+	}
+	for i, e := range expected {
+		logs, err := decoder.DecodeLogs()
+		require.NoError(t, err, "batch %d", i)
+		assert.Equal(t, e.records, logs.LogRecordCount(), "batch %d", i)
+		assert.Equal(t, e.token, opaqueOffsetToken(t, decoder), "batch %d", i)
+		assert.Equal(t, encoding.NewDecoderOptions(encoding.WithOffsetToken(e.token)), encoding.NewDecoderOptions(encoding.ResumeOption(decoder)), "batch %d", i)
+	}
+	assert.Equal(t, int64(3), stats.Stats().RecordsDecoded)
+
+	_, err := decoder.DecodeLogs()
+	require.ErrorIs(t, err, io.EOF)
+
+	// Without a token on the wrapped decoder, the split middleware provides none.
+	decoder = NewLogsDecoderAdapterWithOptions(decode, func() int64 { return 0 }, WithLogsMiddlewares(NewSplitMiddleware(2*recordSize)))
+	_, ok = encoding.DecoderAs[encoding.OpaqueOffsetDecoder](decoder)
+	assert.False(t, ok)
+}
+
+func TestLogsUnmarshalerDecoderFactory_Middlewares(t *testing.T) {
+	logs := newSplitTestLogs([]string{"a", "b"}, []string{"c"})
+	var marshaler plog.JSONMarshaler
+	buf, err := marshaler.MarshalLogs(logs)
+	require.NoError(t, err)
+
+	factory := NewLogsUnmarshalerDecoderFactory(&plog.JSONUnmarshaler{})
+	decoder, err := factory.NewLogsDecoder(strings.NewReader(string(buf)),
+		WithMiddlewares(NewResourceAttributesMiddleware(map[string]string{"origin": "stream"})),
+	)
+	require.NoError(t, err)
+
+	decoded, err := decoder.DecodeLogs()
+	require.NoError(t, err)
+	require.Equal(t, 2, decoded.ResourceLogs().Len())
+	for i := 0; i < decoded.ResourceLogs().Len(); i++ {
+		origin, ok := decoded.ResourceLogs().At(i).Resource().Attributes().Get("origin")
+		require.True(t, ok)
+		assert.Equal(t, "stream", origin.Str())
+	}
+	assert.Equal(t, int64(len(buf)), decoder.Offset())
+
+	_, err = decoder.DecodeLogs()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestMiddlewares_Disabled(t *testing.T) {
+	decoder := NewLogsDecoderAdapter(func() (plog.Logs, error) { return plog.Logs{}, io.EOF }, func() int64 { return 0 })
+
+	// Middlewares without effect return the decoder as-is.
+	assert.IsType(t, LogsDecoderAdapter{}, NewSplitMiddleware(0)(decoder))
+	assert.IsType(t, LogsDecoderAdapter{}, NewTelemetryMiddleware(component.TelemetrySettings{}, component.MustNewID("text_encoding"))(decoder))
+}
+
+// bodiesDecoder returns a batch holding a log record per body, then io.EOF.
+type bodiesDecoder struct {
+	bodies []string
+	offset int64
+	closed bool
+}
+
+func (d *bodiesDecoder) DecodeLogs() (plog.Logs, error) {
+	if len(d.bodies) == 0 {
+		return plog.NewLogs(), io.EOF
+	}
+	logs := plog.NewLogs()
+	records := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	for _, body := range d.bodies {
+		records.AppendEmpty().Body().SetStr(body)
+	}
+	d.offset += int64(len(d.bodies))
+	d.bodies = nil
+	return logs, nil
+}
+
+func (d *bodiesDecoder) Offset() int64 {
+	return d.offset
+}
+
+func (d *bodiesDecoder) Close() error {
+	d.closed = true
+	return nil
+}
+
+// appendMiddleware appends suffix to the bodies of the log records of each batch.
+func appendMiddleware(suffix string) LogsDecoderMiddleware {
+	return func(decoder encoding.LogsDecoder) encoding.LogsDecoder {
+		return WrapLogsDecoder(decoder, func() (plog.Logs, error) {
+			logs, err := decoder.DecodeLogs()
+			for _, rl := range logs.ResourceLogs().All() {
+				for _, sl := range rl.ScopeLogs().All() {
+					for _, lr := range sl.LogRecords().All() {
+						lr.Body().SetStr(lr.Body().Str() + suffix)
+					}
+				}
+			}
+			return logs, err
+		}, nil)
+	}
+}
+
+func TestChainLogsDecoderMiddlewares(t *testing.T) {
+	inner := &bodiesDecoder{bodies: []string{"foo", "bar"}, offset: 10}
+	decoder := ChainLogsDecoderMiddlewares(appendMiddleware("-a"), appendMiddleware("-b"), appendMiddleware("-c"))(inner)
+	assert.Equal(t, int64(10), decoder.Offset())
+
+	// Batches go through the middlewares in the order they are listed.
+	logs, err := decoder.DecodeLogs()
+	require.NoError(t, err)
+	records := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	assert.Equal(t, "foo-a-b-c", records.At(0).Body().Str())
+	assert.Equal(t, "bar-a-b-c", records.At(1).Body().Str())
+	assert.Equal(t, int64(12), decoder.Offset())
+
+	_, err = decoder.DecodeLogs()
+	require.ErrorIs(t, err, io.EOF)
+
+	closer, ok := decoder.(io.Closer)
+	require.True(t, ok)
+	require.NoError(t, closer.Close())
+	assert.True(t, inner.closed)
+
+	// No middleware returns the decoder as-is.
+	assert.Same(t, inner, ChainLogsDecoderMiddlewares()(inner))
+}
+
+func TestWrapLogsDecoder(t *testing.T) {
+	inner := &bodiesDecoder{offset: 5}
+	errDecode := errors.New("decode failed")
+	decoder := WrapLogsDecoder(inner, func() (plog.Logs, error) {
+		return plog.NewLogs(), errDecode
+	}, func() int64 { return 42 })
+
+	_, err := decoder.DecodeLogs()
+	assert.ErrorIs(t, err, errDecode)
+	assert.Equal(t, int64(42), decoder.Offset())
+	assert.Equal(t, encoding.OffsetSemanticsOpaque, decoder.(encoding.OffsetAware).OffsetSemantics())
+	assert.Same(t, inner, decoder.(interface{ Unwrap() encoding.LogsDecoder }).Unwrap())
+
+	// Decoders not implementing io.Closer are closed as no-ops.
+	wrapped := WrapLogsDecoder(struct{ encoding.LogsDecoder }{inner}, inner.DecodeLogs, nil)
+	require.NoError(t, wrapped.(io.Closer).Close())
+	assert.False(t, inner.closed)
+	assert.Equal(t, int64(5), wrapped.Offset())
+}
+
+func TestWithMiddlewares(t *testing.T) {
+	assert.Empty(t, Middlewares(encoding.NewDecoderOptions()))
+
+	identity := func(decoder encoding.LogsDecoder) encoding.LogsDecoder { return decoder }
+	base := encoding.NewDecoderOptions(WithMiddlewares(identity))
+	options := base
+	WithMiddlewares(identity, identity)(&options)
+	assert.Len(t, Middlewares(options), 3)
+	// Appending does not change the middlewares of the options it was applied to.
+	assert.Len(t, Middlewares(base), 1)
+}
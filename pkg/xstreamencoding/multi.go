@@ -0,0 +1,138 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+// SizedReader is an io.Reader along with its size in bytes.
+type SizedReader struct {
+	Reader io.Reader
+	Size   int64
+}
+
+// MultiScannerHelper behaves like ScannerHelper over a sequence of readers, e.g. rotated files read in order.
+// It advances to the next reader when the current one is exhausted and only returns io.EOF after the final one.
+// Each reader ends the record being read, so a record never spans two readers. Batch flushing is shared
+// across readers, so a batch may contain records from several of them.
+type MultiScannerHelper struct {
+	batchHelper *BatchHelper
+	readers     []SizedReader
+	current     *ScannerHelper
+	// base is the cumulative offset of the readers preceding the current one.
+	base int64
+}
+
+// NewMultiScannerHelper creates a new MultiScannerHelper reading the given readers in order.
+// Offsets are cumulative across readers. An offset configured through encoding.WithOffset
+// skips whole readers based on their sizes, then discards the remainder from the first reader read.
+// Sizes must therefore match the number of bytes each reader yields.
+func NewMultiScannerHelper(readers []SizedReader, opts ...encoding.DecoderOption) (*MultiScannerHelper, error) {
+	batchHelper := NewBatchHelper(opts...)
+	h := &MultiScannerHelper{
+		batchHelper: batchHelper,
+		readers:     readers,
+	}
+
+	offset := batchHelper.options.Offset
+	for len(h.readers) > 0 && offset >= h.readers[0].Size {
+		offset -= h.readers[0].Size
+		h.base += h.readers[0].Size
+		h.readers = h.readers[1:]
+	}
+	if len(h.readers) == 0 {
+		if offset != 0 {
+			return nil, fmt.Errorf("failed to discard offset %d: %w", batchHelper.options.Offset, io.EOF)
+		}
+		return h, nil
+	}
+
+	if err := h.advance(offset); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// advance starts reading the next reader from offset.
+func (h *MultiScannerHelper) advance(offset int64) error {
+	if h.current != nil {
+		h.base += h.current.Offset()
+		_ = h.current.Close()
+	}
+	current, err := newScannerHelper(h.readers[0].Reader, h.batchHelper, offset)
+	if err != nil {
+		return err
+	}
+	h.current = current
+	h.readers = h.readers[1:]
+	return nil
+}
+
+// ScanString scans the next line from the readers and returns it as a string. This excludes new line delimiter.
+// It has the same semantics as ScannerHelper.ScanString, io.EOF being returned once the final reader is exhausted.
+func (h *MultiScannerHelper) ScanString() (line string, flush bool, err error) {
+	b, flush, err := h.batchHelper.scan(h.scanInternal)
+	return string(b), flush, err
+}
+
+// ScanBytes scans the next line from the readers and returns it as a byte slice. This excludes new line delimiter.
+// It has the same semantics as ScannerHelper.ScanBytes, io.EOF being returned once the final reader is exhausted.
+func (h *MultiScannerHelper) ScanBytes() (bytes []byte, flush bool, err error) {
+	b, flush, err := h.batchHelper.scan(h.scanInternal)
+	if b != nil {
+		cpy := make([]byte, len(b))
+		copy(cpy, b)
+		return cpy, flush, err
+	}
+	return nil, flush, err
+}
+
+func (h *MultiScannerHelper) scanInternal() ([]byte, bool, error) {
+	for h.current != nil {
+		b, flush, err := h.current.scanInternal()
+		if err != io.EOF || len(h.readers) == 0 {
+			return b, flush, err
+		}
+
+		// The current reader is exhausted, but more remain: the batch goes on with the next one.
+		if advanceErr := h.advance(0); advanceErr != nil {
+			return nil, false, advanceErr
+		}
+		if b != nil {
+			return b, flush, nil
+		}
+	}
+	return nil, true, io.EOF
+}
+
+// Close stops reading the current reader ahead, see ScannerHelper.Close.
+func (h *MultiScannerHelper) Close() error {
+	if h.current == nil {
+		return nil
+	}
+	return h.current.Close()
+}
+
+// Offset returns the current byte offset read from the readers, cumulative across readers.
+func (h *MultiScannerHelper) Offset() int64 {
+	if h.current == nil {
+		return h.base
+	}
+	return h.base + h.current.Offset()
+}
+
+// Options returns the DecoderOptions used by the MultiScannerHelper's BatchHelper.
+func (h *MultiScannerHelper) Options() encoding.DecoderOptions {
+	return h.batchHelper.Options()
+}
+
+// Stats returns the cumulative statistics of the MultiScannerHelper across readers, see BatchHelper.Stats.
+// It is safe to call concurrently with scanning.
+func (h *MultiScannerHelper) Stats() encoding.DecoderStats {
+	return h.batchHelper.Stats()
+}
@@ -0,0 +1,115 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+func sizedReaders(inputs ...string) []SizedReader {
+	readers := make([]SizedReader, 0, len(inputs))
+	for _, input := range inputs {
+		readers = append(readers, SizedReader{Reader: strings.NewReader(input), Size: int64(len(input))})
+	}
+	return readers
+}
+
+type scanResult struct {
+	line   string
+	flush  bool
+	offset int64
+}
+
+func scanAll(t *testing.T, helper *MultiScannerHelper) []scanResult {
+	var results []scanResult
+	for {
+		line, flush, err := helper.ScanString()
+		if err != nil && err != io.EOF {
+			require.NoError(t, err)
+		}
+		if line != "" || err == nil {
+			results = append(results, scanResult{line: line, flush: flush, offset: helper.Offset()})
+		}
+		if err == io.EOF {
+			return results
+		}
+	}
+}
+
+func TestMultiScannerHelper_Scan(t *testing.T) {
+	helper, err := NewMultiScannerHelper(sizedReaders("a1\na2\n", "", "b1\nb2\n", "c1\n"), encoding.WithFlushItems(3))
+	require.NoError(t, err)
+
+	assert.Equal(t, []scanResult{
+		{line: "a1", offset: 3},
+		{line: "a2", offset: 6},
+		{line: "b1", flush: true, offset: 9},
+		{line: "b2", offset: 12},
+		{line: "c1", offset: 15},
+	}, scanAll(t, helper))
+
+	_, _, err = helper.ScanString()
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, int64(15), helper.Offset())
+}
+
+func TestMultiScannerHelper_RecordEndsWithReader(t *testing.T) {
+	helper, err := NewMultiScannerHelper(sizedReaders("a1\na2", "b1\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []scanResult{
+		{line: "a1", offset: 3},
+		{line: "a2", offset: 5},
+		{line: "b1", offset: 8},
+	}, scanAll(t, helper))
+}
+
+func TestMultiScannerHelper_InitialOffset(t *testing.T) {
+	tests := []struct {
+		name     string
+		offset   int64
+		expected []scanResult
+	}{
+		{
+			name:   "within first reader",
+			offset: 3,
+			expected: []scanResult{
+				{line: "a2", offset: 6},
+				{line: "b1", offset: 9},
+			},
+		},
+		{
+			name:   "skips whole reader",
+			offset: 6,
+			expected: []scanResult{
+				{line: "b1", offset: 9},
+			},
+		},
+		{
+			name:     "skips all readers",
+			offset:   9,
+			expected: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			helper, err := NewMultiScannerHelper(sizedReaders("a1\na2\n", "b1\n"), encoding.WithOffset(tt.offset))
+			require.NoError(t, err)
+			assert.Equal(t, tt.offset, helper.Offset())
+			assert.Equal(t, tt.expected, scanAll(t, helper))
+		})
+	}
+
+	t.Run("offset beyond readers", func(t *testing.T) {
+		_, err := NewMultiScannerHelper(sizedReaders("a1\na2\n", "b1\n"), encoding.WithOffset(10))
+		require.ErrorContains(t, err, "failed to discard offset 10")
+	})
+}
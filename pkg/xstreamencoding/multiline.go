@@ -0,0 +1,290 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+// errMultilineTimeout is returned by multilineTimeoutReader when no data was read within the timeout.
+var errMultilineTimeout = errors.New("no data read within the multiline timeout")
+
+// MultilineConfig configures how MultilineScannerHelper aggregates lines into records.
+// Exactly one of LineStartPattern and LineEndPattern must be set.
+type MultilineConfig struct {
+	// LineStartPattern matches the first line of records: a record spans the lines up to the next match.
+	LineStartPattern *regexp.Regexp
+	// LineEndPattern matches the last line of records: a record spans the lines up to the first match.
+	LineEndPattern *regexp.Regexp
+	// MaxLines is the number of lines after which a record is emitted even though it is not terminated, bounding
+	// the memory held by records that never are. 0 disables it.
+	MaxLines int
+	// Timeout is the time after which the lines read so far are emitted as a record when no more data is read,
+	// e.g. so that the last stack trace of a quiet stream is not held until the next one. The read waiting for
+	// data then goes on in the background, until the reader returns. 0 disables it.
+	Timeout time.Duration
+}
+
+// MultilineScannerHelper is a helper to scan records spanning several lines from io.Reader, e.g. stack traces,
+// and determine when to flush. Lines are delimited as with ScannerHelper, and aggregated into records per
+// MultilineConfig. Not safe for concurrent use.
+type MultilineScannerHelper struct {
+	batchHelper *BatchHelper
+	bufReader   *bufio.Reader
+	config      MultilineConfig
+	// timeoutReader is set when MultilineConfig.Timeout is set, armed while lines are pending.
+	timeoutReader *multilineTimeoutReader
+	// offset is the position of the start of pending, after the last record returned.
+	offset int64
+	// pending holds the lines of the record being aggregated, including their delimiters, and pendingLines counts them.
+	pending      []byte
+	pendingLines int
+	// partial holds an incomplete line read before an error interrupted scanning.
+	partial []byte
+}
+
+// NewMultilineScannerHelper creates a new MultilineScannerHelper that aggregates the lines of the provided
+// io.Reader into records per config. It accepts optional encoding.DecoderOption to configure batch flushing
+// behavior. As with ScannerHelper, a bufio.Reader is used as-is, otherwise one is derived with the buffer size
+// configured through encoding.WithReaderBufferSize. A bufio.Reader cannot be combined with MultilineConfig.Timeout.
+//
+// The offset of the helper always is the start of the next record to be returned, so that decoding resumed
+// from it with encoding.WithOffset never splits a record, even though the lines read ahead of it to find its end,
+// e.g. the first line of the next record, were consumed from the stream.
+func NewMultilineScannerHelper(reader io.Reader, config MultilineConfig, opts ...encoding.DecoderOption) (*MultilineScannerHelper, error) {
+	if (config.LineStartPattern == nil) == (config.LineEndPattern == nil) {
+		return nil, errors.New("exactly one of the line start and line end patterns must be set")
+	}
+	if config.MaxLines < 0 {
+		return nil, fmt.Errorf("max lines must not be negative, got %d", config.MaxLines)
+	}
+	if config.Timeout < 0 {
+		return nil, fmt.Errorf("timeout must not be negative, got %s", config.Timeout)
+	}
+
+	batchHelper := NewBatchHelper(opts...)
+	h := &MultilineScannerHelper{batchHelper: batchHelper, config: config}
+	if br, ok := reader.(*bufio.Reader); ok {
+		if config.Timeout > 0 {
+			return nil, errors.New("timeout requires a reader other than a bufio.Reader")
+		}
+		h.bufReader = br
+	} else {
+		if config.Timeout > 0 {
+			h.timeoutReader = &multilineTimeoutReader{reader: reader, timeout: config.Timeout}
+			reader = h.timeoutReader
+		}
+		size := batchHelper.options.ReaderBufferSize
+		if size <= 0 {
+			size = defaultReaderBufferSize
+		}
+		h.bufReader = bufio.NewReaderSize(reader, size)
+	}
+
+	if offset := batchHelper.options.Offset; offset != 0 {
+		if _, err := h.bufReader.Discard(int(offset)); err != nil {
+			return nil, fmt.Errorf("failed to discard offset %d: %w", offset, err)
+		}
+		h.offset = offset
+	}
+	return h, nil
+}
+
+// ScanString scans the next record from the stream and returns it as a string. This includes the delimiters
+// between its lines, but not the one terminating its last line, as with ScannerHelper.
+// flush indicates whether the batch should be flushed after processing this string.
+// err is non-nil if an error occurred during scanning. If the end of the stream is reached, err will be io.EOF,
+// returned along with the last record, however it ends.
+// As with ScannerHelper, a *QuotaExceededError carries the offset to resume from, the start of the pending record,
+// and scanning may be retried once the quota refreshes.
+func (h *MultilineScannerHelper) ScanString() (record string, flush bool, err error) {
+	b, flush, err := h.batchHelper.scan(h.scanInternal)
+	return string(b), flush, err
+}
+
+// ScanBytes scans the next record from the stream and returns it as a byte slice.
+// It has the same semantics as ScanString.
+func (h *MultilineScannerHelper) ScanBytes() (record []byte, flush bool, err error) {
+	// Records are never reused by the helper, so they are returned as-is.
+	return h.batchHelper.scan(h.scanInternal)
+}
+
+func (h *MultilineScannerHelper) scanInternal() ([]byte, bool, error) {
+	delimiter := h.batchHelper.options.RecordDelimiter()
+	for {
+		if h.timeoutReader != nil {
+			h.timeoutReader.armed = h.pendingLines > 0
+		}
+		line, err := h.bufReader.ReadBytes(delimiter)
+		if len(h.partial) > 0 {
+			line = append(h.partial, line...)
+			h.partial = nil
+		}
+
+		var isEOF bool
+		if err != nil {
+			switch {
+			case err == io.EOF:
+				isEOF = true
+			case errors.Is(err, errMultilineTimeout):
+				// The incomplete line, if any, belongs to the next record.
+				h.partial = line
+				if b, flush, ok := h.emit(nil); ok {
+					return b, flush, nil
+				}
+				continue
+			default:
+				// Keep the incomplete line so that it can be completed by the next call.
+				h.partial = line
+				if errors.Is(err, ErrQuotaExceeded) {
+					return nil, false, &QuotaExceededError{Offset: h.offset}
+				}
+				return nil, false, err
+			}
+		}
+
+		if isEOF {
+			// The last record is emitted whether it is terminated or not.
+			h.pending = append(h.pending, line...)
+			if b, flush, ok := h.emit(nil); ok {
+				return b, flush, io.EOF
+			}
+			return nil, true, io.EOF
+		}
+
+		content := trimDelimiter(line, delimiter)
+		starts := h.config.LineStartPattern != nil && h.config.LineStartPattern.Match(content)
+		full := h.config.MaxLines > 0 && h.pendingLines >= h.config.MaxLines
+		if h.pendingLines > 0 && (starts || full) {
+			// The line starts the next record, which is pending until it ends in turn.
+			if b, flush, ok := h.emit(line); ok {
+				return b, flush, nil
+			}
+			continue
+		}
+
+		h.pending = append(h.pending, line...)
+		h.pendingLines++
+		ended := h.config.LineEndPattern != nil && h.config.LineEndPattern.Match(content)
+		if ended || (h.config.MaxLines > 0 && h.pendingLines >= h.config.MaxLines) {
+			if b, flush, ok := h.emit(nil); ok {
+				return b, flush, nil
+			}
+		}
+	}
+}
+
+// emit returns the pending record, replacing it with next, the first line of the next record if any.
+// ok is false when the record is empty, or skipped per encoding.WithSkipEmptyRecords.
+func (h *MultilineScannerHelper) emit(next []byte) (record []byte, flush, ok bool) {
+	raw := h.pending
+	h.pending, h.pendingLines = next, 0
+	if next != nil {
+		h.pendingLines = 1
+	}
+	if len(raw) == 0 {
+		return nil, false, false
+	}
+
+	h.offset += int64(len(raw))
+	h.batchHelper.IncrementBytes(int64(len(raw)))
+
+	record = trimDelimiter(raw, h.batchHelper.options.RecordDelimiter())
+	flush, skip := h.batchHelper.track(record)
+	if skip {
+		return nil, false, false
+	}
+	return record, flush, true
+}
+
+// Offset returns the offset of the start of the next record, after the last record returned.
+// Lines of the next record already read from the stream are not accounted.
+func (h *MultilineScannerHelper) Offset() int64 {
+	return h.offset
+}
+
+// Options returns the DecoderOptions used by the MultilineScannerHelper's BatchHelper.
+func (h *MultilineScannerHelper) Options() encoding.DecoderOptions {
+	return h.batchHelper.Options()
+}
+
+// Stats returns the cumulative statistics of the MultilineScannerHelper, see BatchHelper.Stats.
+// It is safe to call concurrently with scanning.
+func (h *MultilineScannerHelper) Stats() encoding.DecoderStats {
+	return h.batchHelper.Stats()
+}
+
+// SetLogsBatchID stamps the resources of logs with the id of the batch, see BatchHelper.SetLogsBatchID.
+func (h *MultilineScannerHelper) SetLogsBatchID(logs plog.Logs) {
+	h.batchHelper.SetLogsBatchID(logs)
+}
+
+// multilineReadResult is the result of a read of the wrapped reader of multilineTimeoutReader.
+type multilineReadResult struct {
+	data []byte
+	err  error
+}
+
+// multilineTimeoutReader reads the wrapped reader, returning errMultilineTimeout when armed and no data is read
+// within the timeout. The read then goes on in the background, and its result is returned by the next read.
+// Not safe for concurrent use.
+type multilineTimeoutReader struct {
+	reader  io.Reader
+	timeout time.Duration
+	// armed enables the timeout, set by the helper while lines are pending.
+	armed bool
+	// inflight receives the result of the read going on in the background, nil when none.
+	inflight chan multilineReadResult
+	// result holds the data of the last background read not returned yet, then its error.
+	result *multilineReadResult
+}
+
+// Read reads from the wrapped reader, waiting for at most the timeout when armed.
+func (r *multilineTimeoutReader) Read(p []byte) (int, error) {
+	if r.result == nil {
+		if r.inflight == nil && !r.armed {
+			return r.reader.Read(p)
+		}
+		if r.inflight == nil {
+			inflight := make(chan multilineReadResult, 1)
+			buf := make([]byte, len(p))
+			go func() {
+				n, err := r.reader.Read(buf)
+				inflight <- multilineReadResult{data: buf[:n], err: err}
+			}()
+			r.inflight = inflight
+		}
+		if !r.armed {
+			result := <-r.inflight
+			r.result, r.inflight = &result, nil
+		} else {
+			timer := time.NewTimer(r.timeout)
+			defer timer.Stop()
+			select {
+			case result := <-r.inflight:
+				r.result, r.inflight = &result, nil
+			case <-timer.C:
+				return 0, errMultilineTimeout
+			}
+		}
+	}
+
+	n := copy(p, r.result.data)
+	r.result.data = r.result.data[n:]
+	if len(r.result.data) > 0 {
+		return n, nil
+	}
+	err := r.result.err
+	r.result = nil
+	return n, err
+}
@@ -0,0 +1,222 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+const stackTraces = "2024-01-02 ERROR request failed\n" +
+	"java.lang.IllegalStateException: boom\n" +
+	"\tat com.example.Handler.handle(Handler.java:42)\n" +
+	"2024-01-02 INFO request served\r\n" +
+	"2024-01-02 ERROR request failed\n" +
+	"\tat com.example.Handler.handle(Handler.java:42)"
+
+var lineStart = regexp.MustCompile(`^\d{4}-\d{2}-\d{2} `)
+
+// scanMultiline returns the records of helper, including the last one returned along with io.EOF.
+func scanMultiline(t *testing.T, helper *MultilineScannerHelper) []scanResult {
+	var results []scanResult
+	for {
+		record, flush, err := helper.ScanBytes()
+		if record != nil {
+			results = append(results, scanResult{line: string(record), flush: flush, offset: helper.Offset()})
+		}
+		if err == io.EOF {
+			return results
+		}
+		require.NoError(t, err)
+	}
+}
+
+func TestMultilineScannerHelper_LineStartPattern(t *testing.T) {
+	expected := []scanResult{
+		{line: "2024-01-02 ERROR request failed\njava.lang.IllegalStateException: boom\n\tat com.example.Handler.handle(Handler.java:42)", offset: 118},
+		{line: "2024-01-02 INFO request served", flush: true, offset: 150},
+		// The last record is emitted at the end of the stream, without a start pattern following it.
+		{line: "2024-01-02 ERROR request failed\n\tat com.example.Handler.handle(Handler.java:42)", offset: int64(len(stackTraces))},
+	}
+	for _, reader := range []io.Reader{
+		strings.NewReader(stackTraces),
+		iotest.OneByteReader(strings.NewReader(stackTraces)),
+		bufio.NewReader(strings.NewReader(stackTraces)),
+	} {
+		helper, err := NewMultilineScannerHelper(reader, MultilineConfig{LineStartPattern: lineStart}, encoding.WithFlushItems(2))
+		require.NoError(t, err)
+		assert.Equal(t, expected, scanMultiline(t, helper))
+		assert.Equal(t, int64(3), helper.Stats().RecordsDecoded)
+	}
+}
+
+func TestMultilineScannerHelper_LineEndPattern(t *testing.T) {
+	input := "SELECT *\nFROM logs\nWHERE id = 1;\nCOMMIT;\nSELECT 1"
+	helper, err := NewMultilineScannerHelper(strings.NewReader(input), MultilineConfig{LineEndPattern: regexp.MustCompile(`;$`)})
+	require.NoError(t, err)
+
+	assert.Equal(t, []scanResult{
+		{line: "SELECT *\nFROM logs\nWHERE id = 1;", offset: 33},
+		{line: "COMMIT;", offset: 41},
+		{line: "SELECT 1", offset: 49},
+	}, scanMultiline(t, helper))
+}
+
+func TestMultilineScannerHelper_MaxLines(t *testing.T) {
+	t.Run("line start pattern", func(t *testing.T) {
+		input := "start\na\nb\nc\nd\nstart\ne\n"
+		helper, err := NewMultilineScannerHelper(strings.NewReader(input), MultilineConfig{LineStartPattern: regexp.MustCompile(`^start`), MaxLines: 2})
+		require.NoError(t, err)
+
+		var records []string
+		for _, result := range scanMultiline(t, helper) {
+			records = append(records, result.line)
+		}
+		assert.Equal(t, []string{"start\na", "b\nc", "d", "start\ne"}, records)
+	})
+
+	t.Run("line end pattern", func(t *testing.T) {
+		input := "a\nb\nc\nend\nd\nend\n"
+		helper, err := NewMultilineScannerHelper(strings.NewReader(input), MultilineConfig{LineEndPattern: regexp.MustCompile(`^end$`), MaxLines: 2})
+		require.NoError(t, err)
+
+		var records []string
+		for _, result := range scanMultiline(t, helper) {
+			records = append(records, result.line)
+		}
+		assert.Equal(t, []string{"a\nb", "c\nend", "d\nend"}, records)
+	})
+
+	t.Run("single line", func(t *testing.T) {
+		helper, err := NewMultilineScannerHelper(strings.NewReader("start\na\nb"), MultilineConfig{LineStartPattern: regexp.MustCompile(`^start`), MaxLines: 1})
+		require.NoError(t, err)
+
+		var records []string
+		for _, result := range scanMultiline(t, helper) {
+			records = append(records, result.line)
+		}
+		assert.Equal(t, []string{"start", "a", "b"}, records)
+	})
+}
+
+func TestMultilineScannerHelper_Resume(t *testing.T) {
+	helper, err := NewMultilineScannerHelper(strings.NewReader(stackTraces), MultilineConfig{LineStartPattern: lineStart})
+	require.NoError(t, err)
+	expected := scanMultiline(t, helper)
+
+	// Resuming from the offset of any record yields the records following it, whole.
+	for i, result := range expected[:len(expected)-1] {
+		resumed, err := NewMultilineScannerHelper(strings.NewReader(stackTraces), MultilineConfig{LineStartPattern: lineStart}, encoding.WithOffset(result.offset))
+		require.NoError(t, err)
+
+		var got, want []string
+		for _, r := range scanMultiline(t, resumed) {
+			got = append(got, r.line)
+		}
+		for _, r := range expected[i+1:] {
+			want = append(want, r.line)
+		}
+		assert.Equal(t, want, got, "resumed from offset %d", result.offset)
+	}
+}
+
+func TestMultilineScannerHelper_QuotaExceeded(t *testing.T) {
+	quota := &tokenBucket{tokens: 20}
+	input := "start 1\nfoo\nstart 2\nbar\n"
+	helper, err := NewMultilineScannerHelper(NewQuotaReader(strings.NewReader(input), quota), MultilineConfig{LineStartPattern: regexp.MustCompile(`^start`)})
+	require.NoError(t, err)
+
+	record, _, err := helper.ScanString()
+	require.NoError(t, err)
+	assert.Equal(t, "start 1\nfoo", record)
+
+	// The second record is pending, so the offset to resume from is its start.
+	_, _, err = helper.ScanString()
+	var quotaErr *QuotaExceededError
+	require.ErrorAs(t, err, &quotaErr)
+	assert.Equal(t, int64(12), quotaErr.Offset)
+
+	quota.refill(100)
+	record, _, err = helper.ScanString()
+	require.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, "start 2\nbar", record)
+	assert.Equal(t, int64(len(input)), helper.Offset())
+}
+
+func TestMultilineScannerHelper_Timeout(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	reader, writer := io.Pipe()
+	helper, err := NewMultilineScannerHelper(reader, MultilineConfig{LineStartPattern: regexp.MustCompile(`^start`), Timeout: 20 * time.Millisecond})
+	require.NoError(t, err)
+
+	go func() {
+		_, _ = writer.Write([]byte("start 1\nfoo\n"))
+	}()
+	// No start pattern follows, the pending record is emitted once no data is read for the timeout.
+	record, _, err := helper.ScanString()
+	require.NoError(t, err)
+	assert.Equal(t, "start 1\nfoo", record)
+	assert.Equal(t, int64(12), helper.Offset())
+
+	go func() {
+		_, _ = writer.Write([]byte("start 2\nbar"))
+		_ = writer.Close()
+	}()
+	record, _, err = helper.ScanString()
+	require.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, "start 2\nbar", record)
+	assert.Equal(t, int64(23), helper.Offset())
+}
+
+func TestMultilineScannerHelper_Config(t *testing.T) {
+	tests := []struct {
+		name   string
+		reader io.Reader
+		config MultilineConfig
+		err    string
+	}{
+		{
+			name:   "no pattern",
+			config: MultilineConfig{},
+			err:    "exactly one of the line start and line end patterns must be set",
+		},
+		{
+			name:   "both patterns",
+			config: MultilineConfig{LineStartPattern: lineStart, LineEndPattern: lineStart},
+			err:    "exactly one of the line start and line end patterns must be set",
+		},
+		{
+			name:   "negative max lines",
+			config: MultilineConfig{LineStartPattern: lineStart, MaxLines: -1},
+			err:    "max lines must not be negative, got -1",
+		},
+		{
+			name:   "timeout with bufio.Reader",
+			reader: bufio.NewReader(strings.NewReader("")),
+			config: MultilineConfig{LineStartPattern: lineStart, Timeout: time.Second},
+			err:    "timeout requires a reader other than a bufio.Reader",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := tt.reader
+			if reader == nil {
+				reader = strings.NewReader("")
+			}
+			_, err := NewMultilineScannerHelper(reader, tt.config)
+			assert.EqualError(t, err, tt.err)
+		})
+	}
+}
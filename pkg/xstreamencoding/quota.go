@@ -0,0 +1,97 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrQuotaExceeded is matched by errors.Is for any QuotaExceededError.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaExceededError is returned when a Quota denies reading more bytes from the stream.
+type QuotaExceededError struct {
+	// Offset is the stream offset to resume reading from once the quota refreshes.
+	// When returned by QuotaReader, it is the number of bytes read through the reader.
+	// When returned by ScannerHelper, it is the offset after the last complete record.
+	Offset int64
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s at offset %d", ErrQuotaExceeded, e.Offset)
+}
+
+// Is reports whether target is ErrQuotaExceeded.
+func (*QuotaExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// Quota grants byte budgets to a QuotaReader, e.g. per tenant and time window.
+// Implementations must be safe for concurrent use if shared between readers.
+type Quota interface {
+	// Allow requests n bytes and returns the number of bytes granted, which can be less than n.
+	// Granting 0 bytes denies the read.
+	Allow(n int64) (granted int64, err error)
+}
+
+// QuotaReader is an io.Reader that consults a Quota before handing out bytes read from the wrapped reader.
+// Once the quota is exhausted, Read returns a *QuotaExceededError. Bytes read from the wrapped reader but not
+// granted are kept, so reading can continue once the quota refreshes.
+// Not safe for concurrent use.
+type QuotaReader struct {
+	reader  io.Reader
+	quota   Quota
+	offset  int64
+	buf     []byte
+	pending []byte
+	err     error
+}
+
+// NewQuotaReader creates a new QuotaReader reading from reader within the budget granted by quota.
+func NewQuotaReader(reader io.Reader, quota Quota) *QuotaReader {
+	return &QuotaReader{
+		reader: reader,
+		quota:  quota,
+	}
+}
+
+// Read reads up to len(p) bytes granted by the quota.
+func (r *QuotaReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		n, err := r.reader.Read(p)
+		r.err = err
+		if n == 0 {
+			return 0, err
+		}
+		r.buf = append(r.buf[:0], p[:n]...)
+		r.pending = r.buf
+	}
+
+	n := min(len(p), len(r.pending))
+	granted, err := r.quota.Allow(int64(n))
+	if err != nil {
+		return 0, err
+	}
+	if granted <= 0 {
+		return 0, &QuotaExceededError{Offset: r.offset}
+	}
+	n = copy(p, r.pending[:min(int64(n), granted)])
+	r.pending = r.pending[n:]
+	r.offset += int64(n)
+	return n, nil
+}
+
+// Offset returns the number of bytes read through the QuotaReader.
+func (r *QuotaReader) Offset() int64 {
+	return r.offset
+}
@@ -0,0 +1,124 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+// tokenBucket is a fake Quota granting up to the remaining tokens until refilled.
+type tokenBucket struct {
+	tokens int64
+}
+
+func (b *tokenBucket) Allow(n int64) (int64, error) {
+	granted := min(n, b.tokens)
+	b.tokens -= granted
+	return granted, nil
+}
+
+func (b *tokenBucket) refill(n int64) {
+	b.tokens += n
+}
+
+type errQuota struct{}
+
+func (errQuota) Allow(int64) (int64, error) {
+	return 0, assert.AnError
+}
+
+func TestQuotaReader(t *testing.T) {
+	t.Run("partial grants", func(t *testing.T) {
+		quota := &tokenBucket{tokens: 4}
+		reader := NewQuotaReader(strings.NewReader("0123456789"), quota)
+
+		buf := make([]byte, 10)
+		n, err := reader.Read(buf)
+		require.NoError(t, err)
+		assert.Equal(t, "0123", string(buf[:n]))
+		assert.Equal(t, int64(4), reader.Offset())
+
+		_, err = reader.Read(buf)
+		var quotaErr *QuotaExceededError
+		require.ErrorAs(t, err, &quotaErr)
+		require.ErrorIs(t, err, ErrQuotaExceeded)
+		assert.Equal(t, int64(4), quotaErr.Offset)
+
+		quota.refill(100)
+		b, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "456789", string(b))
+		assert.Equal(t, int64(10), reader.Offset())
+	})
+
+	t.Run("quota error propagates", func(t *testing.T) {
+		reader := NewQuotaReader(strings.NewReader("0123456789"), errQuota{})
+		_, err := reader.Read(make([]byte, 10))
+		require.ErrorIs(t, err, assert.AnError)
+	})
+}
+
+func TestStreamScannerHelper_QuotaExceeded(t *testing.T) {
+	input := "line1\nline2\nline3\n"
+
+	t.Run("resume in place after refill", func(t *testing.T) {
+		quota := &tokenBucket{tokens: 9}
+		helper, err := NewScannerHelper(NewQuotaReader(strings.NewReader(input), quota))
+		require.NoError(t, err)
+
+		line, _, err := helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "line1", line)
+
+		// "lin" of line2 was granted but the record is incomplete
+		_, _, err = helper.ScanString()
+		var quotaErr *QuotaExceededError
+		require.ErrorAs(t, err, &quotaErr)
+		assert.Equal(t, int64(6), quotaErr.Offset)
+		assert.Equal(t, int64(6), helper.Offset())
+
+		quota.refill(100)
+		line, _, err = helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "line2", line)
+		assert.Equal(t, int64(12), helper.Offset())
+
+		line, _, err = helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "line3", line)
+
+		_, _, err = helper.ScanString()
+		assert.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("resume from offset after refill", func(t *testing.T) {
+		quota := &tokenBucket{tokens: 9}
+		helper, err := NewScannerHelper(NewQuotaReader(strings.NewReader(input), quota))
+		require.NoError(t, err)
+
+		line, _, err := helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "line1", line)
+
+		_, _, err = helper.ScanString()
+		var quotaErr *QuotaExceededError
+		require.ErrorAs(t, err, &quotaErr)
+
+		quota.refill(100)
+		helper, err = NewScannerHelper(NewQuotaReader(strings.NewReader(input), quota), encoding.WithOffset(quotaErr.Offset))
+		require.NoError(t, err)
+
+		line, _, err = helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "line2", line)
+		assert.Equal(t, int64(12), helper.Offset())
+	})
+}
@@ -0,0 +1,135 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrReadAheadClosed is returned by ReadAheadReader.Read once the reader is closed.
+var ErrReadAheadClosed = errors.New("read-ahead reader is closed")
+
+// readAheadChunk is the result of a read of the wrapped reader into buf.
+type readAheadChunk struct {
+	buf []byte
+	n   int
+	err error
+}
+
+// ReadAheadReader is an io.ReadCloser reading the wrapped reader ahead from a background goroutine, into a ring of
+// buffers, while the data already read is consumed, e.g. to overlap the latency of reads from object storage with
+// parsing. The goroutine waits for a buffer to be consumed once all of them are filled, and stops at the first
+// error of the wrapped reader, which is returned once the data read before it is consumed.
+// Not safe for concurrent use, except for Close.
+type ReadAheadReader struct {
+	ctx    context.Context
+	reader io.Reader
+	// free holds the buffers available to the goroutine, filled the chunks it read, in order.
+	free   chan []byte
+	filled chan readAheadChunk
+	// current is the chunk being consumed, from pos.
+	current readAheadChunk
+	pos     int
+	// closed is closed along with the reader, stopping the goroutine, and finished once it returned.
+	closed    chan struct{}
+	closeOnce sync.Once
+	finished  chan struct{}
+}
+
+// NewReadAheadReader creates a new ReadAheadReader filling up to buffers buffers of size bytes from reader.
+// It reads ahead until the first error of reader, ctx is cancelled or Close is called, whichever comes first.
+// Reads return the error of ctx once cancelled, and ErrReadAheadClosed once closed.
+func NewReadAheadReader(ctx context.Context, reader io.Reader, buffers, size int) *ReadAheadReader {
+	buffers, size = max(buffers, 1), max(size, 1)
+	r := &ReadAheadReader{
+		ctx:      ctx,
+		reader:   reader,
+		free:     make(chan []byte, buffers),
+		filled:   make(chan readAheadChunk, buffers),
+		closed:   make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+	for range buffers {
+		r.free <- make([]byte, size)
+	}
+	go r.readAhead()
+	return r
+}
+
+// readAhead fills the free buffers from the wrapped reader until it fails, ctx is cancelled or r is closed.
+func (r *ReadAheadReader) readAhead() {
+	defer close(r.finished)
+	for {
+		var buf []byte
+		select {
+		case buf = <-r.free:
+		case <-r.closed:
+			return
+		case <-r.ctx.Done():
+			return
+		}
+
+		n, err := r.reader.Read(buf)
+		select {
+		case r.filled <- readAheadChunk{buf: buf, n: n, err: err}:
+		case <-r.closed:
+			return
+		case <-r.ctx.Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Read reads the data read ahead, waiting for the next buffer to be filled when all of it was consumed.
+// Once closed, it returns ErrReadAheadClosed, discarding the data read ahead.
+func (r *ReadAheadReader) Read(p []byte) (int, error) {
+	select {
+	case <-r.closed:
+		return 0, ErrReadAheadClosed
+	default:
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for r.pos == r.current.n {
+		if r.current.err != nil {
+			return 0, r.current.err
+		}
+		if r.current.buf != nil {
+			// The channel has room for all the buffers, so returning one never blocks.
+			r.free <- r.current.buf
+			r.current.buf = nil
+		}
+
+		select {
+		case r.current = <-r.filled:
+			r.pos = 0
+		case <-r.closed:
+			return 0, ErrReadAheadClosed
+		case <-r.ctx.Done():
+			return 0, r.ctx.Err()
+		}
+	}
+
+	n := copy(p, r.current.buf[r.pos:r.current.n])
+	r.pos += n
+	return n, nil
+}
+
+// Close stops reading ahead and waits for the goroutine to return, along with the read of the wrapped reader it
+// may be waiting for. The wrapped reader is not closed. Close is safe to call several times, and concurrently
+// with Read.
+func (r *ReadAheadReader) Close() error {
+	r.closeOnce.Do(func() {
+		close(r.closed)
+	})
+	<-r.finished
+	return nil
+}
@@ -0,0 +1,207 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+// readCounter counts the reads of the wrapped reader.
+type readCounter struct {
+	reader io.Reader
+	reads  atomic.Int64
+}
+
+func (r *readCounter) Read(p []byte) (int, error) {
+	r.reads.Add(1)
+	return r.reader.Read(p)
+}
+
+// blockingReader signals reads, then blocks them until unblocked and returns io.EOF.
+type blockingReader struct {
+	reading chan struct{}
+	unblock chan struct{}
+}
+
+func (r *blockingReader) Read([]byte) (int, error) {
+	close(r.reading)
+	<-r.unblock
+	return 0, io.EOF
+}
+
+func TestReadAheadReader(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	content := []byte(strings.Repeat("0123456789", 100))
+
+	r := NewReadAheadReader(context.Background(), iotest.HalfReader(strings.NewReader(string(content))), 3, 16)
+	require.NoError(t, iotest.TestReader(r, content))
+	require.NoError(t, r.Close())
+}
+
+func TestReadAheadReader_ErrorPropagation(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	errRead := errors.New("connection reset")
+
+	r := NewReadAheadReader(context.Background(), iotest.DataErrReader(io.MultiReader(strings.NewReader("foo"), iotest.ErrReader(errRead))), 2, 16)
+
+	// The data read before the error is returned first
+	data, err := io.ReadAll(r)
+	require.ErrorIs(t, err, errRead)
+	assert.Equal(t, "foo", string(data))
+
+	_, err = r.Read(make([]byte, 8))
+	require.ErrorIs(t, err, errRead)
+	require.NoError(t, r.Close())
+}
+
+func TestReadAheadReader_Backpressure(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	reader := &readCounter{reader: strings.NewReader(strings.Repeat("x", 1024))}
+
+	r := NewReadAheadReader(context.Background(), reader, 2, 8)
+
+	// Reading ahead stops once both buffers are filled, until one is consumed.
+	require.Eventually(t, func() bool { return reader.reads.Load() == 2 }, time.Second, time.Millisecond)
+	require.Never(t, func() bool { return reader.reads.Load() > 2 }, 50*time.Millisecond, time.Millisecond)
+
+	_, err := io.ReadFull(r, make([]byte, 9))
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return reader.reads.Load() == 3 }, time.Second, time.Millisecond)
+
+	require.NoError(t, r.Close())
+	require.NoError(t, r.Close())
+	_, err = r.Read(make([]byte, 16))
+	assert.ErrorIs(t, err, ErrReadAheadClosed)
+}
+
+func TestReadAheadReader_CloseWaitsForRead(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	reader := &blockingReader{reading: make(chan struct{}), unblock: make(chan struct{})}
+
+	r := NewReadAheadReader(context.Background(), reader, 1, 8)
+	<-reader.reading
+	closed := make(chan struct{})
+	go func() {
+		assert.NoError(t, r.Close())
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+		t.Fatal("Close returned while a read was pending")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(reader.unblock)
+	<-closed
+}
+
+func TestReadAheadReader_Cancel(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	reader := &readCounter{reader: strings.NewReader(strings.Repeat("x", 1024))}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r := NewReadAheadReader(ctx, reader, 2, 8)
+	_, err := io.ReadFull(r, make([]byte, 8))
+	require.NoError(t, err)
+
+	cancel()
+	// Data already read ahead may still be returned, but reads stop at the latest once it is consumed.
+	_, err = io.ReadAll(r)
+	require.ErrorIs(t, err, context.Canceled)
+	require.NoError(t, r.Close())
+	assert.Less(t, reader.reads.Load(), int64(1024/8))
+}
+
+func TestScannerHelper_ReadAhead(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	input := "foo\nbar\r\n\nbaz\nqux"
+
+	expected := scanClosing(t, strings.NewReader(input), encoding.WithFlushItems(2))
+	for _, size := range []int{0, 1, 3, 64} {
+		got := scanClosing(t, iotest.OneByteReader(strings.NewReader(input)), encoding.WithFlushItems(2), encoding.WithReadAhead(2, size))
+		assert.Equal(t, expected, got, "read-ahead buffers of %d bytes", size)
+	}
+
+	t.Run("initial offset", func(t *testing.T) {
+		defer goleak.VerifyNone(t)
+		helper, err := NewScannerHelper(strings.NewReader(input), encoding.WithOffset(4), encoding.WithReadAhead(2, 4))
+		require.NoError(t, err)
+		line, _, err := helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "bar", line)
+		assert.Equal(t, int64(9), helper.Offset())
+		require.NoError(t, helper.Close())
+		require.NoError(t, helper.Close())
+	})
+
+	t.Run("rewind and reset", func(t *testing.T) {
+		defer goleak.VerifyNone(t)
+		helper, err := NewScannerHelper(strings.NewReader(input), encoding.WithReadAhead(2, 4))
+		require.NoError(t, err)
+		_, _, err = helper.ScanString()
+		require.NoError(t, err)
+
+		// Rewinding discards the data read ahead of the previous position
+		require.NoError(t, helper.Rewind(9))
+		line, _, err := helper.ScanString()
+		require.NoError(t, err)
+		assert.Empty(t, line)
+		line, _, err = helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "baz", line)
+
+		require.NoError(t, helper.Reset(strings.NewReader("reset\n"), encoding.WithReadAhead(1, 2)))
+		line, _, err = helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "reset", line)
+		require.NoError(t, helper.Close())
+	})
+}
+
+// scanClosing returns the lines, flushes and offsets scanned from reader, closing the helper at the end.
+func scanClosing(t *testing.T, reader io.Reader, opts ...encoding.DecoderOption) []scanResult {
+	helper, err := NewScannerHelper(reader, opts...)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, helper.Close()) }()
+
+	var results []scanResult
+	for {
+		line, flush, err := helper.ScanString()
+		results = append(results, scanResult{line: line, flush: flush, offset: helper.Offset()})
+		if err == io.EOF {
+			return results
+		}
+		require.NoError(t, err)
+	}
+}
+
+func TestMultiScannerHelper_ReadAhead(t *testing.T) {
+	defer goleak.VerifyNone(t)
+	helper, err := NewMultiScannerHelper([]SizedReader{
+		{Reader: strings.NewReader("foo\nbar\n"), Size: 8},
+		{Reader: strings.NewReader("baz\n"), Size: 4},
+	}, encoding.WithReadAhead(2, 2))
+	require.NoError(t, err)
+
+	assert.Equal(t, []scanResult{
+		{line: "foo", offset: 4},
+		{line: "bar", offset: 8},
+		{line: "baz", offset: 12},
+	}, scanAll(t, helper))
+	assert.Equal(t, int64(12), helper.Offset())
+	require.NoError(t, helper.Close())
+}
@@ -0,0 +1,192 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+// maxSeparatorSize is the room left after a record of encoding.WithMaxRecordSize for the separator following it.
+// Records not followed by a separator within this room fail with bufio.ErrTooLong.
+const maxSeparatorSize = 64
+
+// RegexpScannerHelper is a helper to scan records separated by the matches of a regular expression from io.Reader
+// and determine when to flush, e.g. for text streams whose records are separated by blank lines.
+// Not safe for concurrent use.
+type RegexpScannerHelper struct {
+	batchHelper *BatchHelper
+	bufReader   *bufio.Reader
+	separator   *regexp.Regexp
+	offset      int64
+	// buf holds the bytes read from bufReader and not scanned yet.
+	buf []byte
+	// eof is set once bufReader returned io.EOF, buf then holds the rest of the stream.
+	eof bool
+}
+
+// NewRegexpScannerHelper creates a new RegexpScannerHelper that reads the records of the provided io.Reader,
+// separated by the matches of separator, which must not match the empty string. It accepts optional
+// encoding.DecoderOption to configure batch flushing behavior. As with ScannerHelper, a bufio.Reader is used as-is,
+// otherwise one is derived with the buffer size configured through encoding.WithReaderBufferSize.
+//
+// encoding.WithMaxRecordSize limits the size of records, excluding their separator, beyond which scanning fails
+// with bufio.ErrTooLong. Records are not limited by default.
+func NewRegexpScannerHelper(reader io.Reader, separator *regexp.Regexp, opts ...encoding.DecoderOption) (*RegexpScannerHelper, error) {
+	if separator.MatchString("") {
+		return nil, fmt.Errorf("separator %q matches the empty string", separator)
+	}
+	batchHelper := NewBatchHelper(opts...)
+	h := &RegexpScannerHelper{batchHelper: batchHelper, separator: separator}
+	if br, ok := reader.(*bufio.Reader); ok {
+		h.bufReader = br
+	} else {
+		size := batchHelper.options.ReaderBufferSize
+		if size <= 0 {
+			size = defaultReaderBufferSize
+		}
+		h.bufReader = bufio.NewReaderSize(reader, size)
+	}
+
+	if offset := batchHelper.options.Offset; offset != 0 {
+		if _, err := h.bufReader.Discard(int(offset)); err != nil {
+			return nil, fmt.Errorf("failed to discard offset %d: %w", offset, err)
+		}
+		h.offset = offset
+	}
+	return h, nil
+}
+
+// ScanString scans the next record from the stream and returns it as a string. This excludes the separator.
+// flush indicates whether the batch should be flushed after processing this string.
+// err is non-nil if an error occurred during scanning. If the end of the stream is reached, err will be io.EOF,
+// returned along with the last record when it is not followed by a separator.
+// A separator match ending at the end of the bytes read so far is only accepted once more bytes are read, or the
+// stream ends, so that separators matching a variable number of bytes, e.g. "\n+", are never split across reads.
+// As with ScannerHelper, a record interrupted by an error, e.g. a *QuotaExceededError, is completed by the next call.
+func (h *RegexpScannerHelper) ScanString() (record string, flush bool, err error) {
+	b, flush, err := h.batchHelper.scan(h.scanInternal)
+	return string(b), flush, err
+}
+
+// ScanBytes scans the next record from the stream and returns it as a byte slice.
+// It has the same semantics as ScanString.
+func (h *RegexpScannerHelper) ScanBytes() (record []byte, flush bool, err error) {
+	b, flush, err := h.batchHelper.scan(h.scanInternal)
+	if b != nil {
+		cpy := make([]byte, len(b))
+		copy(cpy, b)
+		return cpy, flush, err
+	}
+	return nil, flush, err
+}
+
+func (h *RegexpScannerHelper) scanInternal() ([]byte, bool, error) {
+	for {
+		b, advance, isEOF, err := h.next()
+		if err != nil {
+			return nil, false, err
+		}
+		if advance == 0 && isEOF {
+			return nil, true, io.EOF
+		}
+		h.buf = h.buf[advance:]
+
+		h.offset += int64(advance)
+		h.batchHelper.IncrementBytes(int64(advance))
+
+		flush, skip := h.batchHelper.track(b)
+		if skip {
+			// The offset still moves past skipped records so that decoding can be resumed.
+			if isEOF {
+				return nil, true, io.EOF
+			}
+			continue
+		}
+
+		if isEOF {
+			return b, flush, io.EOF
+		}
+		return b, flush, nil
+	}
+}
+
+// next returns the next record of buf, excluding its separator, and the number of bytes it spans along with its
+// separator, reading from bufReader until a separator is matched or the stream ends. last is set for the bytes
+// following the last separator of the stream, if any.
+func (h *RegexpScannerHelper) next() (record []byte, advance int, last bool, err error) {
+	maxSize := h.batchHelper.options.MaxRecordSize
+	for {
+		loc := h.separator.FindIndex(h.buf)
+		// A match ending at the end of buf may extend over the bytes not read yet.
+		if loc != nil && (loc[1] < len(h.buf) || h.eof) {
+			if maxSize > 0 && loc[0] > maxSize {
+				return nil, 0, false, bufio.ErrTooLong
+			}
+			return h.buf[:loc[0]], loc[1], false, nil
+		}
+		if h.eof {
+			if maxSize > 0 && len(h.buf) > maxSize {
+				return nil, 0, false, bufio.ErrTooLong
+			}
+			return h.buf, len(h.buf), true, nil
+		}
+		if maxSize > 0 && len(h.buf) > maxSize+maxSeparatorSize {
+			return nil, 0, false, bufio.ErrTooLong
+		}
+		if err := h.fill(); err != nil {
+			return nil, 0, false, err
+		}
+	}
+}
+
+// fill appends the next bytes of bufReader to buf, setting eof at the end of the stream. The bytes read before
+// an error are kept, so that scanning can be retried.
+func (h *RegexpScannerHelper) fill() error {
+	if len(h.buf) == cap(h.buf) {
+		// Move the bytes left to the start of a larger buffer, rather than growing the scanned ones with them.
+		buf := make([]byte, len(h.buf), max(2*cap(h.buf), h.bufReader.Size()))
+		copy(buf, h.buf)
+		h.buf = buf
+	}
+	n, err := h.bufReader.Read(h.buf[len(h.buf):cap(h.buf)])
+	h.buf = h.buf[:len(h.buf)+n]
+	switch {
+	case err == io.EOF:
+		h.eof = true
+		return nil
+	case errors.Is(err, ErrQuotaExceeded):
+		return &QuotaExceededError{Offset: h.offset}
+	default:
+		return err
+	}
+}
+
+// Offset returns the current byte offset read from the stream, always after the separator of the last record.
+func (h *RegexpScannerHelper) Offset() int64 {
+	return h.offset
+}
+
+// Options returns the DecoderOptions used by the RegexpScannerHelper's BatchHelper.
+func (h *RegexpScannerHelper) Options() encoding.DecoderOptions {
+	return h.batchHelper.Options()
+}
+
+// Stats returns the cumulative statistics of the RegexpScannerHelper, see BatchHelper.Stats.
+// It is safe to call concurrently with scanning.
+func (h *RegexpScannerHelper) Stats() encoding.DecoderStats {
+	return h.batchHelper.Stats()
+}
+
+// SetLogsBatchID stamps the resources of logs with the id of the batch, see BatchHelper.SetLogsBatchID.
+func (h *RegexpScannerHelper) SetLogsBatchID(logs plog.Logs) {
+	h.batchHelper.SetLogsBatchID(logs)
+}
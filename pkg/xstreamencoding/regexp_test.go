@@ -0,0 +1,188 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+// scanRegexp returns the records of helper, including the last one returned along with io.EOF.
+func scanRegexp(t *testing.T, helper *RegexpScannerHelper) []scanResult {
+	var results []scanResult
+	for {
+		record, flush, err := helper.ScanBytes()
+		if record != nil {
+			results = append(results, scanResult{line: string(record), flush: flush, offset: helper.Offset()})
+		}
+		if err == io.EOF {
+			return results
+		}
+		require.NoError(t, err)
+	}
+}
+
+func TestRegexpScannerHelper_Scan(t *testing.T) {
+	helper, err := NewRegexpScannerHelper(strings.NewReader("foo\r\nbar\n\nbaz"), regexp.MustCompile(`\r?\n`), encoding.WithFlushItems(2))
+	require.NoError(t, err)
+
+	assert.Equal(t, []scanResult{
+		{line: "foo", offset: 5},
+		{line: "bar", flush: true, offset: 9},
+		{line: "", offset: 10},
+		{line: "baz", flush: true, offset: 13},
+	}, scanRegexp(t, helper))
+
+	_, _, err = helper.ScanString()
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, int64(13), helper.Offset())
+}
+
+func TestRegexpScannerHelper_ScanString(t *testing.T) {
+	helper, err := NewRegexpScannerHelper(strings.NewReader("foo--bar--"), regexp.MustCompile(`--`))
+	require.NoError(t, err)
+
+	record, _, err := helper.ScanString()
+	require.NoError(t, err)
+	assert.Equal(t, "foo", record)
+
+	record, _, err = helper.ScanString()
+	require.NoError(t, err)
+	assert.Equal(t, "bar", record)
+
+	// The stream ending with a separator has no last record
+	record, flush, err := helper.ScanString()
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Empty(t, record)
+	assert.True(t, flush)
+}
+
+func TestRegexpScannerHelper_SeparatorAcrossReads(t *testing.T) {
+	tests := []struct {
+		name      string
+		separator string
+		input     string
+		want      []string
+	}{
+		{
+			name:      "variable length separator",
+			separator: `\n+`,
+			input:     "foo\n\n\nbar\n\nbaz\n",
+			want:      []string{"foo", "bar", "baz"},
+		},
+		{
+			name:      "multi-byte separator",
+			separator: `\r?\n---\r?\n`,
+			input:     "foo\r\n---\r\nbar\n---\nbaz",
+			want:      []string{"foo", "bar", "baz"},
+		},
+		{
+			name:      "blank line separator",
+			separator: `\n\s*\n`,
+			input:     "first\nrecord\n \t\nsecond\nrecord\n\n",
+			want:      []string{"first\nrecord", "second\nrecord"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Reading a byte at a time makes every separator match straddle reads.
+			for _, reader := range []io.Reader{
+				strings.NewReader(tt.input),
+				iotest.OneByteReader(strings.NewReader(tt.input)),
+				bufio.NewReaderSize(iotest.HalfReader(strings.NewReader(tt.input)), 16),
+			} {
+				helper, err := NewRegexpScannerHelper(reader, regexp.MustCompile(tt.separator))
+				require.NoError(t, err)
+
+				var records []string
+				for _, result := range scanRegexp(t, helper) {
+					records = append(records, result.line)
+				}
+				assert.Equal(t, tt.want, records)
+				assert.Equal(t, int64(len(tt.input)), helper.Offset())
+			}
+		})
+	}
+}
+
+func TestRegexpScannerHelper_SkipEmptyRecords(t *testing.T) {
+	helper, err := NewRegexpScannerHelper(strings.NewReader("foo;;  ;bar;"), regexp.MustCompile(`;`), encoding.WithSkipEmptyRecords(true))
+	require.NoError(t, err)
+
+	assert.Equal(t, []scanResult{
+		{line: "foo", offset: 4},
+		{line: "bar", offset: 12},
+	}, scanRegexp(t, helper))
+	assert.Equal(t, int64(2), helper.Stats().RecordsSkipped)
+}
+
+func TestRegexpScannerHelper_InitialOffset(t *testing.T) {
+	helper, err := NewRegexpScannerHelper(strings.NewReader("foo\nbar\nbaz\n"), regexp.MustCompile(`\n`), encoding.WithOffset(4))
+	require.NoError(t, err)
+
+	assert.Equal(t, []scanResult{
+		{line: "bar", offset: 8},
+		{line: "baz", offset: 12},
+	}, scanRegexp(t, helper))
+
+	_, err = NewRegexpScannerHelper(strings.NewReader("foo\n"), regexp.MustCompile(`\n`), encoding.WithOffset(8))
+	assert.ErrorContains(t, err, "failed to discard offset 8")
+}
+
+func TestRegexpScannerHelper_MaxRecordSize(t *testing.T) {
+	helper, err := NewRegexpScannerHelper(iotest.OneByteReader(strings.NewReader("foo\nbarbaz\n")), regexp.MustCompile(`\n`), encoding.WithMaxRecordSize(3))
+	require.NoError(t, err)
+
+	record, _, err := helper.ScanString()
+	require.NoError(t, err)
+	assert.Equal(t, "foo", record)
+
+	_, _, err = helper.ScanString()
+	require.ErrorIs(t, err, bufio.ErrTooLong)
+	assert.Equal(t, int64(4), helper.Offset())
+
+	t.Run("without separator", func(t *testing.T) {
+		helper, err := NewRegexpScannerHelper(strings.NewReader(strings.Repeat("a", 100)), regexp.MustCompile(`\n`), encoding.WithMaxRecordSize(3), encoding.WithReaderBufferSize(16))
+		require.NoError(t, err)
+
+		_, _, err = helper.ScanString()
+		assert.ErrorIs(t, err, bufio.ErrTooLong)
+	})
+}
+
+func TestRegexpScannerHelper_EmptySeparator(t *testing.T) {
+	_, err := NewRegexpScannerHelper(strings.NewReader("foo"), regexp.MustCompile(`\n*`))
+	assert.ErrorContains(t, err, `separator "\\n*" matches the empty string`)
+}
+
+func TestRegexpScannerHelper_QuotaExceeded(t *testing.T) {
+	quota := &tokenBucket{tokens: 6}
+	helper, err := NewRegexpScannerHelper(NewQuotaReader(strings.NewReader("foo\nbarbaz\n"), quota), regexp.MustCompile(`\n`))
+	require.NoError(t, err)
+
+	record, _, err := helper.ScanString()
+	require.NoError(t, err)
+	assert.Equal(t, "foo", record)
+
+	// "ba" of the second record was granted but the record is incomplete
+	_, _, err = helper.ScanString()
+	var quotaErr *QuotaExceededError
+	require.ErrorAs(t, err, &quotaErr)
+	assert.Equal(t, int64(4), quotaErr.Offset)
+
+	quota.refill(100)
+	record, _, err = helper.ScanString()
+	require.NoError(t, err)
+	assert.Equal(t, "barbaz", record)
+	assert.Equal(t, int64(11), helper.Offset())
+}
@@ -0,0 +1,360 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"math/bits"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// The Estimate functions estimate the size in bytes of telemetry once marshaled to OTLP protobuf, without marshaling
+// it: they walk the fields of the telemetry, summing the length of strings and bytes and the size of numbers along
+// with the overhead of their field tags and length prefixes. Rarely set fields, such as entity references, exemplars
+// and the dropped attributes counts of span events and links, are not accounted. Estimates are within 5% of the
+// actual size for telemetry without them. Use a proto marshaler where exact sizes matter.
+
+const (
+	// fixed64Size is the size of a fixed64 or double field along with its tag.
+	fixed64Size = 1 + 8
+	// fixed32Size is the size of a fixed32 field along with its tag.
+	fixed32Size = 1 + 4
+	// boolSize is the size of a bool field along with its tag.
+	boolSize = 1 + 1
+)
+
+// EstimatedSizer is a LogRecordSizer estimating the size of log records with EstimateLogRecordSize, e.g. for
+// SplitLogs to split large batches without marshaling their records.
+type EstimatedSizer struct{}
+
+// LogRecordSize returns the estimated size in bytes of the log record.
+func (EstimatedSizer) LogRecordSize(lr plog.LogRecord) int {
+	return EstimateLogRecordSize(lr)
+}
+
+// EstimateLogsSize estimates the size in bytes of ld once marshaled to OTLP protobuf.
+func EstimateLogsSize(ld plog.Logs) int {
+	size := 0
+	for i := 0; i < ld.ResourceLogs().Len(); i++ {
+		rl := ld.ResourceLogs().At(i)
+		rlSize := messageFieldSize(estimateResourceSize(rl.Resource())) + stringFieldSize(rl.SchemaUrl())
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			sl := rl.ScopeLogs().At(j)
+			slSize := messageFieldSize(estimateScopeSize(sl.Scope())) + stringFieldSize(sl.SchemaUrl())
+			for k := 0; k < sl.LogRecords().Len(); k++ {
+				slSize += messageFieldSize(EstimateLogRecordSize(sl.LogRecords().At(k)))
+			}
+			rlSize += messageFieldSize(slSize)
+		}
+		size += messageFieldSize(rlSize)
+	}
+	return size
+}
+
+// EstimateLogRecordSize estimates the size in bytes of lr once marshaled to OTLP protobuf, like
+// plog.ProtoMarshaler.LogRecordSize.
+func EstimateLogRecordSize(lr plog.LogRecord) int {
+	size := timestampFieldSize(lr.Timestamp()) + timestampFieldSize(lr.ObservedTimestamp()) +
+		varintFieldSize(uint64(lr.SeverityNumber())) + stringFieldSize(lr.SeverityText()) +
+		estimateAttributesSize(lr.Attributes()) + varintFieldSize(uint64(lr.DroppedAttributesCount())) +
+		stringFieldSize(lr.EventName()) + messageFieldSize(estimateValueSize(lr.Body())) +
+		idFieldSize(lr.TraceID().IsEmpty(), len(lr.TraceID())) + idFieldSize(lr.SpanID().IsEmpty(), len(lr.SpanID()))
+	if lr.Flags() != 0 {
+		size += fixed32Size
+	}
+	return size
+}
+
+// EstimateMetricsSize estimates the size in bytes of md once marshaled to OTLP protobuf.
+func EstimateMetricsSize(md pmetric.Metrics) int {
+	size := 0
+	for i := 0; i < md.ResourceMetrics().Len(); i++ {
+		rm := md.ResourceMetrics().At(i)
+		rmSize := messageFieldSize(estimateResourceSize(rm.Resource())) + stringFieldSize(rm.SchemaUrl())
+		for j := 0; j < rm.ScopeMetrics().Len(); j++ {
+			sm := rm.ScopeMetrics().At(j)
+			smSize := messageFieldSize(estimateScopeSize(sm.Scope())) + stringFieldSize(sm.SchemaUrl())
+			for k := 0; k < sm.Metrics().Len(); k++ {
+				smSize += messageFieldSize(EstimateMetricSize(sm.Metrics().At(k)))
+			}
+			rmSize += messageFieldSize(smSize)
+		}
+		size += messageFieldSize(rmSize)
+	}
+	return size
+}
+
+// EstimateMetricSize estimates the size in bytes of m, along with its data points, once marshaled to OTLP protobuf.
+func EstimateMetricSize(m pmetric.Metric) int {
+	size := stringFieldSize(m.Name()) + stringFieldSize(m.Description()) + stringFieldSize(m.Unit()) +
+		estimateAttributesSize(m.Metadata())
+	dataSize := 0
+	switch m.Type() {
+	case pmetric.MetricTypeGauge:
+		dataSize = estimateNumberDataPointsSize(m.Gauge().DataPoints())
+	case pmetric.MetricTypeSum:
+		dataSize = estimateNumberDataPointsSize(m.Sum().DataPoints()) +
+			varintFieldSize(uint64(m.Sum().AggregationTemporality())) + boolFieldSize(m.Sum().IsMonotonic())
+	case pmetric.MetricTypeHistogram:
+		dps := m.Histogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dataSize += messageFieldSize(EstimateHistogramDataPointSize(dps.At(i)))
+		}
+		dataSize += varintFieldSize(uint64(m.Histogram().AggregationTemporality()))
+	case pmetric.MetricTypeExponentialHistogram:
+		dps := m.ExponentialHistogram().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dataSize += messageFieldSize(EstimateExponentialHistogramDataPointSize(dps.At(i)))
+		}
+		dataSize += varintFieldSize(uint64(m.ExponentialHistogram().AggregationTemporality()))
+	case pmetric.MetricTypeSummary:
+		dps := m.Summary().DataPoints()
+		for i := 0; i < dps.Len(); i++ {
+			dataSize += messageFieldSize(EstimateSummaryDataPointSize(dps.At(i)))
+		}
+	case pmetric.MetricTypeEmpty:
+		return size
+	}
+	return size + messageFieldSize(dataSize)
+}
+
+func estimateNumberDataPointsSize(dps pmetric.NumberDataPointSlice) int {
+	size := 0
+	for i := 0; i < dps.Len(); i++ {
+		size += messageFieldSize(EstimateNumberDataPointSize(dps.At(i)))
+	}
+	return size
+}
+
+// EstimateNumberDataPointSize estimates the size in bytes of dp once marshaled to OTLP protobuf.
+func EstimateNumberDataPointSize(dp pmetric.NumberDataPoint) int {
+	size := estimateAttributesSize(dp.Attributes()) + timestampFieldSize(dp.StartTimestamp()) +
+		timestampFieldSize(dp.Timestamp())
+	if dp.ValueType() != pmetric.NumberDataPointValueTypeEmpty {
+		// Both int and double values are fixed64 fields.
+		size += fixed64Size
+	}
+	if dp.Flags() != 0 {
+		size += varintFieldSize(uint64(dp.Flags()))
+	}
+	return size
+}
+
+// EstimateHistogramDataPointSize estimates the size in bytes of dp once marshaled to OTLP protobuf.
+func EstimateHistogramDataPointSize(dp pmetric.HistogramDataPoint) int {
+	size := estimateAttributesSize(dp.Attributes()) + timestampFieldSize(dp.StartTimestamp()) +
+		timestampFieldSize(dp.Timestamp()) + fixed64FieldSize(dp.Count() != 0) +
+		packedFieldSize(8*dp.BucketCounts().Len()) + packedFieldSize(8*dp.ExplicitBounds().Len()) +
+		fixed64FieldSize(dp.HasSum()) + fixed64FieldSize(dp.HasMin()) + fixed64FieldSize(dp.HasMax())
+	if dp.Flags() != 0 {
+		size += varintFieldSize(uint64(dp.Flags()))
+	}
+	return size
+}
+
+// EstimateExponentialHistogramDataPointSize estimates the size in bytes of dp once marshaled to OTLP protobuf.
+func EstimateExponentialHistogramDataPointSize(dp pmetric.ExponentialHistogramDataPoint) int {
+	size := estimateAttributesSize(dp.Attributes()) + timestampFieldSize(dp.StartTimestamp()) +
+		timestampFieldSize(dp.Timestamp()) + fixed64FieldSize(dp.Count() != 0) + fixed64FieldSize(dp.HasSum()) +
+		varintFieldSize(zigzag(int64(dp.Scale()))) + fixed64FieldSize(dp.ZeroCount() != 0) +
+		fixed64FieldSize(dp.HasMin()) + fixed64FieldSize(dp.HasMax()) + fixed64FieldSize(dp.ZeroThreshold() != 0) +
+		messageFieldSize(estimateBucketsSize(dp.Positive())) + messageFieldSize(estimateBucketsSize(dp.Negative()))
+	if dp.Flags() != 0 {
+		size += varintFieldSize(uint64(dp.Flags()))
+	}
+	return size
+}
+
+func estimateBucketsSize(buckets pmetric.ExponentialHistogramDataPointBuckets) int {
+	countsSize := 0
+	for _, count := range buckets.BucketCounts().All() {
+		countsSize += varintSize(count)
+	}
+	return varintFieldSize(zigzag(int64(buckets.Offset()))) + packedFieldSize(countsSize)
+}
+
+// EstimateSummaryDataPointSize estimates the size in bytes of dp once marshaled to OTLP protobuf.
+func EstimateSummaryDataPointSize(dp pmetric.SummaryDataPoint) int {
+	size := estimateAttributesSize(dp.Attributes()) + timestampFieldSize(dp.StartTimestamp()) +
+		timestampFieldSize(dp.Timestamp()) + fixed64FieldSize(dp.Count() != 0) + fixed64FieldSize(dp.Sum() != 0)
+	for i := 0; i < dp.QuantileValues().Len(); i++ {
+		q := dp.QuantileValues().At(i)
+		size += messageFieldSize(fixed64FieldSize(q.Quantile() != 0) + fixed64FieldSize(q.Value() != 0))
+	}
+	if dp.Flags() != 0 {
+		size += varintFieldSize(uint64(dp.Flags()))
+	}
+	return size
+}
+
+// EstimateTracesSize estimates the size in bytes of td once marshaled to OTLP protobuf.
+func EstimateTracesSize(td ptrace.Traces) int {
+	size := 0
+	for i := 0; i < td.ResourceSpans().Len(); i++ {
+		rs := td.ResourceSpans().At(i)
+		rsSize := messageFieldSize(estimateResourceSize(rs.Resource())) + stringFieldSize(rs.SchemaUrl())
+		for j := 0; j < rs.ScopeSpans().Len(); j++ {
+			ss := rs.ScopeSpans().At(j)
+			ssSize := messageFieldSize(estimateScopeSize(ss.Scope())) + stringFieldSize(ss.SchemaUrl())
+			for k := 0; k < ss.Spans().Len(); k++ {
+				ssSize += messageFieldSize(EstimateSpanSize(ss.Spans().At(k)))
+			}
+			rsSize += messageFieldSize(ssSize)
+		}
+		size += messageFieldSize(rsSize)
+	}
+	return size
+}
+
+// EstimateSpanSize estimates the size in bytes of span, along with its events and links, once marshaled to OTLP
+// protobuf.
+func EstimateSpanSize(span ptrace.Span) int {
+	size := stringFieldSize(span.TraceState().AsRaw()) + stringFieldSize(span.Name()) +
+		varintFieldSize(uint64(span.Kind())) + timestampFieldSize(span.StartTimestamp()) +
+		timestampFieldSize(span.EndTimestamp()) + estimateAttributesSize(span.Attributes()) +
+		varintFieldSize(uint64(span.DroppedAttributesCount())) + varintFieldSize(uint64(span.DroppedEventsCount())) +
+		varintFieldSize(uint64(span.DroppedLinksCount())) +
+		idFieldSize(span.TraceID().IsEmpty(), len(span.TraceID())) + idFieldSize(span.SpanID().IsEmpty(), len(span.SpanID())) +
+		idFieldSize(span.ParentSpanID().IsEmpty(), len(span.ParentSpanID())) +
+		messageFieldSize(stringFieldSize(span.Status().Message())+varintFieldSize(uint64(span.Status().Code())))
+	if span.Flags() != 0 {
+		size += fixed32Size
+	}
+	for i := 0; i < span.Events().Len(); i++ {
+		event := span.Events().At(i)
+		size += messageFieldSize(timestampFieldSize(event.Timestamp()) + stringFieldSize(event.Name()) +
+			estimateAttributesSize(event.Attributes()))
+	}
+	for i := 0; i < span.Links().Len(); i++ {
+		link := span.Links().At(i)
+		linkSize := stringFieldSize(link.TraceState().AsRaw()) + estimateAttributesSize(link.Attributes()) +
+			idFieldSize(link.TraceID().IsEmpty(), len(link.TraceID())) + idFieldSize(link.SpanID().IsEmpty(), len(link.SpanID()))
+		if link.Flags() != 0 {
+			linkSize += fixed32Size
+		}
+		size += messageFieldSize(linkSize)
+	}
+	return size
+}
+
+func estimateResourceSize(resource pcommon.Resource) int {
+	return estimateAttributesSize(resource.Attributes()) + varintFieldSize(uint64(resource.DroppedAttributesCount()))
+}
+
+func estimateScopeSize(scope pcommon.InstrumentationScope) int {
+	return stringFieldSize(scope.Name()) + stringFieldSize(scope.Version()) +
+		estimateAttributesSize(scope.Attributes()) + varintFieldSize(uint64(scope.DroppedAttributesCount()))
+}
+
+// estimateAttributesSize estimates the size of attributes as repeated key-value fields.
+func estimateAttributesSize(attributes pcommon.Map) int {
+	size := 0
+	for key, value := range attributes.All() {
+		size += messageFieldSize(stringFieldSize(key) + messageFieldSize(estimateValueSize(value)))
+	}
+	return size
+}
+
+// estimateValueSize estimates the size of value as an AnyValue message.
+func estimateValueSize(value pcommon.Value) int {
+	switch value.Type() {
+	case pcommon.ValueTypeStr:
+		return bytesFieldSize(len(value.Str()))
+	case pcommon.ValueTypeInt:
+		return 1 + varintSize(uint64(value.Int()))
+	case pcommon.ValueTypeDouble:
+		return fixed64Size
+	case pcommon.ValueTypeBool:
+		return boolSize
+	case pcommon.ValueTypeBytes:
+		return bytesFieldSize(value.Bytes().Len())
+	case pcommon.ValueTypeMap:
+		return messageFieldSize(estimateAttributesSize(value.Map()))
+	case pcommon.ValueTypeSlice:
+		size := 0
+		for _, v := range value.Slice().All() {
+			size += messageFieldSize(estimateValueSize(v))
+		}
+		return messageFieldSize(size)
+	default:
+		return 0
+	}
+}
+
+// varintSize returns the size of x encoded as a varint.
+func varintSize(x uint64) int {
+	return (bits.Len64(x|1) + 6) / 7
+}
+
+// zigzag returns the zigzag encoding of x, as used by sint fields.
+func zigzag(x int64) uint64 {
+	return uint64((x << 1) ^ (x >> 63))
+}
+
+// varintFieldSize returns the size of a varint field holding x along with its tag, 0 for the default value.
+func varintFieldSize(x uint64) int {
+	if x == 0 {
+		return 0
+	}
+	return 1 + varintSize(x)
+}
+
+// fixed64FieldSize returns the size of a fixed64 or double field along with its tag, 0 when not set.
+func fixed64FieldSize(set bool) int {
+	if !set {
+		return 0
+	}
+	return fixed64Size
+}
+
+// boolFieldSize returns the size of a bool field along with its tag, 0 when false.
+func boolFieldSize(b bool) int {
+	if !b {
+		return 0
+	}
+	return boolSize
+}
+
+// timestampFieldSize returns the size of a fixed64 timestamp field along with its tag, 0 when not set.
+func timestampFieldSize(ts pcommon.Timestamp) int {
+	return fixed64FieldSize(ts != 0)
+}
+
+// stringFieldSize returns the size of a string field holding s along with its tag and length, 0 when empty.
+func stringFieldSize(s string) int {
+	if s == "" {
+		return 0
+	}
+	return bytesFieldSize(len(s))
+}
+
+// bytesFieldSize returns the size of a length-delimited field of n bytes along with its tag and length.
+func bytesFieldSize(n int) int {
+	return 1 + varintSize(uint64(n)) + n
+}
+
+// idFieldSize returns the size of a trace or span ID field of n bytes along with its tag and length, which holds no
+// bytes when the ID is empty.
+func idFieldSize(empty bool, n int) int {
+	if empty {
+		return bytesFieldSize(0)
+	}
+	return bytesFieldSize(n)
+}
+
+// messageFieldSize returns the size of a message field of n bytes along with its tag and length.
+func messageFieldSize(n int) int {
+	return bytesFieldSize(n)
+}
+
+// packedFieldSize returns the size of a packed repeated field of n bytes along with its tag and length, 0 when empty.
+func packedFieldSize(n int) int {
+	if n == 0 {
+		return 0
+	}
+	return bytesFieldSize(n)
+}
@@ -0,0 +1,305 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+)
+
+// estimateErrorBound is the documented bound of the error of estimates relative to actual proto sizes.
+const estimateErrorBound = 0.05
+
+var testTimestamp = pcommon.NewTimestampFromTime(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+
+// assertEstimate asserts that estimated is within estimateErrorBound of actual.
+func assertEstimate(t *testing.T, actual, estimated int) {
+	t.Helper()
+	assert.InEpsilon(t, float64(actual), float64(estimated), estimateErrorBound, "actual %d, estimated %d", actual, estimated)
+}
+
+func newResourceLogs(ld plog.Logs, resourceAttributes int) plog.ScopeLogs {
+	rl := ld.ResourceLogs().AppendEmpty()
+	rl.SetSchemaUrl("https://opentelemetry.io/schemas/1.26.0")
+	for i := range resourceAttributes {
+		rl.Resource().Attributes().PutStr("resource.attribute."+strings.Repeat("k", i), "value")
+	}
+	sl := rl.ScopeLogs().AppendEmpty()
+	sl.Scope().SetName("github.com/open-telemetry/opentelemetry-collector-contrib/receiver/filelogreceiver")
+	sl.Scope().SetVersion("v0.157.0")
+	return sl
+}
+
+func testLogs() map[string]plog.Logs {
+	shapes := map[string]func(lr plog.LogRecord){
+		"empty": func(plog.LogRecord) {},
+		"short line": func(lr plog.LogRecord) {
+			lr.SetObservedTimestamp(testTimestamp)
+			lr.Body().SetStr("2024-05-01T10:00:00Z INFO request served in 12ms")
+		},
+		"large body": func(lr plog.LogRecord) {
+			lr.SetTimestamp(testTimestamp)
+			lr.SetObservedTimestamp(testTimestamp)
+			lr.Body().SetStr(strings.Repeat("x", 64*1024))
+		},
+		"many attributes": func(lr plog.LogRecord) {
+			lr.SetTimestamp(testTimestamp)
+			lr.SetSeverityNumber(plog.SeverityNumberWarn)
+			lr.SetSeverityText("WARN")
+			lr.Body().SetStr("disk almost full")
+			for i := range 50 {
+				lr.Attributes().PutStr("attribute."+strings.Repeat("k", i%10), strings.Repeat("v", i))
+				lr.Attributes().PutInt("int."+strings.Repeat("k", i), int64(i)*1_000_003)
+			}
+			lr.Attributes().PutDouble("double", 1.5)
+			lr.Attributes().PutBool("bool", true)
+			lr.Attributes().PutInt("negative", -1)
+		},
+		"nested body": func(lr plog.LogRecord) {
+			body := lr.Body().SetEmptyMap()
+			body.PutStr("msg", "user logged in")
+			user := body.PutEmptyMap("user")
+			user.PutStr("name", "alice")
+			user.PutInt("id", 42)
+			roles := user.PutEmptySlice("roles")
+			roles.AppendEmpty().SetStr("admin")
+			roles.AppendEmpty().SetStr("ops")
+			roles.AppendEmpty().SetEmptyMap().PutBool("temporary", true)
+			body.PutEmptyBytes("payload").FromRaw(make([]byte, 300))
+		},
+		"trace context": func(lr plog.LogRecord) {
+			lr.SetTimestamp(testTimestamp)
+			lr.SetTraceID(pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+			lr.SetSpanID(pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, 8})
+			lr.SetFlags(plog.DefaultLogRecordFlags.WithIsSampled(true))
+			lr.SetEventName("session.start")
+			lr.SetDroppedAttributesCount(3)
+			lr.Body().SetStr("span event")
+		},
+	}
+
+	logs := make(map[string]plog.Logs, len(shapes)+1)
+	for name, shape := range shapes {
+		ld := plog.NewLogs()
+		shape(newResourceLogs(ld, 3).LogRecords().AppendEmpty())
+		logs[name] = ld
+	}
+
+	// A batch of records of every shape, across resources
+	batch := plog.NewLogs()
+	for i := range 4 {
+		sl := newResourceLogs(batch, i*5)
+		for _, shape := range shapes {
+			for range 25 {
+				shape(sl.LogRecords().AppendEmpty())
+			}
+		}
+	}
+	logs["batch"] = batch
+	return logs
+}
+
+func TestEstimateLogsSize(t *testing.T) {
+	var marshaler plog.ProtoMarshaler
+	for name, ld := range testLogs() {
+		t.Run(name, func(t *testing.T) {
+			assertEstimate(t, marshaler.LogsSize(ld), EstimateLogsSize(ld))
+
+			records := ld.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+			for i := 0; i < records.Len(); i++ {
+				lr := records.At(i)
+				if size := marshaler.LogRecordSize(lr); size > 0 {
+					assertEstimate(t, size, EstimateLogRecordSize(lr))
+				} else {
+					assert.Zero(t, EstimateLogRecordSize(lr))
+				}
+			}
+		})
+	}
+	assert.Zero(t, EstimateLogsSize(plog.NewLogs()))
+}
+
+func TestEstimatedSizer(t *testing.T) {
+	ld := testLogs()["batch"]
+	var marshaler plog.ProtoMarshaler
+
+	estimated, _ := SplitLogs(ld, 16*1024, EstimatedSizer{})
+	actual, _ := SplitLogs(ld, 16*1024, &marshaler)
+	require.NotEmpty(t, estimated)
+	assert.Equal(t, ld.LogRecordCount(), totalLogRecords(estimated))
+	// Estimates within the error bound split batches alike
+	assert.InDelta(t, len(actual), len(estimated), float64(len(actual))*estimateErrorBound+1)
+}
+
+func totalLogRecords(chunks []plog.Logs) int {
+	total := 0
+	for _, chunk := range chunks {
+		total += chunk.LogRecordCount()
+	}
+	return total
+}
+
+func testMetrics() pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	rm := md.ResourceMetrics().AppendEmpty()
+	rm.Resource().Attributes().PutStr("service.name", "checkout")
+	rm.Resource().Attributes().PutStr("host.name", "host-1")
+	sm := rm.ScopeMetrics().AppendEmpty()
+	sm.Scope().SetName("github.com/open-telemetry/opentelemetry-collector-contrib/receiver/hostmetricsreceiver")
+
+	setAttributes := func(attributes pcommon.Map, i int) {
+		attributes.PutStr("state", strings.Repeat("s", i%7+1))
+		attributes.PutInt("cpu", int64(i))
+	}
+
+	gauge := sm.Metrics().AppendEmpty()
+	gauge.SetName("system.cpu.utilization")
+	gauge.SetUnit("1")
+	gauge.SetEmptyGauge()
+	for i := range 20 {
+		dp := gauge.Gauge().DataPoints().AppendEmpty()
+		dp.SetTimestamp(testTimestamp)
+		dp.SetDoubleValue(float64(i) / 20)
+		setAttributes(dp.Attributes(), i)
+	}
+
+	sum := sm.Metrics().AppendEmpty()
+	sum.SetName("system.cpu.time")
+	sum.SetDescription("Seconds each logical CPU spent on each mode.")
+	sum.SetUnit("s")
+	sum.SetEmptySum().SetIsMonotonic(true)
+	sum.Sum().SetAggregationTemporality(pmetric.AggregationTemporalityCumulative)
+	for i := range 20 {
+		dp := sum.Sum().DataPoints().AppendEmpty()
+		dp.SetStartTimestamp(testTimestamp)
+		dp.SetTimestamp(testTimestamp)
+		dp.SetIntValue(int64(i) * 1_000_000)
+		setAttributes(dp.Attributes(), i)
+	}
+
+	histogram := sm.Metrics().AppendEmpty()
+	histogram.SetName("http.server.request.duration")
+	histogram.SetEmptyHistogram().SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	for i := range 10 {
+		dp := histogram.Histogram().DataPoints().AppendEmpty()
+		dp.SetTimestamp(testTimestamp)
+		dp.SetCount(uint64(i) * 100)
+		dp.SetSum(float64(i) * 12.5)
+		dp.SetMin(0.001)
+		dp.SetMax(10)
+		dp.BucketCounts().FromRaw([]uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11})
+		dp.ExplicitBounds().FromRaw([]float64{0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1})
+		setAttributes(dp.Attributes(), i)
+	}
+
+	exponential := sm.Metrics().AppendEmpty()
+	exponential.SetName("rpc.server.duration")
+	exponential.SetEmptyExponentialHistogram().SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+	for i := range 10 {
+		dp := exponential.ExponentialHistogram().DataPoints().AppendEmpty()
+		dp.SetTimestamp(testTimestamp)
+		dp.SetCount(uint64(i) * 100)
+		dp.SetSum(float64(i) * 12.5)
+		dp.SetScale(-int32(i % 3))
+		dp.SetZeroCount(2)
+		dp.Positive().SetOffset(-5)
+		dp.Positive().BucketCounts().FromRaw([]uint64{1, 200, 30000, 4, 5})
+		dp.Negative().BucketCounts().FromRaw([]uint64{1})
+		setAttributes(dp.Attributes(), i)
+	}
+
+	summary := sm.Metrics().AppendEmpty()
+	summary.SetName("jvm.gc.duration")
+	summary.SetEmptySummary()
+	for i := range 10 {
+		dp := summary.Summary().DataPoints().AppendEmpty()
+		dp.SetTimestamp(testTimestamp)
+		dp.SetCount(uint64(i) + 1)
+		dp.SetSum(float64(i) * 3.5)
+		for _, quantile := range []float64{0, 0.5, 0.99} {
+			q := dp.QuantileValues().AppendEmpty()
+			q.SetQuantile(quantile)
+			q.SetValue(float64(i) * quantile)
+		}
+		setAttributes(dp.Attributes(), i)
+	}
+	return md
+}
+
+func TestEstimateMetricsSize(t *testing.T) {
+	var marshaler pmetric.ProtoMarshaler
+	md := testMetrics()
+	assertEstimate(t, marshaler.MetricsSize(md), EstimateMetricsSize(md))
+
+	metrics := md.ResourceMetrics().At(0).ScopeMetrics().At(0).Metrics()
+	for i := 0; i < metrics.Len(); i++ {
+		m := metrics.At(i)
+		t.Run(m.Type().String(), func(t *testing.T) {
+			single := pmetric.NewMetrics()
+			m.CopyTo(single.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics().AppendEmpty())
+			assertEstimate(t, marshaler.MetricsSize(single), EstimateMetricsSize(single))
+		})
+	}
+	assert.Zero(t, EstimateMetricsSize(pmetric.NewMetrics()))
+}
+
+func testTraces() ptrace.Traces {
+	td := ptrace.NewTraces()
+	rs := td.ResourceSpans().AppendEmpty()
+	rs.Resource().Attributes().PutStr("service.name", "checkout")
+	ss := rs.ScopeSpans().AppendEmpty()
+	ss.Scope().SetName("go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp")
+	ss.Scope().SetVersion("0.60.0")
+	for i := range 50 {
+		span := ss.Spans().AppendEmpty()
+		span.SetTraceID(pcommon.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, byte(i)})
+		span.SetSpanID(pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, byte(i)})
+		if i > 0 {
+			span.SetParentSpanID(pcommon.SpanID{1, 2, 3, 4, 5, 6, 7, byte(i - 1)})
+		}
+		span.SetName("GET /api/v1/orders/{id}")
+		span.SetKind(ptrace.SpanKindServer)
+		span.SetStartTimestamp(testTimestamp)
+		span.SetEndTimestamp(testTimestamp + pcommon.Timestamp(i))
+		span.TraceState().FromRaw("vendor=value")
+		span.Attributes().PutStr("http.request.method", "GET")
+		span.Attributes().PutInt("http.response.status_code", 200)
+		span.Attributes().PutStr("url.path", "/api/v1/orders/"+strings.Repeat("1", i))
+		if i%5 == 0 {
+			span.Status().SetCode(ptrace.StatusCodeError)
+			span.Status().SetMessage("upstream timeout")
+			event := span.Events().AppendEmpty()
+			event.SetName("exception")
+			event.SetTimestamp(testTimestamp)
+			event.Attributes().PutStr("exception.message", "context deadline exceeded")
+			link := span.Links().AppendEmpty()
+			link.SetTraceID(pcommon.TraceID{16})
+			link.SetSpanID(pcommon.SpanID{8})
+			link.Attributes().PutStr("link.type", "retry")
+		}
+	}
+	return td
+}
+
+func TestEstimateTracesSize(t *testing.T) {
+	var marshaler ptrace.ProtoMarshaler
+	td := testTraces()
+	assertEstimate(t, marshaler.TracesSize(td), EstimateTracesSize(td))
+
+	spans := td.ResourceSpans().At(0).ScopeSpans().At(0).Spans()
+	for _, i := range []int{0, 1, 5} {
+		single := ptrace.NewTraces()
+		spans.At(i).CopyTo(single.ResourceSpans().AppendEmpty().ScopeSpans().AppendEmpty().Spans().AppendEmpty())
+		assertEstimate(t, marshaler.TracesSize(single), EstimateTracesSize(single))
+	}
+	assert.Zero(t, EstimateTracesSize(ptrace.NewTraces()))
+}
@@ -0,0 +1,235 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"go.opentelemetry.io/collector/pdata/plog"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+// LogRecordSizer estimates the size of log records, e.g. *plog.ProtoMarshaler.
+type LogRecordSizer interface {
+	// LogRecordSize returns the size in bytes of the log record.
+	LogRecordSize(lr plog.LogRecord) int
+}
+
+// SplitReport describes the chunks returned by SplitLogs.
+type SplitReport struct {
+	// Oversized holds the indexes of the chunks made of a single log record exceeding the budget, in order.
+	Oversized []int
+}
+
+// SplitLogs splits batch at log record boundaries into chunks whose log records sizes add up to at most maxBytes,
+// as estimated by sizer. The size of resources and scopes is not accounted, so maxBytes should leave some headroom
+// below the limit to enforce. Records keep their order and their resource and scope grouping.
+// A record exceeding maxBytes on its own is returned in a chunk of its own, reported in SplitReport.Oversized.
+// The batch is returned as-is when it fits within maxBytes or when maxBytes is not positive, otherwise
+// it is left unchanged and the chunks hold copies of its records.
+func SplitLogs(batch plog.Logs, maxBytes int, sizer LogRecordSizer) ([]plog.Logs, SplitReport) {
+	var report SplitReport
+	if maxBytes <= 0 {
+		return []plog.Logs{batch}, report
+	}
+
+	sizes := make([]int, 0, batch.LogRecordCount())
+	total := 0
+	forEachLogRecord(batch, func(lr plog.LogRecord) {
+		size := sizer.LogRecordSize(lr)
+		sizes = append(sizes, size)
+		total += size
+	})
+	if total <= maxBytes {
+		return []plog.Logs{batch}, report
+	}
+
+	var (
+		chunks       []plog.Logs
+		chunkSize    int
+		chunkRecords int
+		// destRL and destSL are the resource and scope logs of the last chunk receiving the records
+		// of the current resource and scope, when valid.
+		destRL  plog.ResourceLogs
+		destSL  plog.ScopeLogs
+		validRL bool
+		validSL bool
+		n       int
+	)
+	for i := 0; i < batch.ResourceLogs().Len(); i++ {
+		rl := batch.ResourceLogs().At(i)
+		validRL = false
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			sl := rl.ScopeLogs().At(j)
+			validSL = false
+			for k := 0; k < sl.LogRecords().Len(); k++ {
+				size := sizes[n]
+				n++
+
+				// Records following an oversized record always start a new chunk
+				if len(chunks) == 0 || (chunkRecords > 0 && chunkSize+size > maxBytes) {
+					chunks = append(chunks, plog.NewLogs())
+					chunkSize, chunkRecords = 0, 0
+					validRL, validSL = false, false
+				}
+				if size > maxBytes {
+					report.Oversized = append(report.Oversized, len(chunks)-1)
+				}
+
+				if !validRL {
+					destRL = chunks[len(chunks)-1].ResourceLogs().AppendEmpty()
+					rl.Resource().CopyTo(destRL.Resource())
+					destRL.SetSchemaUrl(rl.SchemaUrl())
+					validRL = true
+				}
+				if !validSL {
+					destSL = destRL.ScopeLogs().AppendEmpty()
+					sl.Scope().CopyTo(destSL.Scope())
+					destSL.SetSchemaUrl(sl.SchemaUrl())
+					validSL = true
+				}
+				sl.LogRecords().At(k).CopyTo(destSL.LogRecords().AppendEmpty())
+				chunkSize += size
+				chunkRecords++
+			}
+		}
+	}
+
+	return chunks, report
+}
+
+// SplitLogsBySize splits batch at log record boundaries into chunks whose marshaled size, as estimated by sizer,
+// e.g. an encoding extension implementing encoding.LogsSizer, is at most maxBytes. Unlike SplitLogs, the size of
+// whole chunks is checked, so that the overhead of resources, scopes and delimiters is accounted.
+// A record exceeding maxBytes on its own is returned in a chunk of its own, reported in SplitReport.Oversized.
+// The batch is returned as-is when it fits within maxBytes or when maxBytes is not positive, otherwise
+// it is left unchanged and the chunks hold copies of its records.
+func SplitLogsBySize(batch plog.Logs, maxBytes int, sizer encoding.LogsSizer) ([]plog.Logs, SplitReport) {
+	var report SplitReport
+	if maxBytes <= 0 || sizer.LogsSize(batch) <= maxBytes {
+		return []plog.Logs{batch}, report
+	}
+
+	// Split by the size of each record first, then halve the chunks exceeding maxBytes once their overhead is accounted.
+	estimated, _ := SplitLogs(batch, maxBytes, newLogsRecordSizer(sizer))
+	var chunks []plog.Logs
+	for _, chunk := range estimated {
+		chunks = appendFittingLogs(chunks, chunk, maxBytes, sizer)
+	}
+	for i, chunk := range chunks {
+		if chunk.LogRecordCount() == 1 && sizer.LogsSize(chunk) > maxBytes {
+			report.Oversized = append(report.Oversized, i)
+		}
+	}
+	return chunks, report
+}
+
+// appendFittingLogs appends logs to chunks, halved until each half fits within maxBytes or holds a single record.
+func appendFittingLogs(chunks []plog.Logs, logs plog.Logs, maxBytes int, sizer encoding.LogsSizer) []plog.Logs {
+	count := logs.LogRecordCount()
+	if count <= 1 || sizer.LogsSize(logs) <= maxBytes {
+		return append(chunks, logs)
+	}
+	halves, _ := SplitLogs(logs, (count+1)/2, recordCounter{})
+	for _, half := range halves {
+		chunks = appendFittingLogs(chunks, half, maxBytes, sizer)
+	}
+	return chunks
+}
+
+// logsRecordSizer estimates the size of a log record as the size of logs holding only this record.
+type logsRecordSizer struct {
+	sizer encoding.LogsSizer
+	// logs holds the single record whose size is estimated, reused across records.
+	logs   plog.Logs
+	record plog.LogRecord
+}
+
+func newLogsRecordSizer(sizer encoding.LogsSizer) *logsRecordSizer {
+	logs := plog.NewLogs()
+	record := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+	return &logsRecordSizer{sizer: sizer, logs: logs, record: record}
+}
+
+func (s *logsRecordSizer) LogRecordSize(lr plog.LogRecord) int {
+	lr.CopyTo(s.record)
+	return s.sizer.LogsSize(s.logs)
+}
+
+// recordCounter sizes every log record as 1, so that SplitLogs splits by record count.
+type recordCounter struct{}
+
+func (recordCounter) LogRecordSize(plog.LogRecord) int {
+	return 1
+}
+
+// logsSplitter splits the batches returned by decode with SplitLogs, returning their chunks one by one.
+type logsSplitter struct {
+	decode func() (plog.Logs, error)
+	offset func() int64
+	// token is nil when the decoder has no opaque position.
+	token    func() string
+	maxBytes int
+	sizer    plog.ProtoMarshaler
+
+	// pending holds the chunks remaining to be returned, in order.
+	pending []plog.Logs
+	// pendingErr is returned along with the last pending chunk.
+	pendingErr error
+	// pendingOffset and pendingToken are the position before the batch the pending chunks were split from.
+	pendingOffset int64
+	pendingToken  string
+}
+
+func (s *logsSplitter) DecodeLogs() (plog.Logs, error) {
+	if len(s.pending) == 0 {
+		offset := s.offset()
+		var token string
+		if s.token != nil {
+			token = s.token()
+		}
+		logs, err := s.decode()
+		if isZeroLogs(logs) || logs.LogRecordCount() == 0 {
+			return logs, err
+		}
+		s.pending, _ = SplitLogs(logs, s.maxBytes, &s.sizer)
+		s.pendingErr, s.pendingOffset, s.pendingToken = err, offset, token
+	}
+
+	logs := s.pending[0]
+	s.pending[0] = plog.Logs{}
+	s.pending = s.pending[1:]
+	if len(s.pending) > 0 {
+		return logs, nil
+	}
+	err := s.pendingErr
+	s.pending, s.pendingErr = nil, nil
+	return logs, err
+}
+
+func (s *logsSplitter) Offset() int64 {
+	if len(s.pending) > 0 {
+		return s.pendingOffset
+	}
+	return s.offset()
+}
+
+func (s *logsSplitter) OffsetToken() string {
+	if len(s.pending) > 0 {
+		return s.pendingToken
+	}
+	return s.token()
+}
+
+// forEachLogRecord calls f for each log record of logs.
+func forEachLogRecord(logs plog.Logs, f func(plog.LogRecord)) {
+	for i := 0; i < logs.ResourceLogs().Len(); i++ {
+		rl := logs.ResourceLogs().At(i)
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			sl := rl.ScopeLogs().At(j)
+			for k := 0; k < sl.LogRecords().Len(); k++ {
+				f(sl.LogRecords().At(k))
+			}
+		}
+	}
+}
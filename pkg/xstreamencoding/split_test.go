@@ -0,0 +1,237 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// bodySizer sizes log records by the length of their body.
+type bodySizer struct{}
+
+func (bodySizer) LogRecordSize(lr plog.LogRecord) int {
+	return len(lr.Body().AsString())
+}
+
+// newSplitTestLogs creates logs with one resource per element of bodies, each with a single scope holding one record per body.
+func newSplitTestLogs(bodies ...[]string) plog.Logs {
+	logs := plog.NewLogs()
+	for i, resourceBodies := range bodies {
+		rl := logs.ResourceLogs().AppendEmpty()
+		rl.Resource().Attributes().PutInt("resource", int64(i))
+		sl := rl.ScopeLogs().AppendEmpty()
+		sl.Scope().SetName("scope")
+		for _, body := range resourceBodies {
+			sl.LogRecords().AppendEmpty().Body().SetStr(body)
+		}
+	}
+	return logs
+}
+
+// chunkContent returns the bodies of each chunk, prefixed by the resource attribute they belong to.
+func chunkContent(chunks []plog.Logs) [][]string {
+	content := make([][]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		var bodies []string
+		for i := 0; i < chunk.ResourceLogs().Len(); i++ {
+			rl := chunk.ResourceLogs().At(i)
+			resource, _ := rl.Resource().Attributes().Get("resource")
+			for j := 0; j < rl.ScopeLogs().Len(); j++ {
+				sl := rl.ScopeLogs().At(j)
+				for k := 0; k < sl.LogRecords().Len(); k++ {
+					bodies = append(bodies, resource.AsString()+":"+sl.LogRecords().At(k).Body().Str())
+				}
+			}
+		}
+		content = append(content, bodies)
+	}
+	return content
+}
+
+func TestSplitLogs(t *testing.T) {
+	large := strings.Repeat("x", 20)
+
+	tests := []struct {
+		name      string
+		logs      plog.Logs
+		maxBytes  int
+		expected  [][]string
+		oversized []int
+	}{
+		{
+			name:     "fits",
+			logs:     newSplitTestLogs([]string{"aaaa", "bbbb"}),
+			maxBytes: 8,
+			expected: [][]string{{"0:aaaa", "0:bbbb"}},
+		},
+		{
+			name:     "no budget",
+			logs:     newSplitTestLogs([]string{"aaaa", large}),
+			maxBytes: 0,
+			expected: [][]string{{"0:aaaa", "0:" + large}},
+		},
+		{
+			name:     "split within resource",
+			logs:     newSplitTestLogs([]string{"aaaa", "bbbb", "cccc"}),
+			maxBytes: 8,
+			expected: [][]string{{"0:aaaa", "0:bbbb"}, {"0:cccc"}},
+		},
+		{
+			name:     "split across resources",
+			logs:     newSplitTestLogs([]string{"aaaa", "bbbb"}, []string{"cccc", "dddd"}),
+			maxBytes: 12,
+			expected: [][]string{{"0:aaaa", "0:bbbb", "1:cccc"}, {"1:dddd"}},
+		},
+		{
+			name:      "oversized record",
+			logs:      newSplitTestLogs([]string{"aaaa", large, "bbbb"}, []string{"cccc"}),
+			maxBytes:  10,
+			expected:  [][]string{{"0:aaaa"}, {"0:" + large}, {"0:bbbb", "1:cccc"}},
+			oversized: []int{1},
+		},
+		{
+			name:      "only oversized records",
+			logs:      newSplitTestLogs([]string{large}, []string{large}),
+			maxBytes:  10,
+			expected:  [][]string{{"0:" + large}, {"1:" + large}},
+			oversized: []int{0, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := plog.NewLogs()
+			tt.logs.CopyTo(original)
+
+			chunks, report := SplitLogs(tt.logs, tt.maxBytes, bodySizer{})
+			assert.Equal(t, tt.expected, chunkContent(chunks))
+			assert.Equal(t, tt.oversized, report.Oversized)
+			// The batch is left unchanged
+			assert.Equal(t, original, tt.logs)
+
+			for _, chunk := range chunks {
+				for i := 0; i < chunk.ResourceLogs().Len(); i++ {
+					rl := chunk.ResourceLogs().At(i)
+					// Records of a resource and scope are grouped within a chunk
+					require.Equal(t, 1, rl.ScopeLogs().Len())
+					assert.Equal(t, "scope", rl.ScopeLogs().At(0).Scope().Name())
+				}
+			}
+		})
+	}
+}
+
+func TestLogsDecoderAdapterWithOptions_MaxBatchBytes(t *testing.T) {
+	record := plog.NewLogRecord()
+	record.Body().SetStr("record")
+	var sizer plog.ProtoMarshaler
+	recordSize := sizer.LogRecordSize(record)
+
+	errDecode := errors.New("decode error")
+	batches := []plog.Logs{
+		newSplitTestLogs([]string{"record", "record", "record"}),
+		newSplitTestLogs([]string{"record"}),
+		newSplitTestLogs([]string{"record", "record"}),
+	}
+	errs := []error{nil, nil, errDecode}
+	var calls int
+	var offset int64
+	decode := func() (plog.Logs, error) {
+		if calls == len(batches) {
+			return plog.NewLogs(), io.EOF
+		}
+		logs, err := batches[calls], errs[calls]
+		calls++
+		offset = int64(calls * 10)
+		return logs, err
+	}
+
+	decoder := NewLogsDecoderAdapterWithOptions(decode, func() int64 { return offset },
+		WithMaxBatchBytes(2*recordSize),
+		WithOffsetTokenFunc(func() string { return "token-" + strconv.FormatInt(offset, 10) }),
+	)
+
+	expected := []struct {
+		records int
+		offset  int64
+		err     error
+	}{
+		// The offset stays before the first batch until all its chunks are returned
+		{records: 2, offset: 0},
+		{records: 1, offset: 10},
+		{records: 1, offset: 20},
+		// The error is returned with the last chunk of its batch
+		{records: 2, offset: 30, err: errDecode},
+		{records: 0, offset: 30, err: io.EOF},
+	}
+	for i, e := range expected {
+		logs, err := decoder.DecodeLogs()
+		if e.err != nil {
+			require.ErrorIs(t, err, e.err, "batch %d", i)
+		} else {
+			require.NoError(t, err, "batch %d", i)
+		}
+		assert.Equal(t, e.records, logs.LogRecordCount(), "batch %d", i)
+		assert.Equal(t, e.offset, decoder.Offset(), "batch %d", i)
+		assert.Equal(t, "token-"+strconv.FormatInt(e.offset, 10), opaqueOffsetToken(t, decoder), "batch %d", i)
+	}
+}
+
+// delimitedSizer sizes logs as the length of their bodies delimited by two bytes, like a text codec would marshal them.
+type delimitedSizer struct{}
+
+func (delimitedSizer) LogsSize(ld plog.Logs) int {
+	size := 0
+	forEachLogRecord(ld, func(lr plog.LogRecord) {
+		if size > 0 {
+			size += 2
+		}
+		size += len(lr.Body().AsString())
+	})
+	return size
+}
+
+func TestSplitLogsBySize(t *testing.T) {
+	batch := newSplitTestLogs([]string{"0000", "1111", "2222", "3333"}, []string{"4444", "oversized-body", "5555"})
+
+	t.Run("fits", func(t *testing.T) {
+		chunks, report := SplitLogsBySize(batch, delimitedSizer{}.LogsSize(batch), delimitedSizer{})
+		require.Len(t, chunks, 1)
+		assert.Equal(t, batch, chunks[0])
+		assert.Empty(t, report.Oversized)
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		chunks, report := SplitLogsBySize(batch, 0, delimitedSizer{})
+		require.Len(t, chunks, 1)
+		assert.Equal(t, batch, chunks[0])
+		assert.Empty(t, report.Oversized)
+	})
+
+	t.Run("split", func(t *testing.T) {
+		// Three records of 4 bytes fit by their own size, but not once delimited, so that chunk is halved.
+		chunks, report := SplitLogsBySize(batch, 12, delimitedSizer{})
+		assert.Equal(t, [][]string{
+			{"0:0000", "0:1111"},
+			{"0:2222"},
+			{"0:3333", "1:4444"},
+			{"1:oversized-body"},
+			{"1:5555"},
+		}, chunkContent(chunks))
+		assert.Equal(t, []int{3}, report.Oversized)
+		for i, chunk := range chunks {
+			if i != 3 {
+				assert.LessOrEqual(t, delimitedSizer{}.LogsSize(chunk), 12)
+			}
+		}
+		assert.Equal(t, 7, batch.LogRecordCount())
+	})
+}
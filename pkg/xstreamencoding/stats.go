@@ -0,0 +1,51 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+// decoderStats holds the cumulative counters of a BatchHelper. They are updated atomically, unlike the counts
+// of the current batch, so that they can be read concurrently with decoding.
+type decoderStats struct {
+	bytesRead      atomic.Int64
+	recordsDecoded atomic.Int64
+	batchesFlushed atomic.Int64
+	recordsSkipped atomic.Int64
+	// lastFlush is the time of the last flush in nanoseconds since the Unix epoch, 0 if none.
+	lastFlush atomic.Int64
+}
+
+// flushed records a flushed batch.
+func (s *decoderStats) flushed() {
+	s.batchesFlushed.Add(1)
+	s.lastFlush.Store(time.Now().UnixNano())
+}
+
+// snapshot returns the current values of the counters.
+func (s *decoderStats) snapshot() encoding.DecoderStats {
+	stats := encoding.DecoderStats{
+		BytesRead:      s.bytesRead.Load(),
+		RecordsDecoded: s.recordsDecoded.Load(),
+		BatchesFlushed: s.batchesFlushed.Load(),
+		RecordsSkipped: s.recordsSkipped.Load(),
+	}
+	if lastFlush := s.lastFlush.Load(); lastFlush != 0 {
+		stats.LastFlushTime = time.Unix(0, lastFlush)
+	}
+	return stats
+}
+
+// reset resets the counters to zero.
+func (s *decoderStats) reset() {
+	s.bytesRead.Store(0)
+	s.recordsDecoded.Store(0)
+	s.batchesFlushed.Store(0)
+	s.recordsSkipped.Store(0)
+	s.lastFlush.Store(0)
+}
@@ -0,0 +1,108 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+func TestBatchHelper_Stats(t *testing.T) {
+	helper := NewBatchHelper(encoding.WithFlushItems(2))
+	assert.Equal(t, encoding.DecoderStats{}, helper.Stats())
+
+	start := time.Now()
+	for range 3 {
+		helper.IncrementBytes(10)
+		helper.IncrementItems(1)
+		if helper.ShouldFlush() {
+			helper.Reset()
+		}
+	}
+	helper.IncrementSkipped(2)
+	// Empty batches are not counted as flushed
+	helper.Reset()
+	helper.Reset()
+
+	stats := helper.Stats()
+	assert.Equal(t, int64(30), stats.BytesRead)
+	assert.Equal(t, int64(3), stats.RecordsDecoded)
+	assert.Equal(t, int64(2), stats.BatchesFlushed)
+	assert.Equal(t, int64(2), stats.RecordsSkipped)
+	assert.False(t, stats.LastFlushTime.Before(start.Truncate(time.Microsecond)))
+
+	// Updating options keeps the stats, unlike resetting them
+	helper.UpdateOptions(encoding.WithFlushItems(1))
+	assert.Equal(t, stats, helper.Stats())
+	helper.resetOptions()
+	assert.Equal(t, encoding.DecoderStats{}, helper.Stats())
+}
+
+func TestScannerHelper_Stats(t *testing.T) {
+	helper, err := NewScannerHelper(strings.NewReader("a\n\nb\n \nc\n"), encoding.WithFlushItems(2), encoding.WithSkipEmptyRecords(true))
+	require.NoError(t, err)
+
+	for {
+		_, _, err = helper.ScanString()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	stats := helper.Stats()
+	assert.Equal(t, int64(9), stats.BytesRead)
+	assert.Equal(t, int64(3), stats.RecordsDecoded)
+	// The end of the stream flushes the last batch
+	assert.Equal(t, int64(2), stats.BatchesFlushed)
+	assert.Equal(t, int64(2), stats.RecordsSkipped)
+	assert.False(t, stats.LastFlushTime.IsZero())
+
+	require.NoError(t, helper.Reset(strings.NewReader("d\n")))
+	assert.Equal(t, encoding.DecoderStats{}, helper.Stats())
+}
+
+func TestScannerHelper_StatsConcurrent(t *testing.T) {
+	helper, err := NewScannerHelper(strings.NewReader(strings.Repeat("line\n", 10000)), encoding.WithFlushItems(100))
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Go(func() {
+		var previous int64
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			// Stats only ever grow while decoding
+			records := helper.Stats().RecordsDecoded
+			assert.GreaterOrEqual(t, records, previous)
+			previous = records
+		}
+	})
+
+	for {
+		_, _, err = helper.ScanString()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+	}
+	close(done)
+	wg.Wait()
+
+	assert.Equal(t, int64(10000), helper.Stats().RecordsDecoded)
+	assert.Equal(t, int64(100), helper.Stats().BatchesFlushed)
+}
@@ -6,8 +6,11 @@ package xstreamencoding // import "github.com/open-telemetry/opentelemetry-colle
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"time"
 
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/pdata/pmetric"
@@ -15,55 +18,196 @@ import (
 	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
 )
 
-// ScannerHelper is a helper to scan new line delimited records from io.Reader and determine when to flush.
-// It uses new line delimiters and bytes for batching.
+// defaultReaderBufferSize is the size of the bufio.Reader derived when encoding.WithReaderBufferSize is not set,
+// the default size of bufio.NewReader.
+const defaultReaderBufferSize = 4096
+
+// ErrReaderNotSeekable is returned by ScannerHelper.Rewind when the wrapped reader does not implement io.Seeker.
+var ErrReaderNotSeekable = errors.New("reader is not seekable")
+
+// ScannerHelper is a helper to scan delimited records from io.Reader and determine when to flush.
+// Records are delimited by new lines, or the byte set with encoding.WithDelimiterByte, and batched by bytes and items.
 // Not safe for concurrent use.
 type ScannerHelper struct {
 	batchHelper *BatchHelper
 	bufReader   *bufio.Reader
-	offset      int64
+	// reader is the wrapped reader, nil when a bufio.Reader was provided.
+	reader io.Reader
+	// source is read by bufReader, the wrapped reader itself or an IdleCloseReader or HeartbeatReader wrapping it.
+	source io.Reader
+	offset int64
+	// partial holds an incomplete record read before an error interrupted scanning.
+	partial []byte
+	// compressed is set when offsets are reported in the compressed domain of a decompressing reader,
+	// starting from compressedBase.
+	compressed     CompressedOffsetReader
+	compressedBase int64
+	// readAhead reads source ahead when encoding.WithReadAhead is set, nil otherwise.
+	readAhead *ReadAheadReader
 }
 
 // NewScannerHelper creates a new ScannerHelper that reads from the provided io.Reader.
 // It accepts optional encoding.DecoderOption to configure batch flushing behavior.
-// If a bufio.Reader is provided, it will be used as-is. Otherwise, one will be derived with default buffer size.
+// If a bufio.Reader is provided, it will be used as-is. Otherwise, one will be derived with the buffer size
+// configured through encoding.WithReaderBufferSize, or the default buffer size if unset.
+//
+// When the reader is a CompressedOffsetReader, such as returned by NewDecompressingReader, and
+// encoding.WithOffsetDomain(encoding.OffsetDomainCompressed) is set, offsets are positions in the compressed stream.
+// The initial offset is then not discarded: the compressed input must already be positioned at it, e.g. by seeking
+// to a gzip member or zstd frame boundary, as decompression cannot resume from an arbitrary position.
+//
+// When encoding.WithIdleCloseTimeout is set and the reader implements io.Closer, e.g. a net.Conn, it is closed once
+// a read waits for data longer than the timeout. Scanning then returns ErrIdleTimeout.
+//
+// When encoding.WithHeartbeat is set, the heartbeat function is called every interval a read waits for data
+// without receiving any.
+//
+// When encoding.WithReadAhead is set, the reader is read ahead from a background goroutine while records are
+// scanned, see ReadAheadReader, which Close stops. Reading ahead stops at the first error of the reader, so that
+// scanning cannot be retried after a *QuotaExceededError.
 func NewScannerHelper(reader io.Reader, opts ...encoding.DecoderOption) (*ScannerHelper, error) {
 	batchHelper := NewBatchHelper(opts...)
+	return newScannerHelper(reader, batchHelper, batchHelper.options.Offset)
+}
+
+// newScannerHelper creates a ScannerHelper sharing the given BatchHelper, starting at offset within reader.
+func newScannerHelper(reader io.Reader, batchHelper *BatchHelper, offset int64) (*ScannerHelper, error) {
+	h := &ScannerHelper{batchHelper: batchHelper}
+	if err := h.bind(reader, offset); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// bind makes h scan reader from offset. The bufio.Reader previously derived by h, if any, is reused
+// when its size matches the configured buffer size.
+func (h *ScannerHelper) bind(reader io.Reader, offset int64) error {
+	options := h.batchHelper.options
+	// bufReader is only derived by h, and so can be reused, when it wraps a reader.
+	derived := h.reader != nil
+	if h.readAhead != nil {
+		_ = h.readAhead.Close()
+		h.readAhead = nil
+	}
+	h.reader, h.source, h.offset, h.partial = nil, nil, 0, nil
+	h.compressed, h.compressedBase = nil, 0
 
-	var bufReader *bufio.Reader
 	if br, ok := reader.(*bufio.Reader); ok {
-		bufReader = br
+		h.bufReader = br
 	} else {
-		bufReader = bufio.NewReader(reader)
+		h.reader, h.source = reader, reader
+		if rc, ok := reader.(io.ReadCloser); ok && options.IdleCloseTimeout > 0 {
+			h.source = NewIdleCloseReader(rc, options.IdleCloseTimeout)
+		}
+		if options.HeartbeatInterval > 0 && options.Heartbeat != nil {
+			h.source = NewHeartbeatReader(h.source, options.HeartbeatInterval, options.Heartbeat)
+		}
+		size := options.ReaderBufferSize
+		if size <= 0 {
+			size = defaultReaderBufferSize
+		}
+		h.source = h.startReadAhead(h.source, size)
+		if derived && h.bufReader.Size() == size {
+			h.bufReader.Reset(h.source)
+		} else {
+			h.bufReader = bufio.NewReaderSize(h.source, size)
+		}
 	}
 
-	if batchHelper.options.Offset != 0 {
-		_, err := bufReader.Discard(int(batchHelper.options.Offset))
+	if cr, ok := h.reader.(CompressedOffsetReader); ok && options.OffsetDomain == encoding.OffsetDomainCompressed {
+		h.compressed = cr
+		h.compressedBase = offset
+		return nil
+	}
+
+	if offset != 0 {
+		_, err := h.bufReader.Discard(int(offset))
 		if err != nil {
-			return nil, fmt.Errorf("failed to discard offset %d: %w", batchHelper.options.Offset, err)
+			return fmt.Errorf("failed to discard offset %d: %w", offset, err)
 		}
 	}
+	h.offset = offset
+	return nil
+}
 
-	return &ScannerHelper{
-		batchHelper: batchHelper,
-		bufReader:   bufReader,
-		offset:      batchHelper.options.Offset,
-	}, nil
+// startReadAhead returns source read ahead into buffers of the configured size, size by default, when
+// encoding.WithReadAhead is set. It returns source as-is otherwise.
+func (h *ScannerHelper) startReadAhead(source io.Reader, size int) io.Reader {
+	options := h.batchHelper.options
+	if options.ReadAheadBuffers <= 0 {
+		return source
+	}
+	if options.ReadAheadBufferSize > 0 {
+		size = options.ReadAheadBufferSize
+	}
+	h.readAhead = NewReadAheadReader(context.Background(), source, options.ReadAheadBuffers, size)
+	return h.readAhead
 }
 
-// ScanString scans the next line from the stream and returns it as a string. This excludes new line delimiter.
+// Close stops reading ahead, waiting for the background goroutine to return, after which scanning fails with
+// ErrReadAheadClosed once the data already buffered is consumed. It does not close the wrapped reader, and does nothing unless encoding.WithReadAhead is set.
+// Reset and Rewind read ahead again.
+func (h *ScannerHelper) Close() error {
+	if h.readAhead == nil {
+		return nil
+	}
+	return h.readAhead.Close()
+}
+
+// Reset discards the state of the helper and binds it to reader, configured with opts as if created by
+// NewScannerHelper, e.g. to pool helpers with sync.Pool rather than creating one per stream.
+// The buffer of the bufio.Reader derived for the previous reader is reused when the buffer size is unchanged.
+// A bufio.Reader provided as reader is used as-is, and a bufio.Reader previously provided is never reset.
+func (h *ScannerHelper) Reset(reader io.Reader, opts ...encoding.DecoderOption) error {
+	h.batchHelper.resetOptions(opts...)
+	return h.bind(reader, h.batchHelper.options.Offset)
+}
+
+// Rewind moves the stream back, or forward, to the given offset, e.g. to retry reading after a failure.
+// The offset is relative to the start of the wrapped reader. The current batch state and any buffered data are discarded.
+// It returns ErrReaderNotSeekable if the wrapped reader does not implement io.Seeker,
+// which is always the case when a bufio.Reader was provided to NewScannerHelper.
+func (h *ScannerHelper) Rewind(offset int64) error {
+	seeker, ok := h.reader.(io.Seeker)
+	if !ok {
+		return ErrReaderNotSeekable
+	}
+	// The reader must not be read ahead while seeking, nor the data read ahead of the previous position kept.
+	if h.readAhead != nil {
+		_ = h.readAhead.Close()
+		h.source = h.readAhead.reader
+		h.readAhead = nil
+	}
+	if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek to offset %d: %w", offset, err)
+	}
+	h.source = h.startReadAhead(h.source, h.bufReader.Size())
+
+	h.bufReader.Reset(h.source)
+	h.batchHelper.reset()
+	h.offset = offset
+	h.partial = nil
+	return nil
+}
+
+// ScanString scans the next record from the stream and returns it as a string. This excludes the delimiter, and the
+// carriage return preceding a new line delimiter. Other leading and trailing whitespace is kept.
 // flush indicates whether the batch should be flushed after processing this string.
 // err is non-nil if an error occurred during scanning. If the end of the stream is reached, err will be io.EOF.
+// If the reader is a QuotaReader whose quota is exhausted, err will be a *QuotaExceededError carrying the offset
+// after the last complete record. Scanning may be retried once the quota refreshes.
 func (h *ScannerHelper) ScanString() (line string, flush bool, err error) {
-	internal, b, err := h.scanInternal()
+	internal, b, err := h.batchHelper.scan(h.scanInternal)
 	return string(internal), b, err
 }
 
-// ScanBytes scans the next line from the stream and returns it as a byte slice. This excludes new line delimiter.
+// ScanBytes scans the next record from the stream and returns it as a byte slice. This excludes the delimiter, as
+// with ScanString.
 // flush indicates whether the batch should be flushed after processing these bytes.
 // err is non-nil if an error occurred during scanning. If the end of the stream is reached, err will be io.EOF.
+// See ScanString for the handling of an exhausted QuotaReader.
 func (h *ScannerHelper) ScanBytes() (bytes []byte, flush bool, err error) {
-	b, flush, err := h.scanInternal()
+	b, flush, err := h.batchHelper.scan(h.scanInternal)
 	if b != nil {
 		cpy := make([]byte, len(b))
 		copy(cpy, b)
@@ -73,40 +217,70 @@ func (h *ScannerHelper) ScanBytes() (bytes []byte, flush bool, err error) {
 }
 
 func (h *ScannerHelper) scanInternal() ([]byte, bool, error) {
-	var isEOF bool
-	b, err := h.bufReader.ReadBytes('\n')
-	if err != nil {
-		if err != io.EOF {
-			return nil, false, err
+	delimiter := h.batchHelper.options.RecordDelimiter()
+	for {
+		var isEOF bool
+		b, err := h.bufReader.ReadBytes(delimiter)
+		if len(h.partial) > 0 {
+			b = append(h.partial, b...)
+			h.partial = nil
+		}
+		if err != nil {
+			if err != io.EOF {
+				// Keep the incomplete record so that it can be completed by the next call.
+				h.partial = b
+				if errors.Is(err, ErrQuotaExceeded) {
+					return nil, false, &QuotaExceededError{Offset: h.offset}
+				}
+				return nil, false, err
+			}
+			isEOF = true
 		}
-		isEOF = true
-	}
-
-	if len(b) == 0 && isEOF {
-		return nil, true, io.EOF
-	}
 
-	h.offset += int64(len(b))
-	h.batchHelper.IncrementBytes(int64(len(b)))
-	h.batchHelper.IncrementItems(1)
+		if len(b) == 0 && isEOF {
+			return nil, true, io.EOF
+		}
 
-	var flush bool
-	if h.batchHelper.ShouldFlush() {
-		h.batchHelper.Reset()
-		flush = true
-	}
+		h.offset += int64(len(b))
+		h.batchHelper.IncrementBytes(int64(len(b)))
+
+		trimmed := trimDelimiter(b, delimiter)
+		flush, skip := h.batchHelper.track(trimmed)
+		if skip {
+			// The offset still moves past skipped records so that decoding can be resumed.
+			if isEOF {
+				return nil, true, io.EOF
+			}
+			continue
+		}
 
-	b = bytes.TrimSpace(b)
+		if isEOF {
+			return trimmed, flush, io.EOF
+		}
 
-	if isEOF {
-		return b, flush, io.EOF
+		return trimmed, flush, nil
 	}
+}
 
-	return b, flush, nil
+// trimDelimiter strips the delimiter terminating record, if any, along with the carriage return preceding a new line.
+func trimDelimiter(record []byte, delimiter byte) []byte {
+	if len(record) == 0 || record[len(record)-1] != delimiter {
+		return record
+	}
+	record = record[:len(record)-1]
+	if delimiter == '\n' {
+		record = bytes.TrimSuffix(record, []byte{'\r'})
+	}
+	return record
 }
 
 // Offset returns the current byte offset read from the stream.
+// In the compressed domain, it is the position of the decompressor in the compressed stream,
+// which may be ahead of the returned records as decompressors read ahead.
 func (h *ScannerHelper) Offset() int64 {
+	if h.compressed != nil {
+		return h.compressedBase + h.compressed.CompressedOffset()
+	}
 	return h.offset
 }
 
@@ -115,6 +289,45 @@ func (h *ScannerHelper) Options() encoding.DecoderOptions {
 	return h.batchHelper.Options()
 }
 
+// Stats returns the cumulative statistics of the ScannerHelper, see BatchHelper.Stats.
+// It is safe to call concurrently with scanning.
+func (h *ScannerHelper) Stats() encoding.DecoderStats {
+	return h.batchHelper.Stats()
+}
+
+// SetLogsBatchID stamps the resources of logs with the id of the batch, see BatchHelper.SetLogsBatchID.
+func (h *ScannerHelper) SetLogsBatchID(logs plog.Logs) {
+	h.batchHelper.SetLogsBatchID(logs)
+}
+
+// FlushReason is the condition that caused BatchHelper.ShouldFlush to return true.
+type FlushReason int
+
+const (
+	// FlushReasonNone indicates that no flush has been triggered yet.
+	FlushReasonNone FlushReason = iota
+	// FlushReasonBytes indicates that the flush was triggered by the FlushBytes threshold.
+	FlushReasonBytes
+	// FlushReasonItems indicates that the flush was triggered by the FlushItems threshold.
+	FlushReasonItems
+	// FlushReasonMemory indicates that the flush was triggered by the MaxBatchMemory threshold.
+	FlushReasonMemory
+)
+
+// String returns the name of the flush reason.
+func (r FlushReason) String() string {
+	switch r {
+	case FlushReasonBytes:
+		return "bytes"
+	case FlushReasonItems:
+		return "items"
+	case FlushReasonMemory:
+		return "memory"
+	default:
+		return "none"
+	}
+}
+
 // BatchHelper is a helper to determine when to flush based on configured options.
 // It tracks the current byte and item counts and compares them against configured thresholds.
 // Not safe for concurrent use.
@@ -122,42 +335,237 @@ type BatchHelper struct {
 	options      encoding.DecoderOptions
 	currentBytes int64
 	currentItems int64
+	// currentMemory is the estimated in-memory size of the current batch, see IncrementMemory.
+	currentMemory int64
+	flushReason   FlushReason
+	// batchID is the id of the last batch stamped by SetLogsBatchID.
+	batchID int64
+	// atBoundary is set by MarkBoundary until the next increment.
+	atBoundary bool
+	// telemetry is nil when no telemetry settings were provided.
+	telemetry *decoderTelemetry
+	// adaptive is nil when encoding.WithAdaptiveBatching is not set.
+	adaptive *adaptiveThresholds
+	// latency is nil when encoding.WithDownstreamLatencyBatching is not set.
+	latency *latencyThresholds
+	// stats are the cumulative counts of all batches, see Stats.
+	stats decoderStats
 }
 
 // NewBatchHelper creates a new BatchHelper with the provided options.
+// When WithTelemetry is set, it records the bytes, items and flushed batches it tracks as metrics.
+// When encoding.WithAdaptiveBatching is set, it adapts the flush thresholds to the time batches take to decode,
+// and when encoding.WithDownstreamLatencyBatching is set, to the latency of the consumers of the batches, see
+// FlushThresholds.
 func NewBatchHelper(opts ...encoding.DecoderOption) *BatchHelper {
+	options := encoding.NewDecoderOptions(opts...)
 	return &BatchHelper{
-		options: encoding.NewDecoderOptions(opts...),
+		options:   options,
+		telemetry: newDecoderTelemetry(options),
+		adaptive:  newAdaptiveThresholds(options.AdaptiveBatchTarget),
+		latency:   newLatencyThresholds(options.DownstreamLatencyTarget, options.DownstreamLatency),
 	}
 }
 
 // IncrementBytes adds n to the current byte count.
 func (sh *BatchHelper) IncrementBytes(n int64) {
 	sh.currentBytes += n
+	sh.stats.bytesRead.Add(n)
+	sh.atBoundary = false
+	if sh.adaptive != nil {
+		sh.adaptive.start()
+	}
+	if sh.telemetry != nil {
+		sh.telemetry.readBytes.Add(context.Background(), n, sh.telemetry.attributes)
+	}
 }
 
 // IncrementItems adds n to the current item count.
 func (sh *BatchHelper) IncrementItems(n int64) {
 	sh.currentItems += n
+	sh.stats.recordsDecoded.Add(n)
+	sh.atBoundary = false
+	if sh.adaptive != nil {
+		sh.adaptive.start()
+	}
+	if sh.telemetry != nil {
+		sh.telemetry.records.Add(context.Background(), n, sh.telemetry.attributes)
+	}
+}
+
+// IncrementMemory adds n to the estimated in-memory size of the current batch, which decoders supporting
+// encoding.WithMaxBatchMemory track as they build it, e.g. as the OTLP protobuf size of each decoded record.
+func (sh *BatchHelper) IncrementMemory(n int64) {
+	sh.currentMemory += n
+}
+
+// IncrementSkipped adds n to the number of skipped records, which are not counted as items.
+func (sh *BatchHelper) IncrementSkipped(n int64) {
+	sh.stats.recordsSkipped.Add(n)
 }
 
-// ShouldFlush returns true if the current counts exceed configured thresholds.
+// ShouldFlush returns true if the current counts exceed the flush thresholds, see FlushThresholds, or the estimated
+// memory of the current batch reaches encoding.WithMaxBatchMemory.
+// With encoding.WithFlushOnResourceBoundary, it only returns true at a boundary marked by MarkBoundary, unless the
+// estimated memory reaches encoding.WithMaxBatchMemory, which then splits the resource across batches.
 // Make sure to call Reset after flushing to start tracking the next batch.
 func (sh *BatchHelper) ShouldFlush() bool {
-	if sh.options.FlushBytes > 0 && sh.currentBytes >= sh.options.FlushBytes {
-		return true
+	flushBytes, flushItems := sh.FlushThresholds()
+	var reason FlushReason
+	switch {
+	case flushBytes > 0 && sh.currentBytes >= flushBytes:
+		reason = FlushReasonBytes
+	case flushItems > 0 && sh.currentItems >= flushItems:
+		reason = FlushReasonItems
+	case sh.options.MaxBatchMemory > 0 && sh.currentMemory >= sh.options.MaxBatchMemory:
+		reason = FlushReasonMemory
+	default:
+		return false
 	}
-	if sh.options.FlushItems > 0 && sh.currentItems >= sh.options.FlushItems {
-		return true
+	if sh.options.FlushOnResourceBoundary && !sh.atBoundary {
+		// The memory cap bounds the batches of resources of any size, so it is not delayed until a boundary.
+		if sh.options.MaxBatchMemory <= 0 || sh.currentMemory < sh.options.MaxBatchMemory {
+			return false
+		}
+		reason = FlushReasonMemory
 	}
-	return false
+	sh.flushReason = reason
+	return true
+}
+
+// MarkBoundary marks a resource boundary, which decoders call before tracking the first item of a resource
+// different from the previous one. Decoders then check ShouldFlush to flush the batch without that item, which
+// with encoding.WithFlushOnResourceBoundary is the only time it returns true. The boundary lasts until the next
+// increment or Reset.
+func (sh *BatchHelper) MarkBoundary() {
+	sh.atBoundary = true
+}
+
+// FlushReason returns the condition that last caused ShouldFlush to return true,
+// or FlushReasonNone if ShouldFlush never returned true. It is not cleared by Reset.
+func (sh *BatchHelper) FlushReason() FlushReason {
+	return sh.flushReason
 }
 
 // Reset resets the current byte and item counts to zero.
 // Should be called after flushing a batch to start tracking the next batch.
+// A non-empty batch being reset is recorded as a flushed batch, and its decode time adapts the flush thresholds
+// with encoding.WithAdaptiveBatching.
 func (sh *BatchHelper) Reset() {
+	if sh.currentBytes > 0 || sh.currentItems > 0 {
+		sh.stats.flushed()
+		if sh.telemetry != nil {
+			sh.telemetry.flushedBatches.Add(context.Background(), 1, sh.telemetry.attributes)
+		}
+	}
+	if sh.adaptive != nil {
+		sh.adaptive.adjust()
+	}
+	if sh.latency != nil && (sh.currentBytes > 0 || sh.currentItems > 0) {
+		sh.latency.adjust()
+	}
+	sh.reset()
+}
+
+// FlushThresholds returns the bytes and items thresholds ShouldFlush compares the current counts against, 0 when
+// disabled. These are the configured FlushBytes and FlushItems, unless encoding.WithAdaptiveBatching is set: they are
+// then lowered, down to 1/64 of the configured ones, while batches take longer than the target to decode, and raised
+// back while batches take less than half of it. With encoding.WithDownstreamLatencyBatching, they are raised, up to
+// 16 times the configured ones, while downstream is slower than its target, and lowered, down to 1/16 of them, while
+// it is faster than half of it, before adaptive batching applies.
+func (sh *BatchHelper) FlushThresholds() (flushBytes, flushItems int64) {
+	return sh.adaptive.apply(sh.latency.apply(sh.options.FlushBytes)), sh.adaptive.apply(sh.latency.apply(sh.options.FlushItems))
+}
+
+// SetLogsBatchID stamps every resource of logs with the id of the batch, under the attribute key set with
+// encoding.WithBatchIDAttribute, if any. Decoders call it on each batch they return, so that ids increase from 1
+// within the decoder. Empty batches are not stamped and do not use an id.
+func (sh *BatchHelper) SetLogsBatchID(logs plog.Logs) {
+	if sh.options.BatchIDAttribute == "" || logs.ResourceLogs().Len() == 0 {
+		return
+	}
+	sh.batchID++
+	for i := 0; i < logs.ResourceLogs().Len(); i++ {
+		logs.ResourceLogs().At(i).Resource().Attributes().PutInt(sh.options.BatchIDAttribute, sh.batchID)
+	}
+}
+
+// track tracks record, whose bytes were counted with IncrementBytes, as an item of the batch. Records of
+// whitespace only are blank, and so empty, whatever the delimiter: with encoding.WithSkipEmptyRecords, they are
+// skipped, neither counted as items nor triggering a flush. Otherwise, flush is set, and the batch reset, when the
+// batch should be flushed after record.
+func (sh *BatchHelper) track(record []byte) (flush, skip bool) {
+	if sh.options.SkipEmptyRecords && len(bytes.TrimSpace(record)) == 0 {
+		sh.IncrementSkipped(1)
+		return false, true
+	}
+
+	sh.IncrementItems(1)
+	if sh.ShouldFlush() {
+		sh.Reset()
+		return true, false
+	}
+	return false, false
+}
+
+// scan returns the next record scanned by next, resetting the batch once the end of the stream is reached, which
+// flushes the last batch.
+func (sh *BatchHelper) scan(next func() ([]byte, bool, error)) ([]byte, bool, error) {
+	b, flush, err := next()
+	if err == io.EOF {
+		sh.Reset()
+	}
+	return b, flush, err
+}
+
+// reset resets the current byte and item counts to zero, without recording a flushed batch.
+func (sh *BatchHelper) reset() {
 	sh.currentBytes = 0
 	sh.currentItems = 0
+	sh.currentMemory = 0
+	sh.atBoundary = false
+	if sh.adaptive != nil {
+		sh.adaptive.batchStart = time.Time{}
+	}
+}
+
+// resetOptions replaces the options with opts and resets the counts, stats and flush reason, as if created by
+// NewBatchHelper.
+func (sh *BatchHelper) resetOptions(opts ...encoding.DecoderOption) {
+	sh.options = encoding.NewDecoderOptions(opts...)
+	sh.telemetry = newDecoderTelemetry(sh.options)
+	sh.adaptive = newAdaptiveThresholds(sh.options.AdaptiveBatchTarget)
+	sh.latency = newLatencyThresholds(sh.options.DownstreamLatencyTarget, sh.options.DownstreamLatency)
+	sh.flushReason = FlushReasonNone
+	sh.batchID = 0
+	sh.stats.reset()
+	sh.reset()
+}
+
+// UpdateOptions applies opts on top of the current options, e.g. to change flush thresholds mid-stream.
+// The current byte and item counts are kept, so the new thresholds apply to the batch being tracked.
+// Adaptive and latency-driven batching start over from the new thresholds only when their target changes.
+func (sh *BatchHelper) UpdateOptions(opts ...encoding.DecoderOption) {
+	target, latencyTarget := sh.options.AdaptiveBatchTarget, sh.options.DownstreamLatencyTarget
+	for _, opt := range opts {
+		opt(&sh.options)
+	}
+	if sh.options.AdaptiveBatchTarget != target {
+		sh.adaptive = newAdaptiveThresholds(sh.options.AdaptiveBatchTarget)
+	}
+	if sh.options.DownstreamLatencyTarget != latencyTarget {
+		sh.latency = newLatencyThresholds(sh.options.DownstreamLatencyTarget, sh.options.DownstreamLatency)
+	} else if sh.latency != nil {
+		// The control signal may be replaced while keeping the scale reached.
+		sh.latency.latency = sh.options.DownstreamLatency
+	}
+}
+
+// Stats returns the cumulative statistics of the batches tracked by the BatchHelper, where bytes and records are
+// the ones it was incremented with and a flushed batch is a non-empty batch being reset.
+// Unlike the other methods, it is safe to call concurrently, e.g. to report the stats of a decoder in use.
+func (sh *BatchHelper) Stats() encoding.DecoderStats {
+	return sh.stats.snapshot()
 }
 
 // Options returns the DecoderOptions used by the BatchHelper.
@@ -169,13 +577,17 @@ func (sh *BatchHelper) Options() encoding.DecoderOptions {
 type LogsDecoderAdapter struct {
 	decode func() (plog.Logs, error)
 	offset func() int64
+	// offsetSemantics is declared through encoding.OffsetAware, encoding.OffsetSemanticsOpaque unless set with
+	// WithOffsetSemantics.
+	offsetSemantics encoding.OffsetSemantics
 }
 
 // NewLogsDecoderAdapter creates a new LogsDecoderAdapter with the provided decode and offset functions.
 func NewLogsDecoderAdapter(decode func() (plog.Logs, error), offset func() int64) LogsDecoderAdapter {
 	return LogsDecoderAdapter{
-		decode: decode,
-		offset: offset,
+		decode:          decode,
+		offset:          offset,
+		offsetSemantics: encoding.OffsetSemanticsOpaque,
 	}
 }
 
@@ -187,17 +599,26 @@ func (a LogsDecoderAdapter) Offset() int64 {
 	return a.offset()
 }
 
+// OffsetSemantics implements encoding.OffsetAware.
+func (a LogsDecoderAdapter) OffsetSemantics() encoding.OffsetSemantics {
+	return a.offsetSemantics
+}
+
 // MetricsDecoderAdapter adapts decode and offset functions to implement encoding.MetricsDecoder.
 type MetricsDecoderAdapter struct {
 	decode func() (pmetric.Metrics, error)
 	offset func() int64
+	// offsetSemantics is declared through encoding.OffsetAware, encoding.OffsetSemanticsOpaque unless set with
+	// WithOffsetSemantics.
+	offsetSemantics encoding.OffsetSemantics
 }
 
 // NewMetricsDecoderAdapter creates a new MetricsDecoderAdapter with the provided decode and offset functions.
 func NewMetricsDecoderAdapter(decode func() (pmetric.Metrics, error), offset func() int64) MetricsDecoderAdapter {
 	return MetricsDecoderAdapter{
-		decode: decode,
-		offset: offset,
+		decode:          decode,
+		offset:          offset,
+		offsetSemantics: encoding.OffsetSemanticsOpaque,
 	}
 }
 
@@ -209,6 +630,11 @@ func (a MetricsDecoderAdapter) Offset() int64 {
 	return a.offset()
 }
 
+// OffsetSemantics implements encoding.OffsetAware.
+func (a MetricsDecoderAdapter) OffsetSemantics() encoding.OffsetSemantics {
+	return a.offsetSemantics
+}
+
 // logsUnmarshalerDecoderFactory adapts a plog.Unmarshaler into an encoding.LogsDecoderFactory.
 // It reads the entire remaining stream and delegates to the unmarshaler on the first decode call.
 type logsUnmarshalerDecoderFactory struct {
@@ -221,12 +647,18 @@ func NewLogsUnmarshalerDecoderFactory(unmarshaler plog.Unmarshaler) encoding.Log
 	return &logsUnmarshalerDecoderFactory{unmarshaler: unmarshaler}
 }
 
+// With encoding.WithFlushOnResourceBoundary, the unmarshaled logs are returned in batches of whole resources,
+// each flushed at the first resource boundary after crossing a flush threshold. Bytes are counted in the OTLP protobuf
+// encoding. Until the last batch is returned, the offset stays at the start of the stream, so that resuming from it
+// does not lose logs. The decoder is wrapped by the middlewares of WithMiddlewares.
 func (f *logsUnmarshalerDecoderFactory) NewLogsDecoder(reader io.Reader, options ...encoding.DecoderOption) (encoding.LogsDecoder, error) {
-	return &logsUnmarshalerDecoder{
+	opts := encoding.NewDecoderOptions(options...)
+	decoder := &logsUnmarshalerDecoder{
 		unmarshaler: f.unmarshaler,
 		reader:      reader,
-		opts:        encoding.NewDecoderOptions(options...),
-	}, nil
+		opts:        opts,
+	}
+	return ChainLogsDecoderMiddlewares(Middlewares(opts)...)(decoder), nil
 }
 
 type logsUnmarshalerDecoder struct {
@@ -235,9 +667,14 @@ type logsUnmarshalerDecoder struct {
 	opts        encoding.DecoderOptions
 	offset      int64
 	done        bool
+	// batches are the batches remaining to be returned when flushing on resource boundaries.
+	batches []plog.Logs
 }
 
 func (d *logsUnmarshalerDecoder) DecodeLogs() (plog.Logs, error) {
+	if len(d.batches) > 0 {
+		return d.nextBatch(), nil
+	}
 	if d.done {
 		return plog.Logs{}, io.EOF
 	}
@@ -256,13 +693,52 @@ func (d *logsUnmarshalerDecoder) DecodeLogs() (plog.Logs, error) {
 	if err != nil {
 		return plog.Logs{}, err
 	}
-	return logs, nil
+	if !d.opts.FlushOnResourceBoundary {
+		return logs, nil
+	}
+	d.batches = batchResourceLogs(logs, d.opts)
+	return d.nextBatch(), nil
+}
+
+// nextBatch removes and returns the next batch of batches.
+func (d *logsUnmarshalerDecoder) nextBatch() plog.Logs {
+	logs := d.batches[0]
+	d.batches = d.batches[1:]
+	return logs
 }
 
 func (d *logsUnmarshalerDecoder) Offset() int64 {
+	if len(d.batches) > 0 {
+		return d.opts.Offset
+	}
 	return d.offset
 }
 
+// batchResourceLogs moves the resources of logs to batches flushed on resource boundaries, as tracked by a BatchHelper
+// with the flush thresholds of options. It returns a single empty batch when logs has no resource.
+func batchResourceLogs(logs plog.Logs, options encoding.DecoderOptions) []plog.Logs {
+	// The bytes tracked are not read from the stream, so they are not recorded as decoder telemetry.
+	helper := &BatchHelper{options: options}
+	var sizer plog.ProtoMarshaler
+	batches := []plog.Logs{plog.NewLogs()}
+	for i := 0; i < logs.ResourceLogs().Len(); i++ {
+		rl := logs.ResourceLogs().At(i)
+		helper.MarkBoundary()
+		if helper.ShouldFlush() {
+			helper.reset()
+			batches = append(batches, plog.NewLogs())
+		}
+		records := 0
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			records += rl.ScopeLogs().At(j).LogRecords().Len()
+		}
+		helper.IncrementItems(int64(records))
+		helper.IncrementBytes(int64(sizer.ResourceLogsSize(rl)))
+		rl.MoveTo(batches[len(batches)-1].ResourceLogs().AppendEmpty())
+	}
+	return batches
+}
+
 // metricsUnmarshalerDecoderFactory adapts a pmetric.Unmarshaler into an encoding.MetricsDecoderFactory.
 // It reads the entire remaining stream and delegates to the unmarshaler on the first decode call.
 type metricsUnmarshalerDecoderFactory struct {
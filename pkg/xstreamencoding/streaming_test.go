@@ -37,6 +37,26 @@ func TestStreamScannerHelper_constructor(t *testing.T) {
 		assert.Equal(t, bufReader, helper.bufReader)
 	})
 
+	t.Run("IO reader uses configured buffer size", func(t *testing.T) {
+		reader := strings.NewReader("test")
+
+		helper, err := NewScannerHelper(reader, encoding.WithReaderBufferSize(64*1024))
+		require.NoError(t, err)
+
+		assert.Equal(t, 64*1024, helper.bufReader.Size())
+	})
+
+	t.Run("Bufio.Reader ignores configured buffer size", func(t *testing.T) {
+		reader := strings.NewReader("test")
+		bufReader := bufio.NewReader(reader)
+
+		helper, err := NewScannerHelper(bufReader, encoding.WithReaderBufferSize(64*1024))
+		require.NoError(t, err)
+
+		assert.Equal(t, bufReader, helper.bufReader)
+		assert.Equal(t, bufReader.Size(), helper.bufReader.Size())
+	})
+
 	t.Run("Offset is checked and error wil be returned if incorrect", func(t *testing.T) {
 		reader := strings.NewReader("test")
 		bufReader := bufio.NewReader(reader)
@@ -102,6 +122,142 @@ func TestStreamScannerHelper_ScanBytes(t *testing.T) {
 	assert.True(t, flush)
 }
 
+func TestStreamScannerHelper_SkipEmptyRecords(t *testing.T) {
+	input := "line1\n\n  \nline2\n\nline3\n\n"
+
+	t.Run("enabled", func(t *testing.T) {
+		helper, err := NewScannerHelper(strings.NewReader(input), encoding.WithSkipEmptyRecords(true), encoding.WithFlushItems(2))
+		require.NoError(t, err)
+
+		line, flush, err := helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "line1", line)
+		assert.False(t, flush)
+		require.Equal(t, int64(6), helper.Offset())
+
+		// Blank lines are skipped, but still move the offset
+		line, flush, err = helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "line2", line)
+		assert.True(t, flush)
+		require.Equal(t, int64(16), helper.Offset())
+
+		line, flush, err = helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "line3", line)
+		assert.False(t, flush)
+		require.Equal(t, int64(23), helper.Offset())
+
+		line, flush, err = helper.ScanString()
+		assert.ErrorIs(t, err, io.EOF)
+		assert.Empty(t, line)
+		assert.True(t, flush)
+		require.Equal(t, int64(len(input)), helper.Offset())
+	})
+
+	t.Run("enabled resumes after skipped records", func(t *testing.T) {
+		helper, err := NewScannerHelper(strings.NewReader(input), encoding.WithSkipEmptyRecords(true), encoding.WithOffset(6))
+		require.NoError(t, err)
+
+		line, _, err := helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "line2", line)
+		require.Equal(t, int64(16), helper.Offset())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		helper, err := NewScannerHelper(strings.NewReader(input), encoding.WithFlushItems(2))
+		require.NoError(t, err)
+
+		var lines []string
+		var flushes int
+		for {
+			line, flush, err := helper.ScanString()
+			if flush {
+				flushes++
+			}
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+			lines = append(lines, line)
+		}
+		// Only the delimiter is stripped, whitespace is kept
+		assert.Equal(t, []string{"line1", "", "  ", "line2", "", "line3", ""}, lines)
+		// Flushes after every two records, and at the end of the stream
+		assert.Equal(t, 4, flushes)
+		require.Equal(t, int64(len(input)), helper.Offset())
+	})
+}
+
+func TestStreamScannerHelper_DelimiterByte(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		opts     []encoding.DecoderOption
+		expected []string
+	}{
+		{
+			name:     "new line strips carriage returns and keeps whitespace",
+			input:    " a \r\nb\r\r\n\tc",
+			expected: []string{" a ", "b\r", "\tc"},
+		},
+		{
+			name:     "NUL",
+			input:    "MESSAGE=a\nb\x00MESSAGE=c\r\x00",
+			opts:     []encoding.DecoderOption{encoding.WithDelimiterByte(0)},
+			expected: []string{"MESSAGE=a\nb", "MESSAGE=c\r"},
+		},
+		{
+			name:     "carriage return",
+			input:    "a\rb\n\rc\r",
+			opts:     []encoding.DecoderOption{encoding.WithDelimiterByte('\r')},
+			expected: []string{"a", "b\n", "c"},
+		},
+		{
+			name:     "pipe skipping empty records",
+			input:    "a|| |b|",
+			opts:     []encoding.DecoderOption{encoding.WithDelimiterByte('|'), encoding.WithSkipEmptyRecords(true)},
+			expected: []string{"a", "b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			helper, err := NewScannerHelper(strings.NewReader(tt.input), tt.opts...)
+			require.NoError(t, err)
+
+			var records []string
+			var offsets []int64
+			for {
+				record, _, err := helper.ScanString()
+				if err == io.EOF {
+					if record != "" {
+						records = append(records, record)
+					}
+					break
+				}
+				require.NoError(t, err)
+				records = append(records, record)
+				offsets = append(offsets, helper.Offset())
+			}
+			assert.Equal(t, tt.expected, records)
+			// Offsets count the delimiters, so that decoding can be resumed after each record
+			require.Equal(t, int64(len(tt.input)), helper.Offset())
+
+			for i, offset := range offsets {
+				resumed, err := NewScannerHelper(strings.NewReader(tt.input), append(tt.opts, encoding.WithOffset(offset))...)
+				require.NoError(t, err)
+				record, _, err := resumed.ScanString()
+				if i+1 < len(records) {
+					assert.Equal(t, records[i+1], record)
+				} else {
+					assert.ErrorIs(t, err, io.EOF)
+				}
+			}
+		})
+	}
+}
+
 func TestStreamScannerHelper_InitialOffset(t *testing.T) {
 	input := "line1\nline2\nline3\n"
 
@@ -128,6 +284,139 @@ func TestStreamScannerHelper_InitialOffset(t *testing.T) {
 	assert.True(t, flush)
 }
 
+// seekableReader is a custom io.ReadSeeker, to make sure Rewind does not depend on a concrete reader type.
+type seekableReader struct {
+	io.ReadSeeker
+}
+
+func TestStreamScannerHelper_Rewind(t *testing.T) {
+	input := "line1\nline2\nline3\n"
+
+	t.Run("seekable reader", func(t *testing.T) {
+		helper, err := NewScannerHelper(seekableReader{strings.NewReader(input)}, encoding.WithFlushItems(2))
+		require.NoError(t, err)
+
+		line, flush, err := helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "line1", line)
+		assert.False(t, flush)
+
+		line, flush, err = helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "line2", line)
+		assert.True(t, flush)
+
+		line, _, err = helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "line3", line)
+		require.Equal(t, int64(18), helper.Offset())
+
+		// Rewind to the start of line2, batch state is reset so the flush happens after line3 again
+		require.NoError(t, helper.Rewind(6))
+		require.Equal(t, int64(6), helper.Offset())
+
+		line, flush, err = helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "line2", line)
+		assert.False(t, flush)
+		require.Equal(t, int64(12), helper.Offset())
+
+		line, flush, err = helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "line3", line)
+		assert.True(t, flush)
+		require.Equal(t, int64(18), helper.Offset())
+
+		_, _, err = helper.ScanString()
+		assert.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("bufio reader is not seekable", func(t *testing.T) {
+		helper, err := NewScannerHelper(bufio.NewReader(strings.NewReader(input)))
+		require.NoError(t, err)
+
+		_, _, err = helper.ScanString()
+		require.NoError(t, err)
+
+		require.ErrorIs(t, helper.Rewind(0), ErrReaderNotSeekable)
+		require.Equal(t, int64(6), helper.Offset())
+	})
+
+	t.Run("non-seekable reader", func(t *testing.T) {
+		helper, err := NewScannerHelper(io.MultiReader(strings.NewReader(input)))
+		require.NoError(t, err)
+
+		require.ErrorIs(t, helper.Rewind(0), ErrReaderNotSeekable)
+	})
+}
+
+func TestStreamScannerHelper_Reset(t *testing.T) {
+	t.Run("reuses the derived buffer", func(t *testing.T) {
+		helper, err := NewScannerHelper(strings.NewReader("line1\nline2\n"), encoding.WithFlushItems(1))
+		require.NoError(t, err)
+		bufReader := helper.bufReader
+
+		line, flush, err := helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "line1", line)
+		assert.True(t, flush)
+
+		// The remaining records of the previous stream are discarded along with the options
+		require.NoError(t, helper.Reset(strings.NewReader("first\nsecond\nthird\n"), encoding.WithOffset(6), encoding.WithFlushItems(2)))
+		assert.Same(t, bufReader, helper.bufReader)
+		assert.Equal(t, int64(6), helper.Offset())
+		assert.Equal(t, int64(2), helper.Options().FlushItems)
+
+		line, flush, err = helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "second", line)
+		assert.False(t, flush)
+
+		line, flush, err = helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "third", line)
+		assert.True(t, flush)
+		assert.Equal(t, int64(19), helper.Offset())
+
+		_, _, err = helper.ScanString()
+		assert.ErrorIs(t, err, io.EOF)
+	})
+
+	t.Run("derives a new buffer when its size changes", func(t *testing.T) {
+		helper, err := NewScannerHelper(strings.NewReader("line1\n"))
+		require.NoError(t, err)
+		bufReader := helper.bufReader
+
+		require.NoError(t, helper.Reset(strings.NewReader("line1\n"), encoding.WithReaderBufferSize(64*1024)))
+		assert.NotSame(t, bufReader, helper.bufReader)
+		assert.Equal(t, 64*1024, helper.bufReader.Size())
+	})
+
+	t.Run("never resets a provided bufio.Reader", func(t *testing.T) {
+		provided := bufio.NewReader(strings.NewReader("line1\n"))
+		helper, err := NewScannerHelper(provided)
+		require.NoError(t, err)
+
+		require.NoError(t, helper.Reset(strings.NewReader("other\n")))
+		assert.NotSame(t, provided, helper.bufReader)
+		line, _, err := helper.ScanString()
+		require.NoError(t, err)
+		assert.Equal(t, "other", line)
+
+		// The provided reader was left untouched
+		remaining, err := io.ReadAll(provided)
+		require.NoError(t, err)
+		assert.Equal(t, "line1\n", string(remaining))
+	})
+
+	t.Run("checks the offset", func(t *testing.T) {
+		helper, err := NewScannerHelper(strings.NewReader("line1\n"))
+		require.NoError(t, err)
+
+		require.ErrorContains(t, helper.Reset(strings.NewReader("test"), encoding.WithOffset(10)), "failed to discard offset 10")
+	})
+}
+
 func TestStreamBatchHelper_ShouldFlush(t *testing.T) {
 	helper := NewBatchHelper(encoding.WithFlushBytes(5), encoding.WithFlushItems(5))
 
@@ -143,6 +432,192 @@ func TestStreamBatchHelper_ShouldFlush(t *testing.T) {
 	assert.True(t, helper.ShouldFlush())
 }
 
+func TestStreamBatchHelper_track(t *testing.T) {
+	helper := NewBatchHelper(encoding.WithFlushBytes(0), encoding.WithFlushItems(2), encoding.WithSkipEmptyRecords(true))
+
+	flush, skip := helper.track([]byte("first"))
+	assert.False(t, flush)
+	assert.False(t, skip)
+
+	// Blank records are skipped without counting as items
+	flush, skip = helper.track([]byte(" \t"))
+	assert.False(t, flush)
+	assert.True(t, skip)
+
+	// The batch is reset once it should be flushed
+	flush, skip = helper.track([]byte("second"))
+	assert.True(t, flush)
+	assert.False(t, skip)
+	assert.False(t, helper.ShouldFlush())
+
+	stats := helper.Stats()
+	assert.Equal(t, int64(2), stats.RecordsDecoded)
+	assert.Equal(t, int64(1), stats.RecordsSkipped)
+}
+
+func TestStreamBatchHelper_UpdateOptions(t *testing.T) {
+	helper := NewBatchHelper(encoding.WithFlushBytes(100), encoding.WithFlushItems(5), encoding.WithOffset(10))
+
+	helper.IncrementItems(2)
+	assert.False(t, helper.ShouldFlush())
+
+	helper.UpdateOptions(encoding.WithFlushItems(2))
+	assert.True(t, helper.ShouldFlush())
+	assert.Equal(t, FlushReasonItems, helper.FlushReason())
+
+	options := helper.Options()
+	assert.Equal(t, int64(2), options.FlushItems)
+	assert.Equal(t, int64(100), options.FlushBytes)
+	assert.Equal(t, int64(10), options.Offset)
+}
+
+func TestStreamBatchHelper_FlushReason(t *testing.T) {
+	t.Run("bytes", func(t *testing.T) {
+		helper := NewBatchHelper(encoding.WithFlushBytes(5), encoding.WithFlushItems(0))
+		assert.Equal(t, FlushReasonNone, helper.FlushReason())
+
+		helper.IncrementItems(100)
+		helper.IncrementBytes(4)
+		assert.False(t, helper.ShouldFlush())
+		assert.Equal(t, FlushReasonNone, helper.FlushReason())
+
+		helper.IncrementBytes(1)
+		assert.True(t, helper.ShouldFlush())
+		assert.Equal(t, FlushReasonBytes, helper.FlushReason())
+		assert.Equal(t, "bytes", helper.FlushReason().String())
+
+		helper.Reset()
+		assert.Equal(t, FlushReasonBytes, helper.FlushReason())
+	})
+
+	t.Run("items", func(t *testing.T) {
+		helper := NewBatchHelper(encoding.WithFlushBytes(0), encoding.WithFlushItems(5))
+		assert.Equal(t, FlushReasonNone, helper.FlushReason())
+
+		helper.IncrementBytes(1000)
+		helper.IncrementItems(4)
+		assert.False(t, helper.ShouldFlush())
+		assert.Equal(t, FlushReasonNone, helper.FlushReason())
+
+		helper.IncrementItems(1)
+		assert.True(t, helper.ShouldFlush())
+		assert.Equal(t, FlushReasonItems, helper.FlushReason())
+		assert.Equal(t, "items", helper.FlushReason().String())
+	})
+
+	t.Run("memory", func(t *testing.T) {
+		helper := NewBatchHelper(encoding.WithFlushBytes(100), encoding.WithFlushItems(100), encoding.WithMaxBatchMemory(50))
+
+		helper.IncrementBytes(10)
+		helper.IncrementItems(10)
+		helper.IncrementMemory(49)
+		assert.False(t, helper.ShouldFlush())
+
+		helper.IncrementMemory(1)
+		assert.True(t, helper.ShouldFlush())
+		assert.Equal(t, FlushReasonMemory, helper.FlushReason())
+		assert.Equal(t, "memory", helper.FlushReason().String())
+
+		// Reset starts tracking the memory of the next batch
+		helper.Reset()
+		helper.IncrementMemory(49)
+		assert.False(t, helper.ShouldFlush())
+	})
+}
+
+func TestStreamBatchHelper_SetLogsBatchID(t *testing.T) {
+	newBatch := func(resources int) plog.Logs {
+		logs := plog.NewLogs()
+		for range resources {
+			logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+		}
+		return logs
+	}
+	batchIDs := func(logs plog.Logs) []int64 {
+		var ids []int64
+		for i := 0; i < logs.ResourceLogs().Len(); i++ {
+			id, ok := logs.ResourceLogs().At(i).Resource().Attributes().Get("batch.id")
+			require.True(t, ok)
+			ids = append(ids, id.Int())
+		}
+		return ids
+	}
+
+	helper := NewBatchHelper(encoding.WithBatchIDAttribute("batch.id"))
+	first, second := newBatch(2), newBatch(3)
+	helper.SetLogsBatchID(first)
+	// Empty batches do not use an id
+	helper.SetLogsBatchID(plog.NewLogs())
+	helper.SetLogsBatchID(second)
+	assert.Equal(t, []int64{1, 1}, batchIDs(first))
+	assert.Equal(t, []int64{2, 2, 2}, batchIDs(second))
+
+	// Batches are not stamped without attribute key
+	unstamped := newBatch(1)
+	NewBatchHelper().SetLogsBatchID(unstamped)
+	assert.Equal(t, 0, unstamped.ResourceLogs().At(0).Resource().Attributes().Len())
+}
+
+func TestStreamBatchHelper_MarkBoundary(t *testing.T) {
+	t.Run("flush on resource boundary", func(t *testing.T) {
+		helper := NewBatchHelper(encoding.WithFlushItems(2), encoding.WithFlushBytes(0), encoding.WithFlushOnResourceBoundary())
+
+		// Crossing the threshold mid-resource does not flush
+		helper.MarkBoundary()
+		assert.False(t, helper.ShouldFlush())
+		helper.IncrementItems(3)
+		assert.False(t, helper.ShouldFlush())
+		assert.Equal(t, FlushReasonNone, helper.FlushReason())
+
+		// The batch is flushed at the next boundary
+		helper.MarkBoundary()
+		assert.True(t, helper.ShouldFlush())
+		assert.Equal(t, FlushReasonItems, helper.FlushReason())
+
+		// Boundaries are cleared by Reset and increments
+		helper.Reset()
+		helper.IncrementItems(2)
+		assert.False(t, helper.ShouldFlush())
+		helper.MarkBoundary()
+		helper.IncrementItems(1)
+		assert.False(t, helper.ShouldFlush())
+	})
+
+	t.Run("memory cap within a resource", func(t *testing.T) {
+		helper := NewBatchHelper(encoding.WithFlushItems(2), encoding.WithFlushBytes(0), encoding.WithMaxBatchMemory(100),
+			encoding.WithFlushOnResourceBoundary())
+
+		// A single resource crossing the item threshold is not split
+		helper.MarkBoundary()
+		helper.IncrementItems(3)
+		helper.IncrementMemory(60)
+		assert.False(t, helper.ShouldFlush())
+
+		// but it is once it crosses the memory cap, even though the item threshold was crossed first
+		helper.IncrementItems(1)
+		helper.IncrementMemory(60)
+		assert.True(t, helper.ShouldFlush())
+		assert.Equal(t, FlushReasonMemory, helper.FlushReason())
+
+		// The rest of the resource is tracked in the next batch, flushed at the next boundary
+		helper.Reset()
+		helper.IncrementItems(2)
+		helper.IncrementMemory(10)
+		assert.False(t, helper.ShouldFlush())
+		helper.MarkBoundary()
+		assert.True(t, helper.ShouldFlush())
+		assert.Equal(t, FlushReasonItems, helper.FlushReason())
+	})
+
+	t.Run("without flush on resource boundary", func(t *testing.T) {
+		helper := NewBatchHelper(encoding.WithFlushItems(2), encoding.WithFlushBytes(0))
+		helper.IncrementItems(2)
+		assert.True(t, helper.ShouldFlush())
+		helper.MarkBoundary()
+		assert.True(t, helper.ShouldFlush())
+	})
+}
+
 type stubLogsUnmarshaler struct {
 	logs plog.Logs
 	err  error
@@ -225,6 +700,42 @@ func TestLogsUnmarshalerDecoderFactory(t *testing.T) {
 		assert.Equal(t, int64(len(input)), decoder.Offset())
 	})
 
+	t.Run("flush on resource boundary", func(t *testing.T) {
+		// Resources with 1, 2, 1 and 1 records
+		logs := plog.NewLogs()
+		for i, records := range []int{1, 2, 1, 1} {
+			rl := logs.ResourceLogs().AppendEmpty()
+			rl.Resource().Attributes().PutInt("resource", int64(i))
+			for range records {
+				rl.ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+			}
+		}
+
+		factory := NewLogsUnmarshalerDecoderFactory(&stubLogsUnmarshaler{logs: logs})
+		input := "some log data"
+		decoder, err := factory.NewLogsDecoder(strings.NewReader(input),
+			encoding.WithFlushItems(2), encoding.WithFlushBytes(0), encoding.WithFlushOnResourceBoundary())
+		require.NoError(t, err)
+
+		// The first batch exceeds FlushItems so as not to split the second resource
+		batch, err := decoder.DecodeLogs()
+		require.NoError(t, err)
+		assert.Equal(t, 2, batch.ResourceLogs().Len())
+		assert.Equal(t, 3, batch.LogRecordCount())
+		assert.Equal(t, int64(0), decoder.Offset(), "offset should stay at the start until the last batch")
+
+		batch, err = decoder.DecodeLogs()
+		require.NoError(t, err)
+		assert.Equal(t, 2, batch.ResourceLogs().Len())
+		assert.Equal(t, 2, batch.LogRecordCount())
+		resource, _ := batch.ResourceLogs().At(0).Resource().Attributes().Get("resource")
+		assert.Equal(t, int64(2), resource.Int())
+		assert.Equal(t, int64(len(input)), decoder.Offset())
+
+		_, err = decoder.DecodeLogs()
+		assert.ErrorIs(t, err, io.EOF)
+	})
+
 	t.Run("unmarshal error propagates", func(t *testing.T) {
 		factory := NewLogsUnmarshalerDecoderFactory(&stubLogsUnmarshaler{err: assert.AnError})
 		decoder, err := factory.NewLogsDecoder(strings.NewReader("data"))
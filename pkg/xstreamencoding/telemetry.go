@@ -0,0 +1,98 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+const (
+	scopeName = "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+	// encodingAttribute is the attribute identifying the encoding extension in decoder metrics.
+	encodingAttribute = "encoding"
+)
+
+// telemetryKey and encodingIDKey are the keys of the options set with WithTelemetry and WithEncodingID in
+// encoding.DecoderOptions.
+type (
+	telemetryKey  struct{}
+	encodingIDKey struct{}
+)
+
+// WithTelemetry sets the telemetry settings decoders record their metrics with. Without a MeterProvider, decoders
+// record no metrics.
+func WithTelemetry(settings component.TelemetrySettings) encoding.DecoderOption {
+	return encoding.WithValue(telemetryKey{}, settings)
+}
+
+// Telemetry returns the telemetry settings set with WithTelemetry in options.
+func Telemetry(options encoding.DecoderOptions) component.TelemetrySettings {
+	settings, _ := options.Value(telemetryKey{}).(component.TelemetrySettings)
+	return settings
+}
+
+// WithEncodingID sets the ID of the encoding extension creating the decoders, recorded as the encoding attribute of
+// their metrics.
+func WithEncodingID(id component.ID) encoding.DecoderOption {
+	return encoding.WithValue(encodingIDKey{}, id)
+}
+
+// EncodingID returns the ID set with WithEncodingID in options.
+func EncodingID(options encoding.DecoderOptions) component.ID {
+	id, _ := options.Value(encodingIDKey{}).(component.ID)
+	return id
+}
+
+// decoderTelemetry records metrics about the work done by a decoder.
+type decoderTelemetry struct {
+	flushedBatches metric.Int64Counter
+	readBytes      metric.Int64Counter
+	records        metric.Int64Counter
+	attributes     metric.MeasurementOption
+}
+
+// newDecoderTelemetry creates the decoder metrics from the telemetry settings of options.
+// It returns nil when no MeterProvider is configured.
+func newDecoderTelemetry(options encoding.DecoderOptions) *decoderTelemetry {
+	settings := Telemetry(options)
+	if settings.MeterProvider == nil {
+		return nil
+	}
+
+	meter := settings.MeterProvider.Meter(scopeName)
+	flushedBatches, errBatches := meter.Int64Counter(
+		"otelcol_decoder_flushed_batches",
+		metric.WithDescription("Number of batches flushed by stream decoders."),
+		metric.WithUnit("{batches}"),
+	)
+	readBytes, errBytes := meter.Int64Counter(
+		"otelcol_decoder_read_bytes",
+		metric.WithDescription("Number of bytes read from streams by stream decoders."),
+		metric.WithUnit("By"),
+	)
+	records, errRecords := meter.Int64Counter(
+		"otelcol_decoder_records",
+		metric.WithDescription("Number of records decoded by stream decoders."),
+		metric.WithUnit("{records}"),
+	)
+	if err := errors.Join(errBatches, errBytes, errRecords); err != nil && settings.Logger != nil {
+		// The returned instruments are still usable, possibly as no-ops
+		settings.Logger.Warn("failed to create decoder metrics", zap.Error(err))
+	}
+
+	return &decoderTelemetry{
+		flushedBatches: flushedBatches,
+		readBytes:      readBytes,
+		records:        records,
+		attributes:     metric.WithAttributeSet(attribute.NewSet(attribute.String(encodingAttribute, EncodingID(options).String()))),
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package xstreamencoding // import "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/xstreamencoding"
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.uber.org/zap"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/extension/encoding"
+)
+
+// collectCounters returns the value of each counter collected by reader, along with their encoding attribute.
+func collectCounters(t *testing.T, reader *sdkmetric.ManualReader) map[string]int64 {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	counters := make(map[string]int64)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			require.True(t, ok, m.Name)
+			for _, dp := range sum.DataPoints {
+				encodingID, ok := dp.Attributes.Value(attribute.Key(encodingAttribute))
+				require.True(t, ok)
+				assert.Equal(t, "text_encoding/test", encodingID.AsString())
+				counters[m.Name] += dp.Value
+			}
+		}
+	}
+	return counters
+}
+
+func TestScannerHelper_Telemetry(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	settings := component.TelemetrySettings{
+		Logger:        zap.NewNop(),
+		MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+	}
+
+	input := "line1\nline2\nline3\nline4\nline5\n"
+	helper, err := NewScannerHelper(strings.NewReader(input),
+		encoding.WithFlushItems(2),
+		WithTelemetry(settings),
+		WithEncodingID(component.MustNewIDWithName("text_encoding", "test")),
+	)
+	require.NoError(t, err)
+
+	for {
+		_, _, err = helper.ScanString()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, map[string]int64{
+		"otelcol_decoder_flushed_batches": 3,
+		"otelcol_decoder_read_bytes":      int64(len(input)),
+		"otelcol_decoder_records":         5,
+	}, collectCounters(t, reader))
+}
+
+func TestBatchHelper_Telemetry(t *testing.T) {
+	t.Run("no-op without telemetry", func(t *testing.T) {
+		helper := NewBatchHelper()
+		assert.Nil(t, helper.telemetry)
+
+		helper.IncrementBytes(10)
+		helper.IncrementItems(1)
+		helper.Reset()
+	})
+
+	t.Run("empty batches are not recorded", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		helper := NewBatchHelper(
+			WithTelemetry(component.TelemetrySettings{MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))}),
+			WithEncodingID(component.MustNewIDWithName("text_encoding", "test")),
+		)
+
+		helper.Reset()
+		helper.IncrementBytes(10)
+		helper.IncrementItems(1)
+		helper.Reset()
+		helper.Reset()
+
+		assert.Equal(t, map[string]int64{
+			"otelcol_decoder_flushed_batches": 1,
+			"otelcol_decoder_read_bytes":      10,
+			"otelcol_decoder_records":         1,
+		}, collectCounters(t, reader))
+	})
+}
+
+func TestWithTelemetry(t *testing.T) {
+	options := encoding.NewDecoderOptions()
+	assert.Nil(t, Telemetry(options).MeterProvider)
+	assert.Equal(t, component.ID{}, EncodingID(options))
+
+	options = encoding.NewDecoderOptions(
+		WithTelemetry(component.TelemetrySettings{MeterProvider: sdkmetric.NewMeterProvider()}),
+		WithEncodingID(component.MustNewID("text_encoding")),
+	)
+	assert.NotNil(t, Telemetry(options).MeterProvider)
+	assert.Equal(t, component.MustNewID("text_encoding"), EncodingID(options))
+}
@@ -0,0 +1,140 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package logdedupprocessor // import "github.com/open-telemetry/opentelemetry-collector-contrib/processor/logdedupprocessor"
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// aggregationFunction is a function aggregating the numeric values of an attribute across duplicates.
+type aggregationFunction string
+
+const (
+	aggregationSum aggregationFunction = "sum"
+	aggregationMin aggregationFunction = "min"
+	aggregationMax aggregationFunction = "max"
+	aggregationAvg aggregationFunction = "avg"
+)
+
+// attributeAggregation is the aggregation of the values of an attribute.
+type attributeAggregation struct {
+	key      string
+	function aggregationFunction
+}
+
+// attributeAggregations holds the aggregations configured by attribute, ordered by attribute key.
+type attributeAggregations []attributeAggregation
+
+// newAttributeAggregations parses the aggregate_attributes configuration. It returns nil if no aggregation is configured.
+func newAttributeAggregations(aggregateAttributes map[string]string) (attributeAggregations, error) {
+	if len(aggregateAttributes) == 0 {
+		return nil, nil
+	}
+	aggregations := make(attributeAggregations, 0, len(aggregateAttributes))
+	for key, function := range aggregateAttributes {
+		if key == "" {
+			return nil, errors.New("aggregate_attributes: attribute key must not be empty")
+		}
+		switch f := aggregationFunction(function); f {
+		case aggregationSum, aggregationMin, aggregationMax, aggregationAvg:
+			aggregations = append(aggregations, attributeAggregation{key: key, function: f})
+		default:
+			return nil, fmt.Errorf("aggregate_attributes %q: unknown function %q, must be one of sum, min, max or avg", key, function)
+		}
+	}
+	sort.Slice(aggregations, func(i, j int) bool {
+		return aggregations[i].key < aggregations[j].key
+	})
+	return aggregations, nil
+}
+
+// take removes the aggregated attributes from logRecord, so that they do not identify duplicates, and returns
+// their values in the order of the aggregations. The values of missing attributes are empty.
+func (a attributeAggregations) take(logRecord plog.LogRecord) []pcommon.Value {
+	values := make([]pcommon.Value, len(a))
+	for i, aggregation := range a {
+		values[i] = pcommon.NewValueEmpty()
+		if value, ok := logRecord.Attributes().Get(aggregation.key); ok {
+			value.CopyTo(values[i])
+			logRecord.Attributes().Remove(aggregation.key)
+		}
+	}
+	return values
+}
+
+// newAggregates creates the aggregates of a log counter, in the order of the aggregations.
+func (a attributeAggregations) newAggregates() []attributeAggregate {
+	if len(a) == 0 {
+		return nil
+	}
+	aggregates := make([]attributeAggregate, len(a))
+	for i, aggregation := range a {
+		aggregates[i].attributeAggregation = aggregation
+	}
+	return aggregates
+}
+
+// attributeAggregate aggregates the values of an attribute across duplicates.
+// Integer values are aggregated as integers until a double value is observed.
+type attributeAggregate struct {
+	attributeAggregation
+	// count is the number of numeric values observed.
+	count       int64
+	isDouble    bool
+	intValue    int64
+	doubleValue float64
+}
+
+// add aggregates value. Values that are not numeric are ignored.
+func (a *attributeAggregate) add(value pcommon.Value) {
+	var intValue int64
+	var doubleValue float64
+	switch value.Type() {
+	case pcommon.ValueTypeInt:
+		intValue = value.Int()
+		doubleValue = float64(intValue)
+	case pcommon.ValueTypeDouble:
+		doubleValue = value.Double()
+	default:
+		return
+	}
+
+	first := a.count == 0
+	a.count++
+	switch a.function {
+	case aggregationSum, aggregationAvg:
+		a.intValue += intValue
+		a.doubleValue += doubleValue
+	case aggregationMin:
+		if first || doubleValue < a.doubleValue {
+			a.intValue, a.doubleValue = intValue, doubleValue
+		}
+	case aggregationMax:
+		if first || doubleValue > a.doubleValue {
+			a.intValue, a.doubleValue = intValue, doubleValue
+		}
+	}
+	if value.Type() == pcommon.ValueTypeDouble {
+		a.isDouble = true
+	}
+}
+
+// put sets the aggregated value as the attribute of attrs. Averages are always doubles, other aggregates are
+// integers unless a double value was observed. The attribute is not set if no numeric value was observed.
+func (a *attributeAggregate) put(attrs pcommon.Map) {
+	switch {
+	case a.count == 0:
+	case a.function == aggregationAvg:
+		attrs.PutDouble(a.key, a.doubleValue/float64(a.count))
+	case a.isDouble:
+		attrs.PutDouble(a.key, a.doubleValue)
+	default:
+		attrs.PutInt(a.key, a.intValue)
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package logdedupprocessor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+func Test_newAttributeAggregations(t *testing.T) {
+	aggregations, err := newAttributeAggregations(map[string]string{"latency": "avg", "bytes": "sum"})
+	require.NoError(t, err)
+	require.Equal(t, attributeAggregations{
+		{key: "bytes", function: aggregationSum},
+		{key: "latency", function: aggregationAvg},
+	}, aggregations)
+
+	aggregations, err = newAttributeAggregations(nil)
+	require.NoError(t, err)
+	require.Nil(t, aggregations)
+
+	_, err = newAttributeAggregations(map[string]string{"bytes": "count"})
+	require.EqualError(t, err, `aggregate_attributes "bytes": unknown function "count", must be one of sum, min, max or avg`)
+
+	_, err = newAttributeAggregations(map[string]string{"": "sum"})
+	require.Error(t, err)
+}
+
+func Test_attributeAggregationsTake(t *testing.T) {
+	aggregations, err := newAttributeAggregations(map[string]string{"bytes": "sum", "missing": "max"})
+	require.NoError(t, err)
+
+	lr := plog.NewLogRecord()
+	lr.Attributes().PutInt("bytes", 10)
+	lr.Attributes().PutStr("other", "kept")
+
+	values := aggregations.take(lr)
+	require.Len(t, values, 2)
+	require.Equal(t, int64(10), values[0].Int())
+	require.Equal(t, pcommon.ValueTypeEmpty, values[1].Type())
+	require.Equal(t, map[string]any{"other": "kept"}, lr.Attributes().AsRaw())
+}
+
+func Test_attributeAggregate(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		function aggregationFunction
+		values   []any
+		expected any
+	}{
+		{
+			desc:     "sum of integers",
+			function: aggregationSum,
+			values:   []any{int64(1), int64(2), int64(3)},
+			expected: int64(6),
+		},
+		{
+			desc:     "sum of integers and doubles",
+			function: aggregationSum,
+			values:   []any{int64(1), 2.5},
+			expected: 3.5,
+		},
+		{
+			desc:     "min",
+			function: aggregationMin,
+			values:   []any{int64(5), int64(-2), int64(3)},
+			expected: int64(-2),
+		},
+		{
+			desc:     "max",
+			function: aggregationMax,
+			values:   []any{1.5, 4.5, 2.0},
+			expected: 4.5,
+		},
+		{
+			desc:     "avg of integers",
+			function: aggregationAvg,
+			values:   []any{int64(1), int64(2)},
+			expected: 1.5,
+		},
+		{
+			desc:     "non-numeric values are ignored",
+			function: aggregationAvg,
+			values:   []any{int64(4), "many", nil, int64(2)},
+			expected: 3.0,
+		},
+		{
+			desc:     "no numeric value",
+			function: aggregationSum,
+			values:   []any{"many"},
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			aggregate := attributeAggregate{attributeAggregation: attributeAggregation{key: "value", function: tc.function}}
+			for _, v := range tc.values {
+				value := pcommon.NewValueEmpty()
+				require.NoError(t, value.FromRaw(v))
+				aggregate.add(value)
+			}
+
+			attrs := pcommon.NewMap()
+			aggregate.put(attrs)
+			actual, ok := attrs.Get("value")
+			if tc.expected == nil {
+				require.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			require.Equal(t, tc.expected, actual.AsRaw())
+		})
+	}
+}
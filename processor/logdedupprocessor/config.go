@@ -7,6 +7,7 @@ package logdedupprocessor // import "github.com/open-telemetry/opentelemetry-col
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -29,6 +30,42 @@ const (
 
 	// attributeField is the name of the attribute field
 	attributeField = "attributes"
+
+	// timestampField is the name of the timestamp field, which never identifies duplicates
+	timestampField = "timestamp"
+
+	// observedTimestampField is the name of the observed timestamp field, which never identifies duplicates
+	observedTimestampField = "observed_timestamp"
+
+	// defaultWindowStartAttribute is the default window start attribute
+	defaultWindowStartAttribute = "window_start"
+
+	// defaultWindowEndAttribute is the default window end attribute
+	defaultWindowEndAttribute = "window_end"
+
+	// defaultEmissionReasonAttribute is the default emission reason attribute
+	defaultEmissionReasonAttribute = "emission_reason"
+)
+
+// Sources of the times set on the observed timestamp attributes
+const (
+	// timestampSourceProcessor sets the times the processor observed the first and last duplicates.
+	timestampSourceProcessor = "processor"
+
+	// timestampSourceRecord sets the earliest and latest timestamps of the duplicates themselves.
+	timestampSourceRecord = "record"
+)
+
+// Scopes of deduplication, defining the logs duplicates are identified among
+const (
+	// dedupScopeScope identifies duplicates among the logs of the same resource and scope.
+	dedupScopeScope = "scope"
+
+	// dedupScopeResource identifies duplicates among the logs of the same resource, across scopes.
+	dedupScopeResource = "resource"
+
+	// dedupScopeGlobal identifies duplicates among all logs, across resources and scopes.
+	dedupScopeGlobal = "global"
 )
 
 // Config errors
@@ -37,6 +74,12 @@ var (
 	errInvalidInterval          = errors.New("interval must be greater than 0")
 	errCannotExcludeBody        = errors.New("cannot exclude the entire body")
 	errCannotIncludeBody        = errors.New("cannot include the entire body")
+	errInvalidBodyKeyPrefixLen  = errors.New("body_key_prefix_len must not be negative")
+	errBodyKeyPrefixWithoutBody = errors.New("body_key_prefix_len requires include_body when dedup_fields or include_severity is set")
+	errInvalidSnapshotThreshold = errors.New("snapshot_threshold must not be negative")
+	errInvalidCountThreshold    = errors.New("emit_count_threshold must not be negative")
+	errInvalidMaxHold           = errors.New("max_hold must not be negative")
+	errInvalidMaxAggregates     = errors.New("max_aggregates must not be negative")
 )
 
 // Config is the config of the processor.
@@ -51,6 +94,87 @@ type Config struct {
 	// MetadataCardinalityLimit limits the number of unique metadata combinations
 	// tracked simultaneously. 0 (default) means unbounded.
 	MetadataCardinalityLimit uint32 `mapstructure:"metadata_cardinality_limit"`
+	// IntervalBySeverity overrides Interval for logs whose severity is within a level, e.g. "error",
+	// or a range of levels, e.g. "warn-fatal". Logs matching several ranges use the shortest interval.
+	IntervalBySeverity map[string]time.Duration `mapstructure:"interval_by_severity"`
+	// EmitSuppressionSummary emits an informational log record summarizing the suppression
+	// alongside each aggregated log that suppressed duplicates.
+	EmitSuppressionSummary bool `mapstructure:"emit_suppression_summary"`
+	// DedupFields lists the attribute keys whose values identify duplicate logs, all other fields are ignored.
+	// The body and severity are selected with IncludeBody and IncludeSeverity, "body" and "severity" are reserved.
+	// When empty, and neither IncludeBody nor IncludeSeverity is set, the whole log record is compared,
+	// unless include_fields or exclude_fields is set.
+	DedupFields []string `mapstructure:"dedup_fields"`
+	// IncludeBody compares the body of logs along with the DedupFields attributes. Without DedupFields,
+	// only the body, and the severity if IncludeSeverity is set, identifies duplicate logs.
+	IncludeBody bool `mapstructure:"include_body"`
+	// IncludeSeverity compares the severity number and text of logs along with the DedupFields attributes.
+	IncludeSeverity bool `mapstructure:"include_severity"`
+	// IncludeTraceID compares the trace ID of logs along with the other fields, so that only logs of the same trace
+	// are duplicates. Logs without trace ID, or with an all-zero one, are only duplicates of each other.
+	IncludeTraceID bool `mapstructure:"include_trace_id"`
+	// KeepExcludedFields keeps the ExcludeFields on the logs, ignoring them only to identify duplicates, so that the
+	// emitted aggregated log carries them from its first occurrence. The entire body can then be excluded.
+	KeepExcludedFields bool `mapstructure:"keep_excluded_fields"`
+	// FirstObservedTimestampAttribute is the name of an attribute set to the time the first duplicate was observed,
+	// as nanoseconds since the Unix epoch, see ObservedTimestampSource. It is not set when empty.
+	FirstObservedTimestampAttribute string `mapstructure:"first_observed_timestamp_attribute"`
+	// LastObservedTimestampAttribute is the name of an attribute set to the time the last duplicate was observed,
+	// as nanoseconds since the Unix epoch, see ObservedTimestampSource. It is not set when empty.
+	LastObservedTimestampAttribute string `mapstructure:"last_observed_timestamp_attribute"`
+	// ObservedTimestampSource is the source of the times set on the FirstObservedTimestampAttribute and
+	// LastObservedTimestampAttribute: "processor" for the times the processor observed the first and last duplicates,
+	// or "record" for the earliest and latest timestamps of the duplicates, their ObservedTimestamp or, when unset,
+	// their Timestamp. With "record", the attributes are not set when none of the duplicates has a timestamp.
+	ObservedTimestampSource string `mapstructure:"observed_timestamp_source"`
+	// DelayPassthroughUntilFlush holds the logs not matching the conditions until the next export of aggregated logs,
+	// so that each export is ordered by time. Pass-through logs are then delayed by up to the interval.
+	DelayPassthroughUntilFlush bool `mapstructure:"delay_passthrough_until_flush"`
+	// AggregateAttributes maps log attribute keys to the function aggregating their numeric values across duplicates:
+	// sum, min, max or avg. These attributes do not identify duplicates.
+	AggregateAttributes map[string]string `mapstructure:"aggregate_attributes"`
+	// CountAsString sets the LogCountAttribute attribute as a string instead of an integer,
+	// e.g. for backends only indexing string attributes.
+	CountAsString bool `mapstructure:"count_as_string"`
+	// Scope defines the logs duplicates are identified among: "scope" for logs of the same resource and scope,
+	// "resource" for logs of the same resource, or "global" for all logs. Aggregated logs keep the resource
+	// and scope of the first duplicate.
+	Scope string `mapstructure:"scope"`
+	// EmissionAttributes sets attributes describing the window each aggregated log covers and why it was emitted.
+	EmissionAttributes EmissionAttributesConfig `mapstructure:"emission_attributes"`
+	// HashAlgorithm is the algorithm hashing logs, resources and scopes into the keys identifying duplicates:
+	// "fnv", "xxhash" or "sha256".
+	HashAlgorithm string `mapstructure:"hash_algorithm"`
+	// BodyKeyPrefixLen limits the characters of string bodies identifying duplicates to their first BodyKeyPrefixLen,
+	// so that logs only differing after the prefix are duplicates. Whole bodies are compared when 0.
+	BodyKeyPrefixLen int `mapstructure:"body_key_prefix_len"`
+	// SnapshotThreshold is the number of distinct logs tracked above which the first occurrence of further logs is
+	// kept serialized until exported, reducing the number of objects the garbage collector scans. 0 disables it.
+	SnapshotThreshold int `mapstructure:"snapshot_threshold"`
+	// EmitCountThreshold emits an aggregated log as soon as it counts that many logs, before its interval elapsed.
+	// 0 disables it.
+	EmitCountThreshold int64 `mapstructure:"emit_count_threshold"`
+	// MaxHold emits an aggregated log once its first log was observed that long ago, before its interval elapsed.
+	// 0 disables it.
+	MaxHold time.Duration `mapstructure:"max_hold"`
+	// MaxAggregates is the number of distinct logs aggregated at once above which the one whose first log was
+	// observed the longest ago is evicted and emitted. 0 disables it.
+	MaxAggregates int `mapstructure:"max_aggregates"`
+}
+
+// EmissionAttributesConfig configures the attributes describing the emission of aggregated logs.
+type EmissionAttributesConfig struct {
+	// Enabled sets the attributes on aggregated logs.
+	Enabled bool `mapstructure:"enabled"`
+	// WindowStart is the name of the attribute set to the start of the aggregation window,
+	// as nanoseconds since the Unix epoch.
+	WindowStart string `mapstructure:"window_start"`
+	// WindowEnd is the name of the attribute set to the end of the aggregation window, i.e. the emission time,
+	// as nanoseconds since the Unix epoch.
+	WindowEnd string `mapstructure:"window_end"`
+	// Reason is the name of the attribute set to the reason of the emission: interval, count_threshold, max_hold,
+	// shutdown or eviction.
+	Reason string `mapstructure:"reason"`
 }
 
 // createDefaultConfig returns the default config for the processor.
@@ -61,9 +185,19 @@ func createDefaultConfig() component.Config {
 		Timezone:                 defaultTimezone,
 		ExcludeFields:            []string{},
 		IncludeFields:            []string{},
+		DedupFields:              []string{},
 		Conditions:               []string{},
 		MetadataKeys:             []string{},
 		MetadataCardinalityLimit: 0,
+		Scope:                    dedupScopeScope,
+		HashAlgorithm:            hashAlgorithmFNV,
+		ObservedTimestampSource:  timestampSourceProcessor,
+		SnapshotThreshold:        defaultSnapshotThreshold,
+		EmissionAttributes: EmissionAttributesConfig{
+			WindowStart: defaultWindowStartAttribute,
+			WindowEnd:   defaultWindowEndAttribute,
+			Reason:      defaultEmissionReasonAttribute,
+		},
 	}
 }
 
@@ -77,6 +211,51 @@ func (c Config) Validate() error {
 		return errInvalidLogCountAttribute
 	}
 
+	if _, err := newSeverityIntervals(c.IntervalBySeverity); err != nil {
+		return err
+	}
+
+	switch c.Scope {
+	case "", dedupScopeScope, dedupScopeResource, dedupScopeGlobal:
+	default:
+		return fmt.Errorf("scope must be one of %s, %s or %s, got %q", dedupScopeResource, dedupScopeScope, dedupScopeGlobal, c.Scope)
+	}
+
+	switch c.ObservedTimestampSource {
+	case "", timestampSourceProcessor, timestampSourceRecord:
+	default:
+		return fmt.Errorf("observed_timestamp_source must be one of %s or %s, got %q", timestampSourceProcessor, timestampSourceRecord, c.ObservedTimestampSource)
+	}
+
+	switch c.HashAlgorithm {
+	case "", hashAlgorithmFNV, hashAlgorithmXXHash, hashAlgorithmSHA256:
+	default:
+		return fmt.Errorf("hash_algorithm must be one of %s, %s or %s, got %q", hashAlgorithmFNV, hashAlgorithmXXHash, hashAlgorithmSHA256, c.HashAlgorithm)
+	}
+
+	if c.SnapshotThreshold < 0 {
+		return errInvalidSnapshotThreshold
+	}
+
+	if c.EmitCountThreshold < 0 {
+		return errInvalidCountThreshold
+	}
+
+	if c.MaxHold < 0 {
+		return errInvalidMaxHold
+	}
+
+	if c.MaxAggregates < 0 {
+		return errInvalidMaxAggregates
+	}
+
+	if c.BodyKeyPrefixLen < 0 {
+		return errInvalidBodyKeyPrefixLen
+	}
+	if c.BodyKeyPrefixLen > 0 && c.selectsDedupFields() && !c.IncludeBody {
+		return errBodyKeyPrefixWithoutBody
+	}
+
 	_, err := time.LoadLocation(c.Timezone)
 	if err != nil {
 		return fmt.Errorf("timezone is invalid: %w", err)
@@ -96,11 +275,36 @@ func (c Config) Validate() error {
 		return err
 	}
 
+	err = c.validateTimestampAttributes()
+	if err != nil {
+		return err
+	}
+
+	err = c.validateDedupFields()
+	if err != nil {
+		return err
+	}
+
+	err = c.validateProcessorAttributes()
+	if err != nil {
+		return err
+	}
+
+	err = c.validateAggregateAttributes()
+	if err != nil {
+		return err
+	}
+
 	err = c.validateMetadataKeys()
 	if err != nil {
 		return err
 	}
 
+	err = c.validateEmissionAttributes()
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -109,8 +313,8 @@ func (c Config) validateExcludeFields() error {
 	knownExcludeFields := make(map[string]struct{})
 
 	for _, field := range c.ExcludeFields {
-		// Special check to make sure the entire body is not excluded
-		if field == bodyField {
+		// Special check to make sure the entire body is not removed
+		if field == bodyField && !c.KeepExcludedFields {
 			return errCannotExcludeBody
 		}
 
@@ -131,6 +335,138 @@ func (c Config) validateExcludeFields() error {
 	return nil
 }
 
+// validateTimestampAttributes validates that the observed timestamp attributes do not overwrite each other or the log count.
+func (c Config) validateTimestampAttributes() error {
+	attrs := []struct{ option, name string }{
+		{"first_observed_timestamp_attribute", c.FirstObservedTimestampAttribute},
+		{"last_observed_timestamp_attribute", c.LastObservedTimestampAttribute},
+	}
+	for i, attr := range attrs {
+		if attr.name == "" {
+			continue
+		}
+		conflicts := attr.name == c.LogCountAttribute
+		for j, other := range attrs {
+			conflicts = conflicts || (i != j && attr.name == other.name)
+		}
+		if conflicts {
+			return fmt.Errorf("%s %q conflicts with another attribute", attr.option, attr.name)
+		}
+	}
+	return nil
+}
+
+// selectsDedupFields reports whether dedup_fields, include_body or include_severity select the fields
+// identifying duplicate logs, instead of the whole log record.
+func (c Config) selectsDedupFields() bool {
+	return len(c.DedupFields) > 0 || c.IncludeBody || c.IncludeSeverity
+}
+
+// validateDedupFields validates that dedup_fields has no duplicates, no reserved entries, and is not combined with
+// other field selections.
+func (c Config) validateDedupFields() error {
+	if !c.selectsDedupFields() {
+		return nil
+	}
+
+	if len(c.IncludeFields) > 0 || len(c.ExcludeFields) > 0 {
+		return errors.New("cannot define dedup_fields, include_body or include_severity with exclude_fields or include_fields")
+	}
+
+	seen := make(map[string]struct{}, len(c.DedupFields))
+	for _, field := range c.DedupFields {
+		// dedup_fields only lists attribute keys, reserved entries would otherwise be silently taken as attributes.
+		switch field {
+		case bodyField:
+			return fmt.Errorf("dedup_fields %q is reserved, set include_body to compare the log body", field)
+		case "severity":
+			return fmt.Errorf("dedup_fields %q is reserved, set include_severity to compare the log severity", field)
+		}
+		if _, ok := seen[field]; ok {
+			return fmt.Errorf("duplicate dedup_fields %s", field)
+		}
+		seen[field] = struct{}{}
+	}
+	return nil
+}
+
+// processorAttributes returns the names of the attributes the processor sets on the logs it emits.
+func (c Config) processorAttributes() []string {
+	attrs := []string{c.LogCountAttribute, firstObservedTSAttr, lastObservedTSAttr}
+	for _, name := range []string{c.FirstObservedTimestampAttribute, c.LastObservedTimestampAttribute} {
+		if name != "" {
+			attrs = append(attrs, name)
+		}
+	}
+	if c.EmissionAttributes.Enabled {
+		attrs = append(attrs, c.EmissionAttributes.WindowStart, c.EmissionAttributes.WindowEnd, c.EmissionAttributes.Reason)
+	}
+	if c.EmitSuppressionSummary {
+		attrs = append(attrs, summaryKeyAttr, summaryCountAttr, summarySuppressedAttr, summaryWindowStartAttr, summaryWindowEndAttr)
+	}
+	return attrs
+}
+
+// validateProcessorAttributes validates that the attributes set by the processor are not selected to identify
+// duplicates, as they differ between the logs emitted for the same duplicates.
+func (c Config) validateProcessorAttributes() error {
+	for _, key := range c.processorAttributes() {
+		field := attributeField + fieldDelimiter + strings.ReplaceAll(key, fieldDelimiter, `\`+fieldDelimiter)
+		if slices.Contains(c.IncludeFields, field) {
+			return fmt.Errorf("include_fields %q is set by the processor and cannot be used to identify duplicates", field)
+		}
+		if slices.Contains(c.DedupFields, key) {
+			return fmt.Errorf("dedup_fields %q is set by the processor and cannot be used to identify duplicates", key)
+		}
+	}
+	return nil
+}
+
+// validateAggregateAttributes validates the aggregation functions and that the aggregated attributes neither
+// overwrite the attributes set on aggregated logs nor are selected to identify duplicates.
+func (c Config) validateAggregateAttributes() error {
+	if _, err := newAttributeAggregations(c.AggregateAttributes); err != nil {
+		return err
+	}
+
+	for key := range c.AggregateAttributes {
+		switch key {
+		case c.LogCountAttribute, c.FirstObservedTimestampAttribute, c.LastObservedTimestampAttribute, firstObservedTSAttr, lastObservedTSAttr:
+			return fmt.Errorf("aggregate_attributes %q conflicts with another attribute", key)
+		}
+
+		field := attributeField + fieldDelimiter + strings.ReplaceAll(key, fieldDelimiter, `\`+fieldDelimiter)
+		if slices.Contains(c.IncludeFields, field) || slices.Contains(c.ExcludeFields, field) || slices.Contains(c.DedupFields, key) {
+			return fmt.Errorf("aggregate_attributes %q cannot be used to identify duplicates", key)
+		}
+	}
+	return nil
+}
+
+// validateEmissionAttributes validates that the emission attributes, when enabled, are named and do not overwrite
+// each other or the other attributes set on aggregated logs.
+func (c Config) validateEmissionAttributes() error {
+	if !c.EmissionAttributes.Enabled {
+		return nil
+	}
+
+	reserved := []string{c.LogCountAttribute, c.FirstObservedTimestampAttribute, c.LastObservedTimestampAttribute, firstObservedTSAttr, lastObservedTSAttr}
+	for _, attr := range []struct{ option, name string }{
+		{"window_start", c.EmissionAttributes.WindowStart},
+		{"window_end", c.EmissionAttributes.WindowEnd},
+		{"reason", c.EmissionAttributes.Reason},
+	} {
+		if attr.name == "" {
+			return fmt.Errorf("emission_attributes::%s must be set", attr.option)
+		}
+		if _, ok := c.AggregateAttributes[attr.name]; ok || slices.Contains(reserved, attr.name) {
+			return fmt.Errorf("emission_attributes::%s %q conflicts with another attribute", attr.option, attr.name)
+		}
+		reserved = append(reserved, attr.name)
+	}
+	return nil
+}
+
 // validateMetadataKeys validates that metadata_keys has no duplicates (case-insensitive).
 func (c Config) validateMetadataKeys() error {
 	seen := make(map[string]struct{}, len(c.MetadataKeys))
@@ -154,6 +490,11 @@ func (c Config) validateIncludeFields() error {
 			return errCannotIncludeBody
 		}
 
+		// Timestamps differ between duplicates, so they never identify them
+		if field == timestampField || field == observedTimestampField {
+			return fmt.Errorf("include_fields cannot include %s, timestamps never identify duplicates", field)
+		}
+
 		// Split and ensure the field starts with `body` or `attributes`
 		parts := strings.Split(field, fieldDelimiter)
 		if parts[0] != bodyField && parts[0] != attributeField {
@@ -6,6 +6,7 @@ package logdedupprocessor
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -16,6 +17,11 @@ func TestCreateDefaultProcessorConfig(t *testing.T) {
 	require.Equal(t, defaultLogCountAttribute, cfg.LogCountAttribute)
 	require.Equal(t, defaultTimezone, cfg.Timezone)
 	require.Equal(t, []string{}, cfg.ExcludeFields)
+	require.Equal(t, dedupScopeScope, cfg.Scope)
+	require.False(t, cfg.EmissionAttributes.Enabled)
+	require.Equal(t, defaultWindowStartAttribute, cfg.EmissionAttributes.WindowStart)
+	require.Equal(t, defaultWindowEndAttribute, cfg.EmissionAttributes.WindowEnd)
+	require.Equal(t, defaultEmissionReasonAttribute, cfg.EmissionAttributes.Reason)
 }
 
 func TestValidateConfig(t *testing.T) {
@@ -54,6 +60,46 @@ func TestValidateConfig(t *testing.T) {
 			},
 			expectedErr: errors.New("timezone is invalid"),
 		},
+		{
+			desc: "invalid scope",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				Scope:             "service",
+			},
+			expectedErr: errors.New(`scope must be one of resource, scope or global, got "service"`),
+		},
+		{
+			desc: "invalid hash_algorithm",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				HashAlgorithm:     "md5",
+			},
+			expectedErr: errors.New(`hash_algorithm must be one of fnv, xxhash or sha256, got "md5"`),
+		},
+		{
+			desc: "sha256 hash_algorithm",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				HashAlgorithm:     hashAlgorithmSHA256,
+			},
+			expectedErr: nil,
+		},
+		{
+			desc: "resource scope",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				Scope:             dedupScopeResource,
+			},
+			expectedErr: nil,
+		},
 		{
 			desc: "invalid exclude entire body",
 			cfg: &Config{
@@ -64,6 +110,17 @@ func TestValidateConfig(t *testing.T) {
 			},
 			expectedErr: errCannotExcludeBody,
 		},
+		{
+			desc: "valid exclude entire body kept on the logs",
+			cfg: &Config{
+				LogCountAttribute:  defaultLogCountAttribute,
+				Interval:           defaultInterval,
+				Timezone:           defaultTimezone,
+				ExcludeFields:      []string{bodyField},
+				KeepExcludedFields: true,
+			},
+			expectedErr: nil,
+		},
 		{
 			desc: "invalid exclude field body",
 			cfg: &Config{
@@ -136,6 +193,36 @@ func TestValidateConfig(t *testing.T) {
 			},
 			expectedErr: nil,
 		},
+		{
+			desc: "invalid interval_by_severity level",
+			cfg: &Config{
+				LogCountAttribute:  defaultLogCountAttribute,
+				Interval:           defaultInterval,
+				Timezone:           defaultTimezone,
+				IntervalBySeverity: map[string]time.Duration{"critical": time.Second},
+			},
+			expectedErr: errors.New(`interval_by_severity "critical": unknown severity level "critical"`),
+		},
+		{
+			desc: "invalid interval_by_severity interval",
+			cfg: &Config{
+				LogCountAttribute:  defaultLogCountAttribute,
+				Interval:           defaultInterval,
+				Timezone:           defaultTimezone,
+				IntervalBySeverity: map[string]time.Duration{"error": 0},
+			},
+			expectedErr: errInvalidInterval,
+		},
+		{
+			desc: "valid config interval_by_severity",
+			cfg: &Config{
+				LogCountAttribute:  defaultLogCountAttribute,
+				Interval:           defaultInterval,
+				Timezone:           defaultTimezone,
+				IntervalBySeverity: map[string]time.Duration{"error": time.Second, "warn-fatal": time.Minute},
+			},
+			expectedErr: nil,
+		},
 		{
 			desc: "invalid config defines both exclude_fields and include_fields",
 			cfg: &Config{
@@ -148,6 +235,371 @@ func TestValidateConfig(t *testing.T) {
 			},
 			expectedErr: errors.New("cannot define both exclude_fields and include_fields"),
 		},
+		{
+			desc: "valid config observed timestamp attributes",
+			cfg: &Config{
+				LogCountAttribute:               defaultLogCountAttribute,
+				Interval:                        defaultInterval,
+				Timezone:                        defaultTimezone,
+				FirstObservedTimestampAttribute: "first_seen",
+				LastObservedTimestampAttribute:  "last_seen",
+			},
+			expectedErr: nil,
+		},
+		{
+			desc: "invalid config same observed timestamp attributes",
+			cfg: &Config{
+				LogCountAttribute:               defaultLogCountAttribute,
+				Interval:                        defaultInterval,
+				Timezone:                        defaultTimezone,
+				FirstObservedTimestampAttribute: "seen",
+				LastObservedTimestampAttribute:  "seen",
+			},
+			expectedErr: errors.New(`first_observed_timestamp_attribute "seen" conflicts with another attribute`),
+		},
+		{
+			desc: "invalid config observed timestamp attribute overwrites log count",
+			cfg: &Config{
+				LogCountAttribute:              defaultLogCountAttribute,
+				Interval:                       defaultInterval,
+				Timezone:                       defaultTimezone,
+				LastObservedTimestampAttribute: defaultLogCountAttribute,
+			},
+			expectedErr: errors.New(`last_observed_timestamp_attribute "log_count" conflicts with another attribute`),
+		},
+		{
+			desc: "valid config record observed timestamp source",
+			cfg: &Config{
+				LogCountAttribute:               defaultLogCountAttribute,
+				Interval:                        defaultInterval,
+				Timezone:                        defaultTimezone,
+				FirstObservedTimestampAttribute: "first_seen",
+				LastObservedTimestampAttribute:  "last_seen",
+				ObservedTimestampSource:         timestampSourceRecord,
+			},
+			expectedErr: nil,
+		},
+		{
+			desc: "invalid config observed timestamp source",
+			cfg: &Config{
+				LogCountAttribute:       defaultLogCountAttribute,
+				Interval:                defaultInterval,
+				Timezone:                defaultTimezone,
+				ObservedTimestampSource: "source",
+			},
+			expectedErr: errors.New(`observed_timestamp_source must be one of processor or record, got "source"`),
+		},
+		{
+			desc: "valid config dedup_fields",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				DedupFields:       []string{"service.name", "code"},
+				IncludeBody:       true,
+			},
+			expectedErr: nil,
+		},
+		{
+			desc: "invalid config include_fields with the log count attribute",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				IncludeFields:     []string{"attributes.log_count"},
+			},
+			expectedErr: errors.New(`include_fields "attributes.log_count" is set by the processor and cannot be used to identify duplicates`),
+		},
+		{
+			desc: "invalid config include_fields with a suppression summary attribute",
+			cfg: &Config{
+				LogCountAttribute:      defaultLogCountAttribute,
+				Interval:               defaultInterval,
+				Timezone:               defaultTimezone,
+				EmitSuppressionSummary: true,
+				IncludeFields:          []string{`attributes.log_dedup\.count`},
+			},
+			expectedErr: errors.New(`include_fields "attributes.log_dedup\\.count" is set by the processor and cannot be used to identify duplicates`),
+		},
+		{
+			desc: "invalid config dedup_fields with an observed timestamp attribute",
+			cfg: &Config{
+				LogCountAttribute:              defaultLogCountAttribute,
+				Interval:                       defaultInterval,
+				Timezone:                       defaultTimezone,
+				LastObservedTimestampAttribute: "last_seen",
+				DedupFields:                    []string{"service.name", "last_seen"},
+			},
+			expectedErr: errors.New(`dedup_fields "last_seen" is set by the processor and cannot be used to identify duplicates`),
+		},
+		{
+			desc: "invalid config include_fields with the observed timestamp",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				IncludeFields:     []string{"observed_timestamp"},
+			},
+			expectedErr: errors.New("include_fields cannot include observed_timestamp, timestamps never identify duplicates"),
+		},
+		{
+			desc: "invalid config duplicate dedup_fields",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				DedupFields:       []string{"code", "code"},
+			},
+			expectedErr: errors.New("duplicate dedup_fields code"),
+		},
+		{
+			desc: "invalid config dedup_fields with the body",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				DedupFields:       []string{"code", "body"},
+			},
+			expectedErr: errors.New(`dedup_fields "body" is reserved, set include_body to compare the log body`),
+		},
+		{
+			desc: "invalid config dedup_fields with the severity",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				DedupFields:       []string{"severity"},
+			},
+			expectedErr: errors.New(`dedup_fields "severity" is reserved, set include_severity to compare the log severity`),
+		},
+		{
+			desc: "invalid config defines both dedup_fields and include_fields",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				DedupFields:       []string{"code"},
+				IncludeFields:     []string{"attributes.code"},
+			},
+			expectedErr: errors.New("cannot define dedup_fields, include_body or include_severity with exclude_fields or include_fields"),
+		},
+		{
+			desc: "valid config include_body without dedup_fields",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				IncludeBody:       true,
+				IncludeSeverity:   true,
+			},
+			expectedErr: nil,
+		},
+		{
+			desc: "invalid config defines both include_severity and exclude_fields",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				IncludeSeverity:   true,
+				ExcludeFields:     []string{"attributes.request_id"},
+			},
+			expectedErr: errors.New("cannot define dedup_fields, include_body or include_severity with exclude_fields or include_fields"),
+		},
+		{
+			desc: "negative snapshot_threshold",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				SnapshotThreshold: -1,
+			},
+			expectedErr: errInvalidSnapshotThreshold,
+		},
+		{
+			desc: "negative emit_count_threshold",
+			cfg: &Config{
+				LogCountAttribute:  defaultLogCountAttribute,
+				Interval:           defaultInterval,
+				Timezone:           defaultTimezone,
+				EmitCountThreshold: -1,
+			},
+			expectedErr: errInvalidCountThreshold,
+		},
+		{
+			desc: "negative max_hold",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				MaxHold:           -time.Second,
+			},
+			expectedErr: errInvalidMaxHold,
+		},
+		{
+			desc: "negative max_aggregates",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				MaxAggregates:     -1,
+			},
+			expectedErr: errInvalidMaxAggregates,
+		},
+		{
+			desc: "negative body_key_prefix_len",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				BodyKeyPrefixLen:  -1,
+			},
+			expectedErr: errInvalidBodyKeyPrefixLen,
+		},
+		{
+			desc: "body_key_prefix_len with dedup_fields without include_body",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				DedupFields:       []string{"code"},
+				BodyKeyPrefixLen:  40,
+			},
+			expectedErr: errBodyKeyPrefixWithoutBody,
+		},
+		{
+			desc: "valid config body_key_prefix_len",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				DedupFields:       []string{"code"},
+				IncludeBody:       true,
+				BodyKeyPrefixLen:  40,
+			},
+			expectedErr: nil,
+		},
+		{
+			desc: "valid config aggregate_attributes",
+			cfg: &Config{
+				LogCountAttribute:   defaultLogCountAttribute,
+				Interval:            defaultInterval,
+				Timezone:            defaultTimezone,
+				DedupFields:         []string{"code"},
+				AggregateAttributes: map[string]string{"bytes_sent": "sum", "latency": "avg"},
+			},
+			expectedErr: nil,
+		},
+		{
+			desc: "invalid config aggregate_attributes function",
+			cfg: &Config{
+				LogCountAttribute:   defaultLogCountAttribute,
+				Interval:            defaultInterval,
+				Timezone:            defaultTimezone,
+				AggregateAttributes: map[string]string{"bytes_sent": "median"},
+			},
+			expectedErr: errors.New(`aggregate_attributes "bytes_sent": unknown function "median"`),
+		},
+		{
+			desc: "invalid config aggregate_attributes overwrites log count",
+			cfg: &Config{
+				LogCountAttribute:   defaultLogCountAttribute,
+				Interval:            defaultInterval,
+				Timezone:            defaultTimezone,
+				AggregateAttributes: map[string]string{defaultLogCountAttribute: "sum"},
+			},
+			expectedErr: errors.New(`aggregate_attributes "log_count" conflicts with another attribute`),
+		},
+		{
+			desc: "invalid config aggregate_attributes in include_fields",
+			cfg: &Config{
+				LogCountAttribute:   defaultLogCountAttribute,
+				Interval:            defaultInterval,
+				Timezone:            defaultTimezone,
+				IncludeFields:       []string{`attributes.http\.bytes`},
+				AggregateAttributes: map[string]string{"http.bytes": "max"},
+			},
+			expectedErr: errors.New(`aggregate_attributes "http.bytes" cannot be used to identify duplicates`),
+		},
+		{
+			desc: "invalid config aggregate_attributes in dedup_fields",
+			cfg: &Config{
+				LogCountAttribute:   defaultLogCountAttribute,
+				Interval:            defaultInterval,
+				Timezone:            defaultTimezone,
+				DedupFields:         []string{"bytes_sent"},
+				AggregateAttributes: map[string]string{"bytes_sent": "sum"},
+			},
+			expectedErr: errors.New(`aggregate_attributes "bytes_sent" cannot be used to identify duplicates`),
+		},
+		{
+			desc: "valid config emission_attributes",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				EmissionAttributes: EmissionAttributesConfig{
+					Enabled:     true,
+					WindowStart: defaultWindowStartAttribute,
+					WindowEnd:   defaultWindowEndAttribute,
+					Reason:      defaultEmissionReasonAttribute,
+				},
+			},
+			expectedErr: nil,
+		},
+		{
+			desc: "disabled emission_attributes are not validated",
+			cfg: &Config{
+				LogCountAttribute:  defaultLogCountAttribute,
+				Interval:           defaultInterval,
+				Timezone:           defaultTimezone,
+				EmissionAttributes: EmissionAttributesConfig{},
+			},
+			expectedErr: nil,
+		},
+		{
+			desc: "invalid config emission_attributes without name",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				EmissionAttributes: EmissionAttributesConfig{
+					Enabled:     true,
+					WindowStart: defaultWindowStartAttribute,
+					WindowEnd:   defaultWindowEndAttribute,
+				},
+			},
+			expectedErr: errors.New("emission_attributes::reason must be set"),
+		},
+		{
+			desc: "invalid config emission_attributes with same names",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				EmissionAttributes: EmissionAttributesConfig{
+					Enabled:     true,
+					WindowStart: "window",
+					WindowEnd:   "window",
+					Reason:      defaultEmissionReasonAttribute,
+				},
+			},
+			expectedErr: errors.New(`emission_attributes::window_end "window" conflicts with another attribute`),
+		},
+		{
+			desc: "invalid config emission_attributes overwrites log count",
+			cfg: &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          defaultInterval,
+				Timezone:          defaultTimezone,
+				EmissionAttributes: EmissionAttributesConfig{
+					Enabled:     true,
+					WindowStart: defaultLogCountAttribute,
+					WindowEnd:   defaultWindowEndAttribute,
+					Reason:      defaultEmissionReasonAttribute,
+				},
+			},
+			expectedErr: errors.New(`emission_attributes::window_start "log_count" conflicts with another attribute`),
+		},
 	}
 
 	for _, tc := range testCases {
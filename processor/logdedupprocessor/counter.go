@@ -5,10 +5,14 @@ package logdedupprocessor // import "github.com/open-telemetry/opentelemetry-col
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"strconv"
 	"time"
 
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/pdatautil"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/processor/logdedupprocessor/internal/metadata"
@@ -20,6 +24,26 @@ const (
 	lastObservedTSAttr  = "last_observed_timestamp"
 )
 
+// Reasons of the emission of aggregated logs
+const (
+	// emissionReasonInterval is set on logs emitted once their interval elapsed.
+	emissionReasonInterval = "interval"
+
+	// emissionReasonCountThreshold is set on logs emitted once they counted the count threshold, before their
+	// interval elapsed.
+	emissionReasonCountThreshold = "count_threshold"
+
+	// emissionReasonMaxHold is set on logs emitted once held for the max hold, before their interval elapsed.
+	emissionReasonMaxHold = "max_hold"
+
+	// emissionReasonShutdown is set on logs emitted when the processor shuts down, before their interval elapsed.
+	emissionReasonShutdown = "shutdown"
+
+	// emissionReasonEviction is set on logs emitted to make room for another one over the max aggregates, before
+	// their interval elapsed.
+	emissionReasonEviction = "eviction"
+)
+
 // timeNow can be reassigned for testing
 var timeNow = time.Now
 
@@ -27,139 +51,444 @@ var timeNow = time.Now
 type logAggregator struct {
 	resources         map[uint64]*resourceAggregator
 	logCountAttribute string
+	// countAsString sets the log count attribute as a string instead of an integer.
+	countAsString    bool
+	timezone         *time.Location
+	telemetryBuilder *metadata.TelemetryBuilder
+	logger           *zap.Logger
+	keyFields        logKeyFields
+	interval         time.Duration
+	// severityIntervals is nil when all logs are aggregated over interval and exported together.
+	// Otherwise, each log counter is exported once its own interval has elapsed.
+	severityIntervals severityIntervals
+	// emitSummary appends a suppression summary log record after each aggregated log that suppressed duplicates.
+	emitSummary bool
+	// timestampAttributes are the additional attributes holding the first and last observed timestamps.
+	timestampAttributes timestampAttributes
+	// aggregations are the attributes whose values are aggregated across duplicates instead of identifying them.
+	aggregations attributeAggregations
+	// dedupScope defines the logs duplicates are identified among, see Config.Scope.
+	dedupScope string
+	// emissionAttributes are the attributes describing the window and reason of the emission of aggregated logs.
+	emissionAttributes emissionAttributes
+	// windowStart is the start of the current aggregation window, i.e. the time of the last export of all log counters.
+	windowStart time.Time
+	// records keeps the log records of the log counters, shared with the scope aggregators.
+	records *recordStore
+	// countThreshold is the count at which a log counter is exported early, 0 disables it.
+	countThreshold int64
+	// maxHold is the time after its first observed log at which a log counter is exported early, 0 disables it.
+	maxHold time.Duration
+	// maxAggregates is the number of log counters above which the oldest one is evicted, 0 disables it.
+	maxAggregates int
+	// thresholdReached is set once a log counter reached countThreshold, until exported by TakeEarly.
+	thresholdReached bool
+	// evicted holds the log counters evicted over maxAggregates, until exported by TakeEarly.
+	evicted plog.Logs
+}
+
+// timestampAttributes are the names of the attributes set to the first and last observed timestamps of
+// aggregated logs, as nanoseconds since the Unix epoch. Empty names are not set.
+type timestampAttributes struct {
+	firstObserved string
+	lastObserved  string
+	// fromRecords sets the earliest and latest timestamps of the duplicates themselves, see recordTimestamp,
+	// instead of the times the processor observed the first and last duplicates.
+	fromRecords bool
+}
+
+// emissionAttributes are the names of the attributes set to the window start and end, as nanoseconds since the
+// Unix epoch, and to the emission reason of aggregated logs. None are set when disabled.
+type emissionAttributes struct {
+	enabled     bool
+	windowStart string
+	windowEnd   string
+	reason      string
+}
+
+// logAggregatorOptions are the settings of a logAggregator, resolved from the Config of the processor.
+type logAggregatorOptions struct {
+	logCountAttribute string
+	countAsString     bool
 	timezone          *time.Location
-	telemetryBuilder  *metadata.TelemetryBuilder
-	dedupFields       []string
+	keyFields         logKeyFields
+	interval          time.Duration
+	severityIntervals severityIntervals
+	emitSummary       bool
+	timestampAttrs    timestampAttributes
+	aggregations      attributeAggregations
+	dedupScope        string
+	emissionAttrs     emissionAttributes
+	snapshotThreshold int
+	countThreshold    int64
+	maxHold           time.Duration
+	maxAggregates     int
 }
 
 // newLogAggregator creates a new LogCounter.
-func newLogAggregator(logCountAttribute string, timezone *time.Location, telemetryBuilder *metadata.TelemetryBuilder, dedupFields []string) *logAggregator {
+func newLogAggregator(opts logAggregatorOptions, telemetryBuilder *metadata.TelemetryBuilder, logger *zap.Logger) *logAggregator {
 	return &logAggregator{
-		resources:         make(map[uint64]*resourceAggregator),
-		logCountAttribute: logCountAttribute,
-		timezone:          timezone,
-		telemetryBuilder:  telemetryBuilder,
-		dedupFields:       dedupFields,
+		resources:           make(map[uint64]*resourceAggregator),
+		logCountAttribute:   opts.logCountAttribute,
+		countAsString:       opts.countAsString,
+		timezone:            opts.timezone,
+		telemetryBuilder:    telemetryBuilder,
+		logger:              logger,
+		keyFields:           opts.keyFields,
+		interval:            opts.interval,
+		severityIntervals:   opts.severityIntervals,
+		emitSummary:         opts.emitSummary,
+		timestampAttributes: opts.timestampAttrs,
+		aggregations:        opts.aggregations,
+		dedupScope:          opts.dedupScope,
+		emissionAttributes:  opts.emissionAttrs,
+		windowStart:         timeNow().UTC(),
+		records:             &recordStore{threshold: opts.snapshotThreshold},
+		countThreshold:      opts.countThreshold,
+		maxHold:             opts.maxHold,
+		maxAggregates:       opts.maxAggregates,
+		evicted:             plog.NewLogs(),
 	}
 }
 
 // Export exports the counter as a Logs
 func (l *logAggregator) Export(ctx context.Context) plog.Logs {
+	return l.export(ctx, timeNow().UTC(), emissionReasonInterval, nil)
+}
+
+// ExportExpired exports the log counters whose deadline is not after now as a Logs, and removes them from the counter.
+func (l *logAggregator) ExportExpired(ctx context.Context, now time.Time) plog.Logs {
+	return l.export(ctx, now, emissionReasonInterval, func(lc *logCounter) bool {
+		return !lc.deadline.After(now)
+	})
+}
+
+// Take exports the log counters due for export and removes them from the counter.
+// All of them are due when force is set, on shutdown, or when no interval is configured by severity.
+func (l *logAggregator) Take(ctx context.Context, force bool) plog.Logs {
+	now := timeNow().UTC()
+	if force || l.severityIntervals == nil {
+		reason := emissionReasonInterval
+		if force {
+			reason = emissionReasonShutdown
+		}
+		logs := l.export(ctx, now, reason, nil)
+		l.Reset()
+		l.windowStart = now
+		return logs
+	}
+	return l.ExportExpired(ctx, now)
+}
+
+// TakeEarly exports the log counters evicted over maxAggregates and the log counters that reached countThreshold,
+// before their interval elapsed, and removes them from the counter.
+func (l *logAggregator) TakeEarly(ctx context.Context) plog.Logs {
+	logs := l.evicted
+	l.evicted = plog.NewLogs()
+	if l.thresholdReached {
+		l.thresholdReached = false
+		reached := l.export(ctx, timeNow().UTC(), emissionReasonCountThreshold, func(lc *logCounter) bool {
+			return lc.count >= l.countThreshold
+		})
+		reached.ResourceLogs().MoveAndAppendTo(logs.ResourceLogs())
+	}
+	return logs
+}
+
+// TakeHeld exports the log counters whose first log was observed at least maxHold ago, before their interval
+// elapsed, and removes them from the counter.
+func (l *logAggregator) TakeHeld(ctx context.Context) plog.Logs {
+	if l.maxHold <= 0 {
+		return plog.NewLogs()
+	}
+	now := timeNow().UTC()
+	return l.export(ctx, now, emissionReasonMaxHold, func(lc *logCounter) bool {
+		return !lc.firstObservedTimestamp.Add(l.maxHold).After(now)
+	})
+}
+
+// export exports the log counters as a Logs, emitted at now for reason. If expired is not nil, only the log counters
+// for which it returns true are exported and they are removed from the counter.
+func (l *logAggregator) export(ctx context.Context, now time.Time, reason string, expired func(*logCounter) bool) plog.Logs {
 	logs := plog.NewLogs()
+	var emitted, dropped, failed int64
+	var lastErr error
+
+	for resourceKey, resourceAggregator := range l.resources {
+		var rl plog.ResourceLogs
+		hasResourceLogs := false
+		for scopeKey, scopeAggregator := range resourceAggregator.scopeCounters {
+			var sl plog.ScopeLogs
+			hasScopeLogs := false
+			for logKey, logAggregator := range scopeAggregator.logCounters {
+				if expired != nil {
+					if !expired(logAggregator) {
+						continue
+					}
+					delete(scopeAggregator.logCounters, logKey)
+					l.records.release(1)
+				}
 
-	for _, resourceAggregator := range l.resources {
-		rl := logs.ResourceLogs().AppendEmpty()
-		resourceAggregator.resource.CopyTo(rl.Resource())
+				// Aggregates whose log record cannot be restored are skipped rather than emitted blank.
+				record, err := logAggregator.record()
+				if err != nil {
+					failed++
+					lastErr = err
+					continue
+				}
 
-		for _, scopeAggregator := range resourceAggregator.scopeCounters {
-			sl := rl.ScopeLogs().AppendEmpty()
-			scopeAggregator.scope.CopyTo(sl.Scope())
+				if !hasScopeLogs {
+					if !hasResourceLogs {
+						rl = logs.ResourceLogs().AppendEmpty()
+						resourceAggregator.resource.CopyTo(rl.Resource())
+						hasResourceLogs = true
+					}
+					sl = rl.ScopeLogs().AppendEmpty()
+					scopeAggregator.scope.CopyTo(sl.Scope())
+					hasScopeLogs = true
+				}
 
-			for _, logAggregator := range scopeAggregator.logCounters {
 				// Record aggregated logs records
 				l.telemetryBuilder.DedupProcessorAggregatedLogs.Record(ctx, logAggregator.count)
+				emitted++
+				dropped += logAggregator.count - 1
 
 				lr := sl.LogRecords().AppendEmpty()
-				logAggregator.logRecord.CopyTo(lr)
+				record.CopyTo(lr)
 
-				// Set log record timestamps
-				lr.SetTimestamp(pcommon.NewTimestampFromTime(timeNow()))
+				// Set log record timestamps within the window of the aggregated logs, so that it is ordered
+				// among the logs it aggregates rather than at export time.
+				lr.SetTimestamp(pcommon.NewTimestampFromTime(logAggregator.lastObservedTimestamp))
 				lr.SetObservedTimestamp(pcommon.NewTimestampFromTime(logAggregator.firstObservedTimestamp))
 
 				// Add attributes for log count and first/last observed timestamps
 				lr.Attributes().EnsureCapacity(lr.Attributes().Len() + 3)
-				lr.Attributes().PutInt(l.logCountAttribute, logAggregator.count)
+				if l.countAsString {
+					lr.Attributes().PutStr(l.logCountAttribute, strconv.FormatInt(logAggregator.count, 10))
+				} else {
+					lr.Attributes().PutInt(l.logCountAttribute, logAggregator.count)
+				}
 				firstTimestampStr := logAggregator.firstObservedTimestamp.In(l.timezone).Format(time.RFC3339)
 				lr.Attributes().PutStr(firstObservedTSAttr, firstTimestampStr)
 				lastTimestampStr := logAggregator.lastObservedTimestamp.In(l.timezone).Format(time.RFC3339)
 				lr.Attributes().PutStr(lastObservedTSAttr, lastTimestampStr)
+				first, last := logAggregator.firstObservedTimestamp.UnixNano(), logAggregator.lastObservedTimestamp.UnixNano()
+				if l.timestampAttributes.fromRecords {
+					first, last = int64(logAggregator.firstRecordTimestamp), int64(logAggregator.lastRecordTimestamp)
+				}
+				// Duplicates without timestamps leave the earliest and latest ones unknown.
+				if name := l.timestampAttributes.firstObserved; name != "" && first != 0 {
+					lr.Attributes().PutInt(name, first)
+				}
+				if name := l.timestampAttributes.lastObserved; name != "" && last != 0 {
+					lr.Attributes().PutInt(name, last)
+				}
+				for i := range logAggregator.aggregates {
+					logAggregator.aggregates[i].put(lr.Attributes())
+				}
+				if l.emissionAttributes.enabled {
+					lr.Attributes().PutInt(l.emissionAttributes.windowStart, l.windowStartOf(logAggregator).UnixNano())
+					lr.Attributes().PutInt(l.emissionAttributes.windowEnd, now.UnixNano())
+					lr.Attributes().PutStr(l.emissionAttributes.reason, reason)
+				}
+
+				if l.emitSummary && logAggregator.count > 1 {
+					l.appendSuppressionSummary(sl.LogRecords(), logKey, logAggregator)
+				}
 			}
+			if expired != nil && len(scopeAggregator.logCounters) == 0 {
+				delete(resourceAggregator.scopeCounters, scopeKey)
+			}
+		}
+		if expired != nil && len(resourceAggregator.scopeCounters) == 0 {
+			delete(l.resources, resourceKey)
 		}
 	}
 
+	if failed > 0 {
+		l.logger.Error("failed to restore the log record of aggregated logs, skipping them",
+			zap.Int64("aggregates", failed),
+			zap.Error(lastErr),
+		)
+	}
+	if emitted > 0 {
+		l.telemetryBuilder.LogdedupAggregatesEmitted.Add(ctx, emitted)
+		l.telemetryBuilder.LogdedupRecordsDropped.Add(ctx, dropped)
+	}
+
 	return logs
 }
 
+// windowStartOf returns the start of the aggregation window of the log counter. Log counters exported on their own
+// interval aggregate logs from the first one observed, others from the last export of all log counters.
+func (l *logAggregator) windowStartOf(lc *logCounter) time.Time {
+	if !lc.deadline.IsZero() {
+		return lc.firstObservedTimestamp
+	}
+	return l.windowStart
+}
+
 // Add adds the logRecord to the resource aggregator that is identified by the resource attributes
 func (l *logAggregator) Add(resource pcommon.Resource, scope pcommon.InstrumentationScope, logRecord plog.LogRecord) {
-	key := getResourceKey(resource)
+	// Logs of all resources share the same resource aggregator when deduplicated globally.
+	var key uint64
+	if l.dedupScope != dedupScopeGlobal {
+		key = getResourceKey(l.keyFields.hashAlgorithm, resource)
+	}
 	resourceAggregator, ok := l.resources[key]
 	if !ok {
-		resourceAggregator = newResourceAggregator(resource, l.dedupFields)
+		mergeScopes := l.dedupScope == dedupScopeResource || l.dedupScope == dedupScopeGlobal
+		resourceAggregator = newResourceAggregator(resource, l.keyFields, l.aggregations, mergeScopes, l.records)
 		l.resources[key] = resourceAggregator
 	}
-	resourceAggregator.Add(scope, logRecord)
+
+	var interval time.Duration
+	if l.severityIntervals != nil {
+		interval = l.severityIntervals.intervalFor(logRecord.SeverityNumber(), l.interval)
+	}
+	lc := resourceAggregator.Add(scope, logRecord, interval)
+
+	if l.countThreshold > 0 && lc.count >= l.countThreshold {
+		l.thresholdReached = true
+	}
+	if l.maxAggregates > 0 && l.records.counters > l.maxAggregates {
+		l.evict(lc)
+	}
+}
+
+// evict exports the log counter whose first log was observed the longest ago, other than added, to the evicted
+// logs and removes it from the counter.
+func (l *logAggregator) evict(added *logCounter) {
+	var oldest *logCounter
+	for _, resourceAggregator := range l.resources {
+		for _, scopeAggregator := range resourceAggregator.scopeCounters {
+			for _, lc := range scopeAggregator.logCounters {
+				if lc != added && (oldest == nil || lc.firstObservedTimestamp.Before(oldest.firstObservedTimestamp)) {
+					oldest = lc
+				}
+			}
+		}
+	}
+	if oldest == nil {
+		return
+	}
+	evicted := l.export(context.Background(), timeNow().UTC(), emissionReasonEviction, func(lc *logCounter) bool {
+		return lc == oldest
+	})
+	evicted.ResourceLogs().MoveAndAppendTo(l.evicted.ResourceLogs())
 }
 
 // Reset resets the counter.
 func (l *logAggregator) Reset() {
 	l.resources = make(map[uint64]*resourceAggregator)
+	l.records.reset()
 }
 
 // resourceAggregator dimensions the counter by resource.
 type resourceAggregator struct {
 	resource      pcommon.Resource
 	scopeCounters map[uint64]*scopeAggregator
-	dedupFields   []string
+	keyFields     logKeyFields
+	aggregations  attributeAggregations
+	// mergeScopes aggregates the logs of all scopes together, under the scope of the first log.
+	mergeScopes bool
+	records     *recordStore
 }
 
 // newResourceAggregator creates a new ResourceCounter.
-func newResourceAggregator(resource pcommon.Resource, dedupFields []string) *resourceAggregator {
+func newResourceAggregator(resource pcommon.Resource, keyFields logKeyFields, aggregations attributeAggregations, mergeScopes bool, records *recordStore) *resourceAggregator {
 	cloneResource := pcommon.NewResource()
 	resource.CopyTo(cloneResource)
 	return &resourceAggregator{
 		resource:      cloneResource,
 		scopeCounters: make(map[uint64]*scopeAggregator),
-		dedupFields:   dedupFields,
+		keyFields:     keyFields,
+		aggregations:  aggregations,
+		mergeScopes:   mergeScopes,
+		records:       records,
 	}
 }
 
-// Add increments the counter that the logRecord matches.
-func (r *resourceAggregator) Add(scope pcommon.InstrumentationScope, logRecord plog.LogRecord) {
-	key := getScopeKey(scope)
+// Add increments the counter that the logRecord matches, and returns it.
+// A non-zero interval bounds the time the counter aggregates logs before being exported.
+func (r *resourceAggregator) Add(scope pcommon.InstrumentationScope, logRecord plog.LogRecord, interval time.Duration) *logCounter {
+	var key uint64
+	if !r.mergeScopes {
+		key = getScopeKey(r.keyFields.hashAlgorithm, scope)
+	}
 	scopeAggregator, ok := r.scopeCounters[key]
 	if !ok {
-		scopeAggregator = newScopeAggregator(scope, r.dedupFields)
+		scopeAggregator = newScopeAggregator(scope, r.keyFields, r.aggregations, r.records)
 		r.scopeCounters[key] = scopeAggregator
 	}
-	scopeAggregator.Add(logRecord)
+	return scopeAggregator.Add(logRecord, interval)
 }
 
 // scopeAggregator dimensions the counter by scope.
 type scopeAggregator struct {
-	scope       pcommon.InstrumentationScope
-	logCounters map[uint64]*logCounter
-	dedupFields []string
+	scope        pcommon.InstrumentationScope
+	logCounters  map[uint64]*logCounter
+	keyFields    logKeyFields
+	aggregations attributeAggregations
+	records      *recordStore
 }
 
 // newScopeAggregator creates a new ScopeCounter.
-func newScopeAggregator(scope pcommon.InstrumentationScope, dedupFields []string) *scopeAggregator {
+func newScopeAggregator(scope pcommon.InstrumentationScope, keyFields logKeyFields, aggregations attributeAggregations, records *recordStore) *scopeAggregator {
 	cloneScope := pcommon.NewInstrumentationScope()
 	scope.CopyTo(cloneScope)
 	return &scopeAggregator{
-		scope:       cloneScope,
-		logCounters: make(map[uint64]*logCounter),
-		dedupFields: dedupFields,
+		scope:        cloneScope,
+		logCounters:  make(map[uint64]*logCounter),
+		keyFields:    keyFields,
+		aggregations: aggregations,
+		records:      records,
 	}
 }
 
-// Add increments the counter that the logRecord matches.
-func (s *scopeAggregator) Add(logRecord plog.LogRecord) {
-	key := getLogKey(logRecord, s.dedupFields)
+// Add increments the counter that the logRecord matches, and returns it.
+// A non-zero interval bounds the time the counter aggregates logs before being exported.
+func (s *scopeAggregator) Add(logRecord plog.LogRecord, interval time.Duration) *logCounter {
+	var values []pcommon.Value
+	if len(s.aggregations) > 0 {
+		values = s.aggregations.take(logRecord)
+	}
+	// The log record is moved to the counter of its first occurrence, so its timestamp is read beforehand.
+	timestamp := recordTimestamp(logRecord)
+	key := s.keyFields.logKey(logRecord)
 	lc, ok := s.logCounters[key]
 	if !ok {
-		lc = newLogCounter(logRecord)
+		lc = s.records.newLogCounter(logRecord)
+		lc.aggregates = s.aggregations.newAggregates()
 		s.logCounters[key] = lc
 	}
 	lc.Increment()
+	lc.observe(timestamp)
+	for i, value := range values {
+		lc.aggregates[i].add(value)
+	}
+	lc.limitInterval(interval)
+	return lc
 }
 
 // logCounter is a counter for a log record.
 type logCounter struct {
-	logRecord              plog.LogRecord
+	// logRecord is the first occurrence of the log record, unless kept as a snapshot.
+	logRecord plog.LogRecord
+	// snapshot holds the first occurrence of the log record as the proto bytes of a single-record plog.Logs,
+	// nil when kept as logRecord. See recordStore.
+	snapshot               []byte
 	firstObservedTimestamp time.Time
 	lastObservedTimestamp  time.Time
-	count                  int64
+	// firstRecordTimestamp and lastRecordTimestamp are the earliest and latest timestamps of the duplicates,
+	// zero until one with a timestamp is observed.
+	firstRecordTimestamp pcommon.Timestamp
+	lastRecordTimestamp  pcommon.Timestamp
+	count                int64
+	// deadline is the time after which the counter is exported, zero when exported on every interval.
+	deadline time.Time
+	// aggregates hold the aggregated attribute values, in the order of the aggregations.
+	aggregates []attributeAggregate
 }
 
 // newLogCounter creates a new AttributeCounter.
@@ -175,32 +504,173 @@ func newLogCounter(logRecord plog.LogRecord) *logCounter {
 	}
 }
 
+// record returns the log record of the counter, unmarshaling its snapshot if any.
+func (a *logCounter) record() (plog.LogRecord, error) {
+	if a.snapshot == nil {
+		return a.logRecord, nil
+	}
+	unmarshaler := plog.ProtoUnmarshaler{}
+	logs, err := unmarshaler.UnmarshalLogs(a.snapshot)
+	if err != nil {
+		return plog.LogRecord{}, fmt.Errorf("failed to unmarshal log record snapshot: %w", err)
+	}
+	// Snapshots are marshaled by recordStore.snapshot, and so should always hold a single log record.
+	if n := logs.LogRecordCount(); n != 1 {
+		return plog.LogRecord{}, fmt.Errorf("log record snapshot holds %d log records instead of 1", n)
+	}
+	return logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0), nil
+}
+
 // Increment increments the counter.
 func (a *logCounter) Increment() {
 	a.lastObservedTimestamp = timeNow().UTC()
 	a.count++
 }
 
+// observe extends the earliest and latest timestamps of the duplicates to timestamp, ignored when zero.
+func (a *logCounter) observe(timestamp pcommon.Timestamp) {
+	if timestamp == 0 {
+		return
+	}
+	if a.firstRecordTimestamp == 0 || timestamp < a.firstRecordTimestamp {
+		a.firstRecordTimestamp = timestamp
+	}
+	if timestamp > a.lastRecordTimestamp {
+		a.lastRecordTimestamp = timestamp
+	}
+}
+
+// recordTimestamp returns the time logRecord was observed: its ObservedTimestamp or, when unset, its Timestamp.
+func recordTimestamp(logRecord plog.LogRecord) pcommon.Timestamp {
+	if ts := logRecord.ObservedTimestamp(); ts != 0 {
+		return ts
+	}
+	return logRecord.Timestamp()
+}
+
+// limitInterval brings the deadline forward to interval after the first observed timestamp, if earlier.
+// Counters aggregating logs of several severities therefore use the shortest applicable interval.
+func (a *logCounter) limitInterval(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	deadline := a.firstObservedTimestamp.Add(interval)
+	if a.deadline.IsZero() || deadline.Before(a.deadline) {
+		a.deadline = deadline
+	}
+}
+
 // getResourceKey creates a unique hash for the resource to use as a map key
-func getResourceKey(resource pcommon.Resource) uint64 {
-	return pdatautil.Hash64(
+func getResourceKey(h hashAlgorithm, resource pcommon.Resource) uint64 {
+	return h.hash64(
 		pdatautil.WithMap(resource.Attributes()),
 	)
 }
 
 // getScopeKey creates a unique hash for the scope to use as a map key
-func getScopeKey(scope pcommon.InstrumentationScope) uint64 {
-	return pdatautil.Hash64(
+func getScopeKey(h hashAlgorithm, scope pcommon.InstrumentationScope) uint64 {
+	return h.hash64(
 		pdatautil.WithMap(scope.Attributes()),
 		pdatautil.WithString(scope.Name()),
 		pdatautil.WithString(scope.Version()),
 	)
 }
 
+// logKeyFields defines the fields of a log record used to identify its duplicates.
+type logKeyFields struct {
+	// includeFields are the body and attributes fields whose values are hashed.
+	includeFields []string
+	// processorAttributes are the attributes set by the processor, never hashed when hashing whole log records.
+	processorAttributes []string
+	// excludeFields removes the fields ignored when hashing whole log records, without removing them from the
+	// log records. Nil when the excluded fields are removed from the log records by the processor instead.
+	excludeFields *fieldRemover
+	// dedupFields are the attribute keys whose values are hashed, takes precedence over includeFields.
+	dedupFields []string
+	// includeBody hashes the body along with dedupFields.
+	includeBody bool
+	// includeSeverity hashes the severity number and text along with dedupFields.
+	includeSeverity bool
+	// includeTraceID hashes the trace ID along with the other fields, unless empty.
+	includeTraceID bool
+	// hashAlgorithm hashes the fields into keys, as well as resources and scopes.
+	hashAlgorithm hashAlgorithm
+	// bodyPrefixLen limits the characters of string bodies hashed to their first bodyPrefixLen, 0 hashes whole bodies.
+	bodyPrefixLen int
+}
+
+// logKey creates a unique hash for the log record to use as a map key.
+func (k logKeyFields) logKey(logRecord plog.LogRecord) uint64 {
+	var key uint64
+	if len(k.dedupFields) > 0 || k.includeBody || k.includeSeverity {
+		key = k.dedupFieldsKey(logRecord)
+	} else {
+		key = getLogKey(k.hashAlgorithm, k.withoutIgnoredFields(logRecord), k.includeFields, k.bodyPrefixLen)
+	}
+
+	// Records without trace ID keep the key of their fields, so that they are grouped together.
+	if traceID := logRecord.TraceID(); k.includeTraceID && !traceID.IsEmpty() {
+		return k.hashAlgorithm.hash64(
+			pdatautil.WithString(strconv.FormatUint(key, 16)),
+			pdatautil.WithString(traceID.String()),
+		)
+	}
+	return key
+}
+
+// withoutIgnoredFields returns the log record without the attributes set by the processor, so that logs already
+// aggregated, e.g. by an earlier log dedup processor, are keyed by their own fields, and without the excluded fields.
+// The log record is only copied when it has fields to remove.
+func (k logKeyFields) withoutIgnoredFields(logRecord plog.LogRecord) plog.LogRecord {
+	attrs := logRecord.Attributes()
+	hasProcessorAttributes := slices.ContainsFunc(k.processorAttributes, func(name string) bool {
+		_, ok := attrs.Get(name)
+		return ok
+	})
+	if !hasProcessorAttributes && k.excludeFields == nil {
+		return logRecord
+	}
+
+	stripped := plog.NewLogRecord()
+	logRecord.CopyTo(stripped)
+	stripped.Attributes().RemoveIf(func(name string, _ pcommon.Value) bool {
+		return slices.Contains(k.processorAttributes, name)
+	})
+	if k.excludeFields != nil {
+		k.excludeFields.RemoveFields(stripped)
+	}
+	return stripped
+}
+
+// dedupFieldsKey creates a unique hash for the log record from the values of the dedupFields attributes,
+// of the body if includeBody is set, and of the severity if includeSeverity is set. All other fields are ignored.
+// Missing attributes are hashed as absent.
+func (k logKeyFields) dedupFieldsKey(logRecord plog.LogRecord) uint64 {
+	attrs := pcommon.NewMap()
+	attrs.EnsureCapacity(len(k.dedupFields))
+	for _, key := range k.dedupFields {
+		if value, ok := logRecord.Attributes().Get(key); ok {
+			value.CopyTo(attrs.PutEmpty(key))
+		}
+	}
+
+	opts := []pdatautil.HashOption{pdatautil.WithMap(attrs)}
+	if k.includeBody {
+		opts = append(opts, withBody(logRecord.Body(), k.bodyPrefixLen))
+	}
+	if k.includeSeverity {
+		opts = append(opts,
+			pdatautil.WithString(logRecord.SeverityNumber().String()),
+			pdatautil.WithString(logRecord.SeverityText()),
+		)
+	}
+	return k.hashAlgorithm.hash64(opts...)
+}
+
 // getLogKey creates a unique hash for the log record to use as a map key.
 // If dedupFields is non-empty, it is used to determine the fields whose values are hashed.
 // If no dedupFields are found in the log record, all fields are hashed.
-func getLogKey(logRecord plog.LogRecord, dedupFields []string) uint64 {
+func getLogKey(h hashAlgorithm, logRecord plog.LogRecord, dedupFields []string, bodyPrefixLen int) uint64 {
 	if len(dedupFields) > 0 {
 		var opts []pdatautil.HashOption
 
@@ -214,18 +684,34 @@ func getLogKey(logRecord plog.LogRecord, dedupFields []string) uint64 {
 		}
 
 		if len(opts) > 0 {
-			return pdatautil.Hash64(opts...)
+			return h.hash64(opts...)
 		}
 	}
 
-	return pdatautil.Hash64(
+	return h.hash64(
 		pdatautil.WithMap(logRecord.Attributes()),
-		pdatautil.WithValue(logRecord.Body()),
+		withBody(logRecord.Body(), bodyPrefixLen),
 		pdatautil.WithString(logRecord.SeverityNumber().String()),
 		pdatautil.WithString(logRecord.SeverityText()),
 	)
 }
 
+// withBody adds the body to the hash calculation, limited to its first prefixLen characters if it is a string
+// and prefixLen is positive.
+func withBody(body pcommon.Value, prefixLen int) pdatautil.HashOption {
+	if prefixLen <= 0 || body.Type() != pcommon.ValueTypeStr {
+		return pdatautil.WithValue(body)
+	}
+	str := body.Str()
+	for i := range str {
+		if prefixLen == 0 {
+			return pdatautil.WithString(str[:i])
+		}
+		prefixLen--
+	}
+	return pdatautil.WithString(str)
+}
+
 func getMap(logRecord plog.LogRecord, leadingPart string) (pcommon.Map, bool) {
 	switch leadingPart {
 	case bodyField:
@@ -4,6 +4,7 @@
 package logdedupprocessor
 
 import (
+	"strconv"
 	"testing"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"go.opentelemetry.io/collector/component/componenttest"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
 
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/pdatautil"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/processor/logdedupprocessor/internal/metadata"
@@ -21,7 +23,7 @@ func Test_newLogAggregator(t *testing.T) {
 	telemetryBuilder, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
 	require.NoError(t, err)
 
-	aggregator := newLogAggregator(cfg.LogCountAttribute, time.UTC, telemetryBuilder, cfg.IncludeFields)
+	aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: cfg.LogCountAttribute, timezone: time.UTC, keyFields: logKeyFields{includeFields: cfg.IncludeFields}, interval: cfg.Interval, dedupScope: dedupScopeScope}, telemetryBuilder, zap.NewNop())
 	require.Equal(t, cfg.LogCountAttribute, aggregator.logCountAttribute)
 	require.Equal(t, time.UTC, aggregator.timezone)
 	require.NotNil(t, aggregator.resources)
@@ -43,7 +45,7 @@ func Test_logAggregatorAdd(t *testing.T) {
 	require.NoError(t, err)
 
 	// Setup aggregator
-	aggregator := newLogAggregator("log_count", time.UTC, telemetryBuilder, nil)
+	aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: "log_count", timezone: time.UTC, interval: defaultInterval, dedupScope: dedupScopeScope}, telemetryBuilder, zap.NewNop())
 	logRecord := plog.NewLogRecord()
 
 	resource := pcommon.NewResource()
@@ -51,9 +53,9 @@ func Test_logAggregatorAdd(t *testing.T) {
 
 	scope := pcommon.NewInstrumentationScope()
 
-	expectedResourceKey := getResourceKey(resource)
-	expectedScopeKey := getScopeKey(scope)
-	expectedLogKey := getLogKey(logRecord, nil)
+	expectedResourceKey := getResourceKey(hashAlgorithmFNV, resource)
+	expectedScopeKey := getScopeKey(hashAlgorithmFNV, scope)
+	expectedLogKey := getLogKey(hashAlgorithmFNV, logRecord, nil, 0)
 
 	// Add logRecord
 	aggregator.Add(resource, scope, logRecord)
@@ -93,12 +95,12 @@ func Test_logAggregatorReset(t *testing.T) {
 	telemetryBuilder, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
 	require.NoError(t, err)
 
-	aggregator := newLogAggregator("log_count", time.UTC, telemetryBuilder, nil)
+	aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: "log_count", timezone: time.UTC, interval: defaultInterval, dedupScope: dedupScopeScope}, telemetryBuilder, zap.NewNop())
 	for i := range 2 {
 		resource := pcommon.NewResource()
 		resource.Attributes().PutInt("i", int64(i))
-		key := getResourceKey(resource)
-		aggregator.resources[key] = newResourceAggregator(resource, nil)
+		key := getResourceKey(hashAlgorithmFNV, resource)
+		aggregator.resources[key] = newResourceAggregator(resource, logKeyFields{}, nil, false, &recordStore{})
 	}
 
 	require.Len(t, aggregator.resources, 2)
@@ -128,7 +130,7 @@ func Test_logAggregatorExport(t *testing.T) {
 	telemetryBuilder, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
 	require.NoError(t, err)
 
-	aggregator := newLogAggregator(defaultLogCountAttribute, location, telemetryBuilder, nil)
+	aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: location, interval: defaultInterval, dedupScope: dedupScopeScope}, telemetryBuilder, zap.NewNop())
 	resource := pcommon.NewResource()
 	resource.Attributes().PutStr("one", "two")
 	expectedHash := pdatautil.MapHash(resource.Attributes())
@@ -184,10 +186,541 @@ func Test_logAggregatorExport(t *testing.T) {
 	require.Equal(t, expectedTimestampStr, actualLastObserved)
 }
 
+func Test_logAggregatorExportCountAttribute(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		name          string
+		countAsString bool
+		expected      any
+	}{
+		{
+			desc:     "default name",
+			name:     defaultLogCountAttribute,
+			expected: int64(3),
+		},
+		{
+			desc:     "custom name",
+			name:     "dedup.count",
+			expected: int64(3),
+		},
+		{
+			desc:          "string",
+			name:          defaultLogCountAttribute,
+			countAsString: true,
+			expected:      "3",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			telemetryBuilder, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
+			require.NoError(t, err)
+
+			aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: tc.name, countAsString: tc.countAsString, timezone: time.UTC, interval: defaultInterval, dedupScope: dedupScopeScope}, telemetryBuilder, zap.NewNop())
+			// The count includes the first occurrence
+			for range 3 {
+				aggregator.Add(pcommon.NewResource(), pcommon.NewInstrumentationScope(), generateTestLogRecord(t, "body string"))
+			}
+
+			exportedLogs := aggregator.Export(t.Context())
+			require.Equal(t, 1, exportedLogs.LogRecordCount())
+			attrs := exportedLogs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+			require.Equal(t, tc.expected, attrs[tc.name])
+		})
+	}
+}
+
+func Test_logAggregatorExportTimestampAttributes(t *testing.T) {
+	oldTimeNow := timeNow
+	defer func() {
+		timeNow = oldTimeNow
+	}()
+
+	telemetryBuilder, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+
+	timestampAttrs := timestampAttributes{firstObserved: "dedup.first_seen", lastObserved: "dedup.last_seen"}
+	aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, interval: defaultInterval, timestampAttrs: timestampAttrs, dedupScope: dedupScopeScope}, telemetryBuilder, zap.NewNop())
+	resource := pcommon.NewResource()
+	scope := pcommon.NewInstrumentationScope()
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC)
+	for i := range 4 {
+		timeNow = func() time.Time { return start.Add(time.Duration(i) * 1500 * time.Millisecond) }
+		aggregator.Add(resource, scope, generateTestLogRecord(t, "duplicated"))
+	}
+	timeNow = func() time.Time { return start.Add(time.Minute) }
+
+	logs := aggregator.Export(t.Context())
+	require.Equal(t, 1, logs.LogRecordCount())
+	attrs := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes()
+
+	count, ok := attrs.Get(defaultLogCountAttribute)
+	require.True(t, ok)
+	require.Equal(t, int64(4), count.Int())
+
+	firstSeen, ok := attrs.Get("dedup.first_seen")
+	require.True(t, ok)
+	require.Equal(t, start.UnixNano(), firstSeen.Int())
+
+	lastSeen, ok := attrs.Get("dedup.last_seen")
+	require.True(t, ok)
+	require.Equal(t, start.Add(4500*time.Millisecond).UnixNano(), lastSeen.Int())
+}
+
+func Test_logAggregatorExportRecordTimestampAttributes(t *testing.T) {
+	telemetryBuilder, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+
+	timestampAttrs := timestampAttributes{firstObserved: "dedup.first", lastObserved: "dedup.last", fromRecords: true}
+	aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, interval: defaultInterval, timestampAttrs: timestampAttrs, dedupScope: dedupScopeScope}, telemetryBuilder, zap.NewNop())
+	resource := pcommon.NewResource()
+	scope := pcommon.NewInstrumentationScope()
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	newRecord := func(body string, observed, timestamp time.Duration) plog.LogRecord {
+		logRecord := generateTestLogRecord(t, body)
+		// Negative durations leave the timestamps unset.
+		logRecord.SetTimestamp(0)
+		if observed >= 0 {
+			logRecord.SetObservedTimestamp(pcommon.NewTimestampFromTime(start.Add(observed)))
+		}
+		if timestamp >= 0 {
+			logRecord.SetTimestamp(pcommon.NewTimestampFromTime(start.Add(timestamp)))
+		}
+		return logRecord
+	}
+
+	// A burst observed out of order, partly without observed timestamp
+	aggregator.Add(resource, scope, newRecord("burst", 3*time.Second, -1))
+	aggregator.Add(resource, scope, newRecord("burst", time.Second, 5*time.Second))
+	aggregator.Add(resource, scope, newRecord("burst", -1, 8*time.Second))
+	aggregator.Add(resource, scope, newRecord("burst", 2*time.Second, -1))
+	aggregator.Add(resource, scope, newRecord("burst", -1, -1))
+	// A single occurrence
+	aggregator.Add(resource, scope, newRecord("single", 4*time.Second, time.Second))
+	// Occurrences without timestamps
+	aggregator.Add(resource, scope, newRecord("untimed", -1, -1))
+	aggregator.Add(resource, scope, newRecord("untimed", -1, -1))
+
+	logs := aggregator.Export(t.Context())
+	require.Equal(t, 3, logs.LogRecordCount())
+	attrs := make(map[string]map[string]any)
+	for _, lr := range logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().All() {
+		attrs[lr.Body().Str()] = lr.Attributes().AsRaw()
+	}
+
+	require.Equal(t, int64(5), attrs["burst"][defaultLogCountAttribute])
+	require.Equal(t, start.Add(time.Second).UnixNano(), attrs["burst"]["dedup.first"])
+	require.Equal(t, start.Add(8*time.Second).UnixNano(), attrs["burst"]["dedup.last"])
+
+	require.Equal(t, start.Add(4*time.Second).UnixNano(), attrs["single"]["dedup.first"])
+	require.Equal(t, attrs["single"]["dedup.first"], attrs["single"]["dedup.last"])
+
+	require.NotContains(t, attrs["untimed"], "dedup.first")
+	require.NotContains(t, attrs["untimed"], "dedup.last")
+}
+
+func Test_logAggregatorEmissionAttributes(t *testing.T) {
+	oldTimeNow := timeNow
+	defer func() {
+		timeNow = oldTimeNow
+	}()
+
+	telemetryBuilder, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+
+	intervals, err := newSeverityIntervals(map[string]time.Duration{"error": 10 * time.Second})
+	require.NoError(t, err)
+
+	emissionAttrs := emissionAttributes{enabled: true, windowStart: "window.start", windowEnd: "window.end", reason: "emission.reason"}
+	resource := pcommon.NewResource()
+	scope := pcommon.NewInstrumentationScope()
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	requireEmission := func(t *testing.T, logs plog.Logs, windowStart, windowEnd time.Time, reason string) {
+		t.Helper()
+		require.Equal(t, 1, logs.LogRecordCount())
+		attrs := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+		require.Equal(t, windowStart.UnixNano(), attrs["window.start"])
+		require.Equal(t, windowEnd.UnixNano(), attrs["window.end"])
+		require.Equal(t, reason, attrs["emission.reason"])
+	}
+
+	t.Run("interval", func(t *testing.T) {
+		timeNow = func() time.Time { return start }
+		aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, interval: defaultInterval, dedupScope: dedupScopeScope, emissionAttrs: emissionAttrs}, telemetryBuilder, zap.NewNop())
+
+		timeNow = func() time.Time { return start.Add(3 * time.Second) }
+		aggregator.Add(resource, scope, generateTestLogRecord(t, "first window"))
+		timeNow = func() time.Time { return start.Add(defaultInterval) }
+		requireEmission(t, aggregator.Take(t.Context(), false), start, start.Add(defaultInterval), emissionReasonInterval)
+
+		// The next window starts at the previous emission
+		timeNow = func() time.Time { return start.Add(defaultInterval + time.Second) }
+		aggregator.Add(resource, scope, generateTestLogRecord(t, "second window"))
+		timeNow = func() time.Time { return start.Add(2 * defaultInterval) }
+		requireEmission(t, aggregator.Take(t.Context(), false), start.Add(defaultInterval), start.Add(2*defaultInterval), emissionReasonInterval)
+	})
+
+	t.Run("interval by severity", func(t *testing.T) {
+		timeNow = func() time.Time { return start }
+		aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, interval: time.Hour, severityIntervals: intervals, dedupScope: dedupScopeScope, emissionAttrs: emissionAttrs}, telemetryBuilder, zap.NewNop())
+
+		timeNow = func() time.Time { return start.Add(2 * time.Second) }
+		errorRecord := generateTestLogRecord(t, "failure")
+		errorRecord.SetSeverityNumber(plog.SeverityNumberError)
+		aggregator.Add(resource, scope, errorRecord)
+
+		// The window of logs exported on their own interval starts at the first one observed
+		timeNow = func() time.Time { return start.Add(12 * time.Second) }
+		requireEmission(t, aggregator.Take(t.Context(), false), start.Add(2*time.Second), start.Add(12*time.Second), emissionReasonInterval)
+	})
+
+	t.Run("shutdown", func(t *testing.T) {
+		timeNow = func() time.Time { return start }
+		aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, interval: defaultInterval, dedupScope: dedupScopeScope, emissionAttrs: emissionAttrs}, telemetryBuilder, zap.NewNop())
+
+		timeNow = func() time.Time { return start.Add(time.Second) }
+		aggregator.Add(resource, scope, generateTestLogRecord(t, "pending"))
+		timeNow = func() time.Time { return start.Add(4 * time.Second) }
+		requireEmission(t, aggregator.Take(t.Context(), true), start, start.Add(4*time.Second), emissionReasonShutdown)
+	})
+
+	t.Run("shutdown interval by severity", func(t *testing.T) {
+		timeNow = func() time.Time { return start }
+		aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, interval: time.Hour, severityIntervals: intervals, dedupScope: dedupScopeScope, emissionAttrs: emissionAttrs}, telemetryBuilder, zap.NewNop())
+
+		timeNow = func() time.Time { return start.Add(time.Second) }
+		errorRecord := generateTestLogRecord(t, "failure")
+		errorRecord.SetSeverityNumber(plog.SeverityNumberError)
+		aggregator.Add(resource, scope, errorRecord)
+		timeNow = func() time.Time { return start.Add(5 * time.Second) }
+		requireEmission(t, aggregator.Take(t.Context(), true), start.Add(time.Second), start.Add(5*time.Second), emissionReasonShutdown)
+	})
+
+	t.Run("count threshold", func(t *testing.T) {
+		timeNow = func() time.Time { return start }
+		aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, interval: defaultInterval, dedupScope: dedupScopeScope, emissionAttrs: emissionAttrs, countThreshold: 3}, telemetryBuilder, zap.NewNop())
+
+		timeNow = func() time.Time { return start.Add(time.Second) }
+		aggregator.Add(resource, scope, generateTestLogRecord(t, "burst"))
+		aggregator.Add(resource, scope, generateTestLogRecord(t, "quiet"))
+		timeNow = func() time.Time { return start.Add(2 * time.Second) }
+		aggregator.Add(resource, scope, generateTestLogRecord(t, "burst"))
+		require.Zero(t, aggregator.TakeEarly(t.Context()).LogRecordCount())
+
+		timeNow = func() time.Time { return start.Add(3 * time.Second) }
+		aggregator.Add(resource, scope, generateTestLogRecord(t, "burst"))
+		logs := aggregator.TakeEarly(t.Context())
+		requireEmission(t, logs, start, start.Add(3*time.Second), emissionReasonCountThreshold)
+		require.Equal(t, "burst", logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+		require.Zero(t, aggregator.TakeEarly(t.Context()).LogRecordCount())
+
+		// The logs below the threshold are emitted on their interval
+		timeNow = func() time.Time { return start.Add(defaultInterval) }
+		requireEmission(t, aggregator.Take(t.Context(), false), start, start.Add(defaultInterval), emissionReasonInterval)
+	})
+
+	t.Run("max hold", func(t *testing.T) {
+		timeNow = func() time.Time { return start }
+		aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, interval: defaultInterval, dedupScope: dedupScopeScope, emissionAttrs: emissionAttrs, maxHold: 5 * time.Second}, telemetryBuilder, zap.NewNop())
+
+		timeNow = func() time.Time { return start.Add(time.Second) }
+		aggregator.Add(resource, scope, generateTestLogRecord(t, "held"))
+		timeNow = func() time.Time { return start.Add(3 * time.Second) }
+		aggregator.Add(resource, scope, generateTestLogRecord(t, "recent"))
+
+		timeNow = func() time.Time { return start.Add(5 * time.Second) }
+		require.Zero(t, aggregator.TakeHeld(t.Context()).LogRecordCount())
+
+		timeNow = func() time.Time { return start.Add(6 * time.Second) }
+		logs := aggregator.TakeHeld(t.Context())
+		requireEmission(t, logs, start, start.Add(6*time.Second), emissionReasonMaxHold)
+		require.Equal(t, "held", logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+
+		timeNow = func() time.Time { return start.Add(8 * time.Second) }
+		logs = aggregator.TakeHeld(t.Context())
+		requireEmission(t, logs, start, start.Add(8*time.Second), emissionReasonMaxHold)
+		require.Equal(t, "recent", logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+	})
+
+	t.Run("eviction", func(t *testing.T) {
+		timeNow = func() time.Time { return start }
+		aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, interval: defaultInterval, dedupScope: dedupScopeScope, emissionAttrs: emissionAttrs, maxAggregates: 2}, telemetryBuilder, zap.NewNop())
+
+		timeNow = func() time.Time { return start.Add(time.Second) }
+		aggregator.Add(resource, scope, generateTestLogRecord(t, "oldest"))
+		timeNow = func() time.Time { return start.Add(2 * time.Second) }
+		aggregator.Add(resource, scope, generateTestLogRecord(t, "newer"))
+		aggregator.Add(resource, scope, generateTestLogRecord(t, "oldest"))
+		require.Zero(t, aggregator.TakeEarly(t.Context()).LogRecordCount())
+
+		timeNow = func() time.Time { return start.Add(4 * time.Second) }
+		aggregator.Add(resource, scope, generateTestLogRecord(t, "newest"))
+		logs := aggregator.TakeEarly(t.Context())
+		requireEmission(t, logs, start, start.Add(4*time.Second), emissionReasonEviction)
+		lr := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+		require.Equal(t, "oldest", lr.Body().Str())
+		count, _ := lr.Attributes().Get(defaultLogCountAttribute)
+		require.Equal(t, int64(2), count.Int())
+
+		timeNow = func() time.Time { return start.Add(defaultInterval) }
+		require.Equal(t, 2, aggregator.Take(t.Context(), false).LogRecordCount())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		timeNow = func() time.Time { return start }
+		aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, interval: defaultInterval, dedupScope: dedupScopeScope, emissionAttrs: emissionAttributes{windowStart: "window.start", windowEnd: "window.end", reason: "emission.reason"}}, telemetryBuilder, zap.NewNop())
+
+		aggregator.Add(resource, scope, generateTestLogRecord(t, "no window"))
+		logs := aggregator.Take(t.Context(), true)
+		require.Equal(t, 1, logs.LogRecordCount())
+		attrs := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().AsRaw()
+		require.NotContains(t, attrs, "window.start")
+		require.NotContains(t, attrs, "window.end")
+		require.NotContains(t, attrs, "emission.reason")
+	})
+}
+
+func Test_logAggregatorExportExpired(t *testing.T) {
+	oldTimeNow := timeNow
+	defer func() {
+		timeNow = oldTimeNow
+	}()
+
+	start := time.Now().UTC()
+	timeNow = func() time.Time { return start }
+
+	telemetryBuilder, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+
+	intervals, err := newSeverityIntervals(map[string]time.Duration{"error": 10 * time.Second})
+	require.NoError(t, err)
+	aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, interval: 5 * time.Minute, severityIntervals: intervals, dedupScope: dedupScopeScope}, telemetryBuilder, zap.NewNop())
+
+	resource := pcommon.NewResource()
+	scope := pcommon.NewInstrumentationScope()
+
+	// Create an ERROR-keyed and an INFO-keyed aggregate at the same time
+	errorRecord := generateTestLogRecord(t, "failure")
+	errorRecord.SetSeverityNumber(plog.SeverityNumberError)
+	aggregator.Add(resource, scope, errorRecord)
+	infoRecord := generateTestLogRecord(t, "progress")
+	infoRecord.SetSeverityNumber(plog.SeverityNumberInfo)
+	aggregator.Add(resource, scope, infoRecord)
+
+	require.Equal(t, 0, aggregator.ExportExpired(t.Context(), start.Add(9*time.Second)).LogRecordCount())
+
+	exported := aggregator.ExportExpired(t.Context(), start.Add(10*time.Second))
+	require.Equal(t, 1, exported.LogRecordCount())
+	require.Equal(t, "failure", exported.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+
+	require.Equal(t, 0, aggregator.ExportExpired(t.Context(), start.Add(5*time.Minute-time.Second)).LogRecordCount())
+
+	exported = aggregator.ExportExpired(t.Context(), start.Add(5*time.Minute))
+	require.Equal(t, 1, exported.LogRecordCount())
+	require.Equal(t, "progress", exported.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+	require.Empty(t, aggregator.resources)
+}
+
+func Test_logAggregatorMixedSeverities(t *testing.T) {
+	oldTimeNow := timeNow
+	defer func() {
+		timeNow = oldTimeNow
+	}()
+
+	start := time.Now().UTC()
+	timeNow = func() time.Time { return start }
+
+	telemetryBuilder, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+
+	intervals, err := newSeverityIntervals(map[string]time.Duration{"error": 10 * time.Second})
+	require.NoError(t, err)
+	// Dedup on the body only so that records of different severities share a key
+	aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, keyFields: logKeyFields{includeFields: []string{"body.msg"}}, interval: 5 * time.Minute, severityIntervals: intervals, dedupScope: dedupScopeScope}, telemetryBuilder, zap.NewNop())
+
+	resource := pcommon.NewResource()
+	scope := pcommon.NewInstrumentationScope()
+
+	infoRecord := generateTestLogRecordWithMap(t)
+	infoRecord.Body().Map().PutStr("msg", "retrying")
+	infoRecord.SetSeverityNumber(plog.SeverityNumberInfo)
+	aggregator.Add(resource, scope, infoRecord)
+
+	timeNow = func() time.Time { return start.Add(time.Minute) }
+	errorRecord := generateTestLogRecordWithMap(t)
+	errorRecord.Body().Map().PutStr("msg", "retrying")
+	errorRecord.SetSeverityNumber(plog.SeverityNumberError)
+	aggregator.Add(resource, scope, errorRecord)
+
+	// The shortest interval applies from the creation of the key
+	exported := aggregator.ExportExpired(t.Context(), start.Add(10*time.Second))
+	require.Equal(t, 1, exported.LogRecordCount())
+	count, ok := exported.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes().Get(defaultLogCountAttribute)
+	require.True(t, ok)
+	require.Equal(t, int64(2), count.Int())
+}
+
+func Test_logAggregatorTake(t *testing.T) {
+	telemetryBuilder, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+
+	intervals, err := newSeverityIntervals(map[string]time.Duration{"error": time.Hour})
+	require.NoError(t, err)
+	aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, interval: time.Hour, severityIntervals: intervals, dedupScope: dedupScopeScope}, telemetryBuilder, zap.NewNop())
+	aggregator.Add(pcommon.NewResource(), pcommon.NewInstrumentationScope(), generateTestLogRecord(t, "body string"))
+
+	require.Equal(t, 0, aggregator.Take(t.Context(), false).LogRecordCount())
+	require.Equal(t, 1, aggregator.Take(t.Context(), true).LogRecordCount())
+	require.Empty(t, aggregator.resources)
+}
+
+func Test_logAggregatorScope(t *testing.T) {
+	telemetryBuilder, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+
+	// aggregated describes an aggregated log by the host.name of its resource, its scope name and its count.
+	type aggregated struct {
+		host  string
+		scope string
+		count int64
+	}
+
+	tests := []struct {
+		dedupScope string
+		expected   []aggregated
+	}{
+		{
+			dedupScope: dedupScopeScope,
+			expected:   []aggregated{{"a", "one", 1}, {"a", "two", 1}, {"b", "one", 1}},
+		},
+		{
+			// Identical bodies under different resources are kept separate
+			dedupScope: dedupScopeResource,
+			expected:   []aggregated{{"a", "one", 2}, {"b", "one", 1}},
+		},
+		{
+			dedupScope: dedupScopeGlobal,
+			expected:   []aggregated{{"a", "one", 3}},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.dedupScope, func(t *testing.T) {
+			aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, interval: defaultInterval, dedupScope: tc.dedupScope}, telemetryBuilder, zap.NewNop())
+			for _, source := range []struct{ host, scope string }{{"a", "one"}, {"a", "two"}, {"b", "one"}} {
+				resource := pcommon.NewResource()
+				resource.Attributes().PutStr("host.name", source.host)
+				scope := pcommon.NewInstrumentationScope()
+				scope.SetName(source.scope)
+				aggregator.Add(resource, scope, generateTestLogRecord(t, "connection refused"))
+			}
+
+			var actual []aggregated
+			logs := aggregator.Export(t.Context())
+			for i := 0; i < logs.ResourceLogs().Len(); i++ {
+				rl := logs.ResourceLogs().At(i)
+				host, _ := rl.Resource().Attributes().Get("host.name")
+				for j := 0; j < rl.ScopeLogs().Len(); j++ {
+					sl := rl.ScopeLogs().At(j)
+					for k := 0; k < sl.LogRecords().Len(); k++ {
+						count, _ := sl.LogRecords().At(k).Attributes().Get(defaultLogCountAttribute)
+						actual = append(actual, aggregated{host.Str(), sl.Scope().Name(), count.Int()})
+					}
+				}
+			}
+			require.ElementsMatch(t, tc.expected, actual)
+		})
+	}
+}
+
+func Test_logAggregatorAggregateAttributes(t *testing.T) {
+	telemetryBuilder, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+
+	aggregations, err := newAttributeAggregations(map[string]string{"bytes_sent": "sum", "latency": "avg"})
+	require.NoError(t, err)
+	aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, interval: defaultInterval, aggregations: aggregations, dedupScope: dedupScopeScope}, telemetryBuilder, zap.NewNop())
+	resource := pcommon.NewResource()
+	scope := pcommon.NewInstrumentationScope()
+
+	// The records only differ by their aggregated attributes, so they are collapsed
+	for i, bytesSent := range []int64{100, 250, 50} {
+		lr := generateTestLogRecord(t, "request served")
+		lr.Attributes().PutInt("bytes_sent", bytesSent)
+		lr.Attributes().PutDouble("latency", 1.5+float64(i))
+		aggregator.Add(resource, scope, lr)
+	}
+
+	exportedLogs := aggregator.Export(t.Context())
+	require.Equal(t, 1, exportedLogs.LogRecordCount())
+	attrs := exportedLogs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Attributes()
+
+	count, ok := attrs.Get(defaultLogCountAttribute)
+	require.True(t, ok)
+	require.Equal(t, int64(3), count.Int())
+	bytesSent, ok := attrs.Get("bytes_sent")
+	require.True(t, ok)
+	require.Equal(t, pcommon.ValueTypeInt, bytesSent.Type())
+	require.Equal(t, int64(400), bytesSent.Int())
+	latency, ok := attrs.Get("latency")
+	require.True(t, ok)
+	require.Equal(t, pcommon.ValueTypeDouble, latency.Type())
+	require.InDelta(t, 2.5, latency.Double(), 1e-9)
+}
+
+func Test_logAggregatorSuppressionSummary(t *testing.T) {
+	oldTimeNow := timeNow
+	defer func() {
+		timeNow = oldTimeNow
+	}()
+
+	first := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	last := first.Add(30 * time.Second)
+
+	telemetryBuilder, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+
+	aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, interval: defaultInterval, emitSummary: true, dedupScope: dedupScopeScope}, telemetryBuilder, zap.NewNop())
+	resource := pcommon.NewResource()
+	scope := pcommon.NewInstrumentationScope()
+
+	timeNow = func() time.Time { return first }
+	aggregator.Add(resource, scope, generateTestLogRecord(t, "duplicated"))
+	aggregator.Add(resource, scope, generateTestLogRecord(t, "single"))
+	timeNow = func() time.Time { return last }
+	aggregator.Add(resource, scope, generateTestLogRecord(t, "duplicated"))
+	aggregator.Add(resource, scope, generateTestLogRecord(t, "duplicated"))
+
+	exportedLogs := aggregator.Export(t.Context())
+	logRecords := exportedLogs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+
+	// Only the aggregated log that suppressed duplicates is followed by a summary
+	require.Equal(t, 3, logRecords.Len())
+	var summary plog.LogRecord
+	for i := 0; i < logRecords.Len(); i++ {
+		if logRecords.At(i).Body().Str() == "duplicated" {
+			require.Less(t, i+1, logRecords.Len())
+			summary = logRecords.At(i + 1)
+		}
+	}
+
+	require.Equal(t, "suppressed 2 duplicate logs", summary.Body().Str())
+	require.Equal(t, plog.SeverityNumberInfo, summary.SeverityNumber())
+	require.Equal(t, map[string]any{
+		summaryKeyAttr:         strconv.FormatUint(getLogKey(hashAlgorithmFNV, generateTestLogRecord(t, "duplicated"), nil, 0), 16),
+		summaryCountAttr:       int64(3),
+		summarySuppressedAttr:  int64(2),
+		summaryWindowStartAttr: "2024-01-02T03:04:05Z",
+		summaryWindowEndAttr:   "2024-01-02T03:04:35Z",
+	}, summary.Attributes().AsRaw())
+}
+
 func Test_newResourceAggregator(t *testing.T) {
 	resource := pcommon.NewResource()
 	resource.Attributes().PutStr("one", "two")
-	aggregator := newResourceAggregator(resource, nil)
+	aggregator := newResourceAggregator(resource, logKeyFields{}, nil, false, &recordStore{})
 	require.NotNil(t, aggregator.scopeCounters)
 	require.Equal(t, resource, aggregator.resource)
 }
@@ -195,7 +728,7 @@ func Test_newResourceAggregator(t *testing.T) {
 func Test_newScopeCounter(t *testing.T) {
 	scope := pcommon.NewInstrumentationScope()
 	scope.Attributes().PutStr("one", "two")
-	sc := newScopeAggregator(scope, nil)
+	sc := newScopeAggregator(scope, logKeyFields{}, nil, &recordStore{})
 	require.Equal(t, scope, sc.scope)
 	require.NotNil(t, sc.logCounters)
 }
@@ -253,8 +786,8 @@ func Test_getLogKey(t *testing.T) {
 
 				logRecord2 := generateTestLogRecord(t, "Body of the log")
 
-				key1 := getLogKey(logRecord1, nil)
-				key2 := getLogKey(logRecord2, nil)
+				key1 := getLogKey(hashAlgorithmFNV, logRecord1, nil, 0)
+				key2 := getLogKey(hashAlgorithmFNV, logRecord2, nil, 0)
 
 				require.Equal(t, key1, key2)
 			},
@@ -266,8 +799,8 @@ func Test_getLogKey(t *testing.T) {
 
 				logRecord2 := generateTestLogRecord(t, "A different Body of the log")
 
-				key1 := getLogKey(logRecord1, nil)
-				key2 := getLogKey(logRecord2, nil)
+				key1 := getLogKey(hashAlgorithmFNV, logRecord1, nil, 0)
+				key2 := getLogKey(hashAlgorithmFNV, logRecord2, nil, 0)
 
 				require.NotEqual(t, key1, key2)
 			},
@@ -280,17 +813,17 @@ func Test_getLogKey(t *testing.T) {
 				logRecord.Body().Map().PutStr("dedup_key", dedupValue)
 				logRecord.Attributes().PutStr("dedup_key", dedupValue)
 
-				expected := pdatautil.Hash64(
+				expected := hashAlgorithm(hashAlgorithmFNV).hash64(
 					pdatautil.WithString(dedupValue),
 				)
-				expectedMulti := pdatautil.Hash64(
+				expectedMulti := hashAlgorithm(hashAlgorithmFNV).hash64(
 					pdatautil.WithString(dedupValue),
 					pdatautil.WithString(dedupValue), //nolint:gocritic // Intentional: testing multi-key deduplication with same value
 				)
 
-				require.Equal(t, expected, getLogKey(logRecord, []string{"body.dedup_key"}))
-				require.Equal(t, expected, getLogKey(logRecord, []string{"attributes.dedup_key"}))
-				require.Equal(t, expectedMulti, getLogKey(logRecord, []string{"body.dedup_key", "attributes.dedup_key"}))
+				require.Equal(t, expected, getLogKey(hashAlgorithmFNV, logRecord, []string{"body.dedup_key"}, 0))
+				require.Equal(t, expected, getLogKey(hashAlgorithmFNV, logRecord, []string{"attributes.dedup_key"}, 0))
+				require.Equal(t, expectedMulti, getLogKey(hashAlgorithmFNV, logRecord, []string{"body.dedup_key", "attributes.dedup_key"}, 0))
 			},
 		},
 		{
@@ -299,14 +832,14 @@ func Test_getLogKey(t *testing.T) {
 				logRecord := plog.NewLogRecord()
 				logRecord.Attributes().PutStr("str", "attr str")
 
-				expected := pdatautil.Hash64(
+				expected := hashAlgorithm(hashAlgorithmFNV).hash64(
 					pdatautil.WithMap(logRecord.Attributes()),
 					pdatautil.WithValue(logRecord.Body()),
 					pdatautil.WithString(logRecord.SeverityNumber().String()),
 					pdatautil.WithString(logRecord.SeverityText()),
 				)
 
-				require.Equal(t, expected, getLogKey(logRecord, []string{"body.dedup_key"}))
+				require.Equal(t, expected, getLogKey(hashAlgorithmFNV, logRecord, []string{"body.dedup_key"}, 0))
 			},
 		},
 		{
@@ -315,14 +848,14 @@ func Test_getLogKey(t *testing.T) {
 				logRecord := plog.NewLogRecord()
 				logRecord.Body().SetStr("hello, this is a message body string")
 
-				expected := pdatautil.Hash64(
+				expected := hashAlgorithm(hashAlgorithmFNV).hash64(
 					pdatautil.WithMap(logRecord.Attributes()),
 					pdatautil.WithValue(logRecord.Body()),
 					pdatautil.WithString(logRecord.SeverityNumber().String()),
 					pdatautil.WithString(logRecord.SeverityText()),
 				)
 
-				require.Equal(t, expected, getLogKey(logRecord, []string{"body.dedup_key"}))
+				require.Equal(t, expected, getLogKey(hashAlgorithmFNV, logRecord, []string{"body.dedup_key"}, 0))
 			},
 		},
 	}
@@ -332,6 +865,331 @@ func Test_getLogKey(t *testing.T) {
 	}
 }
 
+func Test_logKeyFields_dedupFields(t *testing.T) {
+	newRecord := func(body, service, host string) plog.LogRecord {
+		logRecord := plog.NewLogRecord()
+		logRecord.Body().SetStr(body)
+		logRecord.Attributes().PutStr("service.name", service)
+		logRecord.Attributes().PutStr("host.name", host)
+		return logRecord
+	}
+	keyFields := logKeyFields{dedupFields: []string{"service.name"}}
+	withBody := logKeyFields{dedupFields: []string{"service.name"}, includeBody: true}
+
+	t.Run("records differing only in an ignored attribute match", func(t *testing.T) {
+		logRecord1 := newRecord("message", "api", "host-1")
+		logRecord2 := newRecord("message", "api", "host-2")
+		logRecord2.SetSeverityNumber(plog.SeverityNumberError)
+		require.Equal(t, keyFields.logKey(logRecord1), keyFields.logKey(logRecord2))
+		require.Equal(t, withBody.logKey(logRecord1), withBody.logKey(logRecord2))
+	})
+
+	t.Run("records differing in a listed attribute do not match", func(t *testing.T) {
+		logRecord1 := newRecord("message", "api", "host-1")
+		logRecord2 := newRecord("message", "db", "host-1")
+		require.NotEqual(t, keyFields.logKey(logRecord1), keyFields.logKey(logRecord2))
+	})
+
+	t.Run("body is ignored unless included", func(t *testing.T) {
+		logRecord1 := newRecord("message", "api", "host-1")
+		logRecord2 := newRecord("other message", "api", "host-1")
+		require.Equal(t, keyFields.logKey(logRecord1), keyFields.logKey(logRecord2))
+		require.NotEqual(t, withBody.logKey(logRecord1), withBody.logKey(logRecord2))
+	})
+
+	t.Run("missing attribute differs from an empty one", func(t *testing.T) {
+		logRecord1 := newRecord("message", "", "host-1")
+		logRecord2 := plog.NewLogRecord()
+		logRecord2.Body().SetStr("message")
+		require.NotEqual(t, keyFields.logKey(logRecord1), keyFields.logKey(logRecord2))
+	})
+
+	t.Run("aggregator collapses records differing only in ignored attributes", func(t *testing.T) {
+		telemetryBuilder, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
+		require.NoError(t, err)
+		aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, keyFields: keyFields, interval: defaultInterval, dedupScope: dedupScopeScope}, telemetryBuilder, zap.NewNop())
+
+		resource := pcommon.NewResource()
+		scope := pcommon.NewInstrumentationScope()
+		aggregator.Add(resource, scope, newRecord("message", "api", "host-1"))
+		aggregator.Add(resource, scope, newRecord("message", "api", "host-2"))
+		aggregator.Add(resource, scope, newRecord("message", "db", "host-1"))
+
+		logs := aggregator.Export(t.Context())
+		records := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+		require.Equal(t, 2, records.Len())
+		counts := map[string]int64{}
+		for i := 0; i < records.Len(); i++ {
+			service, _ := records.At(i).Attributes().Get("service.name")
+			count, _ := records.At(i).Attributes().Get(defaultLogCountAttribute)
+			counts[service.Str()] = count.Int()
+		}
+		require.Equal(t, map[string]int64{"api": 2, "db": 1}, counts)
+	})
+}
+
+func Test_logKeyFields_keepExcludedFields(t *testing.T) {
+	newRecord := func(body, requestID string) plog.LogRecord {
+		logRecord := plog.NewLogRecord()
+		logRecord.Body().SetStr(body)
+		logRecord.Attributes().PutStr("service.name", "api")
+		logRecord.Attributes().PutStr("request.id", requestID)
+		return logRecord
+	}
+	keyFields := logKeyFields{excludeFields: newFieldRemover([]string{`attributes.request\.id`})}
+	withoutBody := logKeyFields{excludeFields: newFieldRemover([]string{bodyField, `attributes.request\.id`})}
+
+	t.Run("records differing only in an excluded attribute match", func(t *testing.T) {
+		logRecord1 := newRecord("connection reset", "req-1")
+		logRecord2 := newRecord("connection reset", "req-2")
+		require.Equal(t, keyFields.logKey(logRecord1), keyFields.logKey(logRecord2))
+		require.NotEqual(t, keyFields.logKey(logRecord1), keyFields.logKey(newRecord("connection refused", "req-1")))
+
+		// Excluded fields are only ignored to compute the key
+		requestID, ok := logRecord1.Attributes().Get("request.id")
+		require.True(t, ok)
+		require.Equal(t, "req-1", requestID.Str())
+	})
+
+	t.Run("excluded body is ignored", func(t *testing.T) {
+		logRecord1 := newRecord("connection reset", "req-1")
+		logRecord2 := newRecord("connection refused", "req-2")
+		require.Equal(t, withoutBody.logKey(logRecord1), withoutBody.logKey(logRecord2))
+		require.Equal(t, "connection reset", logRecord1.Body().Str())
+	})
+
+	t.Run("aggregator collapses records differing only in excluded attributes", func(t *testing.T) {
+		telemetryBuilder, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
+		require.NoError(t, err)
+		aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, keyFields: keyFields, interval: defaultInterval, dedupScope: dedupScopeScope}, telemetryBuilder, zap.NewNop())
+
+		resource := pcommon.NewResource()
+		scope := pcommon.NewInstrumentationScope()
+		aggregator.Add(resource, scope, newRecord("connection reset", "req-1"))
+		aggregator.Add(resource, scope, newRecord("connection reset", "req-2"))
+
+		logs := aggregator.Export(t.Context())
+		records := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+		require.Equal(t, 1, records.Len())
+		count, _ := records.At(0).Attributes().Get(defaultLogCountAttribute)
+		require.Equal(t, int64(2), count.Int())
+
+		// The aggregated log carries the excluded attribute of its first occurrence
+		requestID, ok := records.At(0).Attributes().Get("request.id")
+		require.True(t, ok)
+		require.Equal(t, "req-1", requestID.Str())
+	})
+}
+
+func Test_logKeyFields_bodyAndSeverity(t *testing.T) {
+	newRecord := func(body, requestID string, severity plog.SeverityNumber) plog.LogRecord {
+		logRecord := plog.NewLogRecord()
+		logRecord.Body().SetStr(body)
+		logRecord.SetSeverityNumber(severity)
+		logRecord.SetSeverityText(severity.String())
+		logRecord.Attributes().PutStr("service.name", "api")
+		logRecord.Attributes().PutStr("request.id", requestID)
+		return logRecord
+	}
+	bodyOnly := logKeyFields{includeBody: true}
+	withSeverity := logKeyFields{dedupFields: []string{"service.name"}, includeSeverity: true}
+
+	t.Run("body only ignores attributes and severity", func(t *testing.T) {
+		logRecord1 := newRecord("connection reset", "req-1", plog.SeverityNumberWarn)
+		logRecord2 := newRecord("connection reset", "req-2", plog.SeverityNumberError)
+		require.Equal(t, bodyOnly.logKey(logRecord1), bodyOnly.logKey(logRecord2))
+		require.NotEqual(t, bodyOnly.logKey(logRecord1), bodyOnly.logKey(newRecord("connection refused", "req-1", plog.SeverityNumberWarn)))
+	})
+
+	t.Run("attribute subset with severity ignores the body and other attributes", func(t *testing.T) {
+		logRecord1 := newRecord("connection reset", "req-1", plog.SeverityNumberWarn)
+		logRecord2 := newRecord("connection refused", "req-2", plog.SeverityNumberWarn)
+		require.Equal(t, withSeverity.logKey(logRecord1), withSeverity.logKey(logRecord2))
+		require.NotEqual(t, withSeverity.logKey(logRecord1), withSeverity.logKey(newRecord("connection reset", "req-1", plog.SeverityNumberError)))
+	})
+
+	t.Run("severity only", func(t *testing.T) {
+		severityOnly := logKeyFields{includeSeverity: true}
+		logRecord1 := newRecord("connection reset", "req-1", plog.SeverityNumberWarn)
+		logRecord2 := newRecord("disk full", "req-2", plog.SeverityNumberWarn)
+		require.Equal(t, severityOnly.logKey(logRecord1), severityOnly.logKey(logRecord2))
+	})
+}
+
+func Test_logKeyFields_excludedFields(t *testing.T) {
+	cfg := createDefaultConfig().(*Config)
+	cfg.FirstObservedTimestampAttribute = "first_seen"
+	cfg.LastObservedTimestampAttribute = "last_seen"
+	cfg.EmissionAttributes.Enabled = true
+	cfg.EmitSuppressionSummary = true
+	processorAttributes := cfg.processorAttributes()
+
+	newRecord := func() plog.LogRecord {
+		logRecord := plog.NewLogRecord()
+		logRecord.Body().SetStr("connection reset")
+		logRecord.SetSeverityNumber(plog.SeverityNumberWarn)
+		logRecord.Attributes().PutStr("service.name", "api")
+		logRecord.SetTimestamp(pcommon.NewTimestampFromTime(time.Unix(100, 0)))
+		logRecord.SetObservedTimestamp(pcommon.NewTimestampFromTime(time.Unix(101, 0)))
+		return logRecord
+	}
+
+	// Each mutation changes a field which must never identify duplicates
+	mutations := map[string]func(plog.LogRecord){
+		"timestamp": func(logRecord plog.LogRecord) {
+			logRecord.SetTimestamp(pcommon.NewTimestampFromTime(time.Unix(200, 0)))
+		},
+		"observed timestamp": func(logRecord plog.LogRecord) {
+			logRecord.SetObservedTimestamp(pcommon.NewTimestampFromTime(time.Unix(201, 0)))
+		},
+	}
+	for _, name := range processorAttributes {
+		mutations["attribute "+name] = func(logRecord plog.LogRecord) {
+			logRecord.Attributes().PutInt(name, 42)
+		}
+	}
+
+	modes := map[string]logKeyFields{
+		"whole record":   {processorAttributes: processorAttributes},
+		"include_fields": {processorAttributes: processorAttributes, includeFields: []string{"attributes.service\\.name"}},
+		"dedup_fields":   {processorAttributes: processorAttributes, dedupFields: []string{"service.name"}, includeBody: true, includeSeverity: true},
+	}
+	for modeName, keyFields := range modes {
+		for mutationName, mutate := range mutations {
+			t.Run(modeName+"/"+mutationName, func(t *testing.T) {
+				logRecord := newRecord()
+				expected := keyFields.logKey(logRecord)
+				mutate(logRecord)
+				require.Equal(t, expected, keyFields.logKey(logRecord))
+			})
+		}
+	}
+
+	t.Run("processor attributes are kept on the log record", func(t *testing.T) {
+		logRecord := newRecord()
+		logRecord.Attributes().PutInt(defaultLogCountAttribute, 3)
+		modes["whole record"].logKey(logRecord)
+		_, ok := logRecord.Attributes().Get(defaultLogCountAttribute)
+		require.True(t, ok)
+	})
+}
+
+func Test_logKeyFields_includeTraceID(t *testing.T) {
+	newRecord := func(traceID pcommon.TraceID) plog.LogRecord {
+		logRecord := plog.NewLogRecord()
+		logRecord.Body().SetStr("request failed")
+		logRecord.SetTraceID(traceID)
+		return logRecord
+	}
+	traceID1 := pcommon.TraceID([16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	traceID2 := pcommon.TraceID([16]byte{16, 15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1})
+	withoutTraceID := logKeyFields{}
+	withTraceID := logKeyFields{includeTraceID: true}
+	withDedupFields := logKeyFields{dedupFields: []string{"service.name"}, includeBody: true, includeTraceID: true}
+
+	t.Run("records with matching trace IDs match", func(t *testing.T) {
+		require.Equal(t, withTraceID.logKey(newRecord(traceID1)), withTraceID.logKey(newRecord(traceID1)))
+		require.Equal(t, withDedupFields.logKey(newRecord(traceID1)), withDedupFields.logKey(newRecord(traceID1)))
+	})
+
+	t.Run("records with differing trace IDs do not match", func(t *testing.T) {
+		require.NotEqual(t, withTraceID.logKey(newRecord(traceID1)), withTraceID.logKey(newRecord(traceID2)))
+		require.NotEqual(t, withDedupFields.logKey(newRecord(traceID1)), withDedupFields.logKey(newRecord(traceID2)))
+		require.Equal(t, withoutTraceID.logKey(newRecord(traceID1)), withoutTraceID.logKey(newRecord(traceID2)))
+	})
+
+	t.Run("records without trace ID match each other only", func(t *testing.T) {
+		absent := plog.NewLogRecord()
+		absent.Body().SetStr("request failed")
+		zero := newRecord(pcommon.NewTraceIDEmpty())
+		require.Equal(t, withTraceID.logKey(absent), withTraceID.logKey(zero))
+		require.Equal(t, withoutTraceID.logKey(absent), withTraceID.logKey(absent))
+		require.NotEqual(t, withTraceID.logKey(absent), withTraceID.logKey(newRecord(traceID1)))
+	})
+
+	t.Run("aggregator collapses records of the same trace", func(t *testing.T) {
+		telemetryBuilder, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
+		require.NoError(t, err)
+		aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, keyFields: withTraceID, interval: defaultInterval, dedupScope: dedupScopeScope}, telemetryBuilder, zap.NewNop())
+
+		resource := pcommon.NewResource()
+		scope := pcommon.NewInstrumentationScope()
+		aggregator.Add(resource, scope, newRecord(traceID1))
+		aggregator.Add(resource, scope, newRecord(traceID1))
+		aggregator.Add(resource, scope, newRecord(traceID2))
+		aggregator.Add(resource, scope, newRecord(pcommon.NewTraceIDEmpty()))
+		aggregator.Add(resource, scope, plog.NewLogRecord())
+		aggregator.Add(resource, scope, newRecord(pcommon.NewTraceIDEmpty()))
+
+		logs := aggregator.Export(t.Context())
+		records := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+		require.Equal(t, 4, records.Len())
+		counts := map[string]int64{}
+		for i := 0; i < records.Len(); i++ {
+			count, _ := records.At(i).Attributes().Get(defaultLogCountAttribute)
+			counts[records.At(i).Body().AsString()+"/"+records.At(i).TraceID().String()] += count.Int()
+		}
+		require.Equal(t, map[string]int64{
+			"request failed/" + traceID1.String(): 2,
+			"request failed/" + traceID2.String(): 1,
+			"request failed/":                     2,
+			"/":                                   1,
+		}, counts)
+	})
+}
+
+func Test_logKeyFields_bodyPrefixLen(t *testing.T) {
+	const prefix = "connection to 10.0.0.1:5432 refused, at " // 40 characters
+	require.Len(t, prefix, 40)
+	withPrefix := logKeyFields{bodyPrefixLen: 40}
+	withDedupFields := logKeyFields{dedupFields: []string{"service.name"}, includeBody: true, bodyPrefixLen: 40}
+	newRecord := func(body string) plog.LogRecord {
+		logRecord := plog.NewLogRecord()
+		logRecord.Body().SetStr(body)
+		return logRecord
+	}
+
+	t.Run("records sharing the prefix match", func(t *testing.T) {
+		require.Equal(t, withPrefix.logKey(newRecord(prefix+"attempt 1")), withPrefix.logKey(newRecord(prefix+"attempt 2")))
+		require.Equal(t, withDedupFields.logKey(newRecord(prefix+"attempt 1")), withDedupFields.logKey(newRecord(prefix+"attempt 2")))
+		require.NotEqual(t, logKeyFields{}.logKey(newRecord(prefix+"attempt 1")), logKeyFields{}.logKey(newRecord(prefix+"attempt 2")))
+	})
+
+	t.Run("records with differing prefixes do not match", func(t *testing.T) {
+		require.NotEqual(t, withPrefix.logKey(newRecord("connection to 10.0.0.2:5432 refused, at attempt 1")), withPrefix.logKey(newRecord(prefix+"attempt 1")))
+	})
+
+	t.Run("bodies shorter than the prefix are hashed whole", func(t *testing.T) {
+		require.Equal(t, logKeyFields{}.logKey(newRecord("refused")), withPrefix.logKey(newRecord("refused")))
+		require.NotEqual(t, withPrefix.logKey(newRecord("refused")), withPrefix.logKey(newRecord("refused!")))
+	})
+
+	t.Run("prefix counts characters rather than bytes", func(t *testing.T) {
+		short := logKeyFields{bodyPrefixLen: 2}
+		require.Equal(t, short.logKey(newRecord("é€1")), short.logKey(newRecord("é€2")))
+		require.NotEqual(t, short.logKey(newRecord("é€1")), short.logKey(newRecord("éé1")))
+	})
+
+	t.Run("aggregator collapses records sharing the prefix", func(t *testing.T) {
+		telemetryBuilder, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
+		require.NoError(t, err)
+		aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, keyFields: withPrefix, interval: defaultInterval, dedupScope: dedupScopeScope}, telemetryBuilder, zap.NewNop())
+
+		resource := pcommon.NewResource()
+		scope := pcommon.NewInstrumentationScope()
+		aggregator.Add(resource, scope, newRecord(prefix+"2026-10-15T10:00:00Z"))
+		aggregator.Add(resource, scope, newRecord(prefix+"2026-10-15T10:00:01Z"))
+		aggregator.Add(resource, scope, newRecord(prefix+"2026-10-15T10:00:02Z"))
+
+		logs := aggregator.Export(t.Context())
+		records := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+		require.Equal(t, 1, records.Len())
+		require.Equal(t, prefix+"2026-10-15T10:00:00Z", records.At(0).Body().Str())
+		count, _ := records.At(0).Attributes().Get(defaultLogCountAttribute)
+		require.Equal(t, int64(3), count.Int())
+	})
+}
+
 func generateTestLogRecord(t *testing.T, body string) plog.LogRecord {
 	t.Helper()
 	logRecord := plog.NewLogRecord()
@@ -57,6 +57,12 @@ func (f *field) removeField(logRecord plog.LogRecord) {
 
 	switch firstPart {
 	case bodyField:
+		// Remove the entire body
+		if len(remainingParts) == 0 {
+			pcommon.NewValueEmpty().CopyTo(logRecord.Body())
+			return
+		}
+
 		// If body is a map then recurse through to remove the field
 		if logRecord.Body().Type() == pcommon.ValueTypeMap {
 			removeFieldFromMap(logRecord.Body().Map(), remainingParts)
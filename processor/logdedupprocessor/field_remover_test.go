@@ -98,3 +98,16 @@ func TestRemoveFields(t *testing.T) {
 	require.Equal(t, expectedAttrHash, actualAttrHash)
 	require.Equal(t, expectedBodyHash, actualBodyHash)
 }
+
+func TestRemoveFieldsEntireBody(t *testing.T) {
+	remover := newFieldRemover([]string{bodyField})
+
+	logRecord := plog.NewLogRecord()
+	logRecord.Body().SetStr("message")
+	logRecord.Attributes().PutStr("str", "attr str")
+
+	remover.RemoveFields(logRecord)
+
+	require.Equal(t, pcommon.ValueTypeEmpty, logRecord.Body().Type())
+	require.Equal(t, 1, logRecord.Attributes().Len())
+}
@@ -0,0 +1,52 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package logdedupprocessor // import "github.com/open-telemetry/opentelemetry-collector-contrib/processor/logdedupprocessor"
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash/fnv"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/pdatautil"
+)
+
+// Hash algorithms of the keys identifying duplicate logs and their resources and scopes
+const (
+	// hashAlgorithmFNV hashes keys with the 64-bit FNV-1a algorithm.
+	hashAlgorithmFNV = "fnv"
+	// hashAlgorithmXXHash hashes keys with the 64-bit xxHash algorithm.
+	hashAlgorithmXXHash = "xxhash"
+	// hashAlgorithmSHA256 hashes keys with SHA-256, truncated to 64 bits.
+	hashAlgorithmSHA256 = "sha256"
+)
+
+// hashAlgorithm is the algorithm hashing keys, hashAlgorithmFNV if empty.
+type hashAlgorithm string
+
+// hash64 returns the key of the fields added by opts.
+func (a hashAlgorithm) hash64(opts ...pdatautil.HashOption) uint64 {
+	switch a {
+	case hashAlgorithmXXHash:
+		return pdatautil.Sum64(xxhash.Sum64, opts...)
+	case hashAlgorithmSHA256:
+		return pdatautil.Sum64(sha256Sum64, opts...)
+	default:
+		return pdatautil.Sum64(fnvSum64, opts...)
+	}
+}
+
+// fnvSum64 returns the 64-bit FNV-1a hash of b.
+func fnvSum64(b []byte) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write(b)
+	return h.Sum64()
+}
+
+// sha256Sum64 returns the first 64 bits of the SHA-256 hash of b.
+func sha256Sum64(b []byte) uint64 {
+	sum := sha256.Sum256(b)
+	return binary.BigEndian.Uint64(sum[:8])
+}
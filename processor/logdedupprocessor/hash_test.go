@@ -0,0 +1,62 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package logdedupprocessor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+var hashAlgorithms = []string{hashAlgorithmFNV, hashAlgorithmXXHash, hashAlgorithmSHA256}
+
+func Test_hashAlgorithm(t *testing.T) {
+	for _, algorithm := range hashAlgorithms {
+		t.Run(algorithm, func(t *testing.T) {
+			keyFields := logKeyFields{hashAlgorithm: hashAlgorithm(algorithm)}
+			withDedupFields := logKeyFields{dedupFields: []string{"str"}, includeBody: true, hashAlgorithm: hashAlgorithm(algorithm)}
+
+			logRecord1 := generateTestLogRecord(t, "message")
+			logRecord2 := generateTestLogRecord(t, "message")
+			require.Equal(t, keyFields.logKey(logRecord1), keyFields.logKey(logRecord2))
+			require.Equal(t, withDedupFields.logKey(logRecord1), withDedupFields.logKey(logRecord2))
+
+			logRecord3 := generateTestLogRecord(t, "other message")
+			require.NotEqual(t, keyFields.logKey(logRecord1), keyFields.logKey(logRecord3))
+			require.NotEqual(t, withDedupFields.logKey(logRecord1), withDedupFields.logKey(logRecord3))
+		})
+	}
+
+	t.Run("algorithms differ", func(t *testing.T) {
+		logRecord := generateTestLogRecord(t, "message")
+		keys := map[uint64]string{}
+		for _, algorithm := range hashAlgorithms {
+			keys[getLogKey(hashAlgorithm(algorithm), logRecord, nil, 0)] = algorithm
+		}
+		require.Len(t, keys, len(hashAlgorithms))
+	})
+
+	t.Run("fnv by default", func(t *testing.T) {
+		logRecord := generateTestLogRecord(t, "message")
+		require.Equal(t, getLogKey(hashAlgorithmFNV, logRecord, nil, 0), getLogKey("", logRecord, nil, 0))
+	})
+}
+
+func Benchmark_hashAlgorithm(b *testing.B) {
+	logRecord := plog.NewLogRecord()
+	logRecord.Body().SetStr("connection refused while calling the payment service")
+	logRecord.SetSeverityNumber(plog.SeverityNumberError)
+	logRecord.Attributes().PutStr("service.name", "checkout")
+	logRecord.Attributes().PutInt("http.status_code", 503)
+	for _, algorithm := range hashAlgorithms {
+		keyFields := logKeyFields{hashAlgorithm: hashAlgorithm(algorithm)}
+		b.Run(algorithm, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				keyFields.logKey(logRecord)
+			}
+		})
+	}
+}
@@ -26,6 +26,9 @@ type TelemetryBuilder struct {
 	mu                           sync.Mutex
 	registrations                []metric.Registration
 	DedupProcessorAggregatedLogs metric.Int64Histogram
+	LogdedupAggregatesEmitted    metric.Int64Counter
+	LogdedupRecordsDropped       metric.Int64Counter
+	LogdedupRecordsIn            metric.Int64Counter
 }
 
 // TelemetryBuilderOption applies changes to default builder.
@@ -63,5 +66,23 @@ func NewTelemetryBuilder(settings component.TelemetrySettings, options ...Teleme
 		metric.WithUnit("{records}"),
 	)
 	errs = errors.Join(errs, err)
+	builder.LogdedupAggregatesEmitted, err = builder.meter.Int64Counter(
+		"otelcol_logdedup_aggregates_emitted",
+		metric.WithDescription("Number of aggregated log records emitted. [Development]"),
+		metric.WithUnit("{records}"),
+	)
+	errs = errors.Join(errs, err)
+	builder.LogdedupRecordsDropped, err = builder.meter.Int64Counter(
+		"otelcol_logdedup_records_dropped",
+		metric.WithDescription("Number of duplicate log records collapsed into aggregated log records. [Development]"),
+		metric.WithUnit("{records}"),
+	)
+	errs = errors.Join(errs, err)
+	builder.LogdedupRecordsIn, err = builder.meter.Int64Counter(
+		"otelcol_logdedup_records_in",
+		metric.WithDescription("Number of log records received for deduplication. [Development]"),
+		metric.WithUnit("{records}"),
+	)
+	errs = errors.Join(errs, err)
 	return &builder, errs
 }
@@ -20,9 +20,21 @@ func TestSetupTelemetry(t *testing.T) {
 	require.NoError(t, err)
 	defer tb.Shutdown()
 	tb.DedupProcessorAggregatedLogs.Record(context.Background(), 1)
+	tb.LogdedupAggregatesEmitted.Add(context.Background(), 1)
+	tb.LogdedupRecordsDropped.Add(context.Background(), 1)
+	tb.LogdedupRecordsIn.Add(context.Background(), 1)
 	AssertEqualDedupProcessorAggregatedLogs(t, testTel,
 		[]metricdata.HistogramDataPoint[int64]{{}}, metricdatatest.IgnoreValue(),
 		metricdatatest.IgnoreTimestamp())
+	AssertEqualLogdedupAggregatesEmitted(t, testTel,
+		[]metricdata.DataPoint[int64]{{Value: 1}},
+		metricdatatest.IgnoreTimestamp())
+	AssertEqualLogdedupRecordsDropped(t, testTel,
+		[]metricdata.DataPoint[int64]{{Value: 1}},
+		metricdatatest.IgnoreTimestamp())
+	AssertEqualLogdedupRecordsIn(t, testTel,
+		[]metricdata.DataPoint[int64]{{Value: 1}},
+		metricdatatest.IgnoreTimestamp())
 
 	require.NoError(t, testTel.Shutdown(context.Background()))
 }
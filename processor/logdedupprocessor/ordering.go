@@ -0,0 +1,120 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package logdedupprocessor // import "github.com/open-telemetry/opentelemetry-collector-contrib/processor/logdedupprocessor"
+
+import (
+	"sort"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// heldLogs holds pass-through logs until the next flush, when they are exported along with the aggregated logs.
+type heldLogs struct {
+	logs plog.Logs
+	// times are the times the held log records are ordered by, in the order of the records in logs.
+	times []pcommon.Timestamp
+}
+
+// hold moves the log records of logs to the held logs.
+func (h *heldLogs) hold(logs plog.Logs) {
+	if len(h.times) == 0 {
+		h.logs = plog.NewLogs()
+	}
+	now := pcommon.NewTimestampFromTime(timeNow())
+	forEachLogRecord(logs, func(lr plog.LogRecord) {
+		h.times = append(h.times, recordTime(lr, now))
+	})
+	logs.ResourceLogs().MoveAndAppendTo(h.logs.ResourceLogs())
+}
+
+// release returns the held log records along with the aggregated ones, ordered by time, and empties the held logs.
+// Aggregated log records are ordered by their timestamp, which is within the window they aggregate.
+// Aggregated logs are returned as-is when no log record is held.
+func (h *heldLogs) release(aggregated plog.Logs) plog.Logs {
+	if len(h.times) == 0 {
+		return aggregated
+	}
+
+	times := h.times
+	forEachLogRecord(aggregated, func(lr plog.LogRecord) {
+		times = append(times, lr.Timestamp())
+	})
+	aggregated.ResourceLogs().MoveAndAppendTo(h.logs.ResourceLogs())
+
+	logs := orderByTime(h.logs, times)
+	h.logs, h.times = plog.Logs{}, nil
+	return logs
+}
+
+// recordTime returns the time a pass-through log record is ordered by: its timestamp, or its observed timestamp
+// if unset, or else the time it was received.
+func recordTime(lr plog.LogRecord, received pcommon.Timestamp) pcommon.Timestamp {
+	if ts := lr.Timestamp(); ts != 0 {
+		return ts
+	}
+	if ts := lr.ObservedTimestamp(); ts != 0 {
+		return ts
+	}
+	return received
+}
+
+// orderByTime moves the log records of logs to new logs, ordered by times, which are in the order of the records.
+// Records with the same time keep their relative order. Consecutive records of the same resource and scope
+// are grouped, so that resources and scopes are only repeated when records of different ones are interleaved.
+func orderByTime(logs plog.Logs, times []pcommon.Timestamp) plog.Logs {
+	type position struct {
+		resource, scope, record int
+		time                    pcommon.Timestamp
+	}
+	positions := make([]position, 0, len(times))
+	for i := 0; i < logs.ResourceLogs().Len(); i++ {
+		rl := logs.ResourceLogs().At(i)
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			sl := rl.ScopeLogs().At(j)
+			for k := 0; k < sl.LogRecords().Len(); k++ {
+				positions = append(positions, position{resource: i, scope: j, record: k, time: times[len(positions)]})
+			}
+		}
+	}
+	sort.SliceStable(positions, func(a, b int) bool {
+		return positions[a].time < positions[b].time
+	})
+
+	ordered := plog.NewLogs()
+	var destRL plog.ResourceLogs
+	var destSL plog.ScopeLogs
+	lastResource, lastScope := -1, -1
+	for _, pos := range positions {
+		rl := logs.ResourceLogs().At(pos.resource)
+		sl := rl.ScopeLogs().At(pos.scope)
+		if pos.resource != lastResource {
+			destRL = ordered.ResourceLogs().AppendEmpty()
+			rl.Resource().CopyTo(destRL.Resource())
+			destRL.SetSchemaUrl(rl.SchemaUrl())
+			lastScope = -1
+		}
+		if pos.scope != lastScope {
+			destSL = destRL.ScopeLogs().AppendEmpty()
+			sl.Scope().CopyTo(destSL.Scope())
+			destSL.SetSchemaUrl(sl.SchemaUrl())
+		}
+		lastResource, lastScope = pos.resource, pos.scope
+		sl.LogRecords().At(pos.record).MoveTo(destSL.LogRecords().AppendEmpty())
+	}
+	return ordered
+}
+
+// forEachLogRecord calls f for each log record of logs.
+func forEachLogRecord(logs plog.Logs, f func(plog.LogRecord)) {
+	for i := 0; i < logs.ResourceLogs().Len(); i++ {
+		rl := logs.ResourceLogs().At(i)
+		for j := 0; j < rl.ScopeLogs().Len(); j++ {
+			sl := rl.ScopeLogs().At(j)
+			for k := 0; k < sl.LogRecords().Len(); k++ {
+				f(sl.LogRecords().At(k))
+			}
+		}
+	}
+}
@@ -31,13 +31,19 @@ import (
 // single bucket or as multiple buckets keyed by metadata combination.
 type shardedAggregator interface {
 	add(ctx context.Context, logRecord plog.LogRecord, scope pcommon.InstrumentationScope, resource pcommon.Resource) error
-	flush(ctx context.Context, nextConsumer consumer.Logs, logger *zap.Logger)
+	// hold holds pass-through logs until the next flush, which exports them along with the aggregated logs.
+	hold(ctx context.Context, logs plog.Logs) error
+	// flush exports the aggregated logs due for export, or all of them if force is set, along with the held logs.
+	flush(ctx context.Context, nextConsumer consumer.Logs, logger *zap.Logger, force bool)
+	// emit exports the aggregated logs taken by take, emitted before their interval elapsed, without the held logs.
+	emit(ctx context.Context, nextConsumer consumer.Logs, logger *zap.Logger, take func(*logAggregator, context.Context) plog.Logs)
 }
 
 // singleShardAggregator is used when no metadata_keys are configured.
 // It wraps a single logAggregator with no runtime overhead compared to the original behavior.
 type singleShardAggregator struct {
 	aggregator *logAggregator
+	held       heldLogs
 }
 
 func (s *singleShardAggregator) add(_ context.Context, logRecord plog.LogRecord, scope pcommon.InstrumentationScope, resource pcommon.Resource) error {
@@ -45,19 +51,33 @@ func (s *singleShardAggregator) add(_ context.Context, logRecord plog.LogRecord,
 	return nil
 }
 
-func (s *singleShardAggregator) flush(ctx context.Context, nextConsumer consumer.Logs, logger *zap.Logger) {
-	logs := s.aggregator.Export(ctx)
+func (s *singleShardAggregator) hold(_ context.Context, logs plog.Logs) error {
+	s.held.hold(logs)
+	return nil
+}
+
+func (s *singleShardAggregator) flush(ctx context.Context, nextConsumer consumer.Logs, logger *zap.Logger, force bool) {
+	logs := s.held.release(s.aggregator.Take(ctx, force))
+	if logs.LogRecordCount() > 0 {
+		if err := nextConsumer.ConsumeLogs(ctx, logs); err != nil {
+			logger.Error("failed to consume logs", zap.Error(err))
+		}
+	}
+}
+
+func (s *singleShardAggregator) emit(ctx context.Context, nextConsumer consumer.Logs, logger *zap.Logger, take func(*logAggregator, context.Context) plog.Logs) {
+	logs := take(s.aggregator, ctx)
 	if logs.LogRecordCount() > 0 {
 		if err := nextConsumer.ConsumeLogs(ctx, logs); err != nil {
 			logger.Error("failed to consume logs", zap.Error(err))
 		}
-		s.aggregator.Reset()
 	}
 }
 
 // aggregatorShard holds a logAggregator and the client metadata for one metadata combination.
 type aggregatorShard struct {
 	aggregator *logAggregator
+	held       heldLogs
 	clientInfo client.Info
 }
 
@@ -68,10 +88,9 @@ type multiShardAggregator struct {
 	metadataCardinalityLimit int
 
 	// Fields below are passed through to newLogAggregator for on-demand shard creation.
-	logCountAttribute string
-	timezone          *time.Location
-	telemetryBuilder  *metadata.TelemetryBuilder
-	includeFields     []string
+	aggregatorOpts   logAggregatorOptions
+	telemetryBuilder *metadata.TelemetryBuilder
+	logger           *zap.Logger
 
 	shards map[attribute.Set]*aggregatorShard
 	// lock protects the shards map during concurrent lookups and creation.
@@ -79,6 +98,27 @@ type multiShardAggregator struct {
 }
 
 func (m *multiShardAggregator) add(ctx context.Context, logRecord plog.LogRecord, scope pcommon.InstrumentationScope, resource pcommon.Resource) error {
+	shard, err := m.shardFor(ctx)
+	if err != nil {
+		return err
+	}
+
+	shard.aggregator.Add(resource, scope, logRecord)
+	return nil
+}
+
+func (m *multiShardAggregator) hold(ctx context.Context, logs plog.Logs) error {
+	shard, err := m.shardFor(ctx)
+	if err != nil {
+		return err
+	}
+
+	shard.held.hold(logs)
+	return nil
+}
+
+// shardFor returns the shard of the metadata combination of the client in ctx, creating it if needed.
+func (m *multiShardAggregator) shardFor(ctx context.Context) (*aggregatorShard, error) {
 	info := client.FromContext(ctx)
 	attrs := make([]attribute.KeyValue, 0, len(m.metadataKeys))
 	for _, k := range m.metadataKeys {
@@ -89,15 +129,7 @@ func (m *multiShardAggregator) add(ctx context.Context, logRecord plog.LogRecord
 			attrs = append(attrs, attribute.StringSlice(k, vs))
 		}
 	}
-	aset := attribute.NewSet(attrs...)
-
-	shard, err := m.getOrCreateShard(info, aset)
-	if err != nil {
-		return err
-	}
-
-	shard.aggregator.Add(resource, scope, logRecord)
-	return nil
+	return m.getOrCreateShard(info, attribute.NewSet(attrs...))
 }
 
 func (m *multiShardAggregator) getOrCreateShard(info client.Info, aset attribute.Set) (*aggregatorShard, error) {
@@ -118,7 +150,7 @@ func (m *multiShardAggregator) getOrCreateShard(info client.Info, aset attribute
 		md[k] = info.Metadata.Get(k)
 	}
 	shard = &aggregatorShard{
-		aggregator: newLogAggregator(m.logCountAttribute, m.timezone, m.telemetryBuilder, m.includeFields),
+		aggregator: newLogAggregator(m.aggregatorOpts, m.telemetryBuilder, m.logger),
 		clientInfo: client.Info{
 			Metadata: client.NewMetadata(md),
 		},
@@ -127,37 +159,59 @@ func (m *multiShardAggregator) getOrCreateShard(info client.Info, aset attribute
 	return shard, nil
 }
 
-func (m *multiShardAggregator) flush(_ context.Context, nextConsumer consumer.Logs, logger *zap.Logger) {
-	m.lock.Lock()
-	shards := make([]*aggregatorShard, 0, len(m.shards))
-	for _, s := range m.shards {
-		shards = append(shards, s)
+func (m *multiShardAggregator) flush(_ context.Context, nextConsumer consumer.Logs, logger *zap.Logger, force bool) {
+	for _, shard := range m.allShards() {
+		exportCtx := client.NewContext(context.Background(), shard.clientInfo)
+		logs := shard.held.release(shard.aggregator.Take(exportCtx, force))
+		if logs.LogRecordCount() > 0 {
+			if err := nextConsumer.ConsumeLogs(exportCtx, logs); err != nil {
+				logger.Error("failed to consume logs", zap.Error(err))
+			}
+		}
 	}
-	m.lock.Unlock()
+}
 
-	for _, shard := range shards {
+func (m *multiShardAggregator) emit(_ context.Context, nextConsumer consumer.Logs, logger *zap.Logger, take func(*logAggregator, context.Context) plog.Logs) {
+	for _, shard := range m.allShards() {
 		exportCtx := client.NewContext(context.Background(), shard.clientInfo)
-		logs := shard.aggregator.Export(exportCtx)
+		logs := take(shard.aggregator, exportCtx)
 		if logs.LogRecordCount() > 0 {
 			if err := nextConsumer.ConsumeLogs(exportCtx, logs); err != nil {
 				logger.Error("failed to consume logs", zap.Error(err))
 			}
-			shard.aggregator.Reset()
 		}
 	}
 }
 
+// allShards returns a snapshot of the shards, so that they are exported without holding the lock.
+func (m *multiShardAggregator) allShards() []*aggregatorShard {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	shards := make([]*aggregatorShard, 0, len(m.shards))
+	for _, s := range m.shards {
+		shards = append(shards, s)
+	}
+	return shards
+}
+
 // logDedupProcessor is a logDedupProcessor that counts duplicate instances of logs.
 type logDedupProcessor struct {
-	emitInterval time.Duration
-	conditions   *ottl.ConditionSequence[*ottllog.TransformContext]
-	aggregator   shardedAggregator
-	remover      *fieldRemover
-	nextConsumer consumer.Logs
-	logger       *zap.Logger
-	cancel       context.CancelFunc
-	wg           sync.WaitGroup
-	mux          sync.Mutex
+	emitInterval     time.Duration
+	conditions       *ottl.ConditionSequence[*ottllog.TransformContext]
+	aggregator       shardedAggregator
+	remover          *fieldRemover
+	delayPassthrough bool
+	// emitEarly exports aggregated logs over the count threshold or max aggregates as soon as they are consumed.
+	emitEarly bool
+	// maxHold is the time after which aggregated logs are exported before their interval elapsed, 0 disables it.
+	maxHold          time.Duration
+	telemetryBuilder *metadata.TelemetryBuilder
+	nextConsumer     consumer.Logs
+	logger           *zap.Logger
+	cancel           context.CancelFunc
+	wg               sync.WaitGroup
+	mux              sync.Mutex
 }
 
 func newProcessor(cfg *Config, nextConsumer consumer.Logs, settings processor.Settings) (*logDedupProcessor, error) {
@@ -181,10 +235,73 @@ func newProcessor(cfg *Config, nextConsumer consumer.Logs, settings processor.Se
 	}
 	sort.Strings(metadataKeys)
 
+	// This should not happen due to config validation but we check anyways.
+	severityIntervals, err := newSeverityIntervals(cfg.IntervalBySeverity)
+	if err != nil {
+		return nil, fmt.Errorf("invalid interval_by_severity: %w", err)
+	}
+	emitInterval := cfg.Interval
+	if severityIntervals != nil {
+		// Log counters are checked for expiry at the shortest interval.
+		emitInterval = severityIntervals.shortest(cfg.Interval)
+	}
+
+	// Excluded fields are removed from the logs, unless kept, in which case they are only ignored to identify duplicates.
+	remover := newFieldRemover(cfg.ExcludeFields)
+	var excludeFields *fieldRemover
+	if cfg.KeepExcludedFields {
+		remover, excludeFields = newFieldRemover(nil), remover
+	}
+
+	keyFields := logKeyFields{
+		includeFields:       cfg.IncludeFields,
+		processorAttributes: cfg.processorAttributes(),
+		excludeFields:       excludeFields,
+		dedupFields:         cfg.DedupFields,
+		includeBody:         cfg.IncludeBody,
+		includeSeverity:     cfg.IncludeSeverity,
+		includeTraceID:      cfg.IncludeTraceID,
+		hashAlgorithm:       hashAlgorithm(cfg.HashAlgorithm),
+		bodyPrefixLen:       cfg.BodyKeyPrefixLen,
+	}
+
+	// This should not happen due to config validation but we check anyways.
+	aggregations, err := newAttributeAggregations(cfg.AggregateAttributes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid aggregate_attributes: %w", err)
+	}
+
+	aggregatorOpts := logAggregatorOptions{
+		logCountAttribute: cfg.LogCountAttribute,
+		countAsString:     cfg.CountAsString,
+		timezone:          timezone,
+		keyFields:         keyFields,
+		interval:          cfg.Interval,
+		severityIntervals: severityIntervals,
+		emitSummary:       cfg.EmitSuppressionSummary,
+		timestampAttrs: timestampAttributes{
+			firstObserved: cfg.FirstObservedTimestampAttribute,
+			lastObserved:  cfg.LastObservedTimestampAttribute,
+			fromRecords:   cfg.ObservedTimestampSource == timestampSourceRecord,
+		},
+		aggregations: aggregations,
+		dedupScope:   cfg.Scope,
+		emissionAttrs: emissionAttributes{
+			enabled:     cfg.EmissionAttributes.Enabled,
+			windowStart: cfg.EmissionAttributes.WindowStart,
+			windowEnd:   cfg.EmissionAttributes.WindowEnd,
+			reason:      cfg.EmissionAttributes.Reason,
+		},
+		snapshotThreshold: cfg.SnapshotThreshold,
+		countThreshold:    cfg.EmitCountThreshold,
+		maxHold:           cfg.MaxHold,
+		maxAggregates:     cfg.MaxAggregates,
+	}
+
 	var agg shardedAggregator
 	if len(metadataKeys) == 0 {
 		agg = &singleShardAggregator{
-			aggregator: newLogAggregator(cfg.LogCountAttribute, timezone, telemetryBuilder, cfg.IncludeFields),
+			aggregator: newLogAggregator(aggregatorOpts, telemetryBuilder, settings.Logger),
 		}
 	} else {
 		if cfg.MetadataCardinalityLimit == 0 {
@@ -195,20 +312,23 @@ func newProcessor(cfg *Config, nextConsumer consumer.Logs, settings processor.Se
 		agg = &multiShardAggregator{
 			metadataKeys:             metadataKeys,
 			metadataCardinalityLimit: int(cfg.MetadataCardinalityLimit),
-			logCountAttribute:        cfg.LogCountAttribute,
-			timezone:                 timezone,
+			aggregatorOpts:           aggregatorOpts,
 			telemetryBuilder:         telemetryBuilder,
-			includeFields:            cfg.IncludeFields,
+			logger:                   settings.Logger,
 			shards:                   make(map[attribute.Set]*aggregatorShard),
 		}
 	}
 
 	return &logDedupProcessor{
-		emitInterval: cfg.Interval,
-		aggregator:   agg,
-		remover:      newFieldRemover(cfg.ExcludeFields),
-		nextConsumer: nextConsumer,
-		logger:       settings.Logger,
+		emitInterval:     emitInterval,
+		aggregator:       agg,
+		remover:          remover,
+		delayPassthrough: cfg.DelayPassthroughUntilFlush,
+		emitEarly:        cfg.EmitCountThreshold > 0 || cfg.MaxAggregates > 0,
+		maxHold:          cfg.MaxHold,
+		telemetryBuilder: telemetryBuilder,
+		nextConsumer:     nextConsumer,
+		logger:           settings.Logger,
 	}, nil
 }
 
@@ -244,6 +364,7 @@ func (p *logDedupProcessor) ConsumeLogs(ctx context.Context, pl plog.Logs) error
 	defer p.mux.Unlock()
 
 	var aggregateErr error
+	var aggregated int64
 	pl.ResourceLogs().RemoveIf(func(rl plog.ResourceLogs) bool {
 		resource := rl.Resource()
 
@@ -257,6 +378,7 @@ func (p *logDedupProcessor) ConsumeLogs(ctx context.Context, pl plog.Logs) error
 						aggregateErr = err
 						return false
 					}
+					aggregated++
 					return true
 				}
 
@@ -274,15 +396,31 @@ func (p *logDedupProcessor) ConsumeLogs(ctx context.Context, pl plog.Logs) error
 					aggregateErr = err
 					return false
 				}
+				aggregated++
 				return true
 			})
 			return sl.LogRecords().Len() == 0
 		})
 		return rl.ScopeLogs().Len() == 0
 	})
+	if aggregated > 0 {
+		p.telemetryBuilder.LogdedupRecordsIn.Add(ctx, aggregated)
+		if p.emitEarly {
+			p.aggregator.emit(ctx, p.nextConsumer, p.logger, (*logAggregator).TakeEarly)
+		}
+	}
 
-	// immediately consume any logs that didn't match any conditions
+	// immediately consume any logs that didn't match any conditions, in their original order,
+	// unless they are held to be exported along with the aggregated logs.
 	if pl.LogRecordCount() > 0 {
+		if p.delayPassthrough {
+			err := p.aggregator.hold(ctx, pl)
+			if err == nil {
+				return aggregateErr
+			}
+			// The logs could not be held, e.g. over the metadata cardinality limit: do not drop them.
+			p.logger.Debug("failed to hold pass-through logs, consuming them immediately", zap.Error(err))
+		}
 		err := p.nextConsumer.ConsumeLogs(ctx, pl)
 		if err != nil {
 			p.logger.Error("failed to consume logs", zap.Error(err))
@@ -304,25 +442,43 @@ func (p *logDedupProcessor) handleExportInterval(ctx context.Context) {
 	ticker := time.NewTicker(p.emitInterval)
 	defer ticker.Stop()
 
+	// Aggregated logs are checked for max hold at a quarter of it, so that they are held at most a quarter longer.
+	var holdTicks <-chan time.Time
+	if p.maxHold > 0 {
+		holdTicker := time.NewTicker(max(p.maxHold/4, 1))
+		defer holdTicker.Stop()
+		holdTicks = holdTicker.C
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			// Export any remaining logs
-			p.exportLogs(ctx)
+			p.exportLogs(ctx, true)
 			if err := ctx.Err(); err != context.Canceled {
 				p.logger.Error("context error", zap.Error(err))
 			}
 			return
 		case <-ticker.C:
-			p.exportLogs(ctx)
+			p.exportLogs(ctx, false)
+		case <-holdTicks:
+			p.exportHeldLogs(ctx)
 		}
 	}
 }
 
-// exportLogs exports the logs to the next consumer.
-func (p *logDedupProcessor) exportLogs(ctx context.Context) {
+// exportLogs exports the logs due for export, or all of them if force is set, to the next consumer.
+func (p *logDedupProcessor) exportLogs(ctx context.Context, force bool) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	p.aggregator.flush(ctx, p.nextConsumer, p.logger, force)
+}
+
+// exportHeldLogs exports the aggregated logs held for the max hold, before their interval elapsed.
+func (p *logDedupProcessor) exportHeldLogs(ctx context.Context) {
 	p.mux.Lock()
 	defer p.mux.Unlock()
 
-	p.aggregator.flush(ctx, p.nextConsumer, p.logger)
+	p.aggregator.emit(ctx, p.nextConsumer, p.logger, (*logAggregator).TakeHeld)
 }
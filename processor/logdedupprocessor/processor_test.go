@@ -19,8 +19,11 @@ import (
 	"go.opentelemetry.io/collector/consumer"
 	"go.opentelemetry.io/collector/consumer/consumererror"
 	"go.opentelemetry.io/collector/consumer/consumertest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/plog"
 	"go.opentelemetry.io/collector/processor/processortest"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata/metricdatatest"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"go.uber.org/zap/zaptest/observer"
@@ -28,6 +31,7 @@ import (
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/golden"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/pdatatest/plogtest"
 	"github.com/open-telemetry/opentelemetry-collector-contrib/processor/logdedupprocessor/internal/metadata"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/processor/logdedupprocessor/internal/metadatatest"
 )
 
 func Test_newProcessor(t *testing.T) {
@@ -217,6 +221,183 @@ func Test_unsetLogsAreExportedOnShutdown(t *testing.T) {
 	require.Len(t, exportedLogs, 1)
 }
 
+func TestProcessorIntervalBySeverity(t *testing.T) {
+	logsSink := &consumertest.LogsSink{}
+	cfg := &Config{
+		LogCountAttribute:  defaultLogCountAttribute,
+		Interval:           time.Hour,
+		Timezone:           defaultTimezone,
+		Conditions:         []string{},
+		IntervalBySeverity: map[string]time.Duration{"error-fatal": 100 * time.Millisecond},
+	}
+
+	p, err := createLogsProcessor(t.Context(), processortest.NewNopSettings(metadata.Type), cfg, logsSink)
+	require.NoError(t, err)
+	err = p.Start(t.Context(), componenttest.NewNopHost())
+	require.NoError(t, err)
+
+	logs := plog.NewLogs()
+	records := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	errorRecord := records.AppendEmpty()
+	errorRecord.Body().SetStr("failure")
+	errorRecord.SetSeverityNumber(plog.SeverityNumberError)
+	infoRecord := records.AppendEmpty()
+	infoRecord.Body().SetStr("progress")
+	infoRecord.SetSeverityNumber(plog.SeverityNumberInfo)
+
+	err = p.ConsumeLogs(t.Context(), logs)
+	require.NoError(t, err)
+
+	// The ERROR-keyed aggregate is exported long before the INFO-keyed one
+	require.Eventually(t, func() bool {
+		return logsSink.LogRecordCount() > 0
+	}, 3*time.Second, 50*time.Millisecond)
+	allSinkLogs := logsSink.AllLogs()
+	require.Len(t, allSinkLogs, 1)
+	require.Equal(t, 1, allSinkLogs[0].LogRecordCount())
+	require.Equal(t, "failure", allSinkLogs[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+
+	// The INFO-keyed aggregate is exported on shutdown
+	err = p.Shutdown(t.Context())
+	require.NoError(t, err)
+	allSinkLogs = logsSink.AllLogs()
+	require.Len(t, allSinkLogs, 2)
+	require.Equal(t, "progress", allSinkLogs[1].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+}
+
+func TestProcessorEarlyEmission(t *testing.T) {
+	newLogs := func(bodies ...string) plog.Logs {
+		logs := plog.NewLogs()
+		records := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+		for _, body := range bodies {
+			records.AppendEmpty().Body().SetStr(body)
+		}
+		return logs
+	}
+	emissionReasons := func(logs plog.Logs) []string {
+		var reasons []string
+		for _, rl := range logs.ResourceLogs().All() {
+			for _, sl := range rl.ScopeLogs().All() {
+				for _, lr := range sl.LogRecords().All() {
+					reason, _ := lr.Attributes().Get(defaultEmissionReasonAttribute)
+					reasons = append(reasons, lr.Body().Str()+":"+reason.Str())
+				}
+			}
+		}
+		return reasons
+	}
+
+	t.Run("count threshold and eviction", func(t *testing.T) {
+		logsSink := &consumertest.LogsSink{}
+		cfg := createDefaultConfig().(*Config)
+		cfg.Interval = time.Hour
+		cfg.EmitCountThreshold = 3
+		cfg.MaxAggregates = 2
+		cfg.EmissionAttributes.Enabled = true
+
+		p, err := createLogsProcessor(t.Context(), processortest.NewNopSettings(metadata.Type), cfg, logsSink)
+		require.NoError(t, err)
+		require.NoError(t, p.Start(t.Context(), componenttest.NewNopHost()))
+
+		require.NoError(t, p.ConsumeLogs(t.Context(), newLogs("other")))
+		require.NoError(t, p.ConsumeLogs(t.Context(), newLogs("burst", "burst")))
+		require.Zero(t, logsSink.LogRecordCount())
+
+		// The burst reaches the threshold, and the third distinct log evicts the oldest one.
+		require.NoError(t, p.ConsumeLogs(t.Context(), newLogs("burst", "third")))
+		allSinkLogs := logsSink.AllLogs()
+		require.Len(t, allSinkLogs, 1)
+		require.ElementsMatch(t, []string{"other:" + emissionReasonEviction, "burst:" + emissionReasonCountThreshold}, emissionReasons(allSinkLogs[0]))
+
+		require.NoError(t, p.Shutdown(t.Context()))
+		allSinkLogs = logsSink.AllLogs()
+		require.Len(t, allSinkLogs, 2)
+		require.Equal(t, []string{"third:" + emissionReasonShutdown}, emissionReasons(allSinkLogs[1]))
+	})
+
+	t.Run("max hold", func(t *testing.T) {
+		logsSink := &consumertest.LogsSink{}
+		cfg := createDefaultConfig().(*Config)
+		cfg.Interval = time.Hour
+		cfg.MaxHold = 100 * time.Millisecond
+		cfg.EmissionAttributes.Enabled = true
+
+		p, err := createLogsProcessor(t.Context(), processortest.NewNopSettings(metadata.Type), cfg, logsSink)
+		require.NoError(t, err)
+		require.NoError(t, p.Start(t.Context(), componenttest.NewNopHost()))
+
+		require.NoError(t, p.ConsumeLogs(t.Context(), newLogs("held", "held")))
+		require.Eventually(t, func() bool {
+			return logsSink.LogRecordCount() > 0
+		}, 3*time.Second, 50*time.Millisecond)
+		require.NoError(t, p.Shutdown(t.Context()))
+
+		allSinkLogs := logsSink.AllLogs()
+		require.Len(t, allSinkLogs, 1)
+		require.Equal(t, []string{"held:" + emissionReasonMaxHold}, emissionReasons(allSinkLogs[0]))
+	})
+}
+
+func TestProcessorIntervalBySeverityDebugBurst(t *testing.T) {
+	logsSink := &consumertest.LogsSink{}
+	cfg := &Config{
+		LogCountAttribute: defaultLogCountAttribute,
+		Interval:          time.Minute,
+		Timezone:          defaultTimezone,
+		Conditions:        []string{},
+		IntervalBySeverity: map[string]time.Duration{
+			"debug": time.Hour,
+			"warn":  100 * time.Millisecond,
+		},
+	}
+
+	p, err := createLogsProcessor(t.Context(), processortest.NewNopSettings(metadata.Type), cfg, logsSink)
+	require.NoError(t, err)
+	err = p.Start(t.Context(), componenttest.NewNopHost())
+	require.NoError(t, err)
+
+	logs := plog.NewLogs()
+	records := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	for range 100 {
+		debugRecord := records.AppendEmpty()
+		debugRecord.Body().SetStr("cache miss")
+		debugRecord.SetSeverityNumber(plog.SeverityNumberDebug)
+	}
+	warnRecord := records.AppendEmpty()
+	warnRecord.Body().SetStr("disk almost full")
+	warnRecord.SetSeverityNumber(plog.SeverityNumberWarn)
+	infoRecord := records.AppendEmpty()
+	infoRecord.Body().SetStr("request served")
+	infoRecord.SetSeverityNumber(plog.SeverityNumberInfo)
+
+	err = p.ConsumeLogs(t.Context(), logs)
+	require.NoError(t, err)
+
+	// Only the WARN-keyed aggregate is exported promptly
+	require.Eventually(t, func() bool {
+		return logsSink.LogRecordCount() > 0
+	}, 3*time.Second, 50*time.Millisecond)
+	allSinkLogs := logsSink.AllLogs()
+	require.Len(t, allSinkLogs, 1)
+	require.Equal(t, 1, allSinkLogs[0].LogRecordCount())
+	require.Equal(t, "disk almost full", allSinkLogs[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body().Str())
+
+	// The DEBUG burst is collapsed into a single aggregate, exported on shutdown along with the INFO-keyed one
+	err = p.Shutdown(t.Context())
+	require.NoError(t, err)
+	allSinkLogs = logsSink.AllLogs()
+	require.Len(t, allSinkLogs, 2)
+	require.Equal(t, 2, allSinkLogs[1].LogRecordCount())
+	counts := map[string]int64{}
+	shutdownRecords := allSinkLogs[1].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+	for i := 0; i < shutdownRecords.Len(); i++ {
+		count, ok := shutdownRecords.At(i).Attributes().Get(defaultLogCountAttribute)
+		require.True(t, ok)
+		counts[shutdownRecords.At(i).Body().Str()] = count.Int()
+	}
+	require.Equal(t, map[string]int64{"cache miss": 100, "request served": 1}, counts)
+}
+
 func TestProcessorConsumeCondition(t *testing.T) {
 	logsSink := &consumertest.LogsSink{}
 	cfg := &Config{
@@ -267,6 +448,84 @@ func TestProcessorConsumeCondition(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestProcessorConsumeConditionSeverity(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition string
+	}{
+		{
+			name:      "severity number",
+			condition: `log.severity_number < SEVERITY_NUMBER_ERROR`,
+		},
+		{
+			// Only INFO records have the attribute, missing attributes evaluate to false.
+			name:      "missing attribute",
+			condition: `log.attributes["noise"] == true`,
+		},
+		{
+			name:      "missing attribute in converter",
+			condition: `IsMatch(log.attributes["noise_source"], "^healthcheck$")`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, observed := observer.New(zapcore.DebugLevel)
+			settings := processortest.NewNopSettings(metadata.Type)
+			settings.Logger = zap.New(core)
+
+			logsSink := &consumertest.LogsSink{}
+			cfg := &Config{
+				LogCountAttribute: defaultLogCountAttribute,
+				Interval:          time.Hour,
+				Timezone:          defaultTimezone,
+				Conditions:        []string{tt.condition},
+			}
+			p, err := createLogsProcessor(t.Context(), settings, cfg, logsSink)
+			require.NoError(t, err)
+			require.NoError(t, p.Start(t.Context(), componenttest.NewNopHost()))
+
+			logs := plog.NewLogs()
+			records := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+			for range 2 {
+				info := records.AppendEmpty()
+				info.Body().SetStr("health check ok")
+				info.SetSeverityNumber(plog.SeverityNumberInfo)
+				info.Attributes().PutBool("noise", true)
+				info.Attributes().PutStr("noise_source", "healthcheck")
+				errorRecord := records.AppendEmpty()
+				errorRecord.Body().SetStr("connection refused")
+				errorRecord.SetSeverityNumber(plog.SeverityNumberError)
+			}
+			require.NoError(t, p.ConsumeLogs(t.Context(), logs))
+
+			// ERROR records pass through right away, unchanged
+			allSinkLogs := logsSink.AllLogs()
+			require.Len(t, allSinkLogs, 1)
+			passedThrough := allSinkLogs[0].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords()
+			require.Equal(t, 2, passedThrough.Len())
+			for i := 0; i < passedThrough.Len(); i++ {
+				assert.Equal(t, "connection refused", passedThrough.At(i).Body().Str())
+				assert.Equal(t, plog.SeverityNumberError, passedThrough.At(i).SeverityNumber())
+				assert.Equal(t, 0, passedThrough.At(i).Attributes().Len())
+			}
+
+			// INFO records are deduplicated
+			require.NoError(t, p.Shutdown(t.Context()))
+			allSinkLogs = logsSink.AllLogs()
+			require.Len(t, allSinkLogs, 2)
+			require.Equal(t, 1, allSinkLogs[1].LogRecordCount())
+			deduped := allSinkLogs[1].ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+			assert.Equal(t, "health check ok", deduped.Body().Str())
+			count, ok := deduped.Attributes().Get(defaultLogCountAttribute)
+			require.True(t, ok)
+			assert.Equal(t, int64(2), count.Int())
+
+			// Evaluating the conditions against records without the attribute is not an error
+			assert.Empty(t, observed.FilterMessage("error matching conditions").All())
+		})
+	}
+}
+
 func TestProcessorConsumeCondition_PathContextSyntax(t *testing.T) {
 	logsSink := &consumertest.LogsSink{}
 	cfg := &Config{
@@ -367,6 +626,109 @@ func TestProcessorConsumeMultipleConditions(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// newOrderingTestLogs creates logs with one resource per element of records, holding records with these bodies.
+// Records whose body is "dedup" match the conditions of TestProcessorOrdering.
+func newOrderingTestLogs(timestamps map[string]time.Time, records ...[]string) plog.Logs {
+	logs := plog.NewLogs()
+	for i, bodies := range records {
+		rl := logs.ResourceLogs().AppendEmpty()
+		rl.Resource().Attributes().PutInt("resource", int64(i))
+		lrs := rl.ScopeLogs().AppendEmpty().LogRecords()
+		for _, body := range bodies {
+			lr := lrs.AppendEmpty()
+			lr.Body().SetStr(body)
+			if ts, ok := timestamps[body]; ok {
+				lr.SetTimestamp(pcommon.NewTimestampFromTime(ts))
+			}
+		}
+	}
+	return logs
+}
+
+func TestProcessorOrdering(t *testing.T) {
+	oldTimeNow := timeNow
+	defer func() {
+		timeNow = oldTimeNow
+	}()
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	timestamps := map[string]time.Time{
+		"late":  start.Add(20 * time.Second),
+		"early": start.Add(10 * time.Second),
+	}
+
+	tests := []struct {
+		name  string
+		delay bool
+		// expected holds the records of each export in order, prefixed by the resource they belong to.
+		expected [][]string
+	}{
+		{
+			name: "pass-through",
+			// Pass-through records are exported right away in their arrival order, aggregates when flushed.
+			expected: [][]string{
+				{"0:late", "0:early"},
+				{"0:untimestamped", "1:other"},
+				{"0:dedup"},
+			},
+		},
+		{
+			name:  "delay pass-through until flush",
+			delay: true,
+			// Records are exported together, ordered by time. Untimestamped records are ordered by
+			// their arrival time, which is the time of the aggregate, and stay ahead of it.
+			expected: [][]string{
+				{"0:early", "0:untimestamped", "1:other", "0:dedup", "0:late"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logsSink := &consumertest.LogsSink{}
+			cfg := &Config{
+				LogCountAttribute:          defaultLogCountAttribute,
+				Interval:                   time.Hour,
+				Timezone:                   defaultTimezone,
+				Conditions:                 []string{`body == "dedup"`},
+				DelayPassthroughUntilFlush: tt.delay,
+			}
+
+			p, err := createLogsProcessor(t.Context(), processortest.NewNopSettings(metadata.Type), cfg, logsSink)
+			require.NoError(t, err)
+			require.NoError(t, p.Start(t.Context(), componenttest.NewNopHost()))
+
+			timeNow = func() time.Time { return start }
+			require.NoError(t, p.ConsumeLogs(t.Context(), newOrderingTestLogs(timestamps, []string{"late", "dedup", "early"})))
+			timeNow = func() time.Time { return start.Add(15 * time.Second) }
+			require.NoError(t, p.ConsumeLogs(t.Context(), newOrderingTestLogs(timestamps, []string{"dedup", "untimestamped"}, []string{"other"})))
+			require.NoError(t, p.Shutdown(t.Context()))
+
+			exports := logsSink.AllLogs()
+			actual := make([][]string, 0, len(exports))
+			for _, logs := range exports {
+				var records []string
+				for i := 0; i < logs.ResourceLogs().Len(); i++ {
+					rl := logs.ResourceLogs().At(i)
+					resource, _ := rl.Resource().Attributes().Get("resource")
+					lrs := rl.ScopeLogs().At(0).LogRecords()
+					for j := 0; j < lrs.Len(); j++ {
+						lr := lrs.At(j)
+						records = append(records, resource.AsString()+":"+lr.Body().Str())
+
+						// The aggregate is timestamped within the window of the records it aggregates
+						if lr.Body().Str() == "dedup" {
+							assert.Equal(t, start, lr.ObservedTimestamp().AsTime())
+							assert.Equal(t, start.Add(15*time.Second), lr.Timestamp().AsTime())
+						}
+					}
+				}
+				actual = append(actual, records)
+			}
+			require.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
 func TestProcessorIncludeFields(t *testing.T) {
 	testCases := []struct {
 		name string
@@ -561,6 +923,40 @@ func TestMetadataKeysSameTenantAggregated(t *testing.T) {
 	assert.Equal(t, []string{"tenant-a"}, client.FromContext(ctxs[0]).Metadata.Get("x-scope-orgid"))
 }
 
+func TestMetadataKeysDelayPassthrough(t *testing.T) {
+	sink := &contextCapturingLogsSink{}
+	cfg := &Config{
+		LogCountAttribute:          defaultLogCountAttribute,
+		Interval:                   time.Hour,
+		Timezone:                   defaultTimezone,
+		Conditions:                 []string{`body == "dedup"`},
+		MetadataKeys:               []string{"x-scope-orgid"},
+		DelayPassthroughUntilFlush: true,
+	}
+
+	p, err := createLogsProcessor(t.Context(), processortest.NewNopSettings(metadata.Type), cfg, sink)
+	require.NoError(t, err)
+	require.NoError(t, p.Start(t.Context(), componenttest.NewNopHost()))
+
+	// Pass-through logs are held along with the aggregated logs of their tenant.
+	require.NoError(t, p.ConsumeLogs(metadataContext(t, "tenant-a"), newSimpleLog()))
+	require.NoError(t, p.ConsumeLogs(metadataContext(t, "tenant-b"), newSimpleLog()))
+	require.NoError(t, p.ConsumeLogs(metadataContext(t, "tenant-b"), newSimpleLog()))
+	require.Equal(t, 0, sink.logRecordCount())
+
+	require.NoError(t, p.Shutdown(t.Context()))
+
+	ctxs := sink.capturedContexts()
+	require.Len(t, ctxs, 2)
+	counts := make(map[string]int)
+	for i, ctx := range ctxs {
+		vs := client.FromContext(ctx).Metadata.Get("x-scope-orgid")
+		require.Len(t, vs, 1)
+		counts[vs[0]] = sink.logs[i].LogRecordCount()
+	}
+	assert.Equal(t, map[string]int{"tenant-a": 1, "tenant-b": 2}, counts)
+}
+
 func TestMetadataKeysCardinalityLimit(t *testing.T) {
 	sink := &contextCapturingLogsSink{}
 	cfg := &Config{
@@ -681,3 +1077,44 @@ func TestProcessorConfigValidate(t *testing.T) {
 	_, err = createLogsProcessor(t.Context(), processortest.NewNopSettings(metadata.Type), validCfg, consumertest.NewNop())
 	require.NoError(t, err)
 }
+
+func TestProcessorTelemetry(t *testing.T) {
+	tt := componenttest.NewTelemetry()
+	t.Cleanup(func() { require.NoError(t, tt.Shutdown(context.Background())) })
+
+	logsSink := &consumertest.LogsSink{}
+	cfg := &Config{
+		LogCountAttribute: defaultLogCountAttribute,
+		Interval:          time.Hour,
+		Timezone:          defaultTimezone,
+		Conditions:        []string{`log.attributes["dedup"] == true`},
+	}
+
+	p, err := createLogsProcessor(t.Context(), metadatatest.NewSettings(tt), cfg, logsSink)
+	require.NoError(t, err)
+	require.NoError(t, p.Start(t.Context(), componenttest.NewNopHost()))
+
+	// 6 duplicates of "a", 3 of "b", 1 of "c" and 2 logs passing through
+	logs := plog.NewLogs()
+	lrs := logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords()
+	for body, count := range map[string]int{"a": 6, "b": 3, "c": 1} {
+		for range count {
+			lr := lrs.AppendEmpty()
+			lr.Body().SetStr(body)
+			lr.Attributes().PutBool("dedup", true)
+		}
+	}
+	for range 2 {
+		lrs.AppendEmpty().Body().SetStr("passthrough")
+	}
+	require.NoError(t, p.ConsumeLogs(t.Context(), logs))
+
+	metadatatest.AssertEqualLogdedupRecordsIn(t, tt, []metricdata.DataPoint[int64]{{Value: 10}}, metricdatatest.IgnoreTimestamp())
+
+	// Aggregated logs are emitted on shutdown
+	require.NoError(t, p.Shutdown(t.Context()))
+	require.Equal(t, 5, logsSink.LogRecordCount())
+
+	metadatatest.AssertEqualLogdedupAggregatesEmitted(t, tt, []metricdata.DataPoint[int64]{{Value: 3}}, metricdatatest.IgnoreTimestamp())
+	metadatatest.AssertEqualLogdedupRecordsDropped(t, tt, []metricdata.DataPoint[int64]{{Value: 7}}, metricdatatest.IgnoreTimestamp())
+}
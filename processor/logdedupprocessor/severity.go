@@ -0,0 +1,101 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package logdedupprocessor // import "github.com/open-telemetry/opentelemetry-collector-contrib/processor/logdedupprocessor"
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// severityRangeDelimiter separates the lowest and highest levels of a severity range, e.g. "warn-fatal".
+const severityRangeDelimiter = "-"
+
+// severityLevels maps severity level names to the lowest severity number of the level.
+var severityLevels = map[string]plog.SeverityNumber{
+	"trace": plog.SeverityNumberTrace,
+	"debug": plog.SeverityNumberDebug,
+	"info":  plog.SeverityNumberInfo,
+	"warn":  plog.SeverityNumberWarn,
+	"error": plog.SeverityNumberError,
+	"fatal": plog.SeverityNumberFatal,
+}
+
+// severityNumbersPerLevel is the number of severity numbers within a level, e.g. ERROR to ERROR4.
+const severityNumbersPerLevel = 4
+
+// severityInterval is the aggregation interval of logs whose severity number is within [from, to].
+type severityInterval struct {
+	from     plog.SeverityNumber
+	to       plog.SeverityNumber
+	interval time.Duration
+}
+
+// severityIntervals holds the aggregation intervals configured by severity.
+type severityIntervals []severityInterval
+
+// newSeverityIntervals parses the interval_by_severity configuration. It returns nil if no interval is configured.
+func newSeverityIntervals(intervalBySeverity map[string]time.Duration) (severityIntervals, error) {
+	if len(intervalBySeverity) == 0 {
+		return nil, nil
+	}
+	intervals := make(severityIntervals, 0, len(intervalBySeverity))
+	for severityRange, interval := range intervalBySeverity {
+		if interval <= 0 {
+			return nil, fmt.Errorf("interval_by_severity %q: %w", severityRange, errInvalidInterval)
+		}
+		from, to, err := parseSeverityRange(severityRange)
+		if err != nil {
+			return nil, fmt.Errorf("interval_by_severity %q: %w", severityRange, err)
+		}
+		intervals = append(intervals, severityInterval{from: from, to: to, interval: interval})
+	}
+	return intervals, nil
+}
+
+// parseSeverityRange parses a severity level name, e.g. "error", or a range of level names, e.g. "warn-fatal".
+func parseSeverityRange(severityRange string) (from, to plog.SeverityNumber, err error) {
+	lowest, highest, isRange := strings.Cut(severityRange, severityRangeDelimiter)
+	if !isRange {
+		highest = lowest
+	}
+
+	from, ok := severityLevels[strings.ToLower(strings.TrimSpace(lowest))]
+	if !ok {
+		return 0, 0, fmt.Errorf("unknown severity level %q", lowest)
+	}
+	to, ok = severityLevels[strings.ToLower(strings.TrimSpace(highest))]
+	if !ok {
+		return 0, 0, fmt.Errorf("unknown severity level %q", highest)
+	}
+	if from > to {
+		return 0, 0, fmt.Errorf("severity level %q is higher than %q", lowest, highest)
+	}
+	return from, to + severityNumbersPerLevel - 1, nil
+}
+
+// intervalFor returns the shortest interval configured for the severity number, or defaultInterval if none applies.
+func (s severityIntervals) intervalFor(severity plog.SeverityNumber, defaultInterval time.Duration) time.Duration {
+	interval := time.Duration(0)
+	for _, si := range s {
+		if severity >= si.from && severity <= si.to && (interval == 0 || si.interval < interval) {
+			interval = si.interval
+		}
+	}
+	if interval == 0 {
+		return defaultInterval
+	}
+	return interval
+}
+
+// shortest returns the shortest of the configured intervals and defaultInterval.
+func (s severityIntervals) shortest(defaultInterval time.Duration) time.Duration {
+	shortest := defaultInterval
+	for _, si := range s {
+		shortest = min(shortest, si.interval)
+	}
+	return shortest
+}
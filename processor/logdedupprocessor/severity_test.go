@@ -0,0 +1,95 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package logdedupprocessor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+func Test_parseSeverityRange(t *testing.T) {
+	testCases := []struct {
+		desc         string
+		input        string
+		expectedFrom plog.SeverityNumber
+		expectedTo   plog.SeverityNumber
+		expectedErr  string
+	}{
+		{
+			desc:         "single level",
+			input:        "error",
+			expectedFrom: plog.SeverityNumberError,
+			expectedTo:   plog.SeverityNumberError4,
+		},
+		{
+			desc:         "case insensitive",
+			input:        "INFO",
+			expectedFrom: plog.SeverityNumberInfo,
+			expectedTo:   plog.SeverityNumberInfo4,
+		},
+		{
+			desc:         "range",
+			input:        "warn-fatal",
+			expectedFrom: plog.SeverityNumberWarn,
+			expectedTo:   plog.SeverityNumberFatal4,
+		},
+		{
+			desc:        "unknown level",
+			input:       "critical",
+			expectedErr: `unknown severity level "critical"`,
+		},
+		{
+			desc:        "unknown range end",
+			input:       "warn-",
+			expectedErr: `unknown severity level ""`,
+		},
+		{
+			desc:        "reversed range",
+			input:       "fatal-warn",
+			expectedErr: `severity level "fatal" is higher than "warn"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			from, to, err := parseSeverityRange(tc.input)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedFrom, from)
+			require.Equal(t, tc.expectedTo, to)
+		})
+	}
+}
+
+func Test_severityIntervals(t *testing.T) {
+	intervals, err := newSeverityIntervals(map[string]time.Duration{
+		"error":       10 * time.Second,
+		"warn-fatal":  time.Minute,
+		"debug-debug": time.Hour,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, 10*time.Second, intervals.intervalFor(plog.SeverityNumberError2, 5*time.Minute))
+	require.Equal(t, time.Minute, intervals.intervalFor(plog.SeverityNumberWarn, 5*time.Minute))
+	require.Equal(t, time.Minute, intervals.intervalFor(plog.SeverityNumberFatal, 5*time.Minute))
+	require.Equal(t, time.Hour, intervals.intervalFor(plog.SeverityNumberDebug4, 5*time.Minute))
+	require.Equal(t, 5*time.Minute, intervals.intervalFor(plog.SeverityNumberInfo, 5*time.Minute))
+	require.Equal(t, 5*time.Minute, intervals.intervalFor(plog.SeverityNumberUnspecified, 5*time.Minute))
+
+	require.Equal(t, 10*time.Second, intervals.shortest(5*time.Minute))
+	require.Equal(t, time.Second, intervals.shortest(time.Second))
+
+	intervals, err = newSeverityIntervals(nil)
+	require.NoError(t, err)
+	require.Nil(t, intervals)
+
+	_, err = newSeverityIntervals(map[string]time.Duration{"error": 0})
+	require.ErrorIs(t, err, errInvalidInterval)
+}
@@ -0,0 +1,79 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package logdedupprocessor // import "github.com/open-telemetry/opentelemetry-collector-contrib/processor/logdedupprocessor"
+
+import (
+	"sync"
+
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// defaultSnapshotThreshold is the default number of log counters above which log records are kept as snapshots.
+const defaultSnapshotThreshold = 10000
+
+// snapshotLogsPool pools the single-record logs log records are moved into to be marshaled as snapshots.
+var snapshotLogsPool = sync.Pool{
+	New: func() any {
+		logs := plog.NewLogs()
+		logs.ResourceLogs().AppendEmpty().ScopeLogs().AppendEmpty().LogRecords().AppendEmpty()
+		return &logs
+	},
+}
+
+// recordStore keeps the log records of the log counters of a logAggregator. Log records are kept as live
+// plog.LogRecord until more than threshold log counters are tracked, then as snapshots: the proto bytes of a
+// single-record plog.Logs, unmarshaled when exported. A snapshot is a single pointer-free allocation, whereas a
+// live log record is made of many small objects, e.g. one per attribute, which the garbage collector has to scan.
+type recordStore struct {
+	// threshold is the number of log counters above which log records are kept as snapshots, 0 disables them.
+	threshold int
+	// counters is the number of log counters tracked.
+	counters  int
+	marshaler plog.ProtoMarshaler
+}
+
+// newLogCounter creates a new log counter keeping logRecord, moved to a snapshot once more than threshold
+// log counters are tracked, and tracks it.
+func (s *recordStore) newLogCounter(logRecord plog.LogRecord) *logCounter {
+	s.counters++
+	if s.threshold > 0 && s.counters > s.threshold {
+		if snapshot, ok := s.snapshot(logRecord); ok {
+			now := timeNow().UTC()
+			return &logCounter{
+				snapshot:               snapshot,
+				firstObservedTimestamp: now,
+				lastObservedTimestamp:  now,
+			}
+		}
+	}
+	return newLogCounter(logRecord)
+}
+
+// snapshot moves logRecord into a snapshot. ok is false when logRecord could not be marshaled, in which case
+// it is left unchanged.
+func (s *recordStore) snapshot(logRecord plog.LogRecord) (snapshot []byte, ok bool) {
+	logs := snapshotLogsPool.Get().(*plog.Logs)
+	defer snapshotLogsPool.Put(logs)
+	record := logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0)
+
+	logRecord.MoveTo(record)
+	snapshot, err := s.marshaler.MarshalLogs(*logs)
+	if err != nil {
+		record.MoveTo(logRecord)
+		return nil, false
+	}
+	// Release the log record rather than pinning it in the pool.
+	plog.NewLogRecord().MoveTo(record)
+	return snapshot, true
+}
+
+// release stops tracking n log counters, once exported.
+func (s *recordStore) release(n int) {
+	s.counters -= n
+}
+
+// reset stops tracking all log counters.
+func (s *recordStore) reset() {
+	s.counters = 0
+}
@@ -0,0 +1,204 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package logdedupprocessor
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/collector/component/componenttest"
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+
+	"github.com/open-telemetry/opentelemetry-collector-contrib/pkg/pdatatest/plogtest"
+	"github.com/open-telemetry/opentelemetry-collector-contrib/processor/logdedupprocessor/internal/metadata"
+)
+
+// newSnapshotTestRecord returns a log record setting every field, identified by i.
+func newSnapshotTestRecord(i int) plog.LogRecord {
+	logRecord := plog.NewLogRecord()
+	logRecord.SetTimestamp(pcommon.NewTimestampFromTime(time.Unix(1700000000, 0)))
+	logRecord.SetObservedTimestamp(pcommon.NewTimestampFromTime(time.Unix(1700000001, 0)))
+	logRecord.SetSeverityNumber(plog.SeverityNumberWarn)
+	logRecord.SetSeverityText("WARN")
+	logRecord.SetEventName("cache.miss")
+	logRecord.SetTraceID(pcommon.TraceID([16]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}))
+	logRecord.SetSpanID(pcommon.SpanID([8]byte{1, 2, 3, 4, 5, 6, 7, 8}))
+	logRecord.SetFlags(plog.DefaultLogRecordFlags.WithIsSampled(true))
+	logRecord.SetDroppedAttributesCount(2)
+	body := logRecord.Body().SetEmptyMap()
+	body.PutStr("message", "cache miss "+strconv.Itoa(i))
+	body.PutEmptySlice("keys").AppendEmpty().SetStr("user:42")
+	attrs := logRecord.Attributes()
+	attrs.PutStr("service.name", "checkout")
+	attrs.PutInt("attempt", int64(i))
+	attrs.PutDouble("ratio", 0.5)
+	attrs.PutBool("cached", false)
+	attrs.PutEmptyBytes("raw").FromRaw([]byte{0xde, 0xad})
+	attrs.PutEmptyMap("http").PutInt("status", 503)
+	return logRecord
+}
+
+func Test_recordStore(t *testing.T) {
+	store := &recordStore{threshold: 2}
+
+	first := store.newLogCounter(newSnapshotTestRecord(1))
+	second := store.newLogCounter(newSnapshotTestRecord(2))
+	third := store.newLogCounter(newSnapshotTestRecord(3))
+	require.Equal(t, 3, store.counters)
+
+	// Log records are kept live up to the threshold, then as snapshots
+	require.Nil(t, first.snapshot)
+	require.Nil(t, second.snapshot)
+	require.NotNil(t, third.snapshot)
+
+	for i, lc := range []*logCounter{first, second, third} {
+		logRecord, err := lc.record()
+		require.NoError(t, err)
+		require.Equal(t, newSnapshotTestRecord(i+1), logRecord)
+	}
+
+	store.release(1)
+	require.Equal(t, 2, store.counters)
+	store.reset()
+	require.Equal(t, 0, store.counters)
+
+	// Disabled snapshots keep all log records live
+	disabled := &recordStore{}
+	for i := range 3 {
+		require.Nil(t, disabled.newLogCounter(newSnapshotTestRecord(i)).snapshot)
+	}
+}
+
+func Test_recordStore_emittedRecordsMatch(t *testing.T) {
+	oldTimeNow := timeNow
+	defer func() {
+		timeNow = oldTimeNow
+	}()
+	now := time.Unix(1700000100, 0)
+	timeNow = func() time.Time { return now }
+
+	export := func(threshold int) plog.Logs {
+		telemetryBuilder, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
+		require.NoError(t, err)
+		aggregations, err := newAttributeAggregations(map[string]string{"bytes": "sum"})
+		require.NoError(t, err)
+		aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, interval: defaultInterval, emitSummary: true, aggregations: aggregations, dedupScope: dedupScopeScope, snapshotThreshold: threshold}, telemetryBuilder, zap.NewNop())
+
+		resource := pcommon.NewResource()
+		resource.Attributes().PutStr("host.name", "host-1")
+		scope := pcommon.NewInstrumentationScope()
+		scope.SetName("scope")
+		for i := range 20 {
+			logRecord := newSnapshotTestRecord(i % 5)
+			logRecord.Attributes().PutInt("bytes", int64(i))
+			aggregator.Add(resource, scope, logRecord)
+		}
+		require.Equal(t, 5, aggregator.records.counters)
+		return aggregator.Export(t.Context())
+	}
+
+	live := export(0)
+	snapshots := export(1)
+	require.Equal(t, 10, live.LogRecordCount(), "5 aggregated logs and their suppression summaries")
+	require.NoError(t, plogtest.CompareLogs(live, snapshots, plogtest.IgnoreLogRecordsOrder()))
+}
+
+func Test_recordStore_corruptSnapshotSkipped(t *testing.T) {
+	telemetryBuilder, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+	core, observed := observer.New(zap.ErrorLevel)
+	aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, interval: defaultInterval, dedupScope: dedupScopeScope, snapshotThreshold: 1}, telemetryBuilder, zap.New(core))
+
+	resource := pcommon.NewResource()
+	scope := pcommon.NewInstrumentationScope()
+	aggregator.Add(resource, scope, newSnapshotTestRecord(1))
+	aggregator.Add(resource, scope, newSnapshotTestRecord(2))
+	aggregator.Add(resource, scope, newSnapshotTestRecord(3))
+	for _, resourceAggregator := range aggregator.resources {
+		for _, scopeAggregator := range resourceAggregator.scopeCounters {
+			for _, lc := range scopeAggregator.logCounters {
+				if lc.snapshot != nil {
+					lc.snapshot = []byte{0xff}
+				}
+			}
+		}
+	}
+
+	// The aggregates of the corrupt snapshots are skipped rather than emitted blank, and the failure is logged.
+	logs := aggregator.Export(t.Context())
+	require.Equal(t, 1, logs.LogRecordCount())
+	require.Equal(t, newSnapshotTestRecord(1).Body(), logs.ResourceLogs().At(0).ScopeLogs().At(0).LogRecords().At(0).Body())
+	require.Equal(t, 1, observed.Len())
+	require.Equal(t, int64(2), observed.All()[0].ContextMap()["aggregates"])
+}
+
+func Test_recordStore_expiredCountersReleased(t *testing.T) {
+	telemetryBuilder, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
+	require.NoError(t, err)
+	intervals, err := newSeverityIntervals(map[string]time.Duration{"warn": time.Second})
+	require.NoError(t, err)
+	aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, interval: time.Hour, severityIntervals: intervals, dedupScope: dedupScopeScope, snapshotThreshold: 1}, telemetryBuilder, zap.NewNop())
+
+	resource := pcommon.NewResource()
+	scope := pcommon.NewInstrumentationScope()
+	aggregator.Add(resource, scope, newSnapshotTestRecord(1))
+	info := newSnapshotTestRecord(2)
+	info.SetSeverityNumber(plog.SeverityNumberInfo)
+	aggregator.Add(resource, scope, info)
+	require.Equal(t, 2, aggregator.records.counters)
+
+	logs := aggregator.ExportExpired(t.Context(), timeNow().Add(time.Minute))
+	require.Equal(t, 1, logs.LogRecordCount())
+	require.Equal(t, 1, aggregator.records.counters)
+
+	aggregator.Take(t.Context(), true)
+	require.Equal(t, 0, aggregator.records.counters)
+}
+
+// heapObjects returns the number of live heap objects, once garbage collected.
+func heapObjects() uint64 {
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapObjects
+}
+
+// Benchmark_recordStore reports the number of heap objects kept alive by 100k distinct log records, kept live
+// or as snapshots.
+func Benchmark_recordStore(b *testing.B) {
+	const keys = 100_000
+	telemetryBuilder, err := metadata.NewTelemetryBuilder(componenttest.NewNopTelemetrySettings())
+	require.NoError(b, err)
+	resource := pcommon.NewResource()
+	scope := pcommon.NewInstrumentationScope()
+
+	for _, bc := range []struct {
+		name      string
+		threshold int
+	}{
+		{name: "live", threshold: 0},
+		{name: "snapshot", threshold: 1},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			var objects uint64
+			for b.Loop() {
+				aggregator := newLogAggregator(logAggregatorOptions{logCountAttribute: defaultLogCountAttribute, timezone: time.UTC, interval: defaultInterval, dedupScope: dedupScopeScope, snapshotThreshold: bc.threshold}, telemetryBuilder, zap.NewNop())
+				before := heapObjects()
+				for i := range keys {
+					aggregator.Add(resource, scope, newSnapshotTestRecord(i))
+				}
+				objects = heapObjects() - before
+				runtime.KeepAlive(aggregator)
+			}
+			b.ReportMetric(float64(objects), "live-objects")
+			b.ReportMetric(float64(objects)/keys, "live-objects/key")
+		})
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright The OpenTelemetry Authors
+// SPDX-License-Identifier: Apache-2.0
+
+package logdedupprocessor // import "github.com/open-telemetry/opentelemetry-collector-contrib/processor/logdedupprocessor"
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/collector/pdata/pcommon"
+	"go.opentelemetry.io/collector/pdata/plog"
+)
+
+// Attribute names of the suppression summary log record
+const (
+	summaryKeyAttr         = "log_dedup.key"
+	summaryCountAttr       = "log_dedup.count"
+	summarySuppressedAttr  = "log_dedup.suppressed_count"
+	summaryWindowStartAttr = "log_dedup.window.start"
+	summaryWindowEndAttr   = "log_dedup.window.end"
+)
+
+// appendSuppressionSummary appends a log record summarizing the duplicates suppressed by the log counter identified by key.
+func (l *logAggregator) appendSuppressionSummary(logRecords plog.LogRecordSlice, key uint64, lc *logCounter) {
+	suppressed := lc.count - 1
+
+	lr := logRecords.AppendEmpty()
+	// The summary is timestamped at the end of its window, like the aggregated log it accompanies.
+	end := pcommon.NewTimestampFromTime(lc.lastObservedTimestamp)
+	lr.SetTimestamp(end)
+	lr.SetObservedTimestamp(end)
+	lr.SetSeverityNumber(plog.SeverityNumberInfo)
+	lr.SetSeverityText(plog.SeverityNumberInfo.String())
+	lr.Body().SetStr(fmt.Sprintf("suppressed %d duplicate logs", suppressed))
+
+	lr.Attributes().EnsureCapacity(5)
+	lr.Attributes().PutStr(summaryKeyAttr, strconv.FormatUint(key, 16))
+	lr.Attributes().PutInt(summaryCountAttr, lc.count)
+	lr.Attributes().PutInt(summarySuppressedAttr, suppressed)
+	lr.Attributes().PutStr(summaryWindowStartAttr, lc.firstObservedTimestamp.In(l.timezone).Format(time.RFC3339))
+	lr.Attributes().PutStr(summaryWindowEndAttr, lc.lastObservedTimestamp.In(l.timezone).Format(time.RFC3339))
+}